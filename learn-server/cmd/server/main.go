@@ -9,6 +9,7 @@ import (
 	"learn/internal/routes"
 	"learn/internal/services"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -42,23 +43,29 @@ func main() {
 	db = db.Debug()
 
 	// 初始化服务
-	authService, quizService := initServices(db, cfg)
+	svc := initServices(db, cfg)
 
 	//初始化admin
-	initAdmin(authService, cfg)
+	initAdmin(svc.auth, cfg)
 
 	// 初始化处理器
-	authHandler, questionHandler := initHandlers(authService, quizService)
+	handlers := initHandlers(svc, cfg)
 
 	// 初始化路由
 	// enforcer, err := loadCasbinEnforcer(authService)
 	// if err != nil {
 	// 	log.Fatalf("Failed to load casbin enforcer: %v", err)
 	// }
-	router := initRouter(authHandler, questionHandler)
+	router := initRouter(svc.auth, handlers...)
 	enableSwagger(router, cfg.Server.Address)
 	// printRoutes(router)
 
+	// 定时推送作业截止提醒
+	startDueReminders(svc.assignment, cfg.Events.DueReminder)
+	// 定时清理已过期的 Refresh Token
+	startTokenCleanup(svc.auth)
+	startKeyRotation(svc.auth)
+
 	// 创建并启动服务器
 	srv := startServer(cfg, router)
 
@@ -79,26 +86,277 @@ func initDB(cfg *config.Config) *gorm.DB {
 	return db
 }
 
+// appServices 汇总服务层的各个服务，供处理器使用
+type appServices struct {
+	auth       *services.AuthService
+	quiz       *services.QuizService
+	class      *services.ClassService
+	assignment *services.AssignmentService
+	paper      *services.PaperService
+	export     *services.ExportService
+	live       *services.LiveQuizService
+	events     *services.EventBus
+	oidc       *services.OIDCService // 未配置 OIDC 时为 nil
+}
+
 // 初始化服务层
-func initServices(db *gorm.DB, cfg *config.Config) (*services.AuthService, *services.QuizService) {
-	authService := services.NewAuthService(db, cfg.JWT.Secret, cfg.JWT.AccessTokenDuration, cfg.JWT.RefreshTokenDuration, 24*time.Hour)
+func initServices(db *gorm.DB, cfg *config.Config) *appServices {
+	authService := services.NewAuthService(db, cfg.JWT.Secret, cfg.JWT.AccessTokenDuration, cfg.JWT.RefreshTokenDuration)
+	if err := authService.ConfigureSigningKeys(signingKeySettings(cfg.JWT)); err != nil {
+		log.Fatalf("Failed to configure JWT signing keys: %v", err)
+	}
+	if cfg.MFA.Issuer != "" {
+		authService.MFAIssuer = cfg.MFA.Issuer
+	}
+	if cfg.Password.MinLength > 0 {
+		authService.PasswordPolicy.MinLength = cfg.Password.MinLength
+	}
+	if cfg.Password.BreachedList != "" {
+		count, err := authService.PasswordPolicy.LoadBreachedPasswords(cfg.Password.BreachedList)
+		if err != nil {
+			log.Fatalf("Failed to load breached password list: %v", err)
+		}
+		log.Printf("Loaded %d breached passwords", count)
+	}
+	authService.LoginThrottle = loginThrottleSettings(cfg.LoginThrottle)
+	if cfg.SMTP.Host != "" {
+		port := cfg.SMTP.Port
+		if port == 0 {
+			port = 587
+		}
+		authService.Notifier = &services.SMTPNotifier{
+			Host:     cfg.SMTP.Host,
+			Port:     port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		}
+	}
+	authService.PasswordResetURL = cfg.Password.ResetURL
+	if cfg.Password.ResetTokenDuration > 0 {
+		authService.PasswordResetTokenDuration = cfg.Password.ResetTokenDuration
+	}
+	authService.Registration = registrationSettings(cfg)
 	quizService := services.NewQuizService(db)
-	return authService, quizService
+	if cfg.Quiz.NotebookClearStreak > 0 {
+		quizService.NotebookClearStreak = cfg.Quiz.NotebookClearStreak
+	}
+	classService := services.NewClassService(db, authService)
+	eventBus := services.NewEventBus()
+	quizService.Events = eventBus
+	if err := quizService.EnsureDefaultBadges(); err != nil {
+		log.Printf("Failed to create default badges: %v", err)
+	}
+	assignmentService := services.NewAssignmentService(db, quizService)
+	assignmentService.Events = eventBus
+	exportService := services.NewExportService(db, quizService)
+	exportService.FontPath = cfg.Export.FontPath
+	if cfg.LDAP.URL != "" {
+		ldapAuthenticator, err := services.NewLDAPAuthenticator(db, services.LDAPSettings{
+			URL:                cfg.LDAP.URL,
+			StartTLS:           cfg.LDAP.StartTLS,
+			InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			BindDN:             cfg.LDAP.BindDN,
+			BindPassword:       cfg.LDAP.BindPassword,
+			BaseDN:             cfg.LDAP.BaseDN,
+			UserFilter:         cfg.LDAP.UserFilter,
+			UsernameAttribute:  cfg.LDAP.UsernameAttribute,
+			EmailAttribute:     cfg.LDAP.EmailAttribute,
+			GroupAttribute:     cfg.LDAP.GroupAttribute,
+			GroupBaseDN:        cfg.LDAP.GroupBaseDN,
+			GroupFilter:        cfg.LDAP.GroupFilter,
+			RoleMapping:        cfg.LDAP.RoleMapping,
+			DefaultRole:        cfg.LDAP.DefaultRole,
+			LinkByUsername:     cfg.LDAP.LinkByUsername,
+			Timeout:            cfg.LDAP.Timeout,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize LDAP authentication: %v", err)
+		}
+		authService.AddAuthenticator(ldapAuthenticator)
+	}
+	var oidcService *services.OIDCService
+	if cfg.OIDC.Issuer != "" {
+		var err error
+		oidcService, err = services.NewOIDCService(context.Background(), db, authService, services.OIDCSettings{
+			Issuer:         cfg.OIDC.Issuer,
+			ClientID:       cfg.OIDC.ClientID,
+			ClientSecret:   cfg.OIDC.ClientSecret,
+			RedirectURL:    cfg.OIDC.RedirectURL,
+			FrontendURL:    cfg.OIDC.FrontendURL,
+			Scopes:         cfg.OIDC.Scopes,
+			UsernameClaim:  cfg.OIDC.UsernameClaim,
+			RolesClaim:     cfg.OIDC.RolesClaim,
+			RoleMapping:    cfg.OIDC.RoleMapping,
+			DefaultRole:    cfg.OIDC.DefaultRole,
+			AutoProvision:  cfg.OIDC.AutoProvision,
+			LinkByUsername: cfg.OIDC.LinkByUsername,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize OIDC login: %v", err)
+		}
+	}
+	return &appServices{
+		auth:       authService,
+		quiz:       quizService,
+		class:      classService,
+		assignment: assignmentService,
+		paper:      services.NewPaperService(db, quizService),
+		export:     exportService,
+		live:       services.NewLiveQuizService(quizService),
+		events:     eventBus,
+		oidc:       oidcService,
+	}
 }
 
 // 初始化处理器
-func initHandlers(authService *services.AuthService, quizService *services.QuizService) (*api.AuthHandler, *api.QuizHandler) {
-	authHandler := &api.AuthHandler{AuthService: authService}
-	questionHandler := &api.QuizHandler{QuizService: quizService}
-	return authHandler, questionHandler
+func initHandlers(svc *appServices, cfg *config.Config) []api.APIEndpointProvider {
+	handlers := []api.APIEndpointProvider{
+		&api.AuthHandler{AuthService: svc.auth},
+		&api.QuizHandler{QuizService: svc.quiz, AuthService: svc.auth},
+		&api.ClassHandler{ClassService: svc.class},
+		&api.AssignmentHandler{AssignmentService: svc.assignment, ClassService: svc.class},
+		&api.PaperHandler{PaperService: svc.paper},
+		&api.ExportHandler{ExportService: svc.export},
+		&api.LiveQuizHandler{LiveQuizService: svc.live, AllowedOrigins: cfg.Server.AllowedOrigins},
+		&api.EventHandler{EventBus: svc.events},
+		&api.GamificationHandler{QuizService: svc.quiz, ClassService: svc.class},
+	}
+	if svc.oidc != nil {
+		handlers = append(handlers, &api.OIDCHandler{OIDCService: svc.oidc, AuthService: svc.auth})
+	}
+	return handlers
+}
+
+// startDueReminders 每分钟检查即将截止的作业，提醒尚未提交的学员
+func startDueReminders(assignmentService *services.AssignmentService, lead time.Duration) {
+	if lead <= 0 {
+		lead = 30 * time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := assignmentService.NotifyDueSoon(lead); err != nil {
+				log.Printf("Failed to send due reminders: %v", err)
+			}
+		}
+	}()
+}
+
+// startTokenCleanup 每小时删除会话已过期的 Refresh Token 记录
+func startTokenCleanup(authService *services.AuthService) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := authService.PurgeExpiredRefreshTokens(); err != nil {
+				log.Printf("Failed to purge expired refresh tokens: %v", err)
+			}
+			if _, err := authService.PurgeLoginFailures(); err != nil {
+				log.Printf("Failed to purge login failures: %v", err)
+			}
+			if _, err := authService.PurgePasswordResetTokens(); err != nil {
+				log.Printf("Failed to purge password reset tokens: %v", err)
+			}
+			if _, err := authService.PurgeEmailVerificationTokens(); err != nil {
+				log.Printf("Failed to purge email verification tokens: %v", err)
+			}
+		}
+	}()
+}
+
+// startKeyRotation 每 10 分钟检查签名密钥是否需要轮换，并加载其他实例生成的密钥
+func startKeyRotation(authService *services.AuthService) {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := authService.RotateSigningKeys(); err != nil {
+				log.Printf("Failed to rotate JWT signing keys: %v", err)
+			}
+		}
+	}()
+}
+
+// signingKeySettings 用配置覆盖默认的签名密钥轮换设置
+func signingKeySettings(cfg config.JWTConfig) services.SigningKeySettings {
+	settings := services.SigningKeySettings{
+		Algorithm:        cfg.Algorithm,
+		RotationInterval: services.DefaultKeyRotationInterval,
+		GracePeriod:      services.DefaultKeyRotationGrace,
+	}
+	if cfg.KeyRotationInterval > 0 {
+		settings.RotationInterval = cfg.KeyRotationInterval
+	}
+	if cfg.KeyRotationGrace > 0 {
+		settings.GracePeriod = cfg.KeyRotationGrace
+	}
+	return settings
+}
+
+// loginThrottleSettings 用配置覆盖默认的登录限流设置，未设置的项保留默认值
+func loginThrottleSettings(cfg config.LoginThrottleConfig) services.LoginThrottleSettings {
+	settings := services.DefaultLoginThrottle
+	if cfg.AccountFreeAttempts > 0 {
+		settings.AccountFreeAttempts = cfg.AccountFreeAttempts
+	}
+	if cfg.IPFreeAttempts > 0 {
+		settings.IPFreeAttempts = cfg.IPFreeAttempts
+	}
+	if cfg.BaseDelay > 0 {
+		settings.BaseDelay = cfg.BaseDelay
+	}
+	if cfg.MaxDelay > 0 {
+		settings.MaxDelay = cfg.MaxDelay
+	}
+	if cfg.ResetAfter > 0 {
+		settings.ResetAfter = cfg.ResetAfter
+	}
+	if cfg.LockoutThreshold != 0 {
+		settings.LockoutThreshold = cfg.LockoutThreshold
+	}
+	if cfg.LockoutDuration > 0 {
+		settings.LockoutDuration = cfg.LockoutDuration
+	}
+	return settings
+}
+
+// registrationSettings 检查并转换自助注册的配置
+func registrationSettings(cfg *config.Config) services.RegistrationSettings {
+	settings := services.RegistrationSettings{
+		RequireEmailVerification:  cfg.Registration.RequireEmailVerification,
+		VerifyEmailURL:            cfg.Registration.VerifyEmailURL,
+		VerificationTokenDuration: cfg.Registration.VerificationTokenDuration,
+	}
+	if settings.VerificationTokenDuration <= 0 {
+		settings.VerificationTokenDuration = services.DefaultEmailVerificationTokenDuration
+	}
+	if settings.RequireEmailVerification && (cfg.SMTP.Host == "" || settings.VerifyEmailURL == "") {
+		log.Fatalf("registration.require_email_verification needs smtp.host and registration.verify_email_url")
+	}
+	for i, rule := range cfg.Registration.AutoApprove {
+		if (rule.InviteCode == "") == (rule.EmailDomain == "") {
+			log.Fatalf("registration.auto_approve[%d] must set exactly one of invite_code and email_domain", i)
+		}
+		if rule.EmailDomain != "" && (cfg.SMTP.Host == "" || settings.VerifyEmailURL == "") {
+			log.Fatalf("registration.auto_approve[%d] matches email domains, which needs smtp.host and registration.verify_email_url", i)
+		}
+		settings.AutoApprove = append(settings.AutoApprove, services.AutoApproveRule{
+			InviteCode:  rule.InviteCode,
+			EmailDomain: rule.EmailDomain,
+			Roles:       rule.Roles,
+		})
+	}
+	return settings
 }
 
 // 初始化路由
-func initRouter(authHandler *api.AuthHandler, quizHandler *api.QuizHandler) *mux.Router {
+func initRouter(authService *services.AuthService, providers ...api.APIEndpointProvider) *mux.Router {
 	router := mux.NewRouter()
 
-	register := routes.NewRoutesRegister(router, authHandler.AuthService)
-	err := register.RegisterRoutes(authHandler, quizHandler)
+	register := routes.NewRoutesRegister(router, authService)
+	err := register.RegisterRoutes(providers...)
 	if err != nil {
 		log.Fatalf("Failed to register routes: %v", err)
 	}
@@ -131,6 +389,11 @@ func initAdmin(authService *services.AuthService, cfg *config.Config) {
 	if err != nil {
 		log.Fatalf("Admin initialize error: %v", err)
 	}
+
+	// 早先注册、没有注册申请的待审核用户补建申请
+	if _, err := authService.EnsureRegistrations(); err != nil {
+		log.Fatalf("Failed to create registrations for pending users: %v", err)
+	}
 }
 
 // func loadCasbinEnforcer(authService *services.AuthService) (*casbin.Enforcer, error) {
@@ -185,16 +448,25 @@ func startServer(cfg *config.Config, router *mux.Router) *http.Server {
 	corsMiddleware := handlers.CORS(
 		handlers.AllowedOrigins(cfg.Server.AllowedOrigins), // 指定允许的来源
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-API-Key"}),
 	)
 
+	handler := corsMiddleware(router)
+	if cfg.Server.TrustProxyHeaders {
+		handler = handlers.ProxyHeaders(handler)
+	}
+
+	// 关闭服务器时取消所有请求的 context，让事件流等长连接及时退出
+	baseCtx, cancel := context.WithCancel(context.Background())
 	srv := &http.Server{
 		Addr:         cfg.Server.Address,
-		Handler:      corsMiddleware(router),
+		Handler:      handler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
 	}
+	srv.RegisterOnShutdown(cancel)
 
 	go func() {
 		log.Printf("Starting server on %s", cfg.Server.Address)
@@ -16,6 +16,8 @@ type ServerConfig struct {
 	IdleTimeout    time.Duration `mapstructure:"idle_timeout"`
 	EnableSwagger  bool          `mapstructure:"enable_swagger"`
 	AllowedOrigins []string      `mapstructure:"allowed_origins"` // 新增字段，用于配置允许的跨域源
+	// TrustProxyHeaders 为 true 时从 X-Forwarded-For 等请求头获取客户端 IP，只应在反向代理之后开启
+	TrustProxyHeaders bool `mapstructure:"trust_proxy_headers"`
 }
 
 // DatabaseConfig 包含数据库相关配置
@@ -28,14 +30,126 @@ type JWTConfig struct {
 	Secret               string        `mapstructure:"secret"`
 	AccessTokenDuration  time.Duration `mapstructure:"access_token_duration"`
 	RefreshTokenDuration time.Duration `mapstructure:"refresh_token_duration"`
+	Algorithm            string        `mapstructure:"algorithm"`             // HS256（默认）、RS256 或 EdDSA
+	KeyRotationInterval  time.Duration `mapstructure:"key_rotation_interval"` // 非对称密钥用于签名的时长，默认 30 天
+	KeyRotationGrace     time.Duration `mapstructure:"key_rotation_grace"`    // 新密钥提前发布、旧密钥继续验证的时长，默认 24 小时
+}
+
+// QuizConfig 包含答题相关配置
+type QuizConfig struct {
+	NotebookClearStreak uint `mapstructure:"notebook_clear_streak"` // 错题连续答对多少次后移出错题本
+}
+
+// ExportConfig 包含导出打印试卷相关配置
+type ExportConfig struct {
+	FontPath string `mapstructure:"font_path"` // PDF 使用的 UTF-8 TrueType 字体，题目含中文时需要配置
+}
+
+// EventsConfig 包含实时通知相关配置
+type EventsConfig struct {
+	DueReminder time.Duration `mapstructure:"due_reminder"` // 作业截止前多久提醒尚未提交的学员
+}
+
+// MFAConfig 包含两步验证相关配置
+type MFAConfig struct {
+	Issuer string `mapstructure:"issuer"` // 验证器中显示的服务名称
+}
+
+// PasswordConfig 包含密码规则
+type PasswordConfig struct {
+	MinLength          int           `mapstructure:"min_length"`           // 最少字符数，默认 8
+	BreachedList       string        `mapstructure:"breached_list"`        // 泄露密码列表文件，每行一个明文密码或 SHA-1
+	ResetURL           string        `mapstructure:"reset_url"`            // 找回密码邮件中的前端页面地址，为空时不能找回密码
+	ResetTokenDuration time.Duration `mapstructure:"reset_token_duration"` // 找回密码链接的有效期，默认 30 分钟
+}
+
+// SMTPConfig 包含发送邮件通知的 SMTP 服务器，host 为空时不发送邮件
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"` // 默认 587
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"` // 发件地址，例如 Learn <noreply@example.edu>
+}
+
+// LoginThrottleConfig 包含登录失败限流和临时锁定的设置，未设置的项使用默认值
+type LoginThrottleConfig struct {
+	AccountFreeAttempts int           `mapstructure:"account_free_attempts"`
+	IPFreeAttempts      int           `mapstructure:"ip_free_attempts"`
+	BaseDelay           time.Duration `mapstructure:"base_delay"`
+	MaxDelay            time.Duration `mapstructure:"max_delay"`
+	ResetAfter          time.Duration `mapstructure:"reset_after"`
+	LockoutThreshold    int           `mapstructure:"lockout_threshold"` // -1 表示不临时停用账号
+	LockoutDuration     time.Duration `mapstructure:"lockout_duration"`
+}
+
+// OIDCConfig 包含 OpenID Connect 单点登录相关配置，issuer 为空时不启用
+type OIDCConfig struct {
+	Issuer         string            `mapstructure:"issuer"`
+	ClientID       string            `mapstructure:"client_id"`
+	ClientSecret   string            `mapstructure:"client_secret"`
+	RedirectURL    string            `mapstructure:"redirect_url"`     // 本服务的回调地址，例如 https://learn.example.com/api/auth/oidc/callback
+	FrontendURL    string            `mapstructure:"frontend_url"`     // 登录或绑定完成后跳转的前端页面
+	Scopes         []string          `mapstructure:"scopes"`           // 默认 openid、profile、email
+	UsernameClaim  string            `mapstructure:"username_claim"`   // 默认 preferred_username
+	RolesClaim     string            `mapstructure:"roles_claim"`      // 例如 groups，为空时不同步角色
+	RoleMapping    map[string]string `mapstructure:"role_mapping"`     // IdP 中的角色或分组（不区分大小写）到本地角色名
+	DefaultRole    string            `mapstructure:"default_role"`     // 自动创建的用户没有匹配到角色时分配
+	AutoProvision  bool              `mapstructure:"auto_provision"`   // 首次登录时自动创建本地用户
+	LinkByUsername bool              `mapstructure:"link_by_username"` // 首次登录自动关联同名本地用户，只有 IdP 用户名可信时才开启
+}
+
+// RegistrationConfig 包含自助注册的审核和邮箱验证设置
+type RegistrationConfig struct {
+	RequireEmailVerification  bool                    `mapstructure:"require_email_verification"`  // 需要配置 smtp 和 verify_email_url
+	VerifyEmailURL            string                  `mapstructure:"verify_email_url"`            // 验证邮件中的链接，令牌作为 token 参数附加
+	VerificationTokenDuration time.Duration           `mapstructure:"verification_token_duration"` // 默认 24 小时
+	AutoApprove               []AutoApproveRuleConfig `mapstructure:"auto_approve"`
+}
+
+// AutoApproveRuleConfig 是一条自动通过注册申请的规则，invite_code 和 email_domain 设置其一
+type AutoApproveRuleConfig struct {
+	InviteCode  string   `mapstructure:"invite_code"`
+	EmailDomain string   `mapstructure:"email_domain"` // 邮箱验证后才生效
+	Roles       []string `mapstructure:"roles"`
+}
+
+// LDAPConfig 包含 LDAP / Active Directory 认证相关配置，url 为空时不启用
+type LDAPConfig struct {
+	URL                string            `mapstructure:"url"` // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool              `mapstructure:"start_tls"`
+	InsecureSkipVerify bool              `mapstructure:"insecure_skip_verify"`
+	BindDN             string            `mapstructure:"bind_dn"` // 用于查找用户的服务账号，为空时匿名查找
+	BindPassword       string            `mapstructure:"bind_password"`
+	BaseDN             string            `mapstructure:"base_dn"`
+	UserFilter         string            `mapstructure:"user_filter"`        // 默认 (uid=%s)，AD 通常为 (sAMAccountName=%s)
+	UsernameAttribute  string            `mapstructure:"username_attribute"` // 默认 uid，AD 通常为 sAMAccountName
+	EmailAttribute     string            `mapstructure:"email_attribute"`    // 默认 mail
+	GroupAttribute     string            `mapstructure:"group_attribute"`    // 默认 memberOf
+	GroupBaseDN        string            `mapstructure:"group_base_dn"`
+	GroupFilter        string            `mapstructure:"group_filter"`     // 目录不支持 memberOf 时使用，例如 (member=%s)
+	RoleMapping        map[string]string `mapstructure:"role_mapping"`     // 组 DN 或 CN（不区分大小写）到本地角色名
+	DefaultRole        string            `mapstructure:"default_role"`     // 首次登录的用户没有匹配到角色时分配
+	LinkByUsername     bool              `mapstructure:"link_by_username"` // 首次登录自动关联同名本地用户
+	Timeout            time.Duration     `mapstructure:"timeout"`
 }
 
 // Config 是包含所有配置的主结构体
 type Config struct {
-	Server       ServerConfig       `mapstructure:"server"`
-	Database     DatabaseConfig     `mapstructure:"database"`
-	JWT          JWTConfig          `mapstructure:"jwt"`
-	DefaultAdmin DefaultAdminConfig `mapstructure:"default_admin"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	JWT           JWTConfig           `mapstructure:"jwt"`
+	Quiz          QuizConfig          `mapstructure:"quiz"`
+	Export        ExportConfig        `mapstructure:"export"`
+	Events        EventsConfig        `mapstructure:"events"`
+	MFA           MFAConfig           `mapstructure:"mfa"`
+	Password      PasswordConfig      `mapstructure:"password"`
+	LoginThrottle LoginThrottleConfig `mapstructure:"login_throttle"`
+	SMTP          SMTPConfig          `mapstructure:"smtp"`
+	Registration  RegistrationConfig  `mapstructure:"registration"`
+	OIDC          OIDCConfig          `mapstructure:"oidc"`
+	LDAP          LDAPConfig          `mapstructure:"ldap"`
+	DefaultAdmin  DefaultAdminConfig  `mapstructure:"default_admin"`
 }
 
 // DefaultAdminConfig 包含默认 admin 用户配置
@@ -86,10 +200,27 @@ func LoadConfig(path string) (*Config, error) {
 			return nil, fmt.Errorf("JWT Secret must be configured")
 		}
 	}
+	if err := config.JWT.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// Validate 检查 JWT 配置。RS256 和 EdDSA 用数据库中的密钥签发 Access Token，
+// 但 MFAToken 仍以 Secret 签名，所以任何算法都必须配置 Secret
+func (c JWTConfig) Validate() error {
+	switch c.Algorithm {
+	case "", "HS256", "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("unsupported JWT algorithm: %s", c.Algorithm)
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("JWT Secret must be configured")
+	}
+	return nil
+}
+
 // 保存生成的 JWT Secret 到配置文件
 func saveConfigWithSecret(path string, secret string) error {
 	viper.Set("jwt.secret", secret)
@@ -0,0 +1,27 @@
+package config_test
+
+import (
+	"learn/config"
+	"testing"
+)
+
+func TestJWTConfigValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		jwt   config.JWTConfig
+		valid bool
+	}{
+		{"default algorithm", config.JWTConfig{Secret: "secret"}, true},
+		{"EdDSA with secret", config.JWTConfig{Secret: "secret", Algorithm: "EdDSA"}, true},
+		{"HS256 without secret", config.JWTConfig{Algorithm: "HS256"}, false},
+		// 非对称算法仍用 Secret 签发 MFAToken，没有 Secret 时不能启动
+		{"RS256 without secret", config.JWTConfig{Algorithm: "RS256"}, false},
+		{"EdDSA without secret", config.JWTConfig{Algorithm: "EdDSA"}, false},
+		{"unsupported algorithm", config.JWTConfig{Secret: "secret", Algorithm: "none"}, false},
+	}
+	for _, c := range cases {
+		if err := c.jwt.Validate(); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid=%v, got %v", c.name, c.valid, err)
+		}
+	}
+}
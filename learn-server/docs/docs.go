@@ -15,45 +15,50 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "处理用户登录并生成JWT令牌和刷新令牌",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "以 JWKS（RFC 7517）格式返回验证 Access Token 的公钥，其他服务按令牌头部的 kid 选择公钥。\n包含即将启用和轮换宽限期内的密钥；使用 HS256 签名时为空",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "用户登录",
-                "parameters": [
-                    {
-                        "description": "登录请求",
-                        "name": "login",
-                        "in": "body",
-                        "required": true,
+                "summary": "获取 JWT 公钥",
+                "responses": {
+                    "200": {
+                        "description": "JWKS",
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginRequest"
+                            "type": "object"
                         }
                     }
+                }
+            }
+        },
+        "/assignments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "获取当前用户所在班级的全部作业及自己的完成情况",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assignment"
+                ],
+                "summary": "获取我的作业",
                 "responses": {
                     "200": {
-                        "description": "登录成功",
-                        "schema": {
-                            "$ref": "#/definitions/Response-dto_TokenPairResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "无效请求",
+                        "description": "作业列表",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/Response-array_dto_AssignmentResponse"
                         }
                     },
                     "401": {
-                        "description": "认证失败",
+                        "description": "未登录",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -67,56 +72,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/refresh": {
-            "post": {
+        "/assignments/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "刷新JWT令牌，支持滑动过期的双Token机制",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "获取作业信息和题目，题目不含答案；作业开放前学员看不到题目",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Assignment"
                 ],
-                "summary": "刷新JWT令牌",
+                "summary": "获取作业详情",
                 "parameters": [
                     {
-                        "description": "刷新令牌请求",
-                        "name": "refreshToken",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.RefreshTokenRequest"
-                        }
+                        "type": "integer",
+                        "description": "作业 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "刷新成功",
-                        "schema": {
-                            "$ref": "#/definitions/Response-dto_TokenPairResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "无效请求",
+                        "description": "作业详情",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/Response-dto_AssignmentResponse"
                         }
                     },
-                    "401": {
-                        "description": "认证失败",
+                    "403": {
+                        "description": "不是班级成员或作业未开放",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "404": {
+                        "description": "作业不存在",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -124,45 +118,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "注册新用户",
-                "consumes": [
-                    "application/json"
+        "/assignments/{id}/gradebook": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "汇总班级每名学员的提交次数、最高得分和完成情况，仅班级教师可以查看",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Assignment"
                 ],
-                "summary": "用户注册",
+                "summary": "获取作业成绩册",
                 "parameters": [
                     {
-                        "description": "用户注册信息",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.RegisterUserRequest"
-                        }
+                        "type": "integer",
+                        "description": "作业 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "注册成功",
+                    "200": {
+                        "description": "成绩册",
                         "schema": {
-                            "$ref": "#/definitions/Response-string"
+                            "$ref": "#/definitions/Response-dto_GradebookResponse"
                         }
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "403": {
+                        "description": "不是班级教师",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "404": {
+                        "description": "作业不存在",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -170,45 +164,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/permissions": {
+        "/assignments/{id}/submissions": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取所有权限的列表",
+                "description": "获取当前用户在作业中的全部提交",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Permission"
+                    "Assignment"
+                ],
+                "summary": "获取我的作业提交",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "作业 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "获取权限列表",
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "提交列表",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_PermissionResponse"
+                            "$ref": "#/definitions/Response-array_dto_AssignmentSubmissionResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "403": {
+                        "description": "不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "作业不存在",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/quiz/question_attempts": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "记录用户对特定问题的答题情况",
+                "description": "提交作业的作答并判分，answers 以题目 ID 为键，未作答的题按错误计",
                 "consumes": [
                     "application/json"
                 ],
@@ -216,35 +223,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "QuestionAttempt"
+                    "Assignment"
                 ],
-                "summary": "记录用户的答题尝试",
+                "summary": "提交作业",
                 "parameters": [
                     {
-                        "description": "答题尝试信息",
-                        "name": "input",
+                        "type": "integer",
+                        "description": "作业 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "作答",
+                        "name": "submission",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.QuestionAttemptRequest"
+                            "$ref": "#/definitions/dto.SubmitAssignmentRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "记录成功",
+                    "201": {
+                        "description": "提交成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_QuestionAttemptResponse"
+                            "$ref": "#/definitions/Response-dto_AssignmentSubmissionResponse"
                         }
                     },
                     "400": {
-                        "description": "无效请求",
+                        "description": "作答格式错误",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "403": {
+                        "description": "不是班级成员、作业未开放、已截止或提交次数用完",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "作业不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "同时提交了多次",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -252,48 +278,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/quiz/question_attempts/{user_id}/{question_bank_id}": {
+        "/audit_logs": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取用户在特定题库中的答题情况",
+                "description": "按时间倒序分页返回账号安全相关的事件，例如 account_locked、account_unlocked",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "QuestionAttempt"
+                    "User"
                 ],
-                "summary": "获取用户的答题尝试情况",
+                "summary": "查询审计日志",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "事件类型",
+                        "name": "action",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "用户 ID",
                         "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "题库 ID",
-                        "name": "question_bank_id",
-                        "in": "path",
-                        "required": true
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "答题尝试列表",
-                        "schema": {
-                            "$ref": "#/definitions/Response-array_dto_QuestionAttemptResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "无效请求",
+                        "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/Response-array_dto_AuditLogResponse"
                         }
                     },
                     "500": {
@@ -305,29 +335,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/quiz/question_banks": {
+        "/auth/api_keys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取所有的题库",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "列出当前用户未吊销的 API Key，不包含密钥本身",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "QuestionBank"
+                    "Auth"
                 ],
-                "summary": "获取题库列表",
+                "summary": "获取我的 API Key",
                 "responses": {
                     "200": {
-                        "description": "题库列表",
+                        "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_QuestionBankResponse"
+                            "$ref": "#/definitions/Response-array_dto_APIKeyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -344,7 +377,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "创建一个新的题库",
+                "description": "创建供脚本调用接口的 API Key，通过 X-API-Key 或 Authorization: ApiKey 请求头使用。\n权限必须是当前用户拥有的权限；密钥只在响应中返回这一次。不能使用 API Key 创建新的 API Key。\n需要提供当前密码或两步验证码（也可以是恢复码）重新校验身份。修改密码或被踢出登录时所有 API Key 一并吊销",
                 "consumes": [
                     "application/json"
                 ],
@@ -352,17 +385,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "QuestionBank"
+                    "Auth"
                 ],
-                "summary": "创建题库",
+                "summary": "创建 API Key",
                 "parameters": [
                     {
-                        "description": "创建题库请求",
-                        "name": "questionBank",
+                        "description": "名称、权限和有效期",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateQuestionBankRequest"
+                            "$ref": "#/definitions/dto.CreateAPIKeyRequest"
                         }
                     }
                 ],
@@ -370,11 +403,35 @@ const docTemplate = `{
                     "201": {
                         "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_QuestionBankResponse"
+                            "$ref": "#/definitions/Response-dto_CreateAPIKeyResponse"
                         }
                     },
                     "400": {
-                        "description": "无效请求",
+                        "description": "无效请求、权限超出范围、密码或验证码错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "使用 API Key 认证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "未启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -388,56 +445,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/quiz/question_banks/{id}/questions": {
-            "get": {
+        "/auth/api_keys/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取指定题库的所有问题（分页查询）",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Question"
+                    "Auth"
                 ],
-                "summary": "获取题库问题",
+                "summary": "吊销我的 API Key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "题库 ID",
+                        "description": "API Key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "标签过滤",
-                        "name": "tag",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "页码",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "每页数量",
-                        "name": "page_size",
-                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "问题列表",
+                    "204": {
+                        "description": "吊销成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_QuestionResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API Key 不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -447,14 +494,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/login": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "创建一个新的问题",
+                "description": "处理用户登录并生成JWT令牌和刷新令牌。启用了两步验证的用户需要再调用 /auth/login/mfa，\n角色要求两步验证但尚未绑定的用户需要先通过 /auth/login/mfa/enroll 绑定验证器",
                 "consumes": [
                     "application/json"
                 ],
@@ -462,32 +506,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Question"
+                    "Auth"
                 ],
-                "summary": "创建问题",
+                "summary": "用户登录",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "题库 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "创建问题请求",
-                        "name": "question",
+                        "description": "登录请求",
+                        "name": "login",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateQuestionRequest"
+                            "$ref": "#/definitions/dto.LoginRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "创建成功",
+                    "200": {
+                        "description": "登录成功，需要两步验证时返回 mfa_token",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                            "$ref": "#/definitions/Response-dto_LoginResponse"
                         }
                     },
                     "400": {
@@ -496,6 +533,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "认证失败",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多，Retry-After 秒后再试",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "内部服务器错误",
                         "schema": {
@@ -505,14 +554,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/quiz/question_banks/{id}/random_questions": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "随机获取题库中的问题",
+        "/auth/login/mfa": {
+            "post": {
+                "description": "使用登录时返回的 mfa_token 和验证器上的验证码（或恢复码）换取令牌，连续失败 5 次后暂停验证 15 分钟",
                 "consumes": [
                     "application/json"
                 ],
@@ -520,29 +564,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Question"
+                    "Auth"
                 ],
-                "summary": "获取随机问题",
+                "summary": "两步验证登录",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "题库 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "随机题目数量",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "两步验证请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MFALoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "随机题目列表",
+                        "description": "登录成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_QuestionResponse"
+                            "$ref": "#/definitions/Response-dto_TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "mfa_token 无效或验证码错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -554,14 +612,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/quiz/questions/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "获取指定问题的详细信息，包括答案和标签",
+        "/auth/login/mfa/enroll": {
+            "post": {
+                "description": "角色要求两步验证但尚未绑定的用户，使用登录时返回的 mfa_token 生成 TOTP 密钥",
                 "consumes": [
                     "application/json"
                 ],
@@ -569,23 +622,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Question"
+                    "Auth"
                 ],
-                "summary": "获取问题详情",
+                "summary": "登录时绑定验证器",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "问题 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "mfa_token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MFATokenRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "问题详情",
+                        "description": "待绑定的密钥",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                            "$ref": "#/definitions/Response-dto_MFAEnrollmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "mfa_token 无效",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "已启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -595,14 +668,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "编辑指定问题",
+            }
+        },
+        "/auth/login/mfa/enroll/verify": {
+            "post": {
+                "description": "提交验证器上的验证码完成绑定，启用两步验证并返回令牌和恢复码，恢复码只显示这一次",
                 "consumes": [
                     "application/json"
                 ],
@@ -610,32 +680,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Question"
+                    "Auth"
                 ],
-                "summary": "更新问题",
+                "summary": "登录时确认绑定验证器",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "问题 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "更新问题请求",
-                        "name": "question",
+                        "description": "mfa_token 和验证码",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateQuestionRequest"
+                            "$ref": "#/definitions/dto.MFALoginRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "登录成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                            "$ref": "#/definitions/Response-dto_MFAEnrollmentLoginResponse"
                         }
                     },
                     "400": {
@@ -644,43 +707,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "401": {
+                        "description": "mfa_token 无效或验证码错误",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "删除指定问题",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Question"
-                ],
-                "summary": "删除问题",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "问题 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "删除成功"
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "409": {
+                        "description": "尚未生成密钥或已启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -694,26 +734,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/roles": {
+        "/auth/mfa": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取所有角色的列表",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Role"
+                    "Auth"
                 ],
-                "summary": "获取角色列表",
+                "summary": "获取两步验证状态",
                 "responses": {
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_RoleResponse"
+                            "$ref": "#/definitions/Response-dto_MFAStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -723,44 +768,58 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/mfa/disable": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "创建一个新角色",
+                "description": "校验验证码后关闭两步验证，角色要求两步验证时不能关闭",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Role"
+                    "Auth"
                 ],
-                "summary": "创建角色",
+                "summary": "关闭两步验证",
                 "parameters": [
                     {
-                        "description": "创建角色请求",
-                        "name": "role",
+                        "description": "验证码或恢复码",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.RoleRequest"
+                            "$ref": "#/definitions/dto.MFACodeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "创建成功",
+                    "204": {
+                        "description": "已关闭"
+                    },
+                    "400": {
+                        "description": "验证码错误",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_RoleResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "未启用或角色要求两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -774,89 +833,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/roles/{id}": {
-            "put": {
+        "/auth/mfa/enroll": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "更新指定角色的信息，包括角色名称和权限",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "生成新的 TOTP 密钥和二维码，提交验证码确认后才启用，未确认前可以重新生成",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Role"
-                ],
-                "summary": "更新角色信息",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "角色 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "更新角色请求",
-                        "name": "role",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.RoleUpdateRequest"
-                        }
-                    }
+                    "Auth"
                 ],
+                "summary": "绑定验证器",
                 "responses": {
                     "200": {
-                        "description": "更新成功",
-                        "schema": {
-                            "$ref": "#/definitions/Response-string"
-                        }
-                    },
-                    "400": {
-                        "description": "无效请求",
+                        "description": "待绑定的密钥",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/Response-dto_MFAEnrollmentResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "401": {
+                        "description": "未登录",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "根据角色ID删除指定的角色",
-                "tags": [
-                    "Role"
-                ],
-                "summary": "删除角色",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "角色 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "删除成功，无内容返回"
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "409": {
+                        "description": "已启用两步验证",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -870,14 +876,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/roles/{id}/permissions": {
-            "get": {
+        "/auth/mfa/enroll/verify": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取指定角色的权限列表",
+                "description": "提交验证器上的验证码，启用两步验证并返回恢复码，恢复码只显示这一次",
                 "consumes": [
                     "application/json"
                 ],
@@ -885,63 +891,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role"
+                    "Auth"
                 ],
-                "summary": "获取角色权限",
+                "summary": "确认绑定验证器",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "角色 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MFACodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "已启用",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_PermissionResponse"
+                            "$ref": "#/definitions/Response-dto_RecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "无效请求",
+                        "description": "验证码错误",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "内部服务器错误",
+                    "401": {
+                        "description": "未登录",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/users": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "获取所有用户的列表",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "User"
-                ],
-                "summary": "获取用户列表",
-                "responses": {
-                    "200": {
-                        "description": "请求成功",
+                    },
+                    "409": {
+                        "description": "尚未生成密钥或已启用两步验证",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_UserResponse"
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -951,14 +943,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/mfa/recovery_codes": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "创建一个新用户",
+                "description": "校验验证码后生成一组新的恢复码，之前的恢复码全部失效",
                 "consumes": [
                     "application/json"
                 ],
@@ -966,29 +960,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "创建用户",
+                "summary": "重新生成恢复码",
                 "parameters": [
                     {
-                        "description": "创建用户请求",
-                        "name": "user",
+                        "description": "验证码或恢复码",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateUserRequest"
+                            "$ref": "#/definitions/dto.MFACodeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "创建成功",
+                    "200": {
+                        "description": "新的恢复码",
                         "schema": {
-                            "$ref": "#/definitions/Response-dto_CreateUserResponse"
+                            "$ref": "#/definitions/Response-dto_RecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "无效请求",
+                        "description": "验证码错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "未启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -1002,51 +1014,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
-            "put": {
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "校验授权结果后跳转回前端页面。登录成功时附带一次性代码 code，前端用它调用 /auth/oidc/token 换取令牌；\n绑定成功时附带 linked=true；失败时附带 error，取值为 invalid_state、account_exists、not_provisioned、identity_linked、user_inactive、access_denied 或 login_failed",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "身份提供方登录回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "授权请求的 state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "授权码",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "身份提供方返回的错误",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "跳转回前端页面"
+                    }
+                }
+            }
+        },
+        "/auth/oidc/identities": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "更新指定用户的信息，包括用户名、角色和状态",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
-                ],
-                "summary": "更新用户信息",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "用户 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "更新用户请求",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateUserRequest"
-                        }
-                    }
+                    "Auth"
                 ],
+                "summary": "获取绑定的身份提供方账号",
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/Response-string"
+                            "$ref": "#/definitions/Response-array_dto_UserIdentityResponse"
                         }
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "401": {
+                        "description": "未登录",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -1060,48 +1085,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}/invalidate_session": {
-            "post": {
+        "/auth/oidc/identities/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Invalidates the user's current session, effectively logging them out by incrementing their token version.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "User"
+                    "Auth"
                 ],
-                "summary": "Invalidate User Session",
+                "summary": "解除绑定身份提供方账号",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "绑定 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User session invalidated successfully",
+                    "204": {
+                        "description": "解除成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
                         "schema": {
-                            "$ref": "#/definitions/Response-string"
+                            "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid user ID",
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "绑定不存在",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "内部服务器错误",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -1109,42 +1136,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}/roles": {
-            "get": {
+        "/auth/oidc/link": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "根据用户ID获取该用户的角色列表",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "返回身份提供方的授权地址，前端跳转过去登录后，回调会把该账号绑定到当前用户。\n响应会设置校验 state 的 HttpOnly Cookie，跨域调用时需要携带凭据（credentials: include）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
-                ],
-                "summary": "获取用户角色",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "用户 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "绑定身份提供方账号",
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "授权地址",
                         "schema": {
-                            "$ref": "#/definitions/Response-array_dto_RoleResponse"
+                            "$ref": "#/definitions/Response-dto_OIDCAuthorizationResponse"
                         }
                     },
-                    "400": {
-                        "description": "无效请求",
+                    "401": {
+                        "description": "未登录",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -1157,418 +1172,6697 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "Response-array_dto_PermissionResponse": {
-            "type": "object",
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "使用授权码模式（PKCE）跳转到身份提供方，登录完成后回调 /auth/oidc/callback。\n授权请求的 state 同时写入 HttpOnly Cookie，回调时校验，必须在同一浏览器中完成登录",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "通过身份提供方登录",
+                "responses": {
+                    "302": {
+                        "description": "跳转到身份提供方"
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/token": {
+            "post": {
+                "description": "代码一分钟内有效且只能使用一次。结果与 /auth/login 相同，需要两步验证时返回 mfa_token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "用一次性代码换取令牌",
+                "parameters": [
+                    {
+                        "description": "一次性代码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OIDCTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "代码无效或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "校验当前密码后设置新密码。所有已签发的令牌和登录设备随即失效，响应中返回当前设备的新令牌。\n当前密码错误与登录失败共用限流和临时停用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "修改密码",
+                "parameters": [
+                    {
+                        "description": "当前密码和新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "当前密码错误或新密码不符合要求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多，Retry-After 秒后再试",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "向用户名或邮箱对应账号的邮箱发送重置密码的链接。为避免泄露账号是否存在，无论账号是否存在都返回 202",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "找回密码",
+                "parameters": [
+                    {
+                        "description": "用户名或邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "已受理"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "未配置邮件发送",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "令牌只能使用一次。重置后所有已签发的令牌和登录设备失效，因登录失败造成的临时停用也一并解除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "重置密码",
+                "parameters": [
+                    {
+                        "description": "令牌和新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "重置成功"
+                    },
+                    "400": {
+                        "description": "令牌无效或新密码不符合要求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "用 Refresh Token 换取新的令牌对，旧的 Refresh Token 随即失效；重复使用已换发的 Refresh Token 会吊销该次登录的所有令牌",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "刷新JWT令牌",
+                "parameters": [
+                    {
+                        "description": "刷新令牌请求",
+                        "name": "refreshToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "刷新成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_TokenPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "认证失败",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "注册新用户。账号在管理员审核通过（或邀请码、邮箱域名自动通过）且邮箱验证后启用；填写了邮箱时发送验证邮件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "用户注册信息",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "注册成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_RegisterUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "邮箱已被使用",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register/resend_verification": {
+            "post": {
+                "description": "为避免泄露账号是否存在，无论账号是否存在都返回 202；一分钟内只发送一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "重新发送验证邮件",
+                "parameters": [
+                    {
+                        "description": "用户名或邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "已受理"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "未配置邮件发送",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register/verify_email": {
+            "post": {
+                "description": "令牌只能使用一次。邮箱域名匹配自动通过规则时注册申请同时通过；申请已通过时账号随即启用",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "验证邮箱",
+                "parameters": [
+                    {
+                        "description": "验证令牌",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "验证成功"
+                    },
+                    "400": {
+                        "description": "令牌无效或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出当前用户仍然有效的登录会话，最近活动的排在前面，current 标记发起请求的会话",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "获取我的登录设备",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_SessionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销当前用户的某个会话，该设备的 Access Token 和 Refresh Token 立即失效",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "注销我的登录设备",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "会话 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "注销成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "会话不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/badges": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取所有徽章及其达成条件",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "获取徽章规则",
+                "responses": {
+                    "200": {
+                        "description": "徽章列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_BadgeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建徽章规则。kind 为 correct_in_bank（在题库中累计答对，未指定题库时任一题库即可）、\ncorrect_total（累计答对）、streak（连续学习天数）或 total_xp（累计经验值），threshold 为需要达到的数值",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "创建徽章规则",
+                "parameters": [
+                    {
+                        "description": "徽章规则",
+                        "name": "badge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BadgeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_BadgeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/badges/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除徽章规则，学员已获得的该徽章一并删除",
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "删除徽章规则",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "徽章 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "删除成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "徽章不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取当前用户以教师或学员身份加入的班级",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "获取我的班级",
+                "responses": {
+                    "200": {
+                        "description": "班级列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_ClassResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个班级，创建者成为班级教师并获得邀请码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "创建班级",
+                "parameters": [
+                    {
+                        "description": "创建班级请求",
+                        "name": "class",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateClassRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_ClassResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/join": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "以学员身份通过邀请码加入班级",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "加入班级",
+                "parameters": [
+                    {
+                        "description": "邀请码",
+                        "name": "join",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.JoinClassRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "加入成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_ClassResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "邀请码无效",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取班级信息和成员列表，仅班级成员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "获取班级详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "班级详情",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_ClassResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "班级不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/assignments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取班级的全部作业，班级成员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assignment"
+                ],
+                "summary": "获取班级作业",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "作业列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_AssignmentResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将整个题库或挑选的题目布置给班级，可设置开放和截止时间、提交次数和迟交规则，仅班级教师可操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assignment"
+                ],
+                "summary": "布置作业",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "作业信息",
+                        "name": "assignment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAssignmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "布置成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_AssignmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/invite_code": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "重新生成班级邀请码，旧邀请码失效，仅班级教师可操作",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "重新生成邀请码",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "新的邀请码",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_InviteCodeResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "班级不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/leaderboard": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按班级学员获得的经验值排名，可按题库筛选。period 为 week（默认，每周一零点重置）或 all，仅班级成员可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "获取班级排行榜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "week",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "统计时间段",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回的名次数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "排行榜",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_LeaderboardResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/members": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "教师调整班级成员的身份；拥有 class:all 权限的用户可以直接将用户加入班级，其他用户需通过邀请码加入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "添加班级成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "成员信息",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddClassMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "添加成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_ClassMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师，或用户不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "教师移出班级成员，学员也可以退出班级",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "移出班级成员",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "移出成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "不是班级成员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/progress": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "汇总班级内每名学员在班级作业题目上的答题情况，仅班级教师可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "查看班级学员进度",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "学员进度",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_StudentProgressResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/classes/{id}/students/{user_id}/attempts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查看班级内某名学员在班级作业题目上的答题记录，仅班级教师可以查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Class"
+                ],
+                "summary": "查看学员答题记录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "班级 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "学员 ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "答题记录",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionAttemptResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "不是班级教师",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "不是班级学员",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "以 Server-Sent Events 推送当前用户的事件：assignment.published、assignment.due_soon、badge.earned。\n浏览器的 EventSource 可通过 access_token 查询参数传递令牌；断线重连时根据 Last-Event-ID 补发最近的事件",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "订阅实时通知",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "访问令牌",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次收到的事件 ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "事件流",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gamification/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取当前用户的累计和本周经验值、连续学习天数，以及所有徽章的进度和获得时间",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "获取我的经验值和徽章",
+                "responses": {
+                    "200": {
+                        "description": "经验值和徽章",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_GamificationStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/leaderboards/question_banks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按在题库中获得的经验值排名。period 为 week（默认，每周一零点重置）或 all",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gamification"
+                ],
+                "summary": "获取题库排行榜",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "week",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "统计时间段",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回的名次数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "排行榜",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_LeaderboardResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/live/sessions": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "从题库创建一场现场答题，返回供学员加入的 PIN。主持人随后连接 WebSocket 控制进度",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Live"
+                ],
+                "summary": "创建现场答题",
+                "parameters": [
+                    {
+                        "description": "现场答题设置",
+                        "name": "session",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateLiveSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_LiveSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/live/sessions/{pin}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按 PIN 获取现场答题的阶段和当前排行榜",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Live"
+                ],
+                "summary": "获取现场答题状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PIN",
+                        "name": "pin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "现场答题状态",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_LiveSessionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "现场答题不存在或已结束",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/live/sessions/{pin}/ws": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "升级为 WebSocket 连接，浏览器可通过 access_token 查询参数传递令牌。\n服务端推送 lobby、question、answer_count、answer_result、reveal、finished、error 事件；\n主持人发送 start、reveal、next、end 指令控制进度，学员发送 {\"type\":\"answer\",\"answer\":...} 作答",
+                "tags": [
+                    "Live"
+                ],
+                "summary": "连接现场答题",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PIN",
+                        "name": "pin",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "访问令牌",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "切换为 WebSocket 协议",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "现场答题不存在或已结束",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebook": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "分页获取当前用户错题本中的题目，可按题库和标签筛选，题目不含答案",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notebook"
+                ],
+                "summary": "获取错题本",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签过滤",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "错题列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_NotebookEntryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebook/practice": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "从当前用户的错题本中随机抽取题目，可按题库和标签筛选",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notebook"
+                ],
+                "summary": "错题练习",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签过滤",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题目数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "练习题列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebook/{question_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将题目加入当前用户的错题本并收藏，或修改笔记；收藏的题不会因连续答对被移出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notebook"
+                ],
+                "summary": "收藏错题或修改笔记",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "问题 ID",
+                        "name": "question_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "收藏和笔记",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateNotebookEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_NotebookEntryResponse"
+                        }
+                    },
+                    "204": {
+                        "description": "取消收藏后已移出错题本"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "问题不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将题目移出当前用户的错题本",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notebook"
+                ],
+                "summary": "移出错题本",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "问题 ID",
+                        "name": "question_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "移出成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/papers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取全部试卷，不含分节",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "获取试卷列表",
+                "responses": {
+                    "200": {
+                        "description": "试卷列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_PaperResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建草稿试卷。每个分节可以手动挑选题目，也可以按组卷规则（题库、题型、标签、难度、数量）抽题，并设置每道题的分值",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "创建试卷",
+                "parameters": [
+                    {
+                        "description": "试卷信息",
+                        "name": "paper",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PaperRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/papers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取试卷的分节、手动挑选的题目和组卷规则",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "获取试卷详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "试卷详情",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改草稿试卷，分节整体替换；冻结后的试卷不能修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "修改试卷",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "试卷信息",
+                        "name": "paper",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PaperRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "试卷已冻结",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除草稿试卷；冻结后的试卷已经下发给学员，不能删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "删除试卷",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-string"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "试卷已冻结",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/papers/{id}/freeze": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "冻结后试卷不能再修改。同一份模式下按规则抽题并固定，所有学员拿到同一份试卷；等价模式下固定候选题，每名学员各自抽到等价的一份",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "冻结试卷",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "冻结成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "题库中没有足够满足规则的题目",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "试卷已冻结",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/papers/{id}/preview": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按出题人视角预览一份具体试卷，含答案和总分。草稿试卷每次从题库中重新抽题，冻结的试卷按第一名学员的抽题结果展示",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "预览试卷",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "试卷预览",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "题库中没有足够满足规则的题目",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/papers/{id}/version": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取当前用户拿到的具体试卷，题目不含答案。只有冻结的试卷可以作答，同一学员每次拿到同一份",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Paper"
+                ],
+                "summary": "获取学员试卷",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "试卷 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "学员试卷",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PaperVersionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "试卷不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "试卷尚未冻结",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取所有权限的列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission"
+                ],
+                "summary": "获取权限列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_PermissionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/export": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将题库、按标签过滤或挑选的题目导出为 PDF 或 DOCX 试卷，题目编号、选项字母、填空和问答的作答横线均在服务端排版。\nvariants 大于 1 时生成 A/B 卷，B 卷起打乱题目和选项顺序；answer_key 为 true 时导出对应各卷的答案和解析。相同的请求（含 seed）得到相同的试卷，试卷和答案可以分两次导出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf",
+                    "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "导出打印试卷",
+                "parameters": [
+                    {
+                        "description": "导出请求",
+                        "name": "export",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "试卷或答案文档",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_attempts": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "记录当前用户对特定问题的答题情况，并按题库设置返回对错、正确答案、解析和相关题目。\n题库设置为不反馈时不返回答错次数、得分、经验值和徽章",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionAttempt"
+                ],
+                "summary": "记录用户的答题尝试",
+                "parameters": [
+                    {
+                        "description": "答题尝试信息",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.QuestionAttemptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "记录成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionAttemptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "问题不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_attempts/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "一次提交整套题的作答，为当前用户判分并在同一事务中记录。单题出错不影响其他题目，\n结果中的 error_code 为 question_not_found、invalid_answer 或 duplicate_question；汇总得分时出错的题按 0 分计。\n每题的反馈按所属题库的设置返回，题库设置为不反馈的题目不返回对错和得分，也不计入汇总",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionAttempt"
+                ],
+                "summary": "批量提交作答",
+                "parameters": [
+                    {
+                        "description": "整套题的作答",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchAttemptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "各题结果和汇总得分",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_BatchAttemptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_attempts/{user_id}/{question_bank_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取指定用户在题库中尚未连续答对 3 次的题目的答题情况，供教师和管理员使用，需要 quiz:read_all_attempts 权限。\n学员查看自己的答题情况请使用 /quiz/question_banks/{id}/attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionAttempt"
+                ],
+                "summary": "获取任意用户的答题尝试情况",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "答题尝试列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionAttemptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_banks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取所有的题库",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionBank"
+                ],
+                "summary": "获取题库列表",
+                "responses": {
+                    "200": {
+                        "description": "题库列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionBankResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个新的题库",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionBank"
+                ],
+                "summary": "创建题库",
+                "parameters": [
+                    {
+                        "description": "创建题库请求",
+                        "name": "questionBank",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateQuestionBankRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionBankResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_banks/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改题库名称和作答反馈方式，考试用的题库可设置为不反馈",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionBank"
+                ],
+                "summary": "修改题库设置",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改题库请求",
+                        "name": "questionBank",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateQuestionBankRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionBankResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_banks/{id}/attempts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取当前用户在题库中尚未连续答对 3 次的题目的答题情况，题库设置为不反馈时只返回作答次数和时间",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "QuestionAttempt"
+                ],
+                "summary": "获取自己的答题情况",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "答题尝试列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionAttemptResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_banks/{id}/questions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取指定题库的所有问题（分页查询）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "获取题库问题",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签过滤",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "问题列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个新的问题",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "创建问题",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "创建问题请求",
+                        "name": "question",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateQuestionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/question_banks/{id}/random_questions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "随机获取题库中的问题",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "获取随机问题",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "随机题目数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "随机题目列表",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_QuestionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/questions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取指定问题的详细信息和标签；答案和解析是否可见取决于调用方权限和查看场景",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "获取问题详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "问题 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "查看场景：practice、exam、review、author",
+                        "name": "view",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "问题详情",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "无权查看答案",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "编辑指定问题",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "更新问题",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "问题 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新问题请求",
+                        "name": "question",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateQuestionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_QuestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除指定问题；被冻结的试卷或仍可提交的作业引用的问题不能删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Question"
+                ],
+                "summary": "删除问题",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "问题 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "删除成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "问题不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "问题被冻结的试卷或仍可提交的作业引用",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/registrations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按提交顺序分页返回注册申请，默认只返回待审核的申请",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "查询注册申请",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending（默认）、approved、rejected 或 all",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_RegistrationResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/registrations/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "通过申请并授予角色，并通过邮件通知用户。需要验证邮箱但尚未验证时，账号在验证后启用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "通过注册申请",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "申请 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "授予的角色",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ApproveRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "审核成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_RegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求或角色不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "申请不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "申请已经审核过",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/registrations/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "拒绝申请，账号保持停用，并通过邮件把原因告知用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "拒绝注册申请",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "申请 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "拒绝原因",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RejectRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "审核成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_RegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "申请不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "申请已经审核过",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取所有角色的列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "获取角色列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_RoleResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个新角色",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "创建角色",
+                "parameters": [
+                    {
+                        "description": "创建角色请求",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_RoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "更新指定角色的信息，包括角色名称和权限",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "更新角色信息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "角色 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新角色请求",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RoleUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-string"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据角色ID删除指定的角色",
+                "tags": [
+                    "Role"
+                ],
+                "summary": "删除角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "角色 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "删除成功，无内容返回"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles/{id}/mfa": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "拥有该角色的用户登录时必须通过两步验证，尚未绑定的用户需要先绑定验证器。对之后的登录生效",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "设置角色是否要求两步验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "角色 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "两步验证策略",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RoleMFAPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "设置成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "角色不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/roles/{id}/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取指定角色的权限列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "获取角色权限",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "角色 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_PermissionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync/attempts": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "批量上传设备离线时的作答，按 answered_at 先后重放，判分、答题记录、错题本和经验值与在线作答相同。\n每条作答带设备生成的 client_id，重复上传返回 duplicate 和首次同步的结果，可以放心重试；\n早于服务端最近一次作答的离线作答只计入作答次数（superseded），不改变最近作答和错题本。\n单条作答无效时只拒绝该条（rejected），其余作答照常记录；\n题库设置为不反馈时不返回对错、得分和经验值（withheld）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OfflineSync"
+                ],
+                "summary": "上传离线作答",
+                "parameters": [
+                    {
+                        "description": "离线作答",
+                        "name": "attempts",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SyncAttemptsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "同步结果",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_SyncAttemptsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未登录",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync/practice_package": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "下载题库的离线练习包，题目不含答案。answer_hashes 以题目 ID 为键，值为标准答案逐项的 hex(sha256(salt:题目 ID:答案))，\n设备按同样规则散列作答即可本地判分：单选和多选题为按 ID 升序的选项 JSON 数组（如 [3,5]），判断题为 true 或 false，填空题每一空单独散列。\n只有完整反馈的题库才下发散列，其他题库只能联网同步时判分",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OfflineSync"
+                ],
+                "summary": "下载离线练习包",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "题库 ID",
+                        "name": "question_bank_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签过滤",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "题目数量上限，最多 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "离线练习包",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_PracticePackageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "题库不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取所有用户的列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "获取用户列表",
+                "responses": {
+                    "200": {
+                        "description": "请求成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_UserResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个新用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "创建用户",
+                "parameters": [
+                    {
+                        "description": "创建用户请求",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_CreateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "更新指定用户的信息，包括用户名、角色和状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "更新用户信息",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新用户请求",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-string"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/api_keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "获取用户的 API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_APIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "常用于服务账号。权限必须是该用户拥有的权限；密钥只在响应中返回这一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "为用户创建 API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "名称、权限和有效期",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-dto_CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求或权限超出范围",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "使用 API Key 认证",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/api_keys/{key_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "吊销用户的 API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "吊销成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API Key 不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/invalidate_session": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Invalidates the user's current session, effectively logging them out by incrementing their token version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Invalidate User Session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User session invalidated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/Response-string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/mfa": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除用户的验证器绑定和恢复码，用于用户丢失验证器的情况。角色要求两步验证的用户下次登录时需要重新绑定",
+                "tags": [
+                    "User"
+                ],
+                "summary": "重置用户的两步验证",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "已重置"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据用户ID获取该用户的角色列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "获取用户角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_RoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出用户仍然有效的登录会话",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "获取用户的登录设备",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/Response-array_dto_SessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/sessions/{session_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销用户的某个会话，不影响用户在其他设备上的登录",
+                "tags": [
+                    "User"
+                ],
+                "summary": "踢出用户的某个登录设备",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "会话 ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "吊销成功"
+                    },
+                    "400": {
+                        "description": "无效请求",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "会话不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "内部服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "Response-array_dto_APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.APIKeyResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_AssignmentResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AssignmentResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_AssignmentSubmissionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AssignmentSubmissionResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditLogResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_BadgeResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BadgeResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_ClassResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ClassResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_NotebookEntryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NotebookEntryResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_PaperResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PaperResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_PermissionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PermissionResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_QuestionAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionAttemptResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_QuestionBankResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionBankResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_QuestionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_RegistrationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RegistrationResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_RoleResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RoleResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_SessionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SessionResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_StudentProgressResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StudentProgressResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_UserIdentityResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UserIdentityResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-array_dto_UserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UserResponse"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_AssignmentResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.AssignmentResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_AssignmentSubmissionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.AssignmentSubmissionResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_BadgeResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.BadgeResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_BatchAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.BatchAttemptResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_ClassMemberResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.ClassMemberResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_ClassResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.ClassResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.CreateAPIKeyResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.CreateUserResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_GamificationStatsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.GamificationStatsResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_GradebookResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.GradebookResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_InviteCodeResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.InviteCodeResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_LeaderboardResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.LeaderboardResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_LiveSessionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.LiveSessionResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_LoginResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.LoginResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_MFAEnrollmentLoginResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.MFAEnrollmentLoginResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_MFAEnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.MFAEnrollmentResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_MFAStatusResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.MFAStatusResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_NotebookEntryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.NotebookEntryResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_OIDCAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.OIDCAuthorizationResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_PaperResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.PaperResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_PaperVersionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.PaperVersionResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_PracticePackageResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.PracticePackageResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_QuestionAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.QuestionAttemptResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_QuestionBankResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.QuestionBankResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_QuestionResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.QuestionResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.RecoveryCodesResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_RegisterUserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.RegisterUserResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_RegistrationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.RegistrationResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_RoleResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.RoleResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_SyncAttemptsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.SyncAttemptsResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-dto_TokenPairResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.TokenPairResponse"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Response-string": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/api.PaginationMeta"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PaginationMeta": {
+            "type": "object",
+            "properties": {
+                "current_page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_records": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prefix": {
+                    "description": "密钥开头的几个字符",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AddClassMemberRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "description": "0 学员，1 教师",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ClassRole"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AnswerOption": {
+            "type": "object",
+            "required": [
+                "option_text"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "is_correct": {
+                    "type": "boolean"
+                },
+                "option_text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ApproveRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "description": "授予的角色",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.AssignmentQuestionResult": {
+            "type": "object",
+            "properties": {
+                "correct": {
+                    "type": "boolean"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.AssignmentResponse": {
+            "type": "object",
+            "properties": {
+                "best_score": {
+                    "description": "当前学员的最高得分",
+                    "type": "number"
+                },
+                "class_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "late_penalty": {
+                    "type": "number"
+                },
+                "late_policy": {
+                    "$ref": "#/definitions/models.LatePolicy"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "open_at": {
+                    "type": "string"
+                },
+                "question_bank_id": {
+                    "type": "integer"
+                },
+                "question_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "questions": {
+                    "description": "查看作业详情时返回，不含答案",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionResponse"
+                    }
+                },
+                "submissions": {
+                    "description": "当前学员已提交次数",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AssignmentSubmissionResponse": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "late": {
+                    "type": "boolean"
+                },
+                "raw_score": {
+                    "type": "number"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AssignmentQuestionResult"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "submitted_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.BadgeRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "kind",
+                "name",
+                "threshold"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "correct_in_bank、correct_total、streak、total_xp",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BadgeKind"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "question_bank_id": {
+                    "description": "仅 correct_in_bank 使用，为空表示任一题库",
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BadgeResponse": {
+            "type": "object",
+            "properties": {
+                "awarded_at": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.BadgeKind"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "integer"
+                },
+                "question_bank_id": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BatchAnswer": {
+            "type": "object",
+            "required": [
+                "question_id"
+            ],
+            "properties": {
+                "answer": {},
+                "question_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BatchAttemptItem": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "$ref": "#/definitions/dto.QuestionAttemptResponse"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_code": {
+                    "description": "question_not_found、invalid_answer 或 duplicate_question",
+                    "type": "string"
+                },
+                "question_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BatchAttemptRequest": {
+            "type": "object",
+            "required": [
+                "answers"
+            ],
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchAnswer"
+                    }
+                }
+            }
+        },
+        "dto.BatchAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "correct": {
+                    "description": "答对的题目数",
+                    "type": "integer"
+                },
+                "graded": {
+                    "description": "成功判分并下发得分的题目数",
+                    "type": "integer"
+                },
+                "percentage": {
+                    "description": "得分率，不含不下发得分的题，未能判分的题按 0 分计",
+                    "type": "number"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchAttemptItem"
+                    }
+                },
+                "score": {
+                    "description": "各题得分之和",
+                    "type": "number"
+                },
+                "total": {
+                    "description": "提交的题目数",
+                    "type": "integer"
+                },
+                "withheld": {
+                    "description": "已记录但题库设置为不反馈、不下发得分的题目数",
+                    "type": "integer"
+                },
+                "xp": {
+                    "description": "本次获得的经验值",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BlueprintRule": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "points": {
+                    "description": "每道题的分值，为 0 时按 1 分计",
+                    "type": "number"
+                },
+                "question_bank_id": {
+                    "type": "integer"
+                },
+                "question_type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "description": "修改后重新签发令牌时使用的设备名称",
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ChildQuestionRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "answer_options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AnswerOption"
+                    }
+                },
+                "answer_text": {
+                    "type": "string"
+                },
+                "blanks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FillInTheBlankAnswer"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "编辑时传入已有小题的 ID 以保留其作答记录，省略时新建",
+                    "type": "integer"
+                },
+                "question_type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "true_false": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.ClassMemberResponse": {
+            "type": "object",
+            "properties": {
+                "joined_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.ClassRole"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ClassResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invite_code": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ClassMemberResponse"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "current_password": {
+                    "description": "创建自己的 API Key 时需要提供当前密码或两步验证码（也可以是恢复码）之一，管理员代为创建时不需要",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "为空表示不过期",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "description": "允许访问的权限，必须是用户拥有的权限",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prefix": {
+                    "description": "密钥开头的几个字符",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAssignmentRequest": {
+            "type": "object",
+            "required": [
+                "due_at",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "late_penalty": {
+                    "description": "迟交扣减的得分比例，取值 0~1",
+                    "type": "number"
+                },
+                "late_policy": {
+                    "description": "0 不能迟交，1 可以迟交，2 迟交扣分",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LatePolicy"
+                        }
+                    ]
+                },
+                "max_attempts": {
+                    "description": "0 表示不限次数",
+                    "type": "integer"
+                },
+                "open_at": {
+                    "description": "为空时立即开放",
+                    "type": "string"
+                },
+                "question_bank_id": {
+                    "description": "布置整个题库",
+                    "type": "integer"
+                },
+                "question_ids": {
+                    "description": "手动挑选的题目，按顺序排列",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateClassRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateLiveSessionRequest": {
+            "type": "object",
+            "required": [
+                "question_bank_id"
+            ],
+            "properties": {
+                "question_bank_id": {
+                    "type": "integer"
+                },
+                "question_count": {
+                    "description": "随机抽取的题数，0 表示题库全部题目",
+                    "type": "integer"
+                },
+                "question_seconds": {
+                    "description": "每道题的倒计时秒数，默认 20",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreateQuestionBankRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "feedback_mode": {
+                    "description": "0 完整反馈，1 只反馈对错，2 不反馈",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FeedbackMode"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateQuestionRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "question_type"
+            ],
+            "properties": {
+                "answer_options": {
+                    "description": "仅选择题使用",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AnswerOption"
+                    }
+                },
+                "answer_text": {
+                    "description": "问答题使用",
+                    "type": "string"
+                },
+                "author_id": {
+                    "description": "问题的作者 ID",
+                    "type": "integer"
+                },
+                "blanks": {
+                    "description": "填空题使用",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FillInTheBlankAnswer"
+                    }
+                },
+                "children": {
+                    "description": "组合题使用",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ChildQuestionRequest"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "description": "难度 1~5，0 表示未设置",
+                    "type": "integer"
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "question_type": {
+                    "$ref": "#/definitions/models.QuestionType"
+                },
+                "related_question_ids": {
+                    "description": "相关题目，作答反馈中推荐",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "stimulus": {
+                    "description": "组合题使用",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Stimulus"
+                        }
+                    ]
+                },
+                "tags": {
+                    "description": "标签列表",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "true_false": {
+                    "description": "判断题使用",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.CreateUserRequest": {
+            "type": "object",
             "properties": {
-                "data": {
+                "email": {
+                    "description": "可选，用于找回密码",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "用户可以有多个角色",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.PermissionResponse"
+                        "type": "string"
+                    }
+                },
+                "service_account": {
+                    "description": "创建服务账号，忽略密码、邮箱和状态，只能使用 API Key",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "用户状态",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserStatus"
+                        }
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "answer_key": {
+                    "description": "导出答案和解析，而不是试卷",
+                    "type": "boolean"
+                },
+                "format": {
+                    "description": "pdf 或 docx，默认 pdf",
+                    "type": "string"
+                },
+                "question_bank_id": {
+                    "type": "integer"
+                },
+                "question_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "seed": {
+                    "description": "打乱顺序所用的种子，相同的请求得到相同的试卷",
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "variants": {
+                    "description": "A/B 卷数量，默认 1；B 卷起打乱题目和选项顺序",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FillInTheBlankAnswer": {
+            "type": "object",
+            "required": [
+                "blank_text"
+            ],
+            "properties": {
+                "blank_text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ForgotPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GamificationStatsResponse": {
+            "type": "object",
+            "properties": {
+                "badges": {
+                    "description": "所有徽章及进度",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BadgeResponse"
+                    }
+                },
+                "correct_answers": {
+                    "type": "integer"
+                },
+                "current_streak": {
+                    "description": "昨天和今天都没有作答时为 0",
+                    "type": "integer"
+                },
+                "last_active_on": {
+                    "type": "string"
+                },
+                "longest_streak": {
+                    "type": "integer"
+                },
+                "total_xp": {
+                    "type": "integer"
+                },
+                "weekly_xp": {
+                    "description": "本周（周一起）获得的经验值",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.GradebookEntry": {
+            "type": "object",
+            "properties": {
+                "best_score": {
+                    "type": "number"
+                },
+                "completed": {
+                    "type": "boolean"
+                },
+                "last_submitted_at": {
+                    "type": "string"
+                },
+                "late": {
+                    "description": "最高得分的提交是否迟交",
+                    "type": "boolean"
+                },
+                "submissions": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GradebookResponse": {
+            "type": "object",
+            "properties": {
+                "assignment_id": {
+                    "type": "integer"
+                },
+                "average_score": {
+                    "description": "已完成学员最高得分的平均值",
+                    "type": "number"
+                },
+                "completed_count": {
+                    "type": "integer"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "student_count": {
+                    "type": "integer"
+                },
+                "students": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GradebookEntry"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.InviteCodeResponse": {
+            "type": "object",
+            "properties": {
+                "invite_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.JoinClassRequest": {
+            "type": "object",
+            "required": [
+                "invite_code"
+            ],
+            "properties": {
+                "invite_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "xp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LeaderboardResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.LeaderboardEntry"
+                    }
+                },
+                "me": {
+                    "description": "当前用户的名次，未上榜时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.LeaderboardEntry"
+                        }
+                    ]
+                },
+                "period": {
+                    "description": "week 或 all",
+                    "type": "string"
+                },
+                "since": {
+                    "description": "本周排行榜的起始时间",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LivePlayer": {
+            "type": "object",
+            "properties": {
+                "correct": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LiveSessionResponse": {
+            "type": "object",
+            "properties": {
+                "host_id": {
+                    "type": "integer"
+                },
+                "pin": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.LivePlayer"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "question_count": {
+                    "type": "integer"
                 },
-                "status": {
+                "question_seconds": {
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "lobby、question、reveal 或 finished",
+                    "type": "string"
+                },
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "Response-array_dto_QuestionAttemptResponse": {
+        "dto.LoginRequest": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.QuestionAttemptResponse"
-                    }
+                "device_name": {
+                    "description": "可选，显示在会话列表中",
+                    "type": "string"
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "password": {
+                    "type": "string"
                 },
-                "status": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "Response-array_dto_QuestionBankResponse": {
+        "dto.LoginResponse": {
             "type": "object",
             "properties": {
-                "data": {
+                "access_token": {
+                    "type": "string"
+                },
+                "mfa_enrollment_required": {
+                    "description": "角色要求两步验证但尚未绑定",
+                    "type": "boolean"
+                },
+                "mfa_required": {
+                    "description": "需要调用 /auth/login/mfa 提交验证码",
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MFACodeRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MFAEnrollmentLoginResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "recovery_codes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.QuestionBankResponse"
+                        "type": "string"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MFAEnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "description": "otpauth:// 地址",
+                    "type": "string"
+                },
+                "qr_code": {
+                    "description": "二维码 PNG 的 data URI",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MFALoginRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MFAStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recovery_codes_remaining": {
+                    "type": "integer"
+                },
+                "required": {
+                    "description": "角色要求启用两步验证",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.MFATokenRequest": {
+            "type": "object",
+            "properties": {
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.NotebookEntryResponse": {
+            "type": "object",
+            "properties": {
+                "consecutive_correct": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "question": {
+                    "description": "不含答案",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.QuestionResponse"
+                        }
+                    ]
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "starred": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "wrong_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.OIDCAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OIDCTokenRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OfflineAttempt": {
+            "type": "object",
+            "required": [
+                "answered_at",
+                "client_id",
+                "question_id"
+            ],
+            "properties": {
+                "answer": {
+                    "description": "格式与在线作答相同"
+                },
+                "answered_at": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "description": "设备生成的唯一 ID，重复上传时据此去重",
+                    "type": "string"
+                },
+                "question_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.OfflineAttemptResult": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "correct": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
                 },
                 "status": {
+                    "description": "applied、duplicate 或 rejected",
                     "type": "string"
+                },
+                "superseded": {
+                    "description": "早于服务端已有的最近作答，只计入作答次数",
+                    "type": "boolean"
+                },
+                "withheld": {
+                    "description": "题库设置为不反馈，不返回对错、得分和经验值",
+                    "type": "boolean"
+                },
+                "xp": {
+                    "type": "integer"
                 }
             }
         },
-        "Response-array_dto_QuestionResponse": {
+        "dto.PaperQuestion": {
             "type": "object",
             "properties": {
-                "data": {
+                "drawn": {
+                    "description": "冻结时按规则抽出的题",
+                    "type": "boolean"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.PaperRequest": {
+            "type": "object",
+            "required": [
+                "sections",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "0 所有学员同一份，1 每名学员抽取等价的一份",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PaperMode"
+                        }
+                    ]
+                },
+                "sections": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.QuestionResponse"
+                        "$ref": "#/definitions/dto.PaperSectionRequest"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
-                },
-                "status": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "Response-array_dto_RoleResponse": {
+        "dto.PaperResponse": {
             "type": "object",
             "properties": {
-                "data": {
+                "author_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "frozen": {
+                    "type": "boolean"
+                },
+                "frozen_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/models.PaperMode"
+                },
+                "sections": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.RoleResponse"
+                        "$ref": "#/definitions/dto.PaperSectionResponse"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "title": {
+                    "type": "string"
                 },
-                "status": {
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "Response-array_dto_UserResponse": {
+        "dto.PaperSectionRequest": {
             "type": "object",
             "properties": {
-                "data": {
+                "questions": {
+                    "description": "手动挑选的题目",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.UserResponse"
+                        "$ref": "#/definitions/dto.PaperQuestion"
                     }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "rules": {
+                    "description": "组卷规则",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BlueprintRule"
+                    }
                 },
-                "status": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "Response-dto_CreateUserResponse": {
+        "dto.PaperSectionResponse": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.CreateUserResponse"
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PaperQuestion"
+                    }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BlueprintRule"
+                    }
                 },
-                "status": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "Response-dto_QuestionAttemptResponse": {
+        "dto.PaperVersionQuestion": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.QuestionAttemptResponse"
-                },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "points": {
+                    "type": "number"
                 },
-                "status": {
-                    "type": "string"
+                "question": {
+                    "$ref": "#/definitions/dto.QuestionResponse"
                 }
             }
         },
-        "Response-dto_QuestionBankResponse": {
+        "dto.PaperVersionResponse": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.QuestionBankResponse"
+                "description": {
+                    "type": "string"
+                },
+                "paper_id": {
+                    "type": "integer"
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PaperVersionSection"
+                    }
                 },
-                "status": {
+                "title": {
                     "type": "string"
+                },
+                "total_points": {
+                    "type": "number"
                 }
             }
         },
-        "Response-dto_QuestionResponse": {
+        "dto.PaperVersionSection": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.QuestionResponse"
+                "points": {
+                    "type": "number"
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PaperVersionQuestion"
+                    }
                 },
-                "status": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "Response-dto_RoleResponse": {
+        "dto.PermissionResponse": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.RoleResponse"
+                "description": {
+                    "type": "string"
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "id": {
+                    "type": "integer"
                 },
-                "status": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "Response-dto_TokenPairResponse": {
+        "dto.PracticeFeedback": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.TokenPairResponse"
+                "blank_results": {
+                    "description": "填空题每一空是否正确",
+                    "type": "array",
+                    "items": {
+                        "type": "boolean"
+                    }
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "children": {
+                    "description": "组合题各小题的反馈",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PracticeFeedback"
+                    }
                 },
-                "status": {
-                    "type": "string"
+                "correct": {
+                    "type": "boolean"
+                },
+                "question": {
+                    "description": "含正确答案和解析的题目，仅完整反馈时返回",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.QuestionResponse"
+                        }
+                    ]
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "related_questions": {
+                    "description": "相关题目，不含答案",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionResponse"
+                    }
+                },
+                "score": {
+                    "type": "number"
                 }
             }
         },
-        "Response-string": {
+        "dto.PracticePackageResponse": {
             "type": "object",
             "properties": {
-                "data": {
+                "answer_hashes": {
+                    "description": "只有完整反馈的题库才下发",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "issued_at": {
                     "type": "string"
                 },
-                "meta": {
-                    "$ref": "#/definitions/api.PaginationMeta"
+                "question_bank_id": {
+                    "type": "integer"
                 },
-                "status": {
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionResponse"
+                    }
+                },
+                "salt": {
+                    "type": "string"
+                },
+                "tag": {
                     "type": "string"
                 }
             }
         },
-        "api.ErrorResponse": {
+        "dto.QuestionAttemptRequest": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string"
+                "answer": {
+                    "description": "Stores the user's answer, can be string, []string, bool, or an object keyed by child question ID for group questions"
                 },
-                "status": {
-                    "type": "string"
+                "question_id": {
+                    "type": "integer"
                 }
             }
         },
-        "api.PaginationMeta": {
+        "dto.QuestionAttemptResponse": {
             "type": "object",
             "properties": {
-                "current_page": {
+                "attempts": {
                     "type": "integer"
                 },
-                "page_size": {
+                "badges": {
+                    "description": "本次新获得的徽章",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BadgeResponse"
+                    }
+                },
+                "children": {
+                    "description": "组合题各小题的作答情况",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.QuestionAttemptResponse"
+                    }
+                },
+                "consecutive_correct": {
                     "type": "integer"
                 },
-                "total_records": {
+                "feedback": {
+                    "description": "作答反馈，题库设置为不反馈时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.PracticeFeedback"
+                        }
+                    ]
+                },
+                "last_answer_at": {
+                    "type": "string"
+                },
+                "last_score": {
+                    "type": "number"
+                },
+                "question_id": {
+                    "type": "integer"
+                },
+                "wrong": {
+                    "type": "integer"
+                },
+                "xp": {
+                    "description": "本次获得的经验值",
                     "type": "integer"
                 }
             }
         },
-        "dto.AnswerOption": {
+        "dto.QuestionBankResponse": {
             "type": "object",
-            "required": [
-                "option_text"
-            ],
             "properties": {
+                "feedback_mode": {
+                    "$ref": "#/definitions/models.FeedbackMode"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "is_correct": {
-                    "type": "boolean"
-                },
-                "option_text": {
-                    "type": "string"
-                }
-            }
-        },
-        "dto.CreateQuestionBankRequest": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
                 "name": {
                     "type": "string"
                 }
             }
         },
-        "dto.CreateQuestionRequest": {
+        "dto.QuestionResponse": {
             "type": "object",
-            "required": [
-                "content",
-                "question_type"
-            ],
             "properties": {
                 "answer_options": {
-                    "description": "仅选择题使用",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/dto.AnswerOption"
                     }
                 },
-                "answer_text": {
-                    "description": "问答题使用",
-                    "type": "string"
-                },
                 "author_id": {
-                    "description": "问题的作者 ID",
                     "type": "integer"
                 },
-                "blanks": {
-                    "description": "填空题使用",
+                "author_name": {
+                    "description": "用户名",
+                    "type": "string"
+                },
+                "children": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.FillInTheBlankAnswer"
+                        "$ref": "#/definitions/dto.QuestionResponse"
                     }
                 },
                 "content": {
                     "type": "string"
                 },
+                "created_at": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
                 "explanation": {
                     "type": "string"
                 },
+                "fill_in_the_blanks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FillInTheBlankAnswer"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "question_bank_id": {
+                    "type": "integer"
+                },
                 "question_type": {
                     "$ref": "#/definitions/models.QuestionType"
                 },
+                "stimulus": {
+                    "$ref": "#/definitions/dto.Stimulus"
+                },
                 "tags": {
-                    "description": "标签列表",
+                    "description": "返回标签",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "true_false": {
-                    "description": "判断题使用",
-                    "type": "boolean"
+                "true_false_answer": {
+                    "$ref": "#/definitions/dto.TrueFalseAnswer"
+                },
+                "written_answer": {
+                    "$ref": "#/definitions/dto.WrittenAnswer"
                 }
             }
         },
-        "dto.CreateUserRequest": {
+        "dto.RecoveryCodesResponse": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "roles": {
-                    "description": "用户可以有多个角色",
+                "recovery_codes": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                }
+            }
+        },
+        "dto.RefreshTokenRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RegisterUserRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "可选，用于找回密码；开启邮箱验证时必填",
+                    "type": "string"
                 },
-                "status": {
-                    "description": "用户状态",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.UserStatus"
-                        }
-                    ]
+                "invite_code": {
+                    "description": "可选，有效的邀请码可以免去人工审核",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
                 },
                 "username": {
                     "type": "string"
                 }
             }
         },
-        "dto.CreateUserResponse": {
+        "dto.RegisterUserResponse": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "description": "申请是否已经通过，例如填写了有效的邀请码",
+                    "type": "boolean"
+                },
+                "email_verification_required": {
+                    "description": "需要先点击验证邮件中的链接",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "账号状态：active 表示已经可以登录，pending 表示等待审核或验证邮箱",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RegistrationResponse": {
             "type": "object",
             "properties": {
+                "approved_by": {
+                    "description": "admin、invite_code 或 email_domain",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer"
+                },
+                "reason": {
+                    "description": "拒绝原因",
+                    "type": "string"
+                },
+                "reviewer_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "pending、approved 或 rejected",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "dto.FillInTheBlankAnswer": {
+        "dto.RejectRegistrationRequest": {
             "type": "object",
-            "required": [
-                "blank_text"
-            ],
             "properties": {
-                "blank_text": {
+                "reason": {
+                    "description": "会通过邮件告知用户",
                     "type": "string"
                 }
             }
         },
-        "dto.LoginRequest": {
+        "dto.ResendVerificationRequest": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string"
-                },
-                "username": {
+                "login": {
                     "type": "string"
                 }
             }
         },
-        "dto.PermissionResponse": {
+        "dto.ResetPasswordRequest": {
             "type": "object",
             "properties": {
-                "description": {
+                "new_password": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "dto.QuestionAttemptRequest": {
+        "dto.RoleMFAPolicyRequest": {
             "type": "object",
             "properties": {
-                "answer": {
-                    "description": "Stores the user's answer, can be string, []string, bool, etc."
-                },
-                "question_id": {
-                    "type": "integer"
-                },
-                "user_id": {
-                    "type": "integer"
+                "require_mfa": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.QuestionAttemptResponse": {
+        "dto.RoleRequest": {
             "type": "object",
             "properties": {
-                "attempts": {
-                    "type": "integer"
-                },
-                "consecutive_correct": {
-                    "type": "integer"
-                },
-                "last_answer_at": {
+                "name": {
                     "type": "string"
-                },
-                "question_id": {
-                    "type": "integer"
-                },
-                "wrong": {
-                    "type": "integer"
                 }
             }
         },
-        "dto.QuestionBankResponse": {
+        "dto.RoleResponse": {
             "type": "object",
             "properties": {
                 "id": {
@@ -1576,112 +7870,139 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "require_mfa": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.QuestionResponse": {
+        "dto.RoleUpdateRequest": {
             "type": "object",
             "properties": {
-                "answer_options": {
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.AnswerOption"
+                        "type": "integer"
                     }
-                },
-                "author_id": {
-                    "type": "integer"
-                },
-                "author_name": {
-                    "description": "用户名",
+                }
+            }
+        },
+        "dto.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "content": {
-                    "type": "string"
+                "current": {
+                    "description": "是否为发起请求的会话",
+                    "type": "boolean"
                 },
-                "created_at": {
+                "device_name": {
                     "type": "string"
                 },
-                "explanation": {
+                "expires_at": {
                     "type": "string"
                 },
-                "fill_in_the_blanks": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.FillInTheBlankAnswer"
-                    }
-                },
                 "id": {
                     "type": "integer"
                 },
-                "question_bank_id": {
-                    "type": "integer"
-                },
-                "question_type": {
-                    "$ref": "#/definitions/models.QuestionType"
-                },
-                "tags": {
-                    "description": "返回标签",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "ip": {
+                    "type": "string"
                 },
-                "true_false_answer": {
-                    "$ref": "#/definitions/dto.TrueFalseAnswer"
+                "last_seen_at": {
+                    "type": "string"
                 },
-                "written_answer": {
-                    "$ref": "#/definitions/dto.WrittenAnswer"
+                "user_agent": {
+                    "type": "string"
                 }
             }
         },
-        "dto.RefreshTokenRequest": {
+        "dto.Stimulus": {
             "type": "object",
             "properties": {
-                "token": {
+                "media_type": {
+                    "type": "string"
+                },
+                "media_url": {
+                    "type": "string"
+                },
+                "text": {
                     "type": "string"
                 }
             }
         },
-        "dto.RegisterUserRequest": {
+        "dto.StudentProgressResponse": {
             "type": "object",
             "properties": {
-                "password": {
+                "attempted_questions": {
+                    "description": "做过的题目数",
+                    "type": "integer"
+                },
+                "attempts": {
+                    "description": "累计作答次数",
+                    "type": "integer"
+                },
+                "average_score": {
+                    "description": "各题最近一次得分的平均值",
+                    "type": "number"
+                },
+                "last_answer_at": {
                     "type": "string"
                 },
+                "user_id": {
+                    "type": "integer"
+                },
                 "username": {
                     "type": "string"
+                },
+                "wrong": {
+                    "description": "累计答错次数",
+                    "type": "integer"
                 }
             }
         },
-        "dto.RoleRequest": {
+        "dto.SubmitAssignmentRequest": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "answers": {
+                    "type": "object",
+                    "additionalProperties": true
                 }
             }
         },
-        "dto.RoleResponse": {
+        "dto.SyncAttemptsRequest": {
             "type": "object",
+            "required": [
+                "attempts"
+            ],
             "properties": {
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OfflineAttempt"
+                    }
                 }
             }
         },
-        "dto.RoleUpdateRequest": {
+        "dto.SyncAttemptsResponse": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string"
+                "applied": {
+                    "type": "integer"
                 },
-                "permissions": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "integer"
+                },
+                "results": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/dto.OfflineAttemptResult"
                     }
                 }
             }
@@ -1705,6 +8026,36 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.UpdateNotebookEntryRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "starred": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.UpdateQuestionBankRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "feedback_mode": {
+                    "description": "0 完整反馈，1 只反馈对错，2 不反馈",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FeedbackMode"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.UpdateQuestionRequest": {
             "type": "object",
             "required": [
@@ -1735,9 +8086,20 @@ const docTemplate = `{
                         "$ref": "#/definitions/dto.FillInTheBlankAnswer"
                     }
                 },
+                "children": {
+                    "description": "组合题使用",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ChildQuestionRequest"
+                    }
+                },
                 "content": {
                     "type": "string"
                 },
+                "difficulty": {
+                    "description": "难度 1~5，0 表示未设置",
+                    "type": "integer"
+                },
                 "explanation": {
                     "type": "string"
                 },
@@ -1747,6 +8109,21 @@ const docTemplate = `{
                 "question_type": {
                     "$ref": "#/definitions/models.QuestionType"
                 },
+                "related_question_ids": {
+                    "description": "相关题目，省略时保持不变",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "stimulus": {
+                    "description": "组合题使用",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.Stimulus"
+                        }
+                    ]
+                },
                 "tags": {
                     "description": "标签列表",
                     "type": "array",
@@ -1763,6 +8140,10 @@ const docTemplate = `{
         "dto.UpdateUserRequest": {
             "type": "object",
             "properties": {
+                "email": {
+                    "description": "为空表示不修改，空字符串表示清除",
+                    "type": "string"
+                },
                 "password": {
                     "description": "可选的密码字段",
                     "type": "string"
@@ -1781,15 +8162,51 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.UserIdentityResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.UserResponse": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "email": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "locked_until": {
+                    "description": "因登录失败过多被临时停用时的解除时间",
+                    "type": "string"
+                },
+                "service_account": {
+                    "type": "boolean"
+                },
                 "status": {
                     "type": "integer"
                 },
@@ -1804,6 +8221,14 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.VerifyEmailRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.WrittenAnswer": {
             "type": "object",
             "required": [
@@ -1815,6 +8240,89 @@ const docTemplate = `{
                 }
             }
         },
+        "models.BadgeKind": {
+            "type": "string",
+            "enum": [
+                "correct_in_bank",
+                "correct_total",
+                "streak",
+                "total_xp"
+            ],
+            "x-enum-comments": {
+                "BadgeCorrectInBank": "在题库中累计答对指定次数，未指定题库时任一题库即可",
+                "BadgeCorrectTotal": "累计答对指定次数",
+                "BadgeStreak": "连续学习指定天数",
+                "BadgeTotalXP": "累计获得指定经验值"
+            },
+            "x-enum-varnames": [
+                "BadgeCorrectInBank",
+                "BadgeCorrectTotal",
+                "BadgeStreak",
+                "BadgeTotalXP"
+            ]
+        },
+        "models.ClassRole": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-varnames": [
+                "ClassRoleStudent",
+                "ClassRoleTeacher"
+            ]
+        },
+        "models.FeedbackMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-comments": {
+                "FeedbackCorrectness": "只反馈对错和每空结果",
+                "FeedbackFull": "对错、正确答案、解析和相关题目",
+                "FeedbackNone": "不反馈，用于考试"
+            },
+            "x-enum-varnames": [
+                "FeedbackFull",
+                "FeedbackCorrectness",
+                "FeedbackNone"
+            ]
+        },
+        "models.LatePolicy": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2
+            ],
+            "x-enum-comments": {
+                "LateAllowed": "可以提交，标记为迟交",
+                "LateNotAllowed": "截止后不能提交",
+                "LatePenalized": "可以提交，得分按比例扣减"
+            },
+            "x-enum-varnames": [
+                "LateNotAllowed",
+                "LateAllowed",
+                "LatePenalized"
+            ]
+        },
+        "models.PaperMode": {
+            "type": "integer",
+            "enum": [
+                0,
+                1
+            ],
+            "x-enum-comments": {
+                "PaperModeEquivalent": "冻结时固定候选题，每名学员按规则抽到等价的一份试卷",
+                "PaperModeSame": "冻结时按规则抽题，所有学员拿到同一份试卷"
+            },
+            "x-enum-varnames": [
+                "PaperModeSame",
+                "PaperModeEquivalent"
+            ]
+        },
         "models.QuestionType": {
             "type": "integer",
             "enum": [
@@ -1822,17 +8330,20 @@ const docTemplate = `{
                 1,
                 2,
                 3,
-                4
+                4,
+                5
             ],
             "x-enum-comments": {
-                "QuestionTypeFillInTheBlank": "新增填空题类型"
+                "QuestionTypeFillInTheBlank": "新增填空题类型",
+                "QuestionTypeGroup": "组合题：共享材料下的多道小题"
             },
             "x-enum-varnames": [
                 "QuestionTypeSingleChoice",
                 "QuestionTypeMultipleChoice",
                 "QuestionTypeTrueFalse",
                 "QuestionTypeWrittenAnswer",
-                "QuestionTypeFillInTheBlank"
+                "QuestionTypeFillInTheBlank",
+                "QuestionTypeGroup"
             ]
         },
         "models.UserStatus": {
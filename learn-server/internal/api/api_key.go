@@ -0,0 +1,232 @@
+// api/api_key.go
+package api
+
+import (
+	"errors"
+	"learn/internal/consts/contextkeys"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetMyAPIKeys 获取当前用户的 API Key
+// @Summary 获取我的 API Key
+// @Description 列出当前用户未吊销的 API Key，不包含密钥本身
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.APIKeyResponse] "获取成功"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/api_keys [get]
+func (h *AuthHandler) GetMyAPIKeys(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.writeAPIKeys(w, user.ID)
+}
+
+// CreateMyAPIKey 为当前用户创建 API Key
+// @Summary 创建 API Key
+// @Description 创建供脚本调用接口的 API Key，通过 X-API-Key 或 Authorization: ApiKey 请求头使用。
+// @Description 权限必须是当前用户拥有的权限；密钥只在响应中返回这一次。不能使用 API Key 创建新的 API Key。
+// @Description 需要提供当前密码或两步验证码（也可以是恢复码）重新校验身份。修改密码或被踢出登录时所有 API Key 一并吊销
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CreateAPIKeyRequest true "名称、权限和有效期"
+// @Success 201 {object} Response[dto.CreateAPIKeyResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求、权限超出范围、密码或验证码错误"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 403 {object} ErrorResponse "使用 API Key 认证"
+// @Failure 409 {object} ErrorResponse "未启用两步验证"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/api_keys [post]
+func (h *AuthHandler) CreateMyAPIKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.createAPIKey(w, r, user.ID, true)
+}
+
+// RevokeMyAPIKey 吊销当前用户的 API Key
+// @Summary 吊销我的 API Key
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Param id path int true "API Key ID"
+// @Success 204 "吊销成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 404 {object} ErrorResponse "API Key 不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/api_keys/{id} [delete]
+func (h *AuthHandler) RevokeMyAPIKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	keyID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+	h.revokeAPIKey(w, r, user.ID, keyID)
+}
+
+// GetUserAPIKeys 获取用户的 API Key
+// @Summary 获取用户的 API Key
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} Response[[]dto.APIKeyResponse] "获取成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/api_keys [get]
+func (h *AuthHandler) GetUserAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	h.writeAPIKeys(w, userID)
+}
+
+// CreateUserAPIKey 为用户或服务账号创建 API Key
+// @Summary 为用户创建 API Key
+// @Description 常用于服务账号。权限必须是该用户拥有的权限；密钥只在响应中返回这一次
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "用户 ID"
+// @Param request body dto.CreateAPIKeyRequest true "名称、权限和有效期"
+// @Success 201 {object} Response[dto.CreateAPIKeyResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求或权限超出范围"
+// @Failure 403 {object} ErrorResponse "使用 API Key 认证"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/api_keys [post]
+func (h *AuthHandler) CreateUserAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	h.createAPIKey(w, r, userID, false)
+}
+
+// RevokeUserAPIKey 吊销用户的 API Key
+// @Summary 吊销用户的 API Key
+// @Tags User
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Param key_id path int true "API Key ID"
+// @Success 204 "吊销成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "API Key 不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/api_keys/{key_id} [delete]
+func (h *AuthHandler) RevokeUserAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	keyID, ok := ParseUintParam(r, "key_id")
+	if !ok {
+		Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+	h.revokeAPIKey(w, r, userID, keyID)
+}
+
+func (h *AuthHandler) writeAPIKeys(w http.ResponseWriter, userID uint) {
+	keys, err := h.AuthService.GetAPIKeys(userID)
+	if err != nil {
+		Error(w, "Failed to get API keys", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		resp[i] = newAPIKeyResponse(key)
+	}
+	Success(w, resp, nil, http.StatusOK)
+}
+
+// createAPIKey 创建 API Key，reauthenticate 为 true 时先校验请求中的当前密码或验证码
+func (h *AuthHandler) createAPIKey(w http.ResponseWriter, r *http.Request, userID uint, reauthenticate bool) {
+	// 防止泄露的密钥用来生成更多密钥
+	if _, ok := r.Context().Value(contextkeys.APIKeyID).(uint); ok {
+		Error(w, "API keys cannot be created with an API key", http.StatusForbidden)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.CreateAPIKeyRequest](w, r)
+	if !ok {
+		return
+	}
+	if reauthenticate {
+		if err := h.AuthService.Reauthenticate(userID, req.CurrentPassword, req.Code, clientIP(r)); err != nil {
+			if writeLoginThrottled(w, err) {
+				return
+			}
+			if errors.Is(err, services.ErrReauthenticationRequired) || errors.Is(err, services.ErrIncorrectPassword) {
+				Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeMFAError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	apiKey, key, err := h.AuthService.CreateAPIKey(userID, req.Name, req.Permissions, req.ExpiresAt, clientIP(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrAPIKeyNameRequired), errors.Is(err, services.ErrAPIKeyScopeRequired),
+			errors.Is(err, services.ErrAPIKeyScopeNotAllowed), errors.Is(err, services.ErrAPIKeyExpiry):
+			Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Failed to create API key: %v", err)
+			Error(w, "Failed to create API key", http.StatusInternalServerError)
+		}
+		return
+	}
+	Success(w, dto.CreateAPIKeyResponse{APIKeyResponse: newAPIKeyResponse(apiKey), Key: key}, nil, http.StatusCreated)
+}
+
+func (h *AuthHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request, userID, keyID uint) {
+	if err := h.AuthService.RevokeAPIKey(userID, keyID, clientIP(r)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newAPIKeyResponse(key models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Scopes(),
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		LastUsedIP:  key.LastUsedIP,
+		CreatedAt:   key.CreatedAt,
+	}
+}
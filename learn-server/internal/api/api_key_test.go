@@ -0,0 +1,226 @@
+// api/api_key_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAPIKeys(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Class{}, &models.ClassMember{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	classService := services.NewClassService(db, authService)
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService},
+		&api.ClassHandler{ClassService: classService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	grant := func(roleName string, names ...string) {
+		role := models.Role{}
+		if err := db.Where("name = ?", roleName).First(&role).Error; err != nil {
+			role, _ = authService.CreateRole(roleName)
+		}
+		req := dto.RoleUpdateRequest{Name: roleName}
+		for _, permission := range permissions {
+			if slices.Contains(names, permission.Name) {
+				req.Permissions = append(req.Permissions, int(permission.ID))
+			}
+		}
+		if err := authService.UpdateRole(role.ID, req); err != nil {
+			t.Fatalf("Failed to grant permissions: %v", err)
+		}
+	}
+	grant("member", "auth:api_keys", "auth:sessions")
+	authService.CreateRole("admin")
+	authService.CreateUser("alice", "password", []string{"member"}, models.StatusActive)
+	authService.CreateUser("root", "password", []string{"admin"}, models.StatusActive)
+
+	send := func(method, path string, header http.Header, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	bearer := func(username string) http.Header {
+		w := send(http.MethodPost, "/auth/login", nil, dto.LoginRequest{Username: username, Password: "password"})
+		var resp api.Response[dto.TokenPairResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return http.Header{"Authorization": {"Bearer " + resp.Data.AccessToken}}
+	}
+	withKey := func(key string) http.Header {
+		return http.Header{"X-Api-Key": {key}}
+	}
+	createKey := func(header http.Header, path string, req dto.CreateAPIKeyRequest) (int, dto.CreateAPIKeyResponse) {
+		w := send(http.MethodPost, path, header, req)
+		var resp api.Response[dto.CreateAPIKeyResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	alice := bearer("alice")
+	root := bearer("root")
+
+	// 需要重新校验当前密码，权限必须是用户拥有的权限
+	past := time.Now().Add(-time.Hour)
+	for _, req := range []dto.CreateAPIKeyRequest{
+		{Name: "script", Permissions: []string{"auth:sessions"}},
+		{Name: "script", Permissions: []string{"auth:sessions"}, CurrentPassword: "wrong"},
+		{Name: "script", CurrentPassword: "password"},
+		{Name: "", Permissions: []string{"auth:sessions"}, CurrentPassword: "password"},
+		{Name: "script", Permissions: []string{"users:read"}, CurrentPassword: "password"},
+		{Name: "script", Permissions: []string{"no:such"}, CurrentPassword: "password"},
+		{Name: "script", Permissions: []string{"auth:sessions"}, ExpiresAt: &past, CurrentPassword: "password"},
+	} {
+		if code, _ := createKey(alice, "/auth/api_keys", req); code != http.StatusBadRequest {
+			t.Errorf("Expected %+v to be rejected, got %v", req, code)
+		}
+	}
+	if code, _ := createKey(alice, "/auth/api_keys", dto.CreateAPIKeyRequest{Name: "script", Permissions: []string{"auth:sessions"}, Code: "123456"}); code != http.StatusConflict {
+		t.Errorf("Expected a verification code without two-factor authentication to be rejected, got %v", code)
+	}
+	var failures int64
+	db.Model(&models.AuditLog{}).Where("action = ?", services.AuditReauthenticationFailed).Count(&failures)
+	if failures != 1 {
+		t.Errorf("Expected the wrong password to be audited, got %d", failures)
+	}
+	code, created := createKey(alice, "/auth/api_keys", dto.CreateAPIKeyRequest{Name: "backup script", Permissions: []string{"auth:sessions"}, CurrentPassword: "password"})
+	if code != http.StatusCreated || !strings.HasPrefix(created.Key, services.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Fatalf("Failed to create API key: %v %+v", code, created)
+	}
+	var stored models.APIKey
+	db.First(&stored, created.ID)
+	if strings.Contains(stored.KeyHash, created.Key) || stored.KeyHash == created.Key {
+		t.Errorf("Expected only the hash to be stored")
+	}
+
+	// 两种请求头都可以使用，权限范围之外的接口返回 403
+	if w := send(http.MethodGet, "/auth/sessions", withKey(created.Key), nil); w.Code != http.StatusOK {
+		t.Errorf("Expected X-API-Key to authenticate, got %v %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", http.Header{"Authorization": {"ApiKey " + created.Key}}, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected Authorization: ApiKey to authenticate, got %v", w.Code)
+	}
+	if w := send(http.MethodGet, "/auth/api_keys", withKey(created.Key), nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected request outside the key's scope to be forbidden, got %v", w.Code)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", withKey(created.Key+"x"), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unknown key to be rejected, got %v", w.Code)
+	}
+	w := send(http.MethodGet, "/auth/api_keys", alice, nil)
+	var keys api.Response[[]dto.APIKeyResponse]
+	json.NewDecoder(w.Body).Decode(&keys)
+	if len(keys.Data) != 1 || keys.Data[0].LastUsedAt == nil || keys.Data[0].LastUsedIP != "192.0.2.1" ||
+		!slices.Equal(keys.Data[0].Permissions, []string{"auth:sessions"}) {
+		t.Errorf("Expected usage to be tracked, got %+v", keys.Data)
+	}
+
+	// 不能用 API Key 创建新的 API Key
+	_, manager := createKey(alice, "/auth/api_keys", dto.CreateAPIKeyRequest{Name: "manager", Permissions: []string{"auth:api_keys"}, CurrentPassword: "password"})
+	if code, _ := createKey(withKey(manager.Key), "/auth/api_keys", dto.CreateAPIKeyRequest{Name: "more", Permissions: []string{"auth:api_keys"}, CurrentPassword: "password"}); code != http.StatusForbidden {
+		t.Errorf("Expected key creation with an API key to be forbidden, got %v", code)
+	}
+
+	// 角色失去权限后密钥同样失去权限
+	grant("member", "auth:api_keys")
+	if w := send(http.MethodGet, "/auth/sessions", withKey(created.Key), nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected key to follow the user's permissions, got %v", w.Code)
+	}
+	grant("member", "auth:api_keys", "auth:sessions")
+
+	// 过期和吊销
+	db.Model(&models.APIKey{}).Where("id = ?", manager.ID).Update("expires_at", time.Now().Add(-time.Second))
+	if w := send(http.MethodGet, "/auth/api_keys", withKey(manager.Key), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected expired key to be rejected, got %v", w.Code)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("/auth/api_keys/%d", created.ID), alice, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to revoke API key: %v", w.Code)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", withKey(created.Key), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked key to be rejected, got %v", w.Code)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("/auth/api_keys/%d", created.ID), alice, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected revoked key to be gone, got %v", w.Code)
+	}
+
+	// 踢出登录时所有 API Key 一并吊销
+	_, remaining := createKey(alice, "/auth/api_keys", dto.CreateAPIKeyRequest{Name: "nightly", Permissions: []string{"auth:sessions"}, CurrentPassword: "password"})
+	if w := send(http.MethodGet, "/auth/sessions", withKey(remaining.Key), nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected new key to work, got %v", w.Code)
+	}
+	var aliceUser models.User
+	db.Where("username = ?", "alice").First(&aliceUser)
+	if w := send(http.MethodPost, fmt.Sprintf("/users/%d/invalidate_session", aliceUser.ID), root, nil); w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to invalidate sessions: %v", w.Code)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", withKey(remaining.Key), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected keys to be revoked with the user's sessions, got %v", w.Code)
+	}
+
+	// 接口内部的权限判断同样受密钥权限范围限制：只有 class:read 的密钥不能凭管理员的 class:all 查看别人的班级
+	var rootUser models.User
+	db.Where("username = ?", "root").First(&rootUser)
+	class, err := classService.CreateClass(aliceUser.ID, "alice's class", "")
+	if err != nil {
+		t.Fatalf("Failed to create class: %v", err)
+	}
+	classURL := fmt.Sprintf("/classes/%d", class.ID)
+	if w := send(http.MethodGet, classURL, root, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected admin to view any class, got %v", w.Code)
+	}
+	_, reader := createKey(root, fmt.Sprintf("/users/%d/api_keys", rootUser.ID), dto.CreateAPIKeyRequest{Name: "reader", Permissions: []string{"class:read"}})
+	if w := send(http.MethodGet, classURL, withKey(reader.Key), nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected class:all outside the key's scope to be ignored, got %v", w.Code)
+	}
+
+	// 服务账号不能用密码登录，由管理员创建密钥
+	w = send(http.MethodPost, "/users", root, dto.CreateUserRequest{Username: "ci-bot", Roles: []string{"member"}, ServiceAccount: true})
+	var bot api.Response[dto.CreateUserResponse]
+	json.NewDecoder(w.Body).Decode(&bot)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create service account: %v %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodPost, "/auth/login", nil, dto.LoginRequest{Username: "ci-bot", Password: ""}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected service account password login to fail, got %v", w.Code)
+	}
+	code, botKey := createKey(root, fmt.Sprintf("/users/%d/api_keys", bot.Data.ID), dto.CreateAPIKeyRequest{Name: "ci", Permissions: []string{"auth:sessions"}})
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create key for service account: %v", code)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", withKey(botKey.Key), nil); w.Code != http.StatusOK {
+		t.Errorf("Expected service account key to work, got %v", w.Code)
+	}
+	authService.DeactivateUser(bot.Data.ID)
+	if w := send(http.MethodGet, "/auth/sessions", withKey(botKey.Key), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected inactive user's key to be rejected, got %v", w.Code)
+	}
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action IN ?", []string{services.AuditAPIKeyCreated, services.AuditAPIKeyRevoked}).Count(&audits)
+	if audits != 6 {
+		t.Errorf("Expected key changes to be audited, got %d", audits)
+	}
+}
@@ -0,0 +1,369 @@
+// api/assignment.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AssignmentHandler struct {
+	AssignmentService *services.AssignmentService
+	ClassService      *services.ClassService
+}
+
+func (h *AssignmentHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/classes/{id}/assignments", "GET", h.GetClassAssignments, "assignment:read", "查看班级作业"},
+		{"/classes/{id}/assignments", "POST", h.CreateAssignment, "assignment:edit", "布置作业"},
+		{"/assignments", "GET", h.GetMyAssignments, "assignment:read", "查看我的作业"},
+		{"/assignments/{id}", "GET", h.GetAssignment, "assignment:read", "查看作业详情"},
+		{"/assignments/{id}/submissions", "GET", h.GetMySubmissions, "assignment:read", "查看我的作业提交"},
+		{"/assignments/{id}/submissions", "POST", h.SubmitAssignment, "assignment:submit", "提交作业"},
+		{"/assignments/{id}/gradebook", "GET", h.GetGradebook, "assignment:read", "查看作业成绩册"},
+	}
+}
+
+// CreateAssignment 为班级布置作业
+// @Summary 布置作业
+// @Description 将整个题库或挑选的题目布置给班级，可设置开放和截止时间、提交次数和迟交规则，仅班级教师可操作
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param assignment body dto.CreateAssignmentRequest true "作业信息"
+// @Success 201 {object} Response[dto.AssignmentResponse] "布置成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes/{id}/assignments [post]
+func (h *AssignmentHandler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	user, classID, ok := h.authorizeClass(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+	req, ok := DecodeJSONBody[dto.CreateAssignmentRequest](w, r)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.AssignmentService.CreateAssignment(classID, user.ID, *req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAssignment) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to create assignment", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, newAssignmentResponse(assignment), nil, http.StatusCreated)
+}
+
+// GetClassAssignments 获取班级的作业
+// @Summary 获取班级作业
+// @Description 获取班级的全部作业，班级成员可以查看
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Success 200 {object} Response[[]dto.AssignmentResponse] "作业列表"
+// @Failure 403 {object} ErrorResponse "不是班级成员"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes/{id}/assignments [get]
+func (h *AssignmentHandler) GetClassAssignments(w http.ResponseWriter, r *http.Request) {
+	_, classID, ok := h.authorizeClass(w, r, services.ClassActionView)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.AssignmentService.GetClassAssignments(classID)
+	if err != nil {
+		Error(w, "Failed to retrieve assignments", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.AssignmentResponse, len(assignments))
+	for i := range assignments {
+		response[i] = newAssignmentResponse(&assignments[i])
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// GetMyAssignments 获取当前用户的作业
+// @Summary 获取我的作业
+// @Description 获取当前用户所在班级的全部作业及自己的完成情况
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.AssignmentResponse] "作业列表"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /assignments [get]
+func (h *AssignmentHandler) GetMyAssignments(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	assignments, err := h.AssignmentService.GetUserAssignments(user.ID)
+	if err != nil {
+		Error(w, "Failed to retrieve assignments", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.AssignmentResponse, len(assignments))
+	for i := range assignments {
+		response[i] = newAssignmentResponse(&assignments[i])
+		submissions, err := h.AssignmentService.GetSubmissions(assignments[i].ID, user.ID)
+		if err != nil {
+			Error(w, "Failed to retrieve submissions", http.StatusInternalServerError)
+			return
+		}
+		count := uint(len(submissions))
+		response[i].Submissions = &count
+		for j, submission := range submissions {
+			if j == 0 || submission.Score > *response[i].BestScore {
+				score := submission.Score
+				response[i].BestScore = &score
+			}
+		}
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// GetAssignment 获取作业详情
+// @Summary 获取作业详情
+// @Description 获取作业信息和题目，题目不含答案；作业开放前学员看不到题目
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "作业 ID"
+// @Success 200 {object} Response[dto.AssignmentResponse] "作业详情"
+// @Failure 403 {object} ErrorResponse "不是班级成员或作业未开放"
+// @Failure 404 {object} ErrorResponse "作业不存在"
+// @Router /assignments/{id} [get]
+func (h *AssignmentHandler) GetAssignment(w http.ResponseWriter, r *http.Request) {
+	user, assignment, ok := h.loadAssignment(w, r, services.ClassActionView)
+	if !ok {
+		return
+	}
+
+	isTeacher := h.ClassService.CanAccessClass(r.Context(), user, assignment.ClassID, services.ClassActionManage)
+	if !isTeacher && time.Now().Before(assignment.OpenAt) {
+		Error(w, services.ErrAssignmentNotOpen.Error(), http.StatusForbidden)
+		return
+	}
+	response := newAssignmentResponse(assignment)
+
+	questions, err := h.AssignmentService.GetAssignmentQuestions(assignment)
+	if err != nil {
+		Error(w, "Failed to retrieve questions", http.StatusInternalServerError)
+		return
+	}
+	view := services.ViewExam
+	if isTeacher {
+		view = services.ViewAuthor
+	}
+	for i := range questions {
+		response.Questions = append(response.Questions, services.NewQuestionResponse(&questions[i], services.AnswerPolicyFor(view)))
+	}
+
+	Success(w, response, nil, http.StatusOK)
+}
+
+// GetMySubmissions 获取当前用户的作业提交
+// @Summary 获取我的作业提交
+// @Description 获取当前用户在作业中的全部提交
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "作业 ID"
+// @Success 200 {object} Response[[]dto.AssignmentSubmissionResponse] "提交列表"
+// @Failure 403 {object} ErrorResponse "不是班级成员"
+// @Failure 404 {object} ErrorResponse "作业不存在"
+// @Router /assignments/{id}/submissions [get]
+func (h *AssignmentHandler) GetMySubmissions(w http.ResponseWriter, r *http.Request) {
+	user, assignment, ok := h.loadAssignment(w, r, services.ClassActionView)
+	if !ok {
+		return
+	}
+
+	submissions, err := h.AssignmentService.GetSubmissions(assignment.ID, user.ID)
+	if err != nil {
+		Error(w, "Failed to retrieve submissions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.AssignmentSubmissionResponse, len(submissions))
+	for i := range submissions {
+		response[i] = newSubmissionResponse(&submissions[i], nil)
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// SubmitAssignment 提交作业
+// @Summary 提交作业
+// @Description 提交作业的作答并判分，answers 以题目 ID 为键，未作答的题按错误计
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "作业 ID"
+// @Param submission body dto.SubmitAssignmentRequest true "作答"
+// @Success 201 {object} Response[dto.AssignmentSubmissionResponse] "提交成功"
+// @Failure 400 {object} ErrorResponse "作答格式错误"
+// @Failure 403 {object} ErrorResponse "不是班级成员、作业未开放、已截止或提交次数用完"
+// @Failure 404 {object} ErrorResponse "作业不存在"
+// @Failure 409 {object} ErrorResponse "同时提交了多次"
+// @Router /assignments/{id}/submissions [post]
+func (h *AssignmentHandler) SubmitAssignment(w http.ResponseWriter, r *http.Request) {
+	user, assignment, ok := h.loadAssignment(w, r, services.ClassActionView)
+	if !ok {
+		return
+	}
+	req, ok := DecodeJSONBody[dto.SubmitAssignmentRequest](w, r)
+	if !ok {
+		return
+	}
+
+	submission, results, err := h.AssignmentService.Submit(assignment, user.ID, req.Answers)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAnswer):
+			Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrAssignmentNotOpen),
+			errors.Is(err, services.ErrAssignmentClosed),
+			errors.Is(err, services.ErrAttemptLimitReached):
+			Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, services.ErrSubmissionConflict):
+			Error(w, err.Error(), http.StatusConflict)
+		default:
+			Error(w, "Failed to submit assignment", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	Success(w, newSubmissionResponse(submission, results), nil, http.StatusCreated)
+}
+
+// GetGradebook 获取作业成绩册
+// @Summary 获取作业成绩册
+// @Description 汇总班级每名学员的提交次数、最高得分和完成情况，仅班级教师可以查看
+// @Tags Assignment
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "作业 ID"
+// @Success 200 {object} Response[dto.GradebookResponse] "成绩册"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 404 {object} ErrorResponse "作业不存在"
+// @Router /assignments/{id}/gradebook [get]
+func (h *AssignmentHandler) GetGradebook(w http.ResponseWriter, r *http.Request) {
+	_, assignment, ok := h.loadAssignment(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+
+	gradebook, err := h.AssignmentService.GetGradebook(assignment)
+	if err != nil {
+		Error(w, "Failed to retrieve gradebook", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, gradebook, nil, http.StatusOK)
+}
+
+// authorizeClass 解析路径中的班级 ID，并检查当前用户能否在班级内执行指定操作
+func (h *AssignmentHandler) authorizeClass(w http.ResponseWriter, r *http.Request, action string) (models.User, uint, bool) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return user, 0, false
+	}
+	classID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid class ID", http.StatusBadRequest)
+		return user, 0, false
+	}
+	if !h.ClassService.CanAccessClass(r.Context(), user, classID, action) {
+		Error(w, "Forbidden", http.StatusForbidden)
+		return user, 0, false
+	}
+	return user, classID, true
+}
+
+// loadAssignment 加载路径中的作业，并检查当前用户能否在作业所属班级内执行指定操作
+func (h *AssignmentHandler) loadAssignment(w http.ResponseWriter, r *http.Request, action string) (models.User, *models.Assignment, bool) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return user, nil, false
+	}
+	assignmentID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid assignment ID", http.StatusBadRequest)
+		return user, nil, false
+	}
+	assignment, err := h.AssignmentService.GetAssignment(assignmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Assignment not found", http.StatusNotFound)
+			return user, nil, false
+		}
+		Error(w, "Failed to retrieve assignment", http.StatusInternalServerError)
+		return user, nil, false
+	}
+	if !h.ClassService.CanAccessClass(r.Context(), user, assignment.ClassID, action) {
+		Error(w, "Forbidden", http.StatusForbidden)
+		return user, nil, false
+	}
+	return user, assignment, true
+}
+
+// newAssignmentResponse 将作业转换为响应
+func newAssignmentResponse(assignment *models.Assignment) dto.AssignmentResponse {
+	response := dto.AssignmentResponse{
+		ID:             assignment.ID,
+		ClassID:        assignment.ClassID,
+		Title:          assignment.Title,
+		Description:    assignment.Description,
+		QuestionBankID: assignment.QuestionBankID,
+		OpenAt:         assignment.OpenAt,
+		DueAt:          assignment.DueAt,
+		MaxAttempts:    assignment.MaxAttempts,
+		LatePolicy:     assignment.LatePolicy,
+		LatePenalty:    assignment.LatePenalty,
+		CreatedAt:      assignment.CreatedAt,
+	}
+	for _, question := range assignment.Questions {
+		response.QuestionIDs = append(response.QuestionIDs, question.QuestionID)
+	}
+	return response
+}
+
+// newSubmissionResponse 将作业提交转换为响应，刚提交时附带每道题的判分结果
+func newSubmissionResponse(submission *models.AssignmentSubmission, results []*services.AttemptResult) dto.AssignmentSubmissionResponse {
+	response := dto.AssignmentSubmissionResponse{
+		ID:          submission.ID,
+		Attempt:     submission.Attempt,
+		RawScore:    submission.RawScore,
+		Score:       submission.Score,
+		Late:        submission.Late,
+		SubmittedAt: submission.SubmittedAt,
+	}
+	for _, result := range results {
+		response.Results = append(response.Results, dto.AssignmentQuestionResult{
+			QuestionID: result.Grade.QuestionID,
+			Correct:    result.Grade.Correct,
+			Score:      result.Grade.Score,
+		})
+	}
+	return response
+}
@@ -0,0 +1,140 @@
+// api/assignment_test.go
+package api_test
+
+import (
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAssignment(t *testing.T) {
+	classHandler, assignmentHandler, authService, quizService := setupTestAssignmentHandler(t)
+
+	teacher, _ := createTestUser(authService, "teacher")
+	student, _ := createTestUser(authService, "student")
+	do := newUserRouter(map[string]models.User{"teacher": *teacher, "student": *student}, classHandler, assignmentHandler)
+
+	class, err := classHandler.ClassService.CreateClass(teacher.ID, "Grade 7", "")
+	if err != nil {
+		t.Fatalf("Failed to create class: %v", err)
+	}
+	if _, err := classHandler.ClassService.JoinClass(student.ID, class.InviteCode); err != nil {
+		t.Fatalf("Failed to join class: %v", err)
+	}
+	classURL := "/classes/" + strconv.Itoa(int(class.ID))
+
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	var questionIDs []uint
+	for _, content := range []string{"Is 7 prime?", "Is 9 prime?"} {
+		question, err := quizService.CreateQuestion(models.Question{
+			QuestionBankID:  bank.ID,
+			Content:         content,
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: content == "Is 7 prime?"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create question: %v", err)
+		}
+		questionIDs = append(questionIDs, question.ID)
+	}
+	answers := func(first, second bool) dto.SubmitAssignmentRequest {
+		return dto.SubmitAssignmentRequest{Answers: map[string]interface{}{
+			strconv.Itoa(int(questionIDs[0])): first,
+			strconv.Itoa(int(questionIDs[1])): second,
+		}}
+	}
+	create := func(req dto.CreateAssignmentRequest) (int, dto.AssignmentResponse) {
+		w := do("teacher", http.MethodPost, classURL+"/assignments", req)
+		var resp api.Response[dto.AssignmentResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	submit := func(assignmentID uint, req dto.SubmitAssignmentRequest) (int, dto.AssignmentSubmissionResponse) {
+		w := do("student", http.MethodPost, "/assignments/"+strconv.Itoa(int(assignmentID))+"/submissions", req)
+		var resp api.Response[dto.AssignmentSubmissionResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+
+	if code, _ := create(dto.CreateAssignmentRequest{Title: "Both", QuestionBankID: &bank.ID, QuestionIDs: questionIDs, DueAt: time.Now().Add(time.Hour)}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 when both a bank and questions are given, got %v", code)
+	}
+	if w := do("student", http.MethodPost, classURL+"/assignments", dto.CreateAssignmentRequest{Title: "Mine", QuestionIDs: questionIDs, DueAt: time.Now().Add(time.Hour)}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected student to be forbidden from assigning, got %v", w.Code)
+	}
+
+	code, homework := create(dto.CreateAssignmentRequest{Title: "Primes", QuestionIDs: questionIDs, DueAt: time.Now().Add(time.Hour), MaxAttempts: 2})
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create assignment, status code: %v", code)
+	}
+
+	// 学员看到的题目不含答案
+	w := do("student", http.MethodGet, "/assignments/"+strconv.Itoa(int(homework.ID)), nil)
+	var detail api.Response[dto.AssignmentResponse]
+	json.NewDecoder(w.Body).Decode(&detail)
+	if len(detail.Data.Questions) != 2 || detail.Data.Questions[0].TrueFalseAnswer != nil {
+		t.Errorf("Expected two questions without answers, got %+v", detail.Data.Questions)
+	}
+
+	if code, submission := submit(homework.ID, answers(true, true)); code != http.StatusCreated || submission.Score != 0.5 || len(submission.Results) != 2 {
+		t.Errorf("Expected half score, got %v %+v", code, submission)
+	}
+	if code, submission := submit(homework.ID, answers(true, false)); code != http.StatusCreated || submission.Score != 1 || submission.Attempt != 2 {
+		t.Errorf("Expected full score on the second attempt, got %v %+v", code, submission)
+	}
+	if code, _ := submit(homework.ID, answers(true, false)); code != http.StatusForbidden {
+		t.Errorf("Expected attempt limit to be enforced, got %v", code)
+	}
+
+	w = do("teacher", http.MethodGet, "/assignments/"+strconv.Itoa(int(homework.ID))+"/gradebook", nil)
+	var gradebook api.Response[dto.GradebookResponse]
+	json.NewDecoder(w.Body).Decode(&gradebook)
+	if w.Code != http.StatusOK || gradebook.Data.CompletedCount != 1 || len(gradebook.Data.Students) != 1 {
+		t.Fatalf("Unexpected gradebook %v %+v", w.Code, gradebook.Data)
+	}
+	if entry := gradebook.Data.Students[0]; entry.UserID != student.ID || entry.Submissions != 2 || entry.BestScore != 1 {
+		t.Errorf("Unexpected gradebook entry %+v", entry)
+	}
+	if w := do("student", http.MethodGet, "/assignments/"+strconv.Itoa(int(homework.ID))+"/gradebook", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected student to be forbidden from the gradebook, got %v", w.Code)
+	}
+
+	// 迟交规则
+	past := time.Now().Add(-time.Hour)
+	_, closed := create(dto.CreateAssignmentRequest{Title: "Closed", QuestionBankID: &bank.ID, OpenAt: past.Add(-time.Hour), DueAt: past})
+	if code, _ := submit(closed.ID, answers(true, false)); code != http.StatusForbidden {
+		t.Errorf("Expected late submission to be rejected, got %v", code)
+	}
+	_, penalized := create(dto.CreateAssignmentRequest{Title: "Penalized", QuestionBankID: &bank.ID, OpenAt: past.Add(-time.Hour), DueAt: past,
+		LatePolicy: models.LatePenalized, LatePenalty: 0.2})
+	if code, submission := submit(penalized.ID, answers(true, false)); code != http.StatusCreated || !submission.Late || submission.Score != 0.8 {
+		t.Errorf("Expected penalized late submission, got %v %+v", code, submission)
+	}
+
+	w = do("student", http.MethodGet, "/assignments", nil)
+	var mine api.Response[[]dto.AssignmentResponse]
+	json.NewDecoder(w.Body).Decode(&mine)
+	if len(mine.Data) != 3 || mine.Data[0].Submissions == nil {
+		t.Errorf("Expected three assignments with submission counts, got %+v", mine.Data)
+	}
+
+	// 问答题计入得分，全部答对时可以拿到满分
+	essay, _ := quizService.CreateQuestion(models.Question{
+		QuestionBankID: bank.ID,
+		Content:        "Why is 7 prime?",
+		QuestionType:   models.QuestionTypeWrittenAnswer,
+		WrittenAnswer:  &models.WrittenAnswer{AnswerText: "It has no divisors other than 1 and itself"},
+	})
+	_, written := create(dto.CreateAssignmentRequest{Title: "Written", QuestionIDs: []uint{questionIDs[0], essay.ID}, DueAt: time.Now().Add(time.Hour)})
+	if code, submission := submit(written.ID, dto.SubmitAssignmentRequest{Answers: map[string]interface{}{
+		strconv.Itoa(int(questionIDs[0])): true,
+		strconv.Itoa(int(essay.ID)):       "Only 1 and 7 divide it",
+	}}); code != http.StatusCreated || submission.RawScore != 1 || submission.Score != 1 {
+		t.Errorf("Expected a submission with a written answer to reach full score, got %v %+v", code, submission)
+	}
+}
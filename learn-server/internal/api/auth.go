@@ -3,9 +3,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"learn/internal/dto"
 	"learn/internal/models"
 	"learn/internal/services"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -23,6 +25,33 @@ func (h *AuthHandler) GetApiEndpoints() []APIEndpoint {
 		{"/auth/login", http.MethodPost, h.Login, "", "用户登录"},
 		{"/auth/register", "POST", h.RegisterUser, "", "用户注册"},
 		{"/auth/refresh", http.MethodPost, h.RefreshToken, "", ""},
+		{"/auth/register/verify_email", http.MethodPost, h.VerifyEmail, "", "验证邮箱"},
+		{"/auth/register/resend_verification", http.MethodPost, h.ResendEmailVerification, "", "重新发送验证邮件"},
+		{"/auth/password/forgot", http.MethodPost, h.ForgotPassword, "", "找回密码"},
+		{"/auth/password/reset", http.MethodPost, h.ResetPassword, "", "重置密码"},
+		{"/.well-known/jwks.json", http.MethodGet, h.GetJWKS, "", "JWT 公钥"},
+
+		//password
+		{"/auth/password", http.MethodPost, h.ChangePassword, "auth:password", "修改密码"},
+
+		//mfa
+		{"/auth/login/mfa", http.MethodPost, h.LoginMFA, "", "两步验证登录"},
+		{"/auth/login/mfa/enroll", http.MethodPost, h.StartLoginMFAEnrollment, "", "登录时绑定验证器"},
+		{"/auth/login/mfa/enroll/verify", http.MethodPost, h.ConfirmLoginMFAEnrollment, "", "登录时确认绑定验证器"},
+		{"/auth/mfa", http.MethodGet, h.GetMFAStatus, "auth:mfa", "查看两步验证状态"},
+		{"/auth/mfa/enroll", http.MethodPost, h.StartMFAEnrollment, "auth:mfa", "绑定验证器"},
+		{"/auth/mfa/enroll/verify", http.MethodPost, h.ConfirmMFAEnrollment, "auth:mfa", "确认绑定验证器"},
+		{"/auth/mfa/recovery_codes", http.MethodPost, h.RegenerateRecoveryCodes, "auth:mfa", "重新生成恢复码"},
+		{"/auth/mfa/disable", http.MethodPost, h.DisableMFA, "auth:mfa", "关闭两步验证"},
+
+		//sessions
+		{"/auth/sessions", http.MethodGet, h.GetMySessions, "auth:sessions", "查看自己的登录设备"},
+		{"/auth/sessions/{id}", http.MethodDelete, h.RevokeMySession, "auth:sessions", "注销自己的登录设备"},
+
+		//api keys
+		{"/auth/api_keys", http.MethodGet, h.GetMyAPIKeys, "auth:api_keys", "查看自己的 API Key"},
+		{"/auth/api_keys", http.MethodPost, h.CreateMyAPIKey, "auth:api_keys", "创建 API Key"},
+		{"/auth/api_keys/{id}", http.MethodDelete, h.RevokeMyAPIKey, "auth:api_keys", "吊销自己的 API Key"},
 
 		//admin:users
 		{"/users", "GET", h.GetUsers, "users:read", "获取用户列表"},
@@ -30,6 +59,17 @@ func (h *AuthHandler) GetApiEndpoints() []APIEndpoint {
 		{"/users/{id}", "PUT", h.UpdateUser, "users:edit", "编辑用户"},
 		{"/users/{id}/roles", "GET", h.GetUserRoles, "users:read", ""},
 		{"/users/{id}/invalidate_session", "POST", h.InvalidateUserSession, "users:logout", "踢出登录"},
+		{"/users/{id}/mfa", http.MethodDelete, h.ResetUserMFA, "users:edit", "重置用户的两步验证"},
+		{"/users/{id}/sessions", http.MethodGet, h.GetUserSessions, "users:read", "查看用户的登录设备"},
+		{"/users/{id}/sessions/{session_id}", http.MethodDelete, h.RevokeUserSession, "users:logout", "踢出用户的某个登录设备"},
+		{"/users/{id}/api_keys", http.MethodGet, h.GetUserAPIKeys, "users:read", "查看用户的 API Key"},
+		{"/users/{id}/api_keys", http.MethodPost, h.CreateUserAPIKey, "users:api_keys", "为用户或服务账号创建 API Key"},
+		{"/users/{id}/api_keys/{key_id}", http.MethodDelete, h.RevokeUserAPIKey, "users:api_keys", "吊销用户的 API Key"},
+
+		//admin:registrations
+		{"/registrations", http.MethodGet, h.GetRegistrations, "registrations:read", "查看注册申请"},
+		{"/registrations/{id}/approve", http.MethodPost, h.ApproveRegistration, "registrations:review", "通过注册申请"},
+		{"/registrations/{id}/reject", http.MethodPost, h.RejectRegistration, "registrations:review", "拒绝注册申请"},
 
 		//admin:roles
 		{"/roles", "GET", h.GetRoles, "roles:read", ""},
@@ -37,9 +77,13 @@ func (h *AuthHandler) GetApiEndpoints() []APIEndpoint {
 		{"/roles/{id}", http.MethodDelete, h.DeleteRole, "roles:delete", ""},
 		{"/roles/{id}", http.MethodPut, h.UpdateRoleHandler, "roles:edit", ""},
 		{"/roles/{id}/permissions", http.MethodGet, h.GetRolePermissionsHandler, "roles:read", ""},
+		{"/roles/{id}/mfa", http.MethodPut, h.UpdateRoleMFAPolicy, "roles:edit", "设置角色是否要求两步验证"},
 
 		//admin: permissions
 		{"/permissions", "GET", h.GetPermissions, "permissions:read", ""},
+
+		//admin: audit
+		{"/audit_logs", http.MethodGet, h.GetAuditLogs, "audit:read", "查看审计日志"},
 	}
 }
 
@@ -62,8 +106,22 @@ func (h *AuthHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.AuthService.CreateUser(req.Username, req.Password, req.Roles, req.Status)
+	var user models.User
+	var err error
+	if req.ServiceAccount {
+		user, err = h.AuthService.CreateServiceAccount(req.Username, req.Roles)
+	} else {
+		user, err = h.AuthService.CreateUserWithEmail(req.Username, req.Password, req.Email, req.Roles, req.Status)
+	}
 	if err != nil {
+		if errors.Is(err, services.ErrWeakPassword) || errors.Is(err, services.ErrInvalidEmail) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -92,12 +150,15 @@ func (h *AuthHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	var userResponses []dto.UserResponse
 	for _, user := range users {
 		userResponses = append(userResponses, dto.UserResponse{
-			ID:           user.ID,
-			Username:     user.Username,
-			CreatedAt:    user.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    user.UpdatedAt.Format(time.RFC3339),
-			TokenVersion: user.TokenVersion,
-			Status:       int(user.Status),
+			ID:             user.ID,
+			Username:       user.Username,
+			Email:          stringValue(user.Email),
+			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:      user.UpdatedAt.Format(time.RFC3339),
+			TokenVersion:   user.TokenVersion,
+			Status:         int(user.Status),
+			LockedUntil:    user.LockedUntil,
+			ServiceAccount: user.ServiceAccount,
 		})
 	}
 
@@ -134,8 +195,9 @@ func (h *AuthHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	var roleResponses []dto.RoleResponse
 	for _, role := range user.Roles {
 		roleResponses = append(roleResponses, dto.RoleResponse{
-			ID:   role.ID,
-			Name: role.Name,
+			ID:         role.ID,
+			Name:       role.Name,
+			RequireMFA: role.RequireMFA,
 		})
 	}
 
@@ -171,6 +233,14 @@ func (h *AuthHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	err = h.AuthService.UpdateUser(uint(userID), req)
 	if err != nil {
+		if errors.Is(err, services.ErrWeakPassword) || errors.Is(err, services.ErrInvalidEmail) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -294,7 +364,7 @@ func (h *AuthHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
 
 	var roleResponses []dto.RoleResponse
 	for _, role := range roles {
-		roleResponses = append(roleResponses, dto.RoleResponse{ID: role.ID, Name: role.Name})
+		roleResponses = append(roleResponses, dto.RoleResponse{ID: role.ID, Name: role.Name, RequireMFA: role.RequireMFA})
 	}
 
 	Success(w, roleResponses, nil, http.StatusOK)
@@ -357,14 +427,16 @@ func (h *AuthHandler) GetPermissions(w http.ResponseWriter, r *http.Request) {
 
 // Login 处理用户登录请求
 // @Summary 用户登录
-// @Description 处理用户登录并生成JWT令牌和刷新令牌
+// @Description 处理用户登录并生成JWT令牌和刷新令牌。启用了两步验证的用户需要再调用 /auth/login/mfa，
+// @Description 角色要求两步验证但尚未绑定的用户需要先通过 /auth/login/mfa/enroll 绑定验证器
 // @Tags Auth
 // @Accept  json
 // @Produce  json
 // @Param login body dto.LoginRequest true "登录请求"
-// @Success 200 {object} Response[dto.TokenPairResponse] "登录成功"
+// @Success 200 {object} Response[dto.LoginResponse] "登录成功，需要两步验证时返回 mfa_token"
 // @Failure 400 {object} ErrorResponse "无效请求"
 // @Failure 401 {object} ErrorResponse "认证失败"
+// @Failure 429 {object} ErrorResponse "失败次数过多，Retry-After 秒后再试"
 // @Failure 500 {object} ErrorResponse "内部服务器错误"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -374,9 +446,17 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.AuthService.Authenticate(req.Username, req.Password)
+	user, err := h.AuthService.AuthenticateLogin(req.Username, req.Password, clientIP(r))
 	if err != nil {
-		Error(w, "Invalid username or password", http.StatusUnauthorized)
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) || errors.Is(err, services.ErrUserInactive) {
+			Error(w, "Invalid username or password", http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Authentication failed: %v", err)
+		Error(w, "Failed to authenticate", http.StatusInternalServerError)
 		return
 	}
 
@@ -386,19 +466,39 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	completeLogin(w, r, h.AuthService, user, req.DeviceName)
+}
+
+// completeLogin 在用户通过身份验证后签发令牌。启用了两步验证或角色要求两步验证时，先下发 MFAToken
+func completeLogin(w http.ResponseWriter, r *http.Request, authService *services.AuthService, user models.User, deviceName string) {
+	mfaEnabled, mfaRequired, err := authService.MFAState(user.ID)
+	if err != nil {
+		Error(w, "Failed to check two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled || mfaRequired {
+		mfaToken, err := authService.IssueMFAChallenge(user, !mfaEnabled)
+		if err != nil {
+			Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+			return
+		}
+		Success(w, dto.LoginResponse{MFARequired: mfaEnabled, MFAEnrollmentRequired: !mfaEnabled, MFAToken: mfaToken}, nil, http.StatusOK)
+		return
+	}
+
 	// 生成 Access Token 和 Refresh Token
-	accessToken, refreshToken, err := h.AuthService.GenerateTokens(user)
+	accessToken, refreshToken, err := authService.GenerateTokens(user, clientDevice(r, deviceName))
 	if err != nil {
 		Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
 	}
 
-	Success(w, dto.TokenPairResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil, http.StatusOK)
+	Success(w, dto.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil, http.StatusOK)
 }
 
 // RefreshToken 处理JWT令牌刷新请求
 // @Summary 刷新JWT令牌
-// @Description 刷新JWT令牌，支持滑动过期的双Token机制
+// @Description 用 Refresh Token 换取新的令牌对，旧的 Refresh Token 随即失效；重复使用已换发的 Refresh Token 会吊销该次登录的所有令牌
 // @Tags Auth
 // @Security ApiKeyAuth
 // @Accept  json
@@ -417,7 +517,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 调用 AuthService 的 RefreshTokens 方法
-	newAccessToken, newRefreshToken, err := h.AuthService.RefreshTokens(req.Token)
+	newAccessToken, newRefreshToken, err := h.AuthService.RefreshTokens(req.Token, clientDevice(r, ""))
 	if err != nil {
 		Error(w, "Failed to refresh token: "+err.Error(), http.StatusUnauthorized)
 		return
@@ -463,13 +563,14 @@ func (h *AuthHandler) InvalidateUserSession(w http.ResponseWriter, r *http.Reque
 
 // RegisterUser 用户注册
 // @Summary 用户注册
-// @Description 注册新用户
+// @Description 注册新用户。账号在管理员审核通过（或邀请码、邮箱域名自动通过）且邮箱验证后启用；填写了邮箱时发送验证邮件
 // @Tags Auth
 // @Accept  json
 // @Produce  json
 // @Param input body dto.RegisterUserRequest true "用户注册信息"
-// @Success 201 {object} Response[string] "注册成功"
+// @Success 201 {object} Response[dto.RegisterUserResponse] "注册成功"
 // @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 409 {object} ErrorResponse "邮箱已被使用"
 // @Failure 500 {object} ErrorResponse "内部服务器错误"
 // @Router /auth/register [post]
 func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
@@ -479,11 +580,63 @@ func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := h.AuthService.CreateUser(req.Username, req.Password, []string{}, models.StatusPending)
+	registration, err := h.AuthService.Register(req.Username, req.Password, req.Email, req.InviteCode, clientIP(r))
 	if err != nil {
+		if errors.Is(err, services.ErrWeakPassword) || errors.Is(err, services.ErrInvalidEmail) ||
+			errors.Is(err, services.ErrEmailRequired) || errors.Is(err, services.ErrInvalidRegistrationCode) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		Error(w, "Failed to register user", http.StatusInternalServerError)
 		return
 	}
 
-	Success(w, "User registered successfully", nil, http.StatusCreated)
+	Success(w, dto.RegisterUserResponse{
+		Status:                    registration.User.Status.String(),
+		Approved:                  registration.Status == models.RegistrationApproved,
+		EmailVerificationRequired: h.AuthService.Registration.RequireEmailVerification,
+	}, nil, http.StatusCreated)
+}
+
+// GetAuditLogs 查询审计日志
+// @Summary 查询审计日志
+// @Description 按时间倒序分页返回账号安全相关的事件，例如 account_locked、account_unlocked
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param action query string false "事件类型"
+// @Param user_id query int false "用户 ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response[[]dto.AuditLogResponse] "获取成功"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /audit_logs [get]
+func (h *AuthHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := GetPaginationParams(r)
+	filter := services.AuditLogFilter{
+		Action: r.URL.Query().Get("action"),
+		UserID: uint(parseQueryParamInt(r, "user_id", 0)),
+	}
+	logs, total, err := h.AuthService.GetAuditLogs(filter, page, pageSize)
+	if err != nil {
+		Error(w, "Failed to retrieve audit logs", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]dto.AuditLogResponse, len(logs))
+	for i, entry := range logs {
+		resp[i] = dto.AuditLogResponse{
+			ID:        entry.ID,
+			Action:    entry.Action,
+			UserID:    entry.UserID,
+			Username:  entry.Username,
+			IP:        entry.IP,
+			Detail:    entry.Detail,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+	Success(w, resp, &PaginationMeta{TotalRecords: total, CurrentPage: page, PageSize: pageSize}, http.StatusOK)
 }
@@ -24,7 +24,10 @@ func setupTestDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{})
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.RefreshToken{}, &models.Session{},
+		&models.UserMFA{}, &models.RecoveryCode{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.OIDCExchangeCode{},
+		&models.LoginFailure{}, &models.AuditLog{}, &models.PasswordResetToken{},
+		&models.Registration{}, &models.EmailVerificationToken{}, &models.APIKey{}, &models.SigningKey{})
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +42,7 @@ func setupTestAuthHandler() (*api.AuthHandler, error) {
 	}
 
 	authService := services.NewAuthService(db, "jwt_secret",
-		time.Hour, 7*24*time.Hour, 24*time.Hour)
+		time.Hour, 7*24*time.Hour)
 	return &api.AuthHandler{AuthService: authService}, nil
 }
 
@@ -280,7 +283,10 @@ func TestRefreshToken(t *testing.T) {
 		t.Fatalf("Failed to update user status to inactive: %v", updateRR.Code)
 	}
 
-	// 再次使用旧的 Refresh Token 尝试进行刷新
+	// 使用换发的 Refresh Token 尝试进行刷新
+	refreshRequestBody, _ = json.Marshal(dto.RefreshTokenRequest{
+		Token: refreshResponse.Data.RefreshToken,
+	})
 	refreshReq, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(refreshRequestBody))
 	refreshRR = httptest.NewRecorder()
 	router.ServeHTTP(refreshRR, refreshReq)
@@ -291,6 +297,77 @@ func TestRefreshToken(t *testing.T) {
 	}
 }
 
+func TestRefreshTokenRotation(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	handler := &api.AuthHandler{AuthService: services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)}
+	router := mux.NewRouter()
+	router.HandleFunc("/auth/refresh", handler.RefreshToken).Methods("POST")
+	refresh := func(token string) (dto.TokenPairResponse, int) {
+		body, _ := json.Marshal(dto.RefreshTokenRequest{Token: token})
+		req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp api.Response[dto.TokenPairResponse]
+		_ = json.NewDecoder(rr.Body).Decode(&resp)
+		return resp.Data, rr.Code
+	}
+
+	user, err := createTestUser(handler.AuthService, "rotator")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	_, phone, _ := handler.AuthService.GenerateTokens(*user, services.SessionDevice{})
+	_, laptop, _ := handler.AuthService.GenerateTokens(*user, services.SessionDevice{})
+
+	// 每次刷新都换发新的 Refresh Token
+	rotated, code := refresh(phone)
+	if code != http.StatusOK || rotated.RefreshToken == "" || rotated.RefreshToken == phone {
+		t.Fatalf("Expected a rotated refresh token, got %v %+v", code, rotated)
+	}
+	rotated, code = refresh(rotated.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("Expected rotated token to be usable, got %v", code)
+	}
+
+	// 重放已换发的令牌会吊销整个令牌族，其他设备不受影响
+	if _, code := refresh(phone); code != http.StatusUnauthorized {
+		t.Errorf("Expected reused token to be rejected, got %v", code)
+	}
+	if _, code := refresh(rotated.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("Expected token family to be revoked after reuse, got %v", code)
+	}
+	laptopPair, code := refresh(laptop)
+	if code != http.StatusOK {
+		t.Errorf("Expected other sessions to be kept, got %v", code)
+	}
+
+	// 会话过期后不能再刷新，换发不会延长会话
+	_, expiring, _ := handler.AuthService.GenerateTokens(*user, services.SessionDevice{})
+	var record models.RefreshToken
+	db.Order("id desc").First(&record)
+	db.Model(&record).Update("expires_at", time.Now().Add(-time.Minute))
+	if _, code := refresh(expiring); code != http.StatusUnauthorized {
+		t.Errorf("Expected expired session to be rejected, got %v", code)
+	}
+	if purged, _ := handler.AuthService.PurgeExpiredRefreshTokens(); purged != 1 {
+		t.Errorf("Expected 1 expired token to be purged, got %v", purged)
+	}
+
+	// 踢出登录吊销所有设备的令牌
+	if err := handler.AuthService.InvalidateUserToken(user.ID); err != nil {
+		t.Fatalf("Failed to invalidate user tokens: %v", err)
+	}
+	if _, code := refresh(laptopPair.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("Expected tokens to be revoked after invalidation, got %v", code)
+	}
+	if _, code := refresh("not-a-token"); code != http.StatusUnauthorized {
+		t.Errorf("Expected unknown token to be rejected, got %v", code)
+	}
+}
+
 func TestGetUsers(t *testing.T) {
 	handler, err := setupTestAuthHandler()
 	if err != nil {
@@ -572,3 +649,66 @@ func TestGetPermissions(t *testing.T) {
 		t.Errorf("Expected at least one permission, got %v", len(response.Data))
 	}
 }
+
+func TestSelfServicePermissionsGrantedOnUpgrade(t *testing.T) {
+	handler, err := setupTestAuthHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup auth handler: %v", err)
+	}
+	authService := handler.AuthService
+
+	// 升级前学员角色用 quiz:edit 作答，只读角色只有 quiz:read
+	authService.EnsurePermissionExists("quiz:read", "")
+	authService.EnsurePermissionExists("quiz:edit", "")
+	learner, _ := authService.CreateRole("learner")
+	reader, _ := authService.CreateRole("reader")
+	guest, _ := authService.CreateRole("guest")
+	authService.AssignPermissionToRole("learner", "quiz:edit")
+	authService.AssignPermissionToRole("reader", "quiz:read")
+
+	hasPermission := func(roleID uint, name string) bool {
+		permissions, _ := authService.GetRolePermissions(roleID)
+		for _, permission := range permissions {
+			if permission.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 每个权限授予拥有来源权限的角色
+	grants := map[string][]models.Role{
+		"quiz:attempt":  {learner},
+		"notebook:read": {learner, reader},
+		"notebook:edit": {learner, reader},
+		"quiz:delete":   nil,
+	}
+	for name, granted := range grants {
+		if err := authService.EnsurePermissionExists(name, ""); err != nil {
+			t.Fatalf("Failed to ensure permission exists: %v", err)
+		}
+		for _, role := range []models.Role{learner, reader, guest} {
+			expected := false
+			for _, g := range granted {
+				expected = expected || g.ID == role.ID
+			}
+			if hasPermission(role.ID, name) != expected {
+				t.Errorf("Expected role %s to have %s: %v", role.Name, name, expected)
+			}
+		}
+	}
+
+	// 之后管理员收回的权限在下次启动时不会被重新授予
+	var quizEdit models.Permission
+	permissions, _ := authService.GetPermissions()
+	for _, permission := range permissions {
+		if permission.Name == "quiz:edit" {
+			quizEdit = permission
+		}
+	}
+	authService.UpdateRole(learner.ID, dto.RoleUpdateRequest{Name: "learner", Permissions: []int{int(quizEdit.ID)}})
+	authService.EnsurePermissionExists("quiz:attempt", "")
+	if hasPermission(learner.ID, "quiz:attempt") {
+		t.Errorf("Expected revoked permission to stay revoked")
+	}
+}
@@ -0,0 +1,402 @@
+// api/class.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type ClassHandler struct {
+	ClassService *services.ClassService
+}
+
+func (h *ClassHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/classes", "GET", h.GetClasses, "class:read", "查看我的班级"},
+		{"/classes", "POST", h.CreateClass, "class:create", "创建班级"},
+		{"/classes/join", "POST", h.JoinClass, "class:join", "通过邀请码加入班级"},
+		{"/classes/{id}", "GET", h.GetClass, "class:read", "查看班级详情"},
+		{"/classes/{id}/invite_code", "POST", h.RegenerateInviteCode, "class:read", "重新生成邀请码"},
+		{"/classes/{id}/members", "POST", h.AddMember, "class:read", "添加班级成员"},
+		{"/classes/{id}/members/{user_id}", "DELETE", h.RemoveMember, "class:read", "移出班级成员"},
+		{"/classes/{id}/progress", "GET", h.GetClassProgress, "class:read", "查看班级学员进度"},
+		{"/classes/{id}/students/{user_id}/attempts", "GET", h.GetStudentAttempts, "class:read", "查看学员答题记录"},
+	}
+}
+
+// GetClasses 获取当前用户所在的班级
+// @Summary 获取我的班级
+// @Description 获取当前用户以教师或学员身份加入的班级
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.ClassResponse] "班级列表"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes [get]
+func (h *ClassHandler) GetClasses(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	classes, err := h.ClassService.GetUserClasses(user.ID)
+	if err != nil {
+		Error(w, "Failed to retrieve classes", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ClassResponse, len(classes))
+	for i := range classes {
+		response[i] = h.newClassResponse(r, user, &classes[i])
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// CreateClass 创建班级
+// @Summary 创建班级
+// @Description 创建一个班级，创建者成为班级教师并获得邀请码
+// @Tags Class
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param class body dto.CreateClassRequest true "创建班级请求"
+// @Success 201 {object} Response[dto.ClassResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes [post]
+func (h *ClassHandler) CreateClass(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.CreateClassRequest](w, r)
+	if !ok {
+		return
+	}
+	if req.Name == "" {
+		Error(w, "Class name is required", http.StatusBadRequest)
+		return
+	}
+
+	class, err := h.ClassService.CreateClass(user.ID, req.Name, req.Description)
+	if err != nil {
+		Error(w, "Failed to create class", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, h.newClassResponse(r, user, class), nil, http.StatusCreated)
+}
+
+// JoinClass 通过邀请码加入班级
+// @Summary 加入班级
+// @Description 以学员身份通过邀请码加入班级
+// @Tags Class
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param join body dto.JoinClassRequest true "邀请码"
+// @Success 200 {object} Response[dto.ClassResponse] "加入成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "邀请码无效"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes/join [post]
+func (h *ClassHandler) JoinClass(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.JoinClassRequest](w, r)
+	if !ok {
+		return
+	}
+
+	class, err := h.ClassService.JoinClass(user.ID, req.InviteCode)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInviteCode) {
+			Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to join class", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, h.newClassResponse(r, user, class), nil, http.StatusOK)
+}
+
+// GetClass 获取班级详情
+// @Summary 获取班级详情
+// @Description 获取班级信息和成员列表，仅班级成员可以查看
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Success 200 {object} Response[dto.ClassResponse] "班级详情"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "不是班级成员"
+// @Failure 404 {object} ErrorResponse "班级不存在"
+// @Router /classes/{id} [get]
+func (h *ClassHandler) GetClass(w http.ResponseWriter, r *http.Request) {
+	user, classID, ok := h.authorize(w, r, services.ClassActionView)
+	if !ok {
+		return
+	}
+
+	class, err := h.ClassService.GetClass(classID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Class not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to retrieve class", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, h.newClassResponse(r, user, class), nil, http.StatusOK)
+}
+
+// RegenerateInviteCode 重新生成邀请码
+// @Summary 重新生成邀请码
+// @Description 重新生成班级邀请码，旧邀请码失效，仅班级教师可操作
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Success 200 {object} Response[dto.InviteCodeResponse] "新的邀请码"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 404 {object} ErrorResponse "班级不存在"
+// @Router /classes/{id}/invite_code [post]
+func (h *ClassHandler) RegenerateInviteCode(w http.ResponseWriter, r *http.Request) {
+	_, classID, ok := h.authorize(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+
+	inviteCode, err := h.ClassService.RegenerateInviteCode(classID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Class not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to regenerate invite code", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, dto.InviteCodeResponse{InviteCode: inviteCode}, nil, http.StatusOK)
+}
+
+// AddMember 添加班级成员
+// @Summary 添加班级成员
+// @Description 教师调整班级成员的身份；拥有 class:all 权限的用户可以直接将用户加入班级，其他用户需通过邀请码加入
+// @Tags Class
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param member body dto.AddClassMemberRequest true "成员信息"
+// @Success 200 {object} Response[dto.ClassMemberResponse] "添加成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "不是班级教师，或用户不是班级成员"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Router /classes/{id}/members [post]
+func (h *ClassHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	user, classID, ok := h.authorize(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+	req, ok := DecodeJSONBody[dto.AddClassMemberRequest](w, r)
+	if !ok {
+		return
+	}
+	if req.Role.String() == "" {
+		Error(w, "Invalid class role", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.ClassService.AddMember(r.Context(), user, classID, req.UserID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInviteRequired):
+			Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrLastTeacher):
+			Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			Error(w, "Failed to add member", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	Success(w, dto.ClassMemberResponse{
+		UserID:   member.UserID,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt,
+	}, nil, http.StatusOK)
+}
+
+// RemoveMember 移出班级成员
+// @Summary 移出班级成员
+// @Description 教师移出班级成员，学员也可以退出班级
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param user_id path int true "用户 ID"
+// @Success 204 "移出成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 404 {object} ErrorResponse "不是班级成员"
+// @Router /classes/{id}/members/{user_id} [delete]
+func (h *ClassHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	classID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid class ID", http.StatusBadRequest)
+		return
+	}
+	userID, ok := ParseUintParam(r, "user_id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	// 成员可以退出班级，移出其他人需要教师身份
+	if userID != user.ID && !h.ClassService.CanAccessClass(r.Context(), user, classID, services.ClassActionManage) {
+		Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := h.ClassService.RemoveMember(classID, userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotClassMember):
+			Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrLastTeacher):
+			Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			Error(w, "Failed to remove member", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetClassProgress 查看班级学员进度
+// @Summary 查看班级学员进度
+// @Description 汇总班级内每名学员在班级作业题目上的答题情况，仅班级教师可以查看
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param question_bank_id query int false "题库 ID"
+// @Success 200 {object} Response[[]dto.StudentProgressResponse] "学员进度"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes/{id}/progress [get]
+func (h *ClassHandler) GetClassProgress(w http.ResponseWriter, r *http.Request) {
+	_, classID, ok := h.authorize(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+	questionBankID := uint(parseQueryParamInt(r, "question_bank_id", 0))
+
+	progress, err := h.ClassService.GetClassProgress(classID, questionBankID)
+	if err != nil {
+		Error(w, "Failed to retrieve class progress", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, progress, nil, http.StatusOK)
+}
+
+// GetStudentAttempts 查看学员答题记录
+// @Summary 查看学员答题记录
+// @Description 查看班级内某名学员在班级作业题目上的答题记录，仅班级教师可以查看
+// @Tags Class
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param user_id path int true "学员 ID"
+// @Param question_bank_id query int false "题库 ID"
+// @Success 200 {object} Response[[]dto.QuestionAttemptResponse] "答题记录"
+// @Failure 403 {object} ErrorResponse "不是班级教师"
+// @Failure 404 {object} ErrorResponse "不是班级学员"
+// @Router /classes/{id}/students/{user_id}/attempts [get]
+func (h *ClassHandler) GetStudentAttempts(w http.ResponseWriter, r *http.Request) {
+	_, classID, ok := h.authorize(w, r, services.ClassActionManage)
+	if !ok {
+		return
+	}
+	userID, ok := ParseUintParam(r, "user_id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	questionBankID := uint(parseQueryParamInt(r, "question_bank_id", 0))
+
+	attempts, err := h.ClassService.GetStudentAttempts(classID, userID, questionBankID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotClassMember) {
+			Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to retrieve attempts", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, attempts, nil, http.StatusOK)
+}
+
+// authorize 解析路径中的班级 ID，并检查当前用户能否在班级内执行指定操作
+func (h *ClassHandler) authorize(w http.ResponseWriter, r *http.Request, action string) (models.User, uint, bool) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return user, 0, false
+	}
+	classID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid class ID", http.StatusBadRequest)
+		return user, 0, false
+	}
+	if !h.ClassService.CanAccessClass(r.Context(), user, classID, action) {
+		Error(w, "Forbidden", http.StatusForbidden)
+		return user, 0, false
+	}
+	return user, classID, true
+}
+
+// newClassResponse 将班级转换为响应，邀请码只对有管理权限的用户返回
+func (h *ClassHandler) newClassResponse(r *http.Request, user models.User, class *models.Class) dto.ClassResponse {
+	response := dto.ClassResponse{
+		ID:          class.ID,
+		Name:        class.Name,
+		Description: class.Description,
+		CreatorID:   class.CreatorID,
+		CreatedAt:   class.CreatedAt,
+	}
+	if h.ClassService.CanAccessClass(r.Context(), user, class.ID, services.ClassActionManage) {
+		response.InviteCode = class.InviteCode
+	}
+	for _, member := range class.Members {
+		response.Members = append(response.Members, dto.ClassMemberResponse{
+			UserID:   member.UserID,
+			Username: member.User.Username,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	return response
+}
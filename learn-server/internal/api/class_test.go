@@ -0,0 +1,199 @@
+// api/class_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestClassHandler(t *testing.T) (*api.ClassHandler, *services.AuthService, *services.QuizService) {
+	handler, _, authService, quizService := setupTestAssignmentHandler(t)
+	return handler, authService, quizService
+}
+
+// setupTestAssignmentHandler 创建共用同一数据库的班级和作业处理器
+func setupTestAssignmentHandler(t *testing.T) (*api.ClassHandler, *api.AssignmentHandler, *services.AuthService, *services.QuizService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{},
+		&models.Class{}, &models.ClassMember{}, &models.QuestionBank{}, &models.Question{},
+		&models.TrueFalseAnswer{}, &models.WrittenAnswer{}, &models.QuestionAttempt{}, &models.NotebookEntry{},
+		&models.Assignment{}, &models.AssignmentQuestion{}, &models.AssignmentSubmission{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	classService := services.NewClassService(db, authService)
+	assignmentService := services.NewAssignmentService(db, quizService)
+	return &api.ClassHandler{ClassService: classService},
+		&api.AssignmentHandler{AssignmentService: assignmentService, ClassService: classService},
+		authService, quizService
+}
+
+// newUserRouter 注册处理器的全部端点，返回以指定用户身份发起请求的函数，用于模拟 AuthMiddleware
+func newUserRouter(users map[string]models.User, providers ...api.APIEndpointProvider) func(as, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			withUser(users[r.Header.Get("X-User")], next.ServeHTTP)(w, r)
+		})
+	})
+	for _, provider := range providers {
+		for _, endpoint := range provider.GetApiEndpoints() {
+			router.HandleFunc(endpoint.Path, endpoint.Handler).Methods(endpoint.Method)
+		}
+	}
+	return func(as, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("X-User", as)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestClassMembership(t *testing.T) {
+	handler, assignmentHandler, authService, quizService := setupTestAssignmentHandler(t)
+
+	teacher, _ := createTestUser(authService, "teacher")
+	student, _ := createTestUser(authService, "student")
+	outsider, _ := createTestUser(authService, "outsider")
+	authService.CreateRole("admin")
+	admin, _ := authService.CreateUser("admin", "password", []string{"admin"}, models.StatusActive)
+
+	do := newUserRouter(map[string]models.User{"teacher": *teacher, "student": *student, "outsider": *outsider, "admin": admin},
+		handler, assignmentHandler)
+
+	w := do("teacher", http.MethodPost, "/classes", dto.CreateClassRequest{Name: "Grade 7"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create class, status code: %v", w.Code)
+	}
+	var created api.Response[dto.ClassResponse]
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Data.InviteCode == "" {
+		t.Fatalf("Expected teacher to receive an invite code")
+	}
+	classURL := "/classes/" + strconv.Itoa(int(created.Data.ID))
+
+	if w := do("student", http.MethodPost, "/classes/join", dto.JoinClassRequest{InviteCode: "WRONG123"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an invalid invite code, got %v", w.Code)
+	}
+	if w := do("student", http.MethodPost, "/classes/join", dto.JoinClassRequest{InviteCode: created.Data.InviteCode}); w.Code != http.StatusOK {
+		t.Fatalf("Failed to join class, status code: %v", w.Code)
+	}
+
+	// 学员能看到班级但看不到邀请码，非成员无权查看
+	w = do("student", http.MethodGet, classURL, nil)
+	var detail api.Response[dto.ClassResponse]
+	json.NewDecoder(w.Body).Decode(&detail)
+	if w.Code != http.StatusOK || len(detail.Data.Members) != 2 || detail.Data.InviteCode != "" {
+		t.Errorf("Unexpected class detail for student: %v %+v", w.Code, detail.Data)
+	}
+	if w := do("outsider", http.MethodGet, classURL, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected outsider to be forbidden, got %v", w.Code)
+	}
+
+	// 教师不能把用户直接拉进班级，只能调整已有成员
+	addOutsider := dto.AddClassMemberRequest{UserID: outsider.ID, Role: models.ClassRoleStudent}
+	if w := do("teacher", http.MethodPost, classURL+"/members", addOutsider); w.Code != http.StatusForbidden {
+		t.Errorf("Expected teacher to be unable to enroll a user directly, got %v", w.Code)
+	}
+	if w := do("outsider", http.MethodGet, classURL, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected outsider to stay out of the class, got %v", w.Code)
+	}
+
+	// 教师只能看到自己班级学员在班级作业题目上的进度
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	question, _ := quizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "Is 7 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	unassigned, _ := quizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "Is 9 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: false},
+	})
+	private, _ := quizService.CreateQuestionBank("Private", models.FeedbackFull)
+	privateQuestion, _ := quizService.CreateQuestion(models.Question{
+		QuestionBankID:  private.ID,
+		Content:         "Is 11 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	if w := do("teacher", http.MethodPost, classURL+"/assignments", dto.CreateAssignmentRequest{
+		Title: "Primes", QuestionIDs: []uint{question.ID}, DueAt: time.Now().Add(time.Hour),
+	}); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create assignment, status code: %v", w.Code)
+	}
+	quizService.RecordQuestionAttempt(student.ID, question.ID, false)
+	quizService.RecordQuestionAttempt(student.ID, question.ID, true)
+	quizService.RecordQuestionAttempt(student.ID, unassigned.ID, false)
+	quizService.RecordQuestionAttempt(student.ID, privateQuestion.ID, false)
+	quizService.RecordQuestionAttempt(outsider.ID, question.ID, true)
+
+	w = do("teacher", http.MethodGet, classURL+"/progress", nil)
+	var progress api.Response[[]dto.StudentProgressResponse]
+	json.NewDecoder(w.Body).Decode(&progress)
+	if w.Code != http.StatusOK || len(progress.Data) != 1 {
+		t.Fatalf("Expected progress of one student, got %v %+v", w.Code, progress.Data)
+	}
+	if p := progress.Data[0]; p.UserID != student.ID || p.Attempts != 2 || p.Wrong != 1 || p.AverageScore != 1 {
+		t.Errorf("Unexpected student progress %+v", p)
+	}
+	if w := do("student", http.MethodGet, classURL+"/progress", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected student to be forbidden from progress, got %v", w.Code)
+	}
+	if w := do("teacher", http.MethodGet, classURL+"/students/"+strconv.Itoa(int(outsider.ID))+"/attempts", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected attempts of a non-member to be hidden, got %v", w.Code)
+	}
+	w = do("teacher", http.MethodGet, classURL+"/students/"+strconv.Itoa(int(student.ID))+"/attempts?question_bank_id=0", nil)
+	var attempts api.Response[[]dto.QuestionAttemptResponse]
+	json.NewDecoder(w.Body).Decode(&attempts)
+	if w.Code != http.StatusOK || len(attempts.Data) != 1 || attempts.Data[0].QuestionID != question.ID {
+		t.Errorf("Expected teacher to see only assigned attempts, got %v %+v", w.Code, attempts.Data)
+	}
+
+	// 唯一的教师不能退出，学员可以退出
+	if w := do("teacher", http.MethodDelete, classURL+"/members/"+strconv.Itoa(int(teacher.ID)), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected the last teacher to stay, got %v", w.Code)
+	}
+	if w := do("student", http.MethodDelete, classURL+"/members/"+strconv.Itoa(int(student.ID)), nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected student to leave the class, got %v", w.Code)
+	}
+	if w := do("student", http.MethodGet, classURL, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected former student to lose access, got %v", w.Code)
+	}
+
+	// 管理员可以直接把用户加入班级
+	if w := do("admin", http.MethodPost, classURL+"/members", dto.AddClassMemberRequest{UserID: student.ID, Role: models.ClassRoleStudent}); w.Code != http.StatusOK {
+		t.Errorf("Expected admin to add a member directly, got %v", w.Code)
+	}
+	if w := do("student", http.MethodGet, classURL, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected student added by admin to regain access, got %v", w.Code)
+	}
+}
@@ -0,0 +1,85 @@
+// api/events.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"learn/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventHeartbeatPeriod 是 SSE 心跳间隔，避免代理因连接空闲而断开
+const eventHeartbeatPeriod = 25 * time.Second
+
+type EventHandler struct {
+	EventBus *services.EventBus
+}
+
+func (h *EventHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/events", "GET", h.Stream, "events:read", "接收实时通知"},
+	}
+}
+
+// Stream 推送当前用户的事件
+// @Summary 订阅实时通知
+// @Description 以 Server-Sent Events 推送当前用户的事件：assignment.published、assignment.due_soon、badge.earned。
+// @Description 浏览器的 EventSource 可通过 access_token 查询参数传递令牌；断线重连时根据 Last-Event-ID 补发最近的事件
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce  text/event-stream
+// @Param access_token query string false "访问令牌"
+// @Param Last-Event-ID header string false "上次收到的事件 ID"
+// @Success 200 {string} string "事件流"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Router /events [get]
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// 事件流是长连接，不受服务器写超时限制
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	lastEventID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	subscription := h.EventBus.Subscribe(user.ID, lastEventID)
+	defer h.EventBus.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatPeriod)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// api/events_test.go
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// readSSEEvent 读取事件流直到遇到指定类型的事件，返回事件 ID 和内容
+func readSSEEvent(t *testing.T, reader *bufio.Reader, eventType string, data interface{}) string {
+	t.Helper()
+	var id, event string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read %s event: %v", eventType, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: ") && event == eventType:
+			var payload struct {
+				Data json.RawMessage `json:"data"`
+			}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &payload)
+			json.Unmarshal(payload.Data, data)
+			return id
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	classHandler, assignmentHandler, authService, quizService := setupTestAssignmentHandler(t)
+	eventBus := services.NewEventBus()
+	assignmentHandler.AssignmentService.Events = eventBus
+	handler := &api.EventHandler{EventBus: eventBus}
+
+	teacher, _ := createTestUser(authService, "teacher")
+	student, _ := createTestUser(authService, "student")
+	router := mux.NewRouter()
+	router.HandleFunc("/events", withUser(*student, handler.Stream)).Methods(http.MethodGet)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	connect := func(lastEventID string) (*bufio.Reader, func()) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Failed to open event stream: %v", err)
+		}
+		return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+	}
+	stream, closeStream := connect("")
+
+	class, _ := classHandler.ClassService.CreateClass(teacher.ID, "Grade 7", "")
+	classHandler.ClassService.JoinClass(student.ID, class.InviteCode)
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	quizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "Is 7 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+
+	assignment, err := assignmentHandler.AssignmentService.CreateAssignment(class.ID, teacher.ID, dto.CreateAssignmentRequest{
+		Title:          "Homework",
+		QuestionBankID: &bank.ID,
+		DueAt:          time.Now().Add(10 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create assignment: %v", err)
+	}
+	var published dto.AssignmentEvent
+	publishedID := readSSEEvent(t, stream, services.EventAssignmentPublished, &published)
+	if published.AssignmentID != assignment.ID || published.Title != "Homework" {
+		t.Errorf("Unexpected published event: %+v", published)
+	}
+
+	// 截止提醒只推送一次
+	if count, err := assignmentHandler.AssignmentService.NotifyDueSoon(30 * time.Minute); err != nil || count != 1 {
+		t.Fatalf("Expected one reminder, got %v %v", count, err)
+	}
+	if count, _ := assignmentHandler.AssignmentService.NotifyDueSoon(30 * time.Minute); count != 0 {
+		t.Errorf("Expected reminder to be sent once, got %v", count)
+	}
+	var dueSoon dto.AssignmentEvent
+	readSSEEvent(t, stream, services.EventAssignmentDueSoon, &dueSoon)
+	if dueSoon.AssignmentID != assignment.ID {
+		t.Errorf("Unexpected due soon event: %+v", dueSoon)
+	}
+
+	closeStream()
+
+	// 重连时补发 Last-Event-ID 之后的事件
+	stream, closeStream = connect(publishedID)
+	defer closeStream()
+	readSSEEvent(t, stream, services.EventAssignmentDueSoon, &dueSoon)
+	if dueSoon.AssignmentID != assignment.ID {
+		t.Errorf("Unexpected replayed event: %+v", dueSoon)
+	}
+}
+
+func TestQueryTokenOnlyForStreams(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	router := mux.NewRouter()
+	register := routes.NewRoutesRegister(router, authService)
+	if err := register.RegisterRoutes(&api.AuthHandler{AuthService: authService}, &api.EventHandler{EventBus: services.NewEventBus()}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	authService.CreateRole("admin")
+	user, _ := authService.CreateUser("alice", "password", []string{"admin"}, models.StatusActive)
+	token, _ := authService.GenerateAccessToken(user)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	get := func(path string) int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path+"?access_token="+token, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := get("/events"); code != http.StatusOK {
+		t.Errorf("Expected query token to work for the event stream, got %v", code)
+	}
+	// 其他接口即使声明接收事件流也不接受 URL 中的令牌
+	if code := get("/auth/sessions"); code != http.StatusUnauthorized {
+		t.Errorf("Expected query token to be rejected on other routes, got %v", code)
+	}
+}
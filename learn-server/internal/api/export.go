@@ -0,0 +1,70 @@
+// api/export.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"net/http"
+	"strconv"
+)
+
+type ExportHandler struct {
+	ExportService *services.ExportService
+}
+
+func (h *ExportHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/quiz/export", "POST", h.Export, "quiz:export", "导出打印试卷和答案"},
+	}
+}
+
+// exportContentTypes 是各导出格式的 MIME 类型
+var exportContentTypes = map[string]string{
+	services.ExportFormatPDF:  "application/pdf",
+	services.ExportFormatDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// Export 导出打印试卷或答案
+// @Summary 导出打印试卷
+// @Description 将题库、按标签过滤或挑选的题目导出为 PDF 或 DOCX 试卷，题目编号、选项字母、填空和问答的作答横线均在服务端排版。
+// @Description variants 大于 1 时生成 A/B 卷，B 卷起打乱题目和选项顺序；answer_key 为 true 时导出对应各卷的答案和解析。相同的请求（含 seed）得到相同的试卷，试卷和答案可以分两次导出
+// @Tags Export
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  application/pdf
+// @Produce  application/vnd.openxmlformats-officedocument.wordprocessingml.document
+// @Param export body dto.ExportRequest true "导出请求"
+// @Success 200 {file} file "试卷或答案文档"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /quiz/export [post]
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.ExportRequest](w, r)
+	if !ok {
+		return
+	}
+	if req.Format == "" {
+		req.Format = services.ExportFormatPDF
+	}
+
+	content, err := h.ExportService.Export(*req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExport) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to export questions", http.StatusInternalServerError)
+		return
+	}
+
+	filename := "exam"
+	if req.AnswerKey {
+		filename = "exam-answer-key"
+	}
+	w.Header().Set("Content-Type", exportContentTypes[req.Format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+"."+req.Format+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
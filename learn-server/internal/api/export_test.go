@@ -0,0 +1,108 @@
+// api/export_test.go
+package api_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// docxText 返回 DOCX 中 document.xml 的内容
+func docxText(t *testing.T, content []byte) string {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("Failed to open docx: %v", err)
+	}
+	for _, file := range archive.File {
+		if file.Name == "word/document.xml" {
+			reader, _ := file.Open()
+			defer reader.Close()
+			document, _ := io.ReadAll(reader)
+			return string(document)
+		}
+	}
+	t.Fatalf("Expected docx to contain word/document.xml")
+	return ""
+}
+
+func TestExport(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{},
+		&models.QuestionBank{}, &models.Question{}, &models.AnswerOption{}, &models.FillInTheBlankAnswer{}, &models.Tag{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	handler := &api.ExportHandler{ExportService: services.NewExportService(db, quizService)}
+	teacher, _ := createTestUser(authService, "teacher")
+	do := newUserRouter(map[string]models.User{"teacher": *teacher}, handler)
+
+	bank, _ := quizService.CreateQuestionBank("Geography", models.FeedbackFull)
+	quizService.CreateQuestion(models.Question{
+		QuestionBankID: bank.ID,
+		Content:        "Which city is the capital of France?",
+		Explanation:    "Paris has been the capital since 987.",
+		QuestionType:   models.QuestionTypeSingleChoice,
+		AnswerOptions:  []models.AnswerOption{{OptionText: "Lyon"}, {OptionText: "Paris", IsCorrect: true}, {OptionText: "Nice"}},
+	})
+	quizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "The Seine flows through ___ and ___.",
+		QuestionType:    models.QuestionTypeFillInTheBlank,
+		FillInTheBlanks: []models.FillInTheBlankAnswer{{BlankText: "Paris"}, {BlankText: "Rouen"}},
+	})
+
+	w := do("teacher", http.MethodPost, "/quiz/export", dto.ExportRequest{QuestionBankID: bank.ID})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(w.Body.String(), "%PDF") {
+		t.Fatalf("Expected a PDF, got %v %v", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	w = do("teacher", http.MethodPost, "/quiz/export", dto.ExportRequest{Title: "Quiz & Test", Format: "docx", QuestionBankID: bank.ID, Variants: 2})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to export docx, status code: %v", w.Code)
+	}
+	paper := docxText(t, w.Body.Bytes())
+	for _, expected := range []string{"Quiz &amp; Test - Variant A", "Variant B", "1. Which city", "B. Paris", "(2) ____"} {
+		if !strings.Contains(paper, expected) {
+			t.Errorf("Expected paper to contain %q", expected)
+		}
+	}
+	if strings.Contains(paper, "Paris has been") {
+		t.Errorf("Expected paper to leave out explanations")
+	}
+
+	// 答案与同一请求导出的各卷对应
+	w = do("teacher", http.MethodPost, "/quiz/export", dto.ExportRequest{Format: "docx", QuestionBankID: bank.ID, Variants: 2, AnswerKey: true})
+	key := docxText(t, w.Body.Bytes())
+	for _, expected := range []string{"Answer Key", "Variant B", "1. B", "Explanation: Paris has been", "(1) Paris; (2) Rouen"} {
+		if !strings.Contains(key, expected) {
+			t.Errorf("Expected answer key to contain %q", expected)
+		}
+	}
+
+	for _, req := range []dto.ExportRequest{
+		{QuestionBankID: bank.ID, Format: "odt"},
+		{QuestionBankID: bank.ID, Variants: services.MaxExportVariants + 1},
+		{},
+		{QuestionIDs: []uint{999}},
+	} {
+		if w := do("teacher", http.MethodPost, "/quiz/export", req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %+v, got %v", req, w.Code)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// api/gamification.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type GamificationHandler struct {
+	QuizService  *services.QuizService
+	ClassService *services.ClassService
+}
+
+func (h *GamificationHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/gamification/me", "GET", h.GetMyStats, "gamification:read", "查看我的经验值和徽章"},
+		{"/badges", "GET", h.GetBadges, "gamification:read", "查看徽章规则"},
+		{"/badges", "POST", h.CreateBadge, "gamification:manage", "创建徽章规则"},
+		{"/badges/{id}", "DELETE", h.DeleteBadge, "gamification:manage", "删除徽章规则"},
+		{"/leaderboards/question_banks/{id}", "GET", h.GetQuestionBankLeaderboard, "gamification:read", "查看题库排行榜"},
+		{"/classes/{id}/leaderboard", "GET", h.GetClassLeaderboard, "gamification:read", "查看班级排行榜"},
+	}
+}
+
+// GetMyStats 获取当前用户的经验值、连续学习天数和徽章
+// @Summary 获取我的经验值和徽章
+// @Description 获取当前用户的累计和本周经验值、连续学习天数，以及所有徽章的进度和获得时间
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[dto.GamificationStatsResponse] "经验值和徽章"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /gamification/me [get]
+func (h *GamificationHandler) GetMyStats(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := h.QuizService.GetGamificationStats(user.ID)
+	if err != nil {
+		Error(w, "Failed to retrieve gamification stats", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, stats, nil, http.StatusOK)
+}
+
+// GetBadges 获取徽章规则
+// @Summary 获取徽章规则
+// @Description 获取所有徽章及其达成条件
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.BadgeResponse] "徽章列表"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /badges [get]
+func (h *GamificationHandler) GetBadges(w http.ResponseWriter, r *http.Request) {
+	badges, err := h.QuizService.GetBadges()
+	if err != nil {
+		Error(w, "Failed to retrieve badges", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.BadgeResponse, len(badges))
+	for i, badge := range badges {
+		response[i] = services.NewBadgeResponse(badge)
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// CreateBadge 创建徽章规则
+// @Summary 创建徽章规则
+// @Description 创建徽章规则。kind 为 correct_in_bank（在题库中累计答对，未指定题库时任一题库即可）、
+// @Description correct_total（累计答对）、streak（连续学习天数）或 total_xp（累计经验值），threshold 为需要达到的数值
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param badge body dto.BadgeRequest true "徽章规则"
+// @Success 201 {object} Response[dto.BadgeResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /badges [post]
+func (h *GamificationHandler) CreateBadge(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.BadgeRequest](w, r)
+	if !ok {
+		return
+	}
+
+	badge, err := h.QuizService.CreateBadge(*req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBadge):
+			Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			Error(w, "Question bank not found", http.StatusNotFound)
+		default:
+			Error(w, "Failed to create badge", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	Success(w, services.NewBadgeResponse(*badge), nil, http.StatusCreated)
+}
+
+// DeleteBadge 删除徽章规则
+// @Summary 删除徽章规则
+// @Description 删除徽章规则，学员已获得的该徽章一并删除
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Param id path int true "徽章 ID"
+// @Success 204 "删除成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "徽章不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /badges/{id} [delete]
+func (h *GamificationHandler) DeleteBadge(w http.ResponseWriter, r *http.Request) {
+	id, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid badge ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.QuizService.DeleteBadge(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Badge not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to delete badge", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetQuestionBankLeaderboard 获取题库排行榜
+// @Summary 获取题库排行榜
+// @Description 按在题库中获得的经验值排名。period 为 week（默认，每周一零点重置）或 all
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "题库 ID"
+// @Param period query string false "统计时间段" Enums(week, all)
+// @Param limit query int false "返回的名次数量"
+// @Success 200 {object} Response[dto.LeaderboardResponse] "排行榜"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /leaderboards/question_banks/{id} [get]
+func (h *GamificationHandler) GetQuestionBankLeaderboard(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	bankID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid question bank ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.QuizService.GetQuestionBank(bankID); err != nil {
+		Error(w, "Question bank not found", http.StatusNotFound)
+		return
+	}
+
+	filter := leaderboardFilter(r)
+	filter.QuestionBankID = bankID
+	h.writeLeaderboard(w, user.ID, filter)
+}
+
+// GetClassLeaderboard 获取班级排行榜
+// @Summary 获取班级排行榜
+// @Description 按班级学员获得的经验值排名，可按题库筛选。period 为 week（默认，每周一零点重置）或 all，仅班级成员可以查看
+// @Tags Gamification
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "班级 ID"
+// @Param question_bank_id query int false "题库 ID"
+// @Param period query string false "统计时间段" Enums(week, all)
+// @Param limit query int false "返回的名次数量"
+// @Success 200 {object} Response[dto.LeaderboardResponse] "排行榜"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "不是班级成员"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /classes/{id}/leaderboard [get]
+func (h *GamificationHandler) GetClassLeaderboard(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	classID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid class ID", http.StatusBadRequest)
+		return
+	}
+	if !h.ClassService.CanAccessClass(r.Context(), user, classID, services.ClassActionView) {
+		Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	filter := leaderboardFilter(r)
+	filter.ClassID = classID
+	filter.QuestionBankID = uint(parseQueryParamInt(r, "question_bank_id", 0))
+	h.writeLeaderboard(w, user.ID, filter)
+}
+
+func (h *GamificationHandler) writeLeaderboard(w http.ResponseWriter, userID uint, filter services.LeaderboardFilter) {
+	leaderboard, err := h.QuizService.GetLeaderboard(userID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLeaderboard) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to retrieve leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, leaderboard, nil, http.StatusOK)
+}
+
+// leaderboardFilter 从查询参数中读取排行榜的时间段和名次数量
+func leaderboardFilter(r *http.Request) services.LeaderboardFilter {
+	return services.LeaderboardFilter{
+		Period: r.URL.Query().Get("period"),
+		Limit:  parseQueryParamInt(r, "limit", 10),
+	}
+}
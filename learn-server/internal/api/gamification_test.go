@@ -0,0 +1,165 @@
+// api/gamification_test.go
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGamification(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{},
+		&models.Class{}, &models.ClassMember{}, &models.QuestionBank{}, &models.Question{},
+		&models.TrueFalseAnswer{}, &models.QuestionAttempt{}, &models.NotebookEntry{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	classService := services.NewClassService(db, authService)
+	if err := quizService.EnsureDefaultBadges(); err != nil {
+		t.Fatalf("Failed to create default badges: %v", err)
+	}
+
+	users := map[string]models.User{}
+	for _, name := range []string{"teacher", "alice", "bob", "carol"} {
+		user, _ := createTestUser(authService, name)
+		users[name] = *user
+	}
+	do := newUserRouter(users, &api.GamificationHandler{QuizService: quizService, ClassService: classService})
+
+	class, _ := classService.CreateClass(users["teacher"].ID, "Grade 7", "")
+	classService.JoinClass(users["alice"].ID, class.InviteCode)
+	classService.JoinClass(users["bob"].ID, class.InviteCode)
+
+	math, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	history, _ := quizService.CreateQuestionBank("History", models.FeedbackFull)
+	newQuestion := func(bankID uint) uint {
+		question, _ := quizService.CreateQuestion(models.Question{
+			QuestionBankID:  bankID,
+			Content:         "Is it true?",
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		})
+		return question.ID
+	}
+	q1, q2, q3 := newQuestion(math.ID), newQuestion(math.ID), newQuestion(history.ID)
+
+	w := do("teacher", http.MethodPost, "/badges", dto.BadgeRequest{
+		Code: "math_2", Name: "Math fan", Kind: models.BadgeCorrectInBank, Threshold: 2, QuestionBankID: &math.ID,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create badge: %v %s", w.Code, w.Body)
+	}
+	if w := do("teacher", http.MethodPost, "/badges", dto.BadgeRequest{
+		Code: "bad", Name: "Bad", Kind: models.BadgeStreak, Threshold: 3, QuestionBankID: &math.ID,
+	}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected bank on a streak badge to be rejected, got %v", w.Code)
+	}
+
+	answer := func(name string, questionID uint, value bool) *services.AttemptResult {
+		result, err := quizService.RecordQuestionAttempt(users[name].ID, questionID, value)
+		if err != nil {
+			t.Fatalf("Failed to record attempt: %v", err)
+		}
+		return result
+	}
+	badgeCodes := func(badges []models.Badge) []string {
+		codes := []string{}
+		for _, badge := range badges {
+			codes = append(codes, badge.Code)
+		}
+		return codes
+	}
+
+	if result := answer("alice", q1, true); result.XP != services.DefaultXPPerQuestion ||
+		fmt.Sprint(badgeCodes(result.Badges)) != "[first_correct]" {
+		t.Errorf("Expected XP and first badge, got %v %v", result.XP, badgeCodes(result.Badges))
+	}
+	// 同一道题当天重复答对不再获得经验值
+	if result := answer("alice", q1, true); result.XP != 0 || len(result.Badges) != 0 {
+		t.Errorf("Expected no XP for a repeated question, got %v", result.XP)
+	}
+	if result := answer("alice", q2, true); fmt.Sprint(badgeCodes(result.Badges)) != "[math_2]" {
+		t.Errorf("Expected bank badge, got %v", badgeCodes(result.Badges))
+	}
+	if result := answer("bob", q1, false); result.XP != 0 {
+		t.Errorf("Expected no XP for a wrong answer, got %v", result.XP)
+	}
+	answer("bob", q3, true)
+	// 上周的经验值只计入总榜
+	db.Create(&models.XPEvent{UserID: users["bob"].ID, QuestionBankID: math.ID, QuestionID: q2, Points: 50,
+		Correct: true, CreatedAt: time.Now().AddDate(0, 0, -8)})
+
+	// 昨天作答过的学员连续天数加一，达到 7 天获得徽章
+	db.Create(&models.LearnerStats{UserID: users["carol"].ID, CurrentStreak: 6, LongestStreak: 6,
+		LastActiveOn: time.Now().AddDate(0, 0, -1).Format("2006-01-02")})
+	if result := answer("carol", q3, false); fmt.Sprint(badgeCodes(result.Badges)) != "[streak_7]" {
+		t.Errorf("Expected streak badge, got %v", badgeCodes(result.Badges))
+	}
+
+	w = do("alice", http.MethodGet, "/gamification/me", nil)
+	var stats api.Response[dto.GamificationStatsResponse]
+	json.NewDecoder(w.Body).Decode(&stats)
+	if stats.Data.TotalXP != 20 || stats.Data.WeeklyXP != 20 || stats.Data.CorrectAnswers != 2 || stats.Data.CurrentStreak != 1 {
+		t.Errorf("Unexpected stats: %+v", stats.Data)
+	}
+	awarded := 0
+	for _, badge := range stats.Data.Badges {
+		if badge.AwardedAt != nil {
+			awarded++
+		}
+	}
+	if len(stats.Data.Badges) != 5 || awarded != 2 {
+		t.Errorf("Expected 2 of 5 badges awarded, got %+v", stats.Data.Badges)
+	}
+
+	leaderboard := func(as, path string) dto.LeaderboardResponse {
+		w := do(as, http.MethodGet, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to get leaderboard %s: %v", path, w.Code)
+		}
+		var resp api.Response[dto.LeaderboardResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+	board := leaderboard("bob", fmt.Sprintf("/leaderboards/question_banks/%d", math.ID))
+	if len(board.Entries) != 1 || board.Entries[0].Username != "alice" || board.Entries[0].XP != 20 || board.Me != nil {
+		t.Errorf("Unexpected weekly bank leaderboard: %+v", board)
+	}
+	board = leaderboard("bob", fmt.Sprintf("/leaderboards/question_banks/%d?period=all", math.ID))
+	if len(board.Entries) != 2 || board.Entries[0].Username != "bob" || board.Me == nil || board.Me.Rank != 1 {
+		t.Errorf("Unexpected all-time bank leaderboard: %+v", board)
+	}
+
+	// 班级排行榜只统计班级学员
+	classPath := fmt.Sprintf("/classes/%d/leaderboard", class.ID)
+	board = leaderboard("bob", classPath)
+	if len(board.Entries) != 2 || board.Entries[1].Username != "bob" || board.Me == nil || board.Me.Rank != 2 {
+		t.Errorf("Unexpected class leaderboard: %+v", board)
+	}
+	board = leaderboard("teacher", fmt.Sprintf("%s?question_bank_id=%d", classPath, history.ID))
+	if len(board.Entries) != 1 || board.Entries[0].Username != "bob" {
+		t.Errorf("Unexpected class bank leaderboard: %+v", board)
+	}
+	if w := do("carol", http.MethodGet, classPath, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected non-member to be forbidden, got %v", w.Code)
+	}
+	if w := do("bob", http.MethodGet, classPath+"?period=month", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown period to be rejected, got %v", w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+// api/jwks.go
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetJWKS 返回验证 Access Token 签名的公钥
+// @Summary 获取 JWT 公钥
+// @Description 以 JWKS（RFC 7517）格式返回验证 Access Token 的公钥，其他服务按令牌头部的 kid 选择公钥。
+// @Description 包含即将启用和轮换宽限期内的密钥；使用 HS256 签名时为空
+// @Tags Auth
+// @Produce  json
+// @Success 200 {object} object "JWKS"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// 缓存时间应远短于轮换宽限期，验证方才能在新密钥启用前取得公钥
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.AuthService.JWKS())
+}
@@ -0,0 +1,223 @@
+// api/jwks_test.go
+package api_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/consts/claimkeys"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/gorilla/mux"
+)
+
+func TestJWKSKeyRotation(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	// 每次遇到未知 kid 都重新加载，限流见 TestSigningKeyMigration
+	settings := services.SigningKeySettings{Algorithm: services.SigningEdDSA, RotationInterval: 24 * time.Hour, GracePeriod: 2 * time.Hour,
+		RefreshInterval: time.Nanosecond}
+	if err := authService.ConfigureSigningKeys(settings); err != nil {
+		t.Fatalf("Failed to configure signing keys: %v", err)
+	}
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	authService.CreateRole("admin")
+	authService.CreateUser("alice", "password", []string{"admin"}, models.StatusActive)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func() string {
+		w := send(http.MethodPost, "/auth/login", "", dto.LoginRequest{Username: "alice", Password: "password"})
+		var resp api.Response[dto.TokenPairResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data.AccessToken
+	}
+	jwks := func() jose.JSONWebKeySet {
+		w := send(http.MethodGet, "/.well-known/jwks.json", "", nil)
+		var set jose.JSONWebKeySet
+		if err := json.NewDecoder(w.Body).Decode(&set); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Failed to get JWKS: %v %v", w.Code, err)
+		}
+		return set
+	}
+	// 像其他服务一样只用 JWKS 验证令牌
+	verify := func(token string) (string, bool) {
+		jws, err := jose.ParseSigned(token, []jose.SignatureAlgorithm{jose.EdDSA, jose.RS256})
+		if err != nil {
+			t.Fatalf("Failed to parse token: %v", err)
+		}
+		kid := jws.Signatures[0].Header.KeyID
+		set := jwks()
+		keys := set.Key(kid)
+		if len(keys) != 1 {
+			return kid, false
+		}
+		payload, err := jws.Verify(keys[0])
+		if err != nil {
+			return kid, false
+		}
+		var claims map[string]interface{}
+		json.Unmarshal(payload, &claims)
+		return kid, claims[claimkeys.UserName] == "alice"
+	}
+	authorized := func(token string) bool {
+		return send(http.MethodGet, "/auth/sessions", token, nil).Code == http.StatusOK
+	}
+	rotate := func() {
+		t.Helper()
+		if err := authService.RotateSigningKeys(); err != nil {
+			t.Fatalf("Failed to rotate signing keys: %v", err)
+		}
+	}
+	setActivation := func(kid string, activatesAt time.Time) {
+		db.Model(&models.SigningKey{}).Where("kid = ?", kid).Update("activates_at", activatesAt)
+		rotate()
+	}
+
+	first := login()
+	firstKID, ok := verify(first)
+	if !ok || !authorized(first) {
+		t.Fatalf("Expected token to verify against the JWKS")
+	}
+	if set := jwks(); len(set.Keys) != 1 || set.Keys[0].Algorithm != services.SigningEdDSA || !set.Keys[0].IsPublic() {
+		t.Fatalf("Expected one public EdDSA key, got %+v", set.Keys)
+	}
+
+	// 到期前宽限期内发布新密钥，但仍用旧密钥签名
+	setActivation(firstKID, time.Now().Add(-23*time.Hour))
+	var pending models.SigningKey
+	db.Where("kid <> ?", firstKID).First(&pending)
+	if len(jwks().Keys) != 2 || pending.ActivatesAt.Before(time.Now()) {
+		t.Fatalf("Expected the next key to be published before it activates, got %+v", pending)
+	}
+	if kid, _ := verify(login()); kid != firstKID {
+		t.Errorf("Expected the current key to keep signing until the next one activates")
+	}
+	rotate()
+	if len(jwks().Keys) != 2 {
+		t.Errorf("Expected no further key while one is pending")
+	}
+
+	// 新密钥启用后旧令牌在宽限期内仍然有效
+	setActivation(pending.KID, time.Now().Add(-time.Minute))
+	second := login()
+	if kid, ok := verify(second); kid != pending.KID || !ok || !authorized(second) {
+		t.Errorf("Expected the new key to sign tokens, got %s", kid)
+	}
+	if _, ok := verify(first); !ok || !authorized(first) {
+		t.Errorf("Expected tokens from the previous key to stay valid during the grace period")
+	}
+
+	// 宽限期过后删除旧密钥
+	setActivation(pending.KID, time.Now().Add(-3*time.Hour))
+	if _, ok := verify(first); ok || authorized(first) {
+		t.Errorf("Expected tokens from the retired key to be rejected")
+	}
+	if set := jwks(); len(set.Keys) != 1 || set.Keys[0].KeyID != pending.KID {
+		t.Errorf("Expected only the current key to be published, got %+v", set.Keys)
+	}
+
+	// 其他实例切换到 RS256 后签发的令牌按 kid 从数据库加载公钥
+	other := services.NewAuthService(db, "other_secret", time.Hour, 7*24*time.Hour)
+	if err := other.ConfigureSigningKeys(services.SigningKeySettings{Algorithm: services.SigningRS256, RotationInterval: 24 * time.Hour}); err != nil {
+		t.Fatalf("Failed to configure signing keys: %v", err)
+	}
+	var alice models.User
+	db.Where("username = ?", "alice").First(&alice)
+	token, err := other.GenerateAccessToken(alice)
+	if err != nil || !authorized(token) {
+		t.Errorf("Expected RS256 token from another instance to be accepted: %v", err)
+	}
+	if _, ok := verify(token); !ok {
+		t.Errorf("Expected RS256 token to verify against the JWKS")
+	}
+
+	// 切换到非对称密钥超过一个 Access Token 有效期后不再接受 HS256 令牌
+	legacy, _ := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour).GenerateAccessToken(alice)
+	if authorized(legacy) {
+		t.Errorf("Expected HS256 token to be rejected")
+	}
+	if err := authService.ConfigureSigningKeys(services.SigningKeySettings{Algorithm: "none"}); err == nil {
+		t.Errorf("Expected unsupported algorithm to be rejected")
+	}
+	// 使用非对称密钥时也必须配置 secret
+	unset := services.NewAuthService(db, "", time.Hour, 7*24*time.Hour)
+	if err := unset.ConfigureSigningKeys(services.SigningKeySettings{Algorithm: services.SigningEdDSA}); err == nil {
+		t.Errorf("Expected startup without a secret to be rejected")
+	}
+}
+
+func TestSigningKeyMigration(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	alice, err := createTestUser(authService, "alice")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	legacy, _ := authService.GenerateAccessToken(*alice)
+
+	// 从 HS256 切换后，之前签发的令牌在一个有效期内仍然有效
+	settings := services.SigningKeySettings{Algorithm: services.SigningEdDSA, RefreshInterval: 500 * time.Millisecond}
+	if err := authService.ConfigureSigningKeys(settings); err != nil {
+		t.Fatalf("Failed to configure signing keys: %v", err)
+	}
+	if _, err := authService.ParseAccessToken(legacy); err != nil {
+		t.Errorf("Expected HS256 token to stay valid right after the switch: %v", err)
+	}
+	db.Model(&models.SigningKey{}).Where("1 = 1").Update("activates_at", time.Now().Add(-61*time.Minute))
+	if err := authService.RotateSigningKeys(); err != nil {
+		t.Fatalf("Failed to rotate signing keys: %v", err)
+	}
+	if _, err := authService.ParseAccessToken(legacy); err == nil {
+		t.Errorf("Expected HS256 token to be rejected one token lifetime after the switch")
+	}
+
+	// 未知 kid 不会让每个请求都查询数据库，间隔过后才重新加载
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	forged := jwt.NewWithClaims(jwt.GetSigningMethod(services.SigningEdDSA), jwt.MapClaims{claimkeys.UserId: alice.ID})
+	forged.Header["kid"] = "unknown"
+	forgedToken, _ := forged.SignedString(private)
+	if _, err := authService.ParseAccessToken(forgedToken); err == nil {
+		t.Fatalf("Expected token with an unknown kid to be rejected")
+	}
+	other := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	if err := other.ConfigureSigningKeys(services.SigningKeySettings{Algorithm: services.SigningRS256}); err != nil {
+		t.Fatalf("Failed to configure signing keys: %v", err)
+	}
+	token, _ := other.GenerateAccessToken(*alice)
+	if _, err := authService.ParseAccessToken(token); err == nil {
+		t.Errorf("Expected keys not to be reloaded again within the refresh interval")
+	}
+	time.Sleep(settings.RefreshInterval)
+	if _, err := authService.ParseAccessToken(token); err != nil {
+		t.Errorf("Expected the new key to be loaded after the refresh interval: %v", err)
+	}
+}
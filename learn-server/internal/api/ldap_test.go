@@ -0,0 +1,294 @@
+// api/ldap_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/gorilla/mux"
+)
+
+// mockLDAPServer 是进程内的简易 LDAP 服务器，只支持简单绑定和相等过滤器的查找
+type mockLDAPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	entries  map[string]map[string][]string // DN 到属性
+}
+
+func newMockLDAPServer(t *testing.T, entries map[string]map[string][]string) *mockLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &mockLDAPServer{listener: listener, entries: entries}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *mockLDAPServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// update 修改目录中的条目
+func (s *mockLDAPServer) update(dn, attribute string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[dn][attribute] = values
+}
+
+func (s *mockLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			s.reply(conn, messageID, ldap.ApplicationBindResponse, s.bind(op.Children[1].Data.String(), op.Children[2].Data.String()))
+		case ldap.ApplicationSearchRequest:
+			for dn, attributes := range s.search(op) {
+				entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+				entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, ""))
+				list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for name, values := range attributes {
+					attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, value := range values {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+					}
+					attribute.AppendChild(set)
+					list.AppendChild(attribute)
+				}
+				entry.AppendChild(list)
+				s.write(conn, messageID, entry)
+			}
+			s.reply(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+		default:
+			return
+		}
+	}
+}
+
+// bind 校验简单绑定，空密码按匿名绑定处理并成功，与真实服务器一致
+func (s *mockLDAPServer) bind(dn, password string) uint16 {
+	if password == "" {
+		return ldap.LDAPResultSuccess
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for entryDN, attributes := range s.entries {
+		if strings.EqualFold(entryDN, dn) && slices.Contains(attributes["userPassword"], password) {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+func (s *mockLDAPServer) search(op *ber.Packet) map[string]map[string][]string {
+	baseDN := strings.ToLower(op.Children[0].Data.String())
+	filter := op.Children[6]
+	if filter.Tag != ldap.FilterEqualityMatch {
+		return nil
+	}
+	name, value := filter.Children[0].Data.String(), filter.Children[1].Data.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := map[string]map[string][]string{}
+	for dn, attributes := range s.entries {
+		if !strings.HasSuffix(strings.ToLower(dn), baseDN) {
+			continue
+		}
+		for attribute, values := range attributes {
+			if strings.EqualFold(attribute, name) && slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) }) {
+				result[dn] = attributes
+			}
+		}
+	}
+	return result
+}
+
+func (s *mockLDAPServer) reply(conn net.Conn, messageID int64, tag ber.Tag, code uint16) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	s.write(conn, messageID, op)
+}
+
+func (s *mockLDAPServer) write(conn net.Conn, messageID int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, ""))
+	packet.AppendChild(op)
+	conn.Write(packet.Bytes())
+}
+
+func TestLDAPLogin(t *testing.T) {
+	const (
+		serviceDN = "cn=learn,ou=services,dc=example,dc=edu"
+		aliceDN   = "uid=alice,ou=people,dc=example,dc=edu"
+		bobDN     = "uid=bob,ou=people,dc=example,dc=edu"
+		teachers  = "cn=Teachers,ou=groups,dc=example,dc=edu"
+		staff     = "cn=staff,ou=groups,dc=example,dc=edu"
+	)
+	server := newMockLDAPServer(t, map[string]map[string][]string{
+		serviceDN:                                {"userPassword": {"service"}},
+		aliceDN:                                  {"uid": {"alice"}, "mail": {"alice@example.edu"}, "userPassword": {"alice-secret"}, "memberOf": {teachers}},
+		bobDN:                                    {"uid": {"bob"}, "userPassword": {"bob-secret"}},
+		"uid=carol,ou=people,dc=example,dc=edu":  {"uid": {"carol"}, "userPassword": {"carol-secret"}},
+		"uid=ci-bot,ou=people,dc=example,dc=edu": {"uid": {"ci-bot"}, "userPassword": {"bot-secret"}},
+		staff:                                    {"cn": {"staff"}, "member": {bobDN}},
+	})
+
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	authenticator, err := services.NewLDAPAuthenticator(db, services.LDAPSettings{
+		URL:          server.URL(),
+		BindDN:       serviceDN,
+		BindPassword: "service",
+		BaseDN:       "ou=people,dc=example,dc=edu",
+		GroupBaseDN:  "ou=groups,dc=example,dc=edu",
+		GroupFilter:  "(member=%s)",
+		RoleMapping:  map[string]string{"teachers": "teacher", staff: "staff"},
+		DefaultRole:  "student",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create LDAP authenticator: %v", err)
+	}
+	authService.AddAuthenticator(authenticator)
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	for _, roleName := range []string{"student", "teacher", "staff"} {
+		authService.CreateRole(roleName)
+	}
+	if _, err := authService.CreateUser("carol", "password", []string{"student"}, models.StatusActive); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	login := func(username, password string) int {
+		payload, _ := json.Marshal(dto.LoginRequest{Username: username, Password: password})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(payload)))
+		return w.Code
+	}
+	localUser := func(username string) models.User {
+		var user models.User
+		if err := db.Preload("Roles").Where("username = ?", username).First(&user).Error; err != nil {
+			t.Fatalf("Expected local account for %s: %v", username, err)
+		}
+		return user
+	}
+	roleNames := func(user models.User) []string {
+		var names []string
+		for _, role := range user.Roles {
+			names = append(names, role.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	// 目录中的用户首次登录时创建本地账号，memberOf 和按成员查找到的组都会映射为角色
+	if status := login("alice", "alice-secret"); status != http.StatusOK {
+		t.Fatalf("Expected LDAP login to succeed, got %v", status)
+	}
+	alice := localUser("alice")
+	if roles := roleNames(alice); !slices.Equal(roles, []string{"teacher"}) {
+		t.Errorf("Expected group mapped by CN, got %v", roles)
+	}
+	if status := login("bob", "bob-secret"); status != http.StatusOK {
+		t.Fatalf("Expected LDAP login to succeed, got %v", status)
+	}
+	if roles := roleNames(localUser("bob")); !slices.Equal(roles, []string{"staff"}) {
+		t.Errorf("Expected group found by member filter, got %v", roles)
+	}
+
+	// 错误密码、空密码、目录中不存在的用户都被拒绝
+	for _, credentials := range [][2]string{{"alice", "wrong"}, {"alice", ""}, {"dave", "dave-secret"}} {
+		if status := login(credentials[0], credentials[1]); status != http.StatusUnauthorized {
+			t.Errorf("Expected %q to be rejected, got %v", credentials[0], status)
+		}
+	}
+
+	// 本地账号仍使用本地密码，目录中的同名账号不会自动接管
+	if status := login("carol", "password"); status != http.StatusOK {
+		t.Errorf("Expected local login to succeed, got %v", status)
+	}
+	if status := login("carol", "carol-secret"); status != http.StatusUnauthorized {
+		t.Errorf("Expected unlinked local account to be protected, got %v", status)
+	}
+
+	// 再次登录时按目录中的组同步角色，并复用同一个本地账号
+	server.update(aliceDN, "memberOf")
+	if status := login("alice", "alice-secret"); status != http.StatusOK {
+		t.Fatalf("Expected LDAP login to succeed, got %v", status)
+	}
+	if user := localUser("alice"); user.ID != alice.ID || len(user.Roles) != 0 {
+		t.Errorf("Expected same account without mapped role, got %d %v", user.ID, roleNames(user))
+	}
+
+	// 本地停用的账号不能通过目录登录
+	db.Model(&models.User{}).Where("id = ?", alice.ID).Update("status", models.StatusSuspended)
+	if status := login("alice", "alice-secret"); status != http.StatusUnauthorized {
+		t.Errorf("Expected suspended account to be rejected, got %v", status)
+	}
+	// 即使允许按用户名关联，目录中的同名账号也不能登录服务账号
+	linking, err := services.NewLDAPAuthenticator(db, services.LDAPSettings{
+		URL:            server.URL(),
+		BindDN:         serviceDN,
+		BindPassword:   "service",
+		BaseDN:         "ou=people,dc=example,dc=edu",
+		LinkByUsername: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create LDAP authenticator: %v", err)
+	}
+	bot, _ := authService.CreateUser("ci-bot", "password", []string{"student"}, models.StatusActive)
+	db.Model(&bot).Update("service_account", true)
+	if _, err := linking.Authenticate("ci-bot", "bot-secret"); !errors.Is(err, services.ErrUserInactive) {
+		t.Errorf("Expected service account not to be linked, got %v", err)
+	}
+	var identities int64
+	db.Model(&models.UserIdentity{}).Where("user_id = ?", bot.ID).Count(&identities)
+	if identities != 0 {
+		t.Errorf("Expected no identity to be linked to the service account")
+	}
+
+	// 服务账号的密码登录直接拒绝，不再交给目录
+	authService.AddAuthenticator(linking)
+	if status := login("ci-bot", "bot-secret"); status != http.StatusUnauthorized {
+		t.Errorf("Expected service account login to be rejected, got %v", status)
+	}
+}
@@ -0,0 +1,197 @@
+// api/live_quiz.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	liveWriteWait      = 10 * time.Second
+	livePongWait       = 60 * time.Second
+	livePingPeriod     = livePongWait * 9 / 10
+	liveMaxMessageSize = 64 * 1024
+)
+
+type LiveQuizHandler struct {
+	LiveQuizService *services.LiveQuizService
+	// AllowedOrigins 是允许建立 WebSocket 连接的来源，为空时只允许同源连接
+	AllowedOrigins []string
+}
+
+func (h *LiveQuizHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/live/sessions", "POST", h.CreateSession, "live:host", "主持现场答题"},
+		{"/live/sessions/{pin}", "GET", h.GetSession, "live:play", "查看现场答题"},
+		{"/live/sessions/{pin}/ws", "GET", h.Connect, "live:play", "参加现场答题"},
+	}
+}
+
+// CreateSession 创建现场答题
+// @Summary 创建现场答题
+// @Description 从题库创建一场现场答题，返回供学员加入的 PIN。主持人随后连接 WebSocket 控制进度
+// @Tags Live
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param session body dto.CreateLiveSessionRequest true "现场答题设置"
+// @Success 201 {object} Response[dto.LiveSessionResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /live/sessions [post]
+func (h *LiveQuizHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.CreateLiveSessionRequest](w, r)
+	if !ok {
+		return
+	}
+
+	session, err := h.LiveQuizService.CreateSession(user, *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidLiveSession):
+			Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			Error(w, "Question bank not found", http.StatusNotFound)
+		default:
+			Error(w, "Failed to create live session", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	Success(w, session.Snapshot(), nil, http.StatusCreated)
+}
+
+// GetSession 获取现场答题状态
+// @Summary 获取现场答题状态
+// @Description 按 PIN 获取现场答题的阶段和当前排行榜
+// @Tags Live
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param pin path string true "PIN"
+// @Success 200 {object} Response[dto.LiveSessionResponse] "现场答题状态"
+// @Failure 404 {object} ErrorResponse "现场答题不存在或已结束"
+// @Router /live/sessions/{pin} [get]
+func (h *LiveQuizHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.LiveQuizService.GetSession(mux.Vars(r)["pin"])
+	if err != nil {
+		Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	Success(w, session.Snapshot(), nil, http.StatusOK)
+}
+
+// Connect 连接现场答题
+// @Summary 连接现场答题
+// @Description 升级为 WebSocket 连接，浏览器可通过 access_token 查询参数传递令牌。
+// @Description 服务端推送 lobby、question、answer_count、answer_result、reveal、finished、error 事件；
+// @Description 主持人发送 start、reveal、next、end 指令控制进度，学员发送 {"type":"answer","answer":...} 作答
+// @Tags Live
+// @Security ApiKeyAuth
+// @Param pin path string true "PIN"
+// @Param access_token query string false "访问令牌"
+// @Success 101 {string} string "切换为 WebSocket 协议"
+// @Failure 404 {object} ErrorResponse "现场答题不存在或已结束"
+// @Router /live/sessions/{pin}/ws [get]
+func (h *LiveQuizHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	session, err := h.LiveQuizService.GetSession(mux.Vars(r)["pin"])
+	if err != nil {
+		Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.checkOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已经写回了错误响应
+		log.Printf("Failed to upgrade live session connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	subscriber, err := session.Join(user)
+	if err != nil {
+		conn.WriteJSON(dto.LiveEvent{Type: services.LiveEventError, Data: dto.LiveErrorEvent{Message: err.Error()}})
+		return
+	}
+	defer session.Leave(subscriber)
+	go writeLiveEvents(conn, subscriber.Events)
+
+	conn.SetReadLimit(liveMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+	for {
+		var command dto.LiveCommand
+		if err := conn.ReadJSON(&command); err != nil {
+			return
+		}
+		if err := session.Handle(user.ID, command); err != nil {
+			session.SendError(subscriber, err)
+		}
+	}
+}
+
+// writeLiveEvents 把订阅的事件写到连接并定时发送心跳，订阅关闭后关闭连接
+func writeLiveEvents(conn *websocket.Conn, events <-chan dto.LiveEvent) {
+	ticker := time.NewTicker(livePingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkOrigin 允许同源连接和配置中的跨域来源
+func (h *LiveQuizHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
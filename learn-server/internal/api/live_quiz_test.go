@@ -0,0 +1,164 @@
+// api/live_quiz_test.go
+package api_test
+
+import (
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type liveTestEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// readLiveEvent 读取事件直到遇到指定类型
+func readLiveEvent(t *testing.T, conn *websocket.Conn, eventType string, data interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var event liveTestEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Failed to read %s event: %v", eventType, err)
+		}
+		if event.Type == eventType {
+			if data != nil {
+				json.Unmarshal(event.Data, data)
+			}
+			return
+		}
+	}
+}
+
+func TestLiveQuiz(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{}, &models.QuestionBank{}, &models.Question{},
+		&models.TrueFalseAnswer{}, &models.QuestionAttempt{}, &models.NotebookEntry{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	handler := &api.LiveQuizHandler{LiveQuizService: services.NewLiveQuizService(quizService)}
+
+	users := map[string]models.User{}
+	for _, name := range []string{"host", "alice", "bob"} {
+		user, _ := createTestUser(authService, name)
+		users[name] = *user
+	}
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			withUser(users[r.URL.Query().Get("as")], next.ServeHTTP)(w, r)
+		})
+	})
+	for _, endpoint := range handler.GetApiEndpoints() {
+		router.HandleFunc(endpoint.Path, endpoint.Handler).Methods(endpoint.Method)
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	question, _ := quizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "Is 7 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+
+	body, _ := json.Marshal(dto.CreateLiveSessionRequest{QuestionBankID: bank.ID})
+	resp, err := http.Post(server.URL+"/live/sessions?as=host", "application/json", strings.NewReader(string(body)))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Failed to create live session: %v %v", err, resp)
+	}
+	var created api.Response[dto.LiveSessionResponse]
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	pin := created.Data.PIN
+
+	connect := func(as string) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/live/sessions/" + pin + "/ws?as=" + as
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect as %s: %v", as, err)
+		}
+		return conn
+	}
+	host := connect("host")
+	defer host.Close()
+	alice := connect("alice")
+	defer alice.Close()
+	bob := connect("bob")
+	defer bob.Close()
+
+	var lobby dto.LiveLobbyEvent
+	for len(lobby.Players) < 2 {
+		readLiveEvent(t, host, services.LiveEventLobby, &lobby)
+	}
+
+	// 只有主持人可以开始
+	alice.WriteJSON(dto.LiveCommand{Type: services.LiveCommandStart})
+	readLiveEvent(t, alice, services.LiveEventError, nil)
+	host.WriteJSON(dto.LiveCommand{Type: services.LiveCommandStart})
+
+	var asked dto.LiveQuestionEvent
+	readLiveEvent(t, alice, services.LiveEventQuestion, &asked)
+	if asked.Question.ID != question.ID || asked.Question.TrueFalseAnswer != nil || asked.Seconds != services.DefaultLiveQuestionSeconds {
+		t.Fatalf("Unexpected question event: %+v", asked)
+	}
+	readLiveEvent(t, bob, services.LiveEventQuestion, nil)
+
+	alice.WriteJSON(dto.LiveCommand{Type: services.LiveCommandAnswer, Answer: true})
+	var result dto.LiveAnswerResultEvent
+	readLiveEvent(t, alice, services.LiveEventAnswer, &result)
+	if !result.Correct || result.Points <= 500 || result.Points > 1000 {
+		t.Errorf("Expected a fast correct answer to score over 500 points, got %+v", result)
+	}
+	alice.WriteJSON(dto.LiveCommand{Type: services.LiveCommandAnswer, Answer: false})
+	readLiveEvent(t, alice, services.LiveEventError, nil)
+
+	// 全员作答后立即公布答案
+	bob.WriteJSON(dto.LiveCommand{Type: services.LiveCommandAnswer, Answer: false})
+	var reveal dto.LiveRevealEvent
+	readLiveEvent(t, host, services.LiveEventReveal, &reveal)
+	if reveal.Answered != 2 || reveal.Correct != 1 || reveal.Question.TrueFalseAnswer == nil {
+		t.Errorf("Unexpected reveal event: %+v", reveal)
+	}
+	if len(reveal.Leaderboard) != 2 || reveal.Leaderboard[0].Username != "alice" || reveal.Leaderboard[1].Score != 0 {
+		t.Errorf("Unexpected leaderboard: %+v", reveal.Leaderboard)
+	}
+
+	// 作答经过常规判分并计入答题记录
+	if attempt, _ := quizService.GetQuestionAttempt(users["bob"].ID, question.ID); attempt == nil || attempt.Wrong != 1 {
+		t.Errorf("Expected bob's attempt to be recorded, got %+v", attempt)
+	}
+
+	host.WriteJSON(dto.LiveCommand{Type: services.LiveCommandNext})
+	var finished dto.LiveFinishedEvent
+	readLiveEvent(t, bob, services.LiveEventFinished, &finished)
+	if len(finished.Leaderboard) != 2 || finished.Leaderboard[0].Correct != 1 {
+		t.Errorf("Unexpected final leaderboard: %+v", finished.Leaderboard)
+	}
+
+	resp, _ = http.Get(server.URL + "/live/sessions/" + pin + "?as=alice")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected finished session to be removed, got %v", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,243 @@
+// api/login_security_test.go
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	handler, err := setupTestAuthHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup auth handler: %v", err)
+	}
+	sum := sha1.Sum([]byte("correct horse"))
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# common passwords\nSummer2024!\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+	if err := os.WriteFile(list, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write list: %v", err)
+	}
+	if count, err := handler.AuthService.PasswordPolicy.LoadBreachedPasswords(list); err != nil || count != 2 {
+		t.Fatalf("Failed to load breached passwords: %d %v", count, err)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/auth/register", handler.RegisterUser).Methods(http.MethodPost)
+
+	for _, tc := range []struct {
+		username, password string
+		expected           int
+	}{
+		{"alice", "short", http.StatusBadRequest},
+		{"alice12345", "ALICE12345", http.StatusBadRequest},
+		{"alice", "Summer2024!", http.StatusBadRequest},
+		{"alice", "correct horse", http.StatusBadRequest},
+		{"alice", strings.Repeat("x", services.PasswordMaxLength+1), http.StatusBadRequest},
+		{"alice", "correct horse battery", http.StatusCreated},
+	} {
+		body, _ := json.Marshal(dto.RegisterUserRequest{Username: tc.username, Password: tc.password})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body)))
+		if w.Code != tc.expected {
+			t.Errorf("Expected %v for password %q, got %v %s", tc.expected, tc.password, w.Code, w.Body)
+		}
+	}
+
+	user, _ := handler.AuthService.CreateUser("bob", "password", nil, models.StatusActive)
+	short := "1234"
+	err = handler.AuthService.UpdateUser(user.ID, dto.UpdateUserRequest{Username: "bob", Password: &short, Status: int(models.StatusActive)})
+	if !errors.Is(err, services.ErrWeakPassword) {
+		t.Errorf("Expected weak password to be rejected on update, got %v", err)
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	authService.LoginThrottle = services.LoginThrottleSettings{
+		AccountFreeAttempts: 2,
+		IPFreeAttempts:      5,
+		BaseDelay:           time.Minute,
+		MaxDelay:            time.Hour,
+		ResetAfter:          time.Hour,
+		LockoutThreshold:    4,
+		LockoutDuration:     time.Hour,
+	}
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	authService.CreateRole("admin")
+	for name, roles := range map[string][]string{"alice": nil, "bob": nil, "root": {"admin"}} {
+		if _, err := authService.CreateUser(name, "password", roles, models.StatusActive); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	login := func(username, password, ip string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.LoginRequest{Username: username, Password: password})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// elapse 模拟等待时间已过
+	elapse := func() {
+		db.Model(&models.LoginFailure{}).Where("1 = 1").Update("blocked_until", time.Now().Add(-time.Second))
+	}
+
+	// 超出免费次数后按指数退避限流，限流期间即使密码正确也拒绝
+	for i := 0; i < 3; i++ {
+		if w := login("alice", "wrong", "10.0.0.1"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected failed login, got %v", w.Code)
+		}
+	}
+	w := login("alice", "password", "10.0.0.1")
+	if retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || retryAfter < 55 || retryAfter > 60 {
+		t.Fatalf("Expected throttled login, got %v %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := login("Alice", "password", "10.0.0.2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected account throttling to ignore case and IP, got %v", w.Code)
+	}
+	login("alice", "wrong", "10.0.0.1")
+	var failure models.LoginFailure
+	db.Where("scope = ? AND target = ?", "account", "alice").First(&failure)
+	if remaining := time.Until(failure.BlockedUntil); failure.Failures != 3 || remaining < time.Minute-5*time.Second || remaining > time.Minute {
+		t.Errorf("Expected throttled attempt not to count, got %d failures and %v", failure.Failures, remaining)
+	}
+	elapse()
+	if w := login("alice", "password", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected login after waiting, got %v %s", w.Code, w.Body)
+	}
+
+	// 连续失败达到阈值后临时停用账号并记录审计日志
+	for i := 0; i < 4; i++ {
+		elapse()
+		login("alice", "wrong", "10.0.0.3")
+	}
+	elapse()
+	if w := login("alice", "password", "10.0.0.3"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected locked account to be rejected, got %v", w.Code)
+	}
+	var alice models.User
+	db.Where("username = ?", "alice").First(&alice)
+	if alice.Status != models.StatusSuspended || alice.LockedUntil == nil {
+		t.Fatalf("Expected account to be suspended temporarily, got %v %v", alice.Status, alice.LockedUntil)
+	}
+	var root api.Response[dto.LoginResponse]
+	json.NewDecoder(login("root", "password", "10.0.0.4").Body).Decode(&root)
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/audit_logs?action=%s&user_id=%d", services.AuditAccountLocked, alice.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+root.Data.AccessToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var logs api.Response[[]dto.AuditLogResponse]
+	json.NewDecoder(w.Body).Decode(&logs)
+	if w.Code != http.StatusOK || len(logs.Data) != 1 || logs.Data[0].IP != "10.0.0.3" || logs.Meta.TotalRecords != 1 {
+		t.Fatalf("Expected lockout audit entry, got %v %+v", w.Code, logs)
+	}
+
+	// 停用到期后自动恢复
+	db.Model(&alice).Update("locked_until", time.Now().Add(-time.Second))
+	if w := login("alice", "password", "10.0.0.3"); w.Code != http.StatusOK {
+		t.Errorf("Expected login after lockout expired, got %v %s", w.Code, w.Body)
+	}
+	var restored models.User
+	db.First(&restored, alice.ID)
+	var unlocked int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND user_id = ?", services.AuditAccountUnlocked, alice.ID).Count(&unlocked)
+	if restored.Status != models.StatusActive || restored.LockedUntil != nil || unlocked != 1 {
+		t.Errorf("Expected account to be restored, got %v %v %d", restored.Status, restored.LockedUntil, unlocked)
+	}
+
+	// 同一 IP 尝试多个账号时按 IP 限流，不影响其他 IP
+	for i := 0; i < 6; i++ {
+		login(fmt.Sprintf("guess%d", i), "password", "10.0.0.9")
+	}
+	if w := login("bob", "password", "10.0.0.9"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected IP to be throttled, got %v", w.Code)
+	}
+	if w := login("bob", "password", "10.0.0.10"); w.Code != http.StatusOK {
+		t.Errorf("Expected other IP to log in, got %v", w.Code)
+	}
+	// 其他认证方式出错时本地密码错误仍然计入失败
+	authService.AddAuthenticator(unavailableAuthenticator{})
+	for i := 0; i < 3; i++ {
+		if w := login("bob", "wrong", "10.0.0.11"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected backend error, got %v", w.Code)
+		}
+	}
+	if w := login("bob", "password", "10.0.0.11"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected failures during a backend outage to be throttled, got %v", w.Code)
+	}
+}
+
+func TestLoginThrottleParallel(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	// 内存数据库的每个连接是独立的库，只用一个连接，并发请求在语句之间交错
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	authService.LoginThrottle = services.LoginThrottleSettings{
+		AccountFreeAttempts: 2,
+		IPFreeAttempts:      100,
+		BaseDelay:           time.Minute,
+		MaxDelay:            time.Hour,
+		ResetAfter:          time.Hour,
+	}
+	authService.CreateUser("alice", "password", nil, models.StatusActive)
+
+	// 并行的请求也要依次计数，超出免费次数后只有一个请求能校验密码
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	checked := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := authService.AuthenticateLogin("alice", "wrong", fmt.Sprintf("10.0.1.%d", i))
+			var throttled *services.LoginThrottledError
+			if !errors.As(err, &throttled) {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if checked != 3 {
+		t.Errorf("Expected 3 password checks before throttling, got %d", checked)
+	}
+}
+
+// unavailableAuthenticator 模拟不可用的目录服务
+type unavailableAuthenticator struct{}
+
+func (unavailableAuthenticator) Authenticate(username, password string) (models.User, error) {
+	return models.User{}, errors.New("directory unavailable")
+}
@@ -0,0 +1,339 @@
+// api/mfa.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// LoginMFA 登录第二步，提交验证码或恢复码
+// @Summary 两步验证登录
+// @Description 使用登录时返回的 mfa_token 和验证器上的验证码（或恢复码）换取令牌，连续失败 5 次后暂停验证 15 分钟
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MFALoginRequest true "两步验证请求"
+// @Success 200 {object} Response[dto.TokenPairResponse] "登录成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "mfa_token 无效或验证码错误"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.MFALoginRequest](w, r)
+	if !ok {
+		return
+	}
+	user, err := h.AuthService.VerifyMFAChallenge(req.MFAToken, false)
+	if err != nil {
+		writeMFAError(w, err, http.StatusUnauthorized)
+		return
+	}
+	if err := h.AuthService.VerifyMFACode(user.ID, req.Code); err != nil {
+		writeMFAError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, refreshToken, err := h.AuthService.GenerateTokens(user, clientDevice(r, req.DeviceName))
+	if err != nil {
+		Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		return
+	}
+	Success(w, dto.TokenPairResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil, http.StatusOK)
+}
+
+// StartLoginMFAEnrollment 登录时绑定验证器
+// @Summary 登录时绑定验证器
+// @Description 角色要求两步验证但尚未绑定的用户，使用登录时返回的 mfa_token 生成 TOTP 密钥
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MFATokenRequest true "mfa_token"
+// @Success 200 {object} Response[dto.MFAEnrollmentResponse] "待绑定的密钥"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "mfa_token 无效"
+// @Failure 409 {object} ErrorResponse "已启用两步验证"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/login/mfa/enroll [post]
+func (h *AuthHandler) StartLoginMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.MFATokenRequest](w, r)
+	if !ok {
+		return
+	}
+	user, err := h.AuthService.VerifyMFAChallenge(req.MFAToken, true)
+	if err != nil {
+		writeMFAError(w, err, http.StatusUnauthorized)
+		return
+	}
+	enrollment, err := h.AuthService.StartMFAEnrollment(user)
+	if err != nil {
+		writeMFAError(w, err, http.StatusBadRequest)
+		return
+	}
+	Success(w, enrollment, nil, http.StatusOK)
+}
+
+// ConfirmLoginMFAEnrollment 登录时确认绑定验证器
+// @Summary 登录时确认绑定验证器
+// @Description 提交验证器上的验证码完成绑定，启用两步验证并返回令牌和恢复码，恢复码只显示这一次
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MFALoginRequest true "mfa_token 和验证码"
+// @Success 200 {object} Response[dto.MFAEnrollmentLoginResponse] "登录成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "mfa_token 无效或验证码错误"
+// @Failure 409 {object} ErrorResponse "尚未生成密钥或已启用两步验证"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/login/mfa/enroll/verify [post]
+func (h *AuthHandler) ConfirmLoginMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.MFALoginRequest](w, r)
+	if !ok {
+		return
+	}
+	user, err := h.AuthService.VerifyMFAChallenge(req.MFAToken, true)
+	if err != nil {
+		writeMFAError(w, err, http.StatusUnauthorized)
+		return
+	}
+	codes, err := h.AuthService.ConfirmMFAEnrollment(user.ID, req.Code)
+	if err != nil {
+		writeMFAError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	accessToken, refreshToken, err := h.AuthService.GenerateTokens(user, clientDevice(r, req.DeviceName))
+	if err != nil {
+		Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		return
+	}
+	Success(w, dto.MFAEnrollmentLoginResponse{
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		RecoveryCodes: codes,
+	}, nil, http.StatusOK)
+}
+
+// GetMFAStatus 获取当前用户的两步验证状态
+// @Summary 获取两步验证状态
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[dto.MFAStatusResponse] "获取成功"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/mfa [get]
+func (h *AuthHandler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	status, err := h.AuthService.GetMFAStatus(user.ID)
+	if err != nil {
+		Error(w, "Failed to get two-factor authentication status", http.StatusInternalServerError)
+		return
+	}
+	Success(w, status, nil, http.StatusOK)
+}
+
+// StartMFAEnrollment 绑定验证器
+// @Summary 绑定验证器
+// @Description 生成新的 TOTP 密钥和二维码，提交验证码确认后才启用，未确认前可以重新生成
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[dto.MFAEnrollmentResponse] "待绑定的密钥"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 409 {object} ErrorResponse "已启用两步验证"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/mfa/enroll [post]
+func (h *AuthHandler) StartMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	enrollment, err := h.AuthService.StartMFAEnrollment(user)
+	if err != nil {
+		writeMFAError(w, err, http.StatusBadRequest)
+		return
+	}
+	Success(w, enrollment, nil, http.StatusOK)
+}
+
+// ConfirmMFAEnrollment 确认绑定验证器
+// @Summary 确认绑定验证器
+// @Description 提交验证器上的验证码，启用两步验证并返回恢复码，恢复码只显示这一次
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MFACodeRequest true "验证码"
+// @Success 200 {object} Response[dto.RecoveryCodesResponse] "已启用"
+// @Failure 400 {object} ErrorResponse "验证码错误"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 409 {object} ErrorResponse "尚未生成密钥或已启用两步验证"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/mfa/enroll/verify [post]
+func (h *AuthHandler) ConfirmMFAEnrollment(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.MFACodeRequest](w, r)
+	if !ok {
+		return
+	}
+	codes, err := h.AuthService.ConfirmMFAEnrollment(user.ID, req.Code)
+	if err != nil {
+		writeMFAError(w, err, http.StatusBadRequest)
+		return
+	}
+	Success(w, dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil, http.StatusOK)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 校验验证码后生成一组新的恢复码，之前的恢复码全部失效
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MFACodeRequest true "验证码或恢复码"
+// @Success 200 {object} Response[dto.RecoveryCodesResponse] "新的恢复码"
+// @Failure 400 {object} ErrorResponse "验证码错误"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 409 {object} ErrorResponse "未启用两步验证"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/mfa/recovery_codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.MFACodeRequest](w, r)
+	if !ok {
+		return
+	}
+	codes, err := h.AuthService.RegenerateRecoveryCodes(user.ID, req.Code)
+	if err != nil {
+		writeMFAError(w, err, http.StatusBadRequest)
+		return
+	}
+	Success(w, dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil, http.StatusOK)
+}
+
+// DisableMFA 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 校验验证码后关闭两步验证，角色要求两步验证时不能关闭
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Param request body dto.MFACodeRequest true "验证码或恢复码"
+// @Success 204 "已关闭"
+// @Failure 400 {object} ErrorResponse "验证码错误"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 409 {object} ErrorResponse "未启用或角色要求两步验证"
+// @Failure 429 {object} ErrorResponse "失败次数过多"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/mfa/disable [post]
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.MFACodeRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.DisableMFA(user.ID, req.Code); err != nil {
+		writeMFAError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetUserMFA 重置用户的两步验证
+// @Summary 重置用户的两步验证
+// @Description 删除用户的验证器绑定和恢复码，用于用户丢失验证器的情况。角色要求两步验证的用户下次登录时需要重新绑定
+// @Tags User
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Success 204 "已重置"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/mfa [delete]
+func (h *AuthHandler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.AuthService.ResetMFA(userID); err != nil {
+		Error(w, "Failed to reset two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateRoleMFAPolicy 设置角色是否要求两步验证
+// @Summary 设置角色是否要求两步验证
+// @Description 拥有该角色的用户登录时必须通过两步验证，尚未绑定的用户需要先绑定验证器。对之后的登录生效
+// @Tags Role
+// @Security ApiKeyAuth
+// @Accept  json
+// @Param id path int true "角色 ID"
+// @Param request body dto.RoleMFAPolicyRequest true "两步验证策略"
+// @Success 204 "设置成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "角色不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /roles/{id}/mfa [put]
+func (h *AuthHandler) UpdateRoleMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	roleID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid role ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.RoleMFAPolicyRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.SetRoleMFARequired(roleID, req.RequireMFA); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Role not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to update role", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeMFAError 将两步验证错误转换为响应，codeStatus 是验证码错误时的状态码
+func writeMFAError(w http.ResponseWriter, err error, codeStatus int) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken):
+		Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, services.ErrInvalidMFACode):
+		Error(w, err.Error(), codeStatus)
+	case errors.Is(err, services.ErrMFALocked):
+		Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, services.ErrMFAAlreadyEnabled), errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFAEnrollmentNotStarted), errors.Is(err, services.ErrMFARequiredByRole):
+		Error(w, err.Error(), http.StatusConflict)
+	default:
+		Error(w, "Failed to process two-factor authentication", http.StatusInternalServerError)
+	}
+}
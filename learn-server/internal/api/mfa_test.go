@@ -0,0 +1,210 @@
+// api/mfa_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/consts/claimkeys"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/pquerna/otp/totp"
+)
+
+func TestMFA(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	roles := map[string]models.Role{}
+	grant := func(roleName string, names ...string) {
+		role, _ := authService.CreateRole(roleName)
+		req := dto.RoleUpdateRequest{Name: roleName}
+		for _, permission := range permissions {
+			if slices.Contains(names, permission.Name) {
+				req.Permissions = append(req.Permissions, int(permission.ID))
+			}
+		}
+		if err := authService.UpdateRole(role.ID, req); err != nil {
+			t.Fatalf("Failed to grant permissions: %v", err)
+		}
+		roles[roleName] = role
+	}
+	grant("member", "auth:mfa")
+	grant("staff", "auth:mfa")
+	grant("admin", "users:edit", "roles:edit")
+	users := map[string]models.User{}
+	for name, role := range map[string]string{"alice": "member", "bob": "staff", "root": "admin"} {
+		users[name], _ = authService.CreateUser(name, "password", []string{role}, models.StatusActive)
+	}
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(name string) dto.LoginResponse {
+		w := send(http.MethodPost, "/auth/login", "", dto.LoginRequest{Username: name, Password: "password"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to login: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[dto.LoginResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+	enroll := func(path, token string, body interface{}) dto.MFAEnrollmentResponse {
+		w := send(http.MethodPost, path, token, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to start enrollment: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[dto.MFAEnrollmentResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+	code := func(secret string, offset time.Duration) string {
+		code, _ := totp.GenerateCode(secret, time.Now().Add(offset))
+		return code
+	}
+
+	// 用户自行绑定验证器
+	alice := login("alice")
+	if alice.AccessToken == "" || alice.MFARequired {
+		t.Fatalf("Expected plain login before enrollment, got %+v", alice)
+	}
+	enrollment := enroll("/auth/mfa/enroll", alice.AccessToken, nil)
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/") || !strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,") {
+		t.Fatalf("Unexpected enrollment: %+v", enrollment)
+	}
+	if w := send(http.MethodPost, "/auth/mfa/enroll/verify", alice.AccessToken, dto.MFACodeRequest{Code: "000000"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected wrong code to be rejected, got %v", w.Code)
+	}
+	enrollmentCode := code(enrollment.Secret, 0)
+	w := send(http.MethodPost, "/auth/mfa/enroll/verify", alice.AccessToken, dto.MFACodeRequest{Code: enrollmentCode})
+	var recovery api.Response[dto.RecoveryCodesResponse]
+	json.NewDecoder(w.Body).Decode(&recovery)
+	if w.Code != http.StatusOK || len(recovery.Data.RecoveryCodes) != services.RecoveryCodeCount {
+		t.Fatalf("Failed to confirm enrollment: %v %s", w.Code, w.Body)
+	}
+
+	// 启用后登录需要第二步，MFAToken 不能当作 Access Token 使用
+	challenge := login("alice")
+	if challenge.AccessToken != "" || !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("Expected an MFA challenge, got %+v", challenge)
+	}
+	if w := send(http.MethodGet, "/auth/mfa", challenge.MFAToken, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected MFA token to be rejected as access token, got %v", w.Code)
+	}
+	mfaLogin := func(mfaToken, code string) int {
+		return send(http.MethodPost, "/auth/login/mfa", "", dto.MFALoginRequest{MFAToken: mfaToken, Code: code}).Code
+	}
+	// 确认绑定时用过的验证码不能再次使用
+	if status := mfaLogin(challenge.MFAToken, enrollmentCode); status != http.StatusUnauthorized {
+		t.Errorf("Expected used code to be rejected, got %v", status)
+	}
+	if status := mfaLogin(challenge.MFAToken, code(enrollment.Secret, 30*time.Second)); status != http.StatusOK {
+		t.Errorf("Expected next code to be accepted, got %v", status)
+	}
+	recoveryCode := strings.ToLower(recovery.Data.RecoveryCodes[0])
+	if status := mfaLogin(challenge.MFAToken, recoveryCode); status != http.StatusOK {
+		t.Errorf("Expected recovery code to be accepted, got %v", status)
+	}
+	if status := mfaLogin(challenge.MFAToken, recoveryCode); status != http.StatusUnauthorized {
+		t.Errorf("Expected recovery code to be single use, got %v", status)
+	}
+	w = send(http.MethodGet, "/auth/mfa", alice.AccessToken, nil)
+	var status api.Response[dto.MFAStatusResponse]
+	json.NewDecoder(w.Body).Decode(&status)
+	if !status.Data.Enabled || status.Data.Required || status.Data.RecoveryCodesRemaining != services.RecoveryCodeCount-1 {
+		t.Errorf("Unexpected MFA status: %+v", status.Data)
+	}
+
+	// 角色要求两步验证时，未绑定的用户登录时先绑定
+	root := login("root")
+	if w := send(http.MethodPut, fmt.Sprintf("/roles/%d/mfa", roles["staff"].ID), root.AccessToken, dto.RoleMFAPolicyRequest{RequireMFA: true}); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to update role policy: %v %s", w.Code, w.Body)
+	}
+	bob := login("bob")
+	if bob.AccessToken != "" || !bob.MFAEnrollmentRequired || bob.MFAToken == "" {
+		t.Fatalf("Expected enrollment to be required, got %+v", bob)
+	}
+	if status := mfaLogin(bob.MFAToken, "123456"); status != http.StatusUnauthorized {
+		t.Errorf("Expected enrollment token to be rejected for verification, got %v", status)
+	}
+	bobEnrollment := enroll("/auth/login/mfa/enroll", "", dto.MFATokenRequest{MFAToken: bob.MFAToken})
+	w = send(http.MethodPost, "/auth/login/mfa/enroll/verify", "", dto.MFALoginRequest{MFAToken: bob.MFAToken, Code: code(bobEnrollment.Secret, 0)})
+	var enrolled api.Response[dto.MFAEnrollmentLoginResponse]
+	json.NewDecoder(w.Body).Decode(&enrolled)
+	if w.Code != http.StatusOK || enrolled.Data.AccessToken == "" || len(enrolled.Data.RecoveryCodes) != services.RecoveryCodeCount {
+		t.Fatalf("Failed to enroll during login: %v %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodPost, "/auth/mfa/disable", enrolled.Data.AccessToken, dto.MFACodeRequest{Code: enrolled.Data.RecoveryCodes[0]}); w.Code != http.StatusConflict {
+		t.Errorf("Expected disabling required MFA to be rejected, got %v", w.Code)
+	}
+
+	// 连续失败后暂停验证
+	bob = login("bob")
+	for i := 0; i < services.MaxMFAFailures; i++ {
+		mfaLogin(bob.MFAToken, "abcdef")
+	}
+	if status := mfaLogin(bob.MFAToken, code(bobEnrollment.Secret, 30*time.Second)); status != http.StatusTooManyRequests {
+		t.Errorf("Expected verification to be locked, got %v", status)
+	}
+
+	// 管理员重置后可以直接登录
+	if w := send(http.MethodDelete, fmt.Sprintf("/users/%d/mfa", users["alice"].ID), root.AccessToken, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to reset MFA: %v %s", w.Code, w.Body)
+	}
+	if alice := login("alice"); alice.AccessToken == "" {
+		t.Errorf("Expected plain login after reset, got %+v", alice)
+	}
+}
+
+func TestMFARequiresJWTSecret(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "", time.Hour, 7*24*time.Hour)
+	if err := authService.ConfigureSigningKeys(services.SigningKeySettings{Algorithm: services.SigningHS256}); !errors.Is(err, services.ErrMissingJWTSecret) {
+		t.Errorf("Expected startup without a JWT secret to be refused, got %v", err)
+	}
+	alice, _ := authService.CreateUser("alice", "password", []string{}, models.StatusActive)
+	if _, err := authService.IssueMFAChallenge(alice, false); err == nil {
+		t.Errorf("Expected MFA challenge to be refused without a JWT secret")
+	}
+
+	// 用公开的 ":mfa" 伪造的 MFAToken 不能通过验证
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		claimkeys.UserId:       alice.ID,
+		claimkeys.TokenVersion: alice.TokenVersion,
+		"purpose":              "mfa",
+		claimkeys.Exp:          time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(":mfa"))
+	if _, err := authService.VerifyMFAChallenge(forged, false); err == nil {
+		t.Errorf("Expected forged MFA token to be rejected")
+	}
+}
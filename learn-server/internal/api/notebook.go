@@ -0,0 +1,192 @@
+// api/notebook.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetNotebook 获取当前用户的错题本
+// @Summary 获取错题本
+// @Description 分页获取当前用户错题本中的题目，可按题库和标签筛选，题目不含答案
+// @Tags Notebook
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param question_bank_id query int false "题库 ID"
+// @Param tag query string false "标签过滤"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response[[]dto.NotebookEntryResponse] "错题列表"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /notebook [get]
+func (h *QuizHandler) GetNotebook(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, pageSize := GetPaginationParams(r)
+
+	items, total, err := h.QuizService.GetNotebook(user.ID, notebookFilter(r), page, pageSize)
+	if err != nil {
+		Error(w, "Failed to retrieve notebook", http.StatusInternalServerError)
+		return
+	}
+
+	policy := services.AnswerPolicyFor(services.ViewPractice)
+	response := make([]dto.NotebookEntryResponse, len(items))
+	for i, item := range items {
+		response[i] = dto.NotebookEntryResponse{
+			QuestionID:         item.Entry.QuestionID,
+			Question:           services.NewQuestionResponse(&item.Entry.Question, policy),
+			Starred:            item.Entry.Starred,
+			Note:               item.Entry.Note,
+			WrongCount:         item.Entry.WrongCount,
+			ConsecutiveCorrect: item.ConsecutiveCorrect,
+			CreatedAt:          item.Entry.CreatedAt,
+			UpdatedAt:          item.Entry.UpdatedAt,
+		}
+	}
+
+	Success(w, response, &PaginationMeta{
+		TotalRecords: total,
+		PageSize:     pageSize,
+		CurrentPage:  page,
+	}, http.StatusOK)
+}
+
+// GetNotebookPractice 从错题本中抽取练习题
+// @Summary 错题练习
+// @Description 从当前用户的错题本中随机抽取题目，可按题库和标签筛选
+// @Tags Notebook
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param question_bank_id query int false "题库 ID"
+// @Param tag query string false "标签过滤"
+// @Param limit query int false "题目数量"
+// @Success 200 {object} Response[[]dto.QuestionResponse] "练习题列表"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /notebook/practice [get]
+func (h *QuizHandler) GetNotebookPractice(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	limit := parseQueryParamInt(r, "limit", 5)
+
+	questions, err := h.QuizService.GetNotebookPractice(user.ID, notebookFilter(r), limit)
+	if err != nil {
+		Error(w, "Failed to retrieve practice questions", http.StatusInternalServerError)
+		return
+	}
+
+	policy := services.AnswerPolicyFor(services.ViewPractice)
+	response := make([]dto.QuestionResponse, len(questions))
+	for i := range questions {
+		response[i] = services.NewQuestionResponse(&questions[i], policy)
+	}
+
+	Success(w, response, nil, http.StatusOK)
+}
+
+// UpdateNotebookEntry 收藏题目或修改笔记
+// @Summary 收藏错题或修改笔记
+// @Description 将题目加入当前用户的错题本并收藏，或修改笔记；收藏的题不会因连续答对被移出
+// @Tags Notebook
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param question_id path int true "问题 ID"
+// @Param entry body dto.UpdateNotebookEntryRequest true "收藏和笔记"
+// @Success 200 {object} Response[dto.NotebookEntryResponse] "修改成功"
+// @Success 204 "取消收藏后已移出错题本"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "问题不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /notebook/{question_id} [put]
+func (h *QuizHandler) UpdateNotebookEntry(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	questionID, ok := ParseUintParam(r, "question_id")
+	if !ok {
+		Error(w, "Invalid question ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.UpdateNotebookEntryRequest](w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.QuizService.UpdateNotebookEntry(user.ID, questionID, req.Starred, req.Note)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Question not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to update notebook", http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	Success(w, dto.NotebookEntryResponse{
+		QuestionID: entry.QuestionID,
+		Question:   services.NewQuestionResponse(&entry.Question, services.AnswerPolicyFor(services.ViewPractice)),
+		Starred:    entry.Starred,
+		Note:       entry.Note,
+		WrongCount: entry.WrongCount,
+		CreatedAt:  entry.CreatedAt,
+		UpdatedAt:  entry.UpdatedAt,
+	}, nil, http.StatusOK)
+}
+
+// RemoveNotebookEntry 将题目移出错题本
+// @Summary 移出错题本
+// @Description 将题目移出当前用户的错题本
+// @Tags Notebook
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param question_id path int true "问题 ID"
+// @Success 204 "移出成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /notebook/{question_id} [delete]
+func (h *QuizHandler) RemoveNotebookEntry(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	questionID, ok := ParseUintParam(r, "question_id")
+	if !ok {
+		Error(w, "Invalid question ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.QuizService.RemoveNotebookEntry(user.ID, questionID); err != nil {
+		Error(w, "Failed to remove notebook entry", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notebookFilter 从查询参数中读取错题本的筛选条件
+func notebookFilter(r *http.Request) services.NotebookFilter {
+	return services.NotebookFilter{
+		QuestionBankID: uint(parseQueryParamInt(r, "question_bank_id", 0)),
+		Tag:            r.URL.Query().Get("tag"),
+	}
+}
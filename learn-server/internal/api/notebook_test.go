@@ -0,0 +1,156 @@
+// api/notebook_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestNotebook(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+	user, err := createTestUser(authService, "student")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/notebook", withUser(*user, handler.GetNotebook)).Methods("GET")
+	router.HandleFunc("/notebook/practice", withUser(*user, handler.GetNotebookPractice)).Methods("GET")
+	router.HandleFunc("/notebook/{question_id}", withUser(*user, handler.UpdateNotebookEntry)).Methods("PUT")
+	router.HandleFunc("/notebook/{question_id}", withUser(*user, handler.RemoveNotebookEntry)).Methods("DELETE")
+
+	math, _ := handler.QuizService.CreateQuestionBank("Math", models.FeedbackFull)
+	history, _ := handler.QuizService.CreateQuestionBank("History", models.FeedbackFull)
+	newQuestion := func(bankID uint, content string) uint {
+		question, err := handler.QuizService.CreateQuestion(models.Question{
+			QuestionBankID:  bankID,
+			Content:         content,
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create question: %v", err)
+		}
+		return question.ID
+	}
+	answer := func(questionID uint, value bool) {
+		if _, err := handler.QuizService.RecordQuestionAttempt(user.ID, questionID, value); err != nil {
+			t.Fatalf("Failed to record attempt: %v", err)
+		}
+	}
+	notebook := func(query string) []dto.NotebookEntryResponse {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notebook"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to get notebook, status code: %v", w.Code)
+		}
+		var resp api.Response[[]dto.NotebookEntryResponse]
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode notebook: %v", err)
+		}
+		return resp.Data
+	}
+
+	mathQuestion := newQuestion(math.ID, "Is 1+1 equal to 2?")
+	historyQuestion := newQuestion(history.ID, "Was Rome founded before Carthage?")
+
+	// 答错的题自动加入错题本
+	answer(mathQuestion, false)
+	answer(historyQuestion, false)
+	entries := notebook("")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 notebook entries, got %v", len(entries))
+	}
+	if entries[0].Question.TrueFalseAnswer != nil {
+		t.Errorf("Expected notebook questions to hide answers")
+	}
+	if entries = notebook("?question_bank_id=" + strconv.Itoa(int(math.ID))); len(entries) != 1 || entries[0].QuestionID != mathQuestion {
+		t.Errorf("Expected only the math question, got %+v", entries)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notebook/practice?question_bank_id="+strconv.Itoa(int(history.ID)), nil))
+	var practice api.Response[[]dto.QuestionResponse]
+	json.NewDecoder(w.Body).Decode(&practice)
+	if len(practice.Data) != 1 || practice.Data[0].ID != historyQuestion {
+		t.Errorf("Expected practice set drawn from the history bank, got %+v", practice.Data)
+	}
+
+	// 收藏并记笔记的题连续答对也不会被移出
+	body, _ := json.Marshal(map[string]interface{}{"starred": true, "note": "Rome: 753 BC"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/notebook/"+strconv.Itoa(int(historyQuestion)), bytes.NewBuffer(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to star question, status code: %v", w.Code)
+	}
+
+	for i := 0; i < 3; i++ {
+		answer(mathQuestion, true)
+		answer(historyQuestion, true)
+	}
+	entries = notebook("")
+	if len(entries) != 1 || entries[0].QuestionID != historyQuestion {
+		t.Fatalf("Expected only the starred question to remain, got %+v", entries)
+	}
+	if entries[0].Note != "Rome: 753 BC" || entries[0].ConsecutiveCorrect != 3 {
+		t.Errorf("Unexpected notebook entry %+v", entries[0])
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/notebook/"+strconv.Itoa(int(historyQuestion)), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to remove notebook entry, status code: %v", w.Code)
+	}
+	if entries = notebook(""); len(entries) != 0 {
+		t.Errorf("Expected empty notebook, got %+v", entries)
+	}
+	// 只有笔记没有收藏的题连续答对后同样保留
+	answer(mathQuestion, false)
+	body, _ = json.Marshal(map[string]interface{}{"note": "carry the one"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/notebook/"+strconv.Itoa(int(mathQuestion)), bytes.NewBuffer(body)))
+	for i := 0; i < 3; i++ {
+		answer(mathQuestion, true)
+	}
+	if entries = notebook(""); len(entries) != 1 || entries[0].Note != "carry the one" {
+		t.Errorf("Expected the question with a note to remain, got %+v", entries)
+	}
+
+	// 组合题带材料和小题显示
+	group, err := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID: history.ID,
+		Content:        "Read and answer.",
+		QuestionType:   models.QuestionTypeGroup,
+		Stimulus:       &models.QuestionStimulus{Text: "Rome was founded in 753 BC."},
+		Children: []models.Question{{
+			QuestionBankID:  history.ID,
+			Content:         "Rome was founded in 753 BC.",
+			QuestionType:    models.QuestionTypeTrueFalse,
+			Position:        1,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create group question: %v", err)
+	}
+	if _, err := handler.QuizService.RecordQuestionAttempt(user.ID, group.ID, map[string]interface{}{}); err != nil {
+		t.Fatalf("Failed to record attempt: %v", err)
+	}
+	for _, entry := range notebook("") {
+		if entry.QuestionID == group.ID && (entry.Question.Stimulus == nil || len(entry.Question.Children) != 1) {
+			t.Errorf("Expected group question with stimulus and children, got %+v", entry.Question)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// api/offline_sync.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetPracticePackage 下载离线练习包
+// @Summary 下载离线练习包
+// @Description 下载题库的离线练习包，题目不含答案。answer_hashes 以题目 ID 为键，值为标准答案逐项的 hex(sha256(salt:题目 ID:答案))，
+// @Description 设备按同样规则散列作答即可本地判分：单选和多选题为按 ID 升序的选项 JSON 数组（如 [3,5]），判断题为 true 或 false，填空题每一空单独散列。
+// @Description 只有完整反馈的题库才下发散列，其他题库只能联网同步时判分
+// @Tags OfflineSync
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param question_bank_id query int true "题库 ID"
+// @Param tag query string false "标签过滤"
+// @Param limit query int false "题目数量上限，最多 500"
+// @Success 200 {object} Response[dto.PracticePackageResponse] "离线练习包"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /sync/practice_package [get]
+func (h *QuizHandler) GetPracticePackage(w http.ResponseWriter, r *http.Request) {
+	questionBankID := uint(parseQueryParamInt(r, "question_bank_id", 0))
+	if questionBankID == 0 {
+		Error(w, "question_bank_id is required", http.StatusBadRequest)
+		return
+	}
+	limit := parseQueryParamInt(r, "limit", services.MaxPracticePackageSize)
+
+	pkg, err := h.QuizService.GetPracticePackage(questionBankID, r.URL.Query().Get("tag"), limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Question bank not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to build practice package", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, pkg, nil, http.StatusOK)
+}
+
+// SyncAttempts 上传离线作答
+// @Summary 上传离线作答
+// @Description 批量上传设备离线时的作答，按 answered_at 先后重放，判分、答题记录、错题本和经验值与在线作答相同。
+// @Description 每条作答带设备生成的 client_id，重复上传返回 duplicate 和首次同步的结果，可以放心重试；
+// @Description 早于服务端最近一次作答的离线作答只计入作答次数（superseded），不改变最近作答和错题本。
+// @Description 单条作答无效时只拒绝该条（rejected），其余作答照常记录；
+// @Description 题库设置为不反馈时不返回对错、得分和经验值（withheld）
+// @Tags OfflineSync
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param attempts body dto.SyncAttemptsRequest true "离线作答"
+// @Success 200 {object} Response[dto.SyncAttemptsResponse] "同步结果"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /sync/attempts [post]
+func (h *QuizHandler) SyncAttempts(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.SyncAttemptsRequest](w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.QuizService.SyncOfflineAttempts(user.ID, req.Attempts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSync) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to sync attempts", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, response, nil, http.StatusOK)
+}
@@ -0,0 +1,164 @@
+// api/offline_sync_test.go
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOfflineSync(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+	user, _ := createTestUser(authService, "student")
+	do := newUserRouter(map[string]models.User{"student": *user}, handler)
+
+	bank, _ := handler.QuizService.CreateQuestionBank("Math", models.FeedbackFull)
+	question, _ := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "Is 7 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	blank, _ := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  bank.ID,
+		Content:         "The capital of France is ___",
+		QuestionType:    models.QuestionTypeFillInTheBlank,
+		FillInTheBlanks: []models.FillInTheBlankAnswer{{BlankText: "Paris"}},
+	})
+
+	w := do("student", http.MethodGet, fmt.Sprintf("/sync/practice_package?question_bank_id=%d", bank.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to download practice package: %v %s", w.Code, w.Body)
+	}
+	var pkg api.Response[dto.PracticePackageResponse]
+	json.NewDecoder(w.Body).Decode(&pkg)
+	if len(pkg.Data.Questions) != 2 || pkg.Data.Questions[0].TrueFalseAnswer != nil {
+		t.Fatalf("Expected questions without answers, got %+v", pkg.Data.Questions)
+	}
+	// 设备按同样规则散列作答即可本地判分
+	hash := func(questionID uint, answer string) string {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%s", pkg.Data.Salt, questionID, answer)))
+		return hex.EncodeToString(sum[:])
+	}
+	if hashes := pkg.Data.AnswerHashes[question.ID]; len(hashes) != 1 || hashes[0] != hash(question.ID, "true") {
+		t.Errorf("Unexpected true/false answer hash: %v", hashes)
+	}
+	if hashes := pkg.Data.AnswerHashes[blank.ID]; len(hashes) != 1 || hashes[0] != hash(blank.ID, "Paris") {
+		t.Errorf("Unexpected fill-in-the-blank answer hash: %v", hashes)
+	}
+
+	// 只反馈对错的题库不下发散列，避免离线穷举出答案
+	quizBank, _ := handler.QuizService.CreateQuestionBank("Quiz", models.FeedbackCorrectness)
+	handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  quizBank.ID,
+		Content:         "Is 9 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: false},
+	})
+	w = do("student", http.MethodGet, fmt.Sprintf("/sync/practice_package?question_bank_id=%d", quizBank.ID), nil)
+	var quizPkg api.Response[dto.PracticePackageResponse]
+	json.NewDecoder(w.Body).Decode(&quizPkg)
+	if len(quizPkg.Data.Questions) != 1 || quizPkg.Data.AnswerHashes != nil {
+		t.Errorf("Expected no answer hashes for a correctness-only bank, got %+v", quizPkg.Data.AnswerHashes)
+	}
+
+	sync := func(attempts ...dto.OfflineAttempt) dto.SyncAttemptsResponse {
+		w := do("student", http.MethodPost, "/sync/attempts", dto.SyncAttemptsRequest{Attempts: attempts})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to sync attempts: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[dto.SyncAttemptsResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+	now := time.Now()
+	correct := dto.OfflineAttempt{ClientID: "a1", QuestionID: question.ID, Answer: true, AnsweredAt: now.Add(-2 * time.Hour)}
+	result := sync(
+		correct,
+		dto.OfflineAttempt{ClientID: "a2", QuestionID: question.ID, Answer: false, AnsweredAt: now.Add(-3 * time.Hour)},
+		dto.OfflineAttempt{ClientID: "a3", QuestionID: 9999, Answer: true, AnsweredAt: now},
+		dto.OfflineAttempt{ClientID: "a4", QuestionID: question.ID, Answer: "yes", AnsweredAt: now},
+	)
+	if result.Applied != 2 || result.Rejected != 2 || result.Results[2].Status != services.SyncStatusRejected {
+		t.Fatalf("Unexpected sync result: %+v", result)
+	}
+	// 按作答时间重放：先答错后答对
+	attempt, _ := handler.QuizService.GetQuestionAttempt(user.ID, question.ID)
+	if attempt.Attempts != 2 || attempt.Wrong != 1 || attempt.ConsecutiveCorrect != 1 || !attempt.LastAnswerAt.Equal(correct.AnsweredAt) {
+		t.Errorf("Expected attempts to be replayed in order, got %+v", attempt)
+	}
+
+	// 重复上传不会重复记录
+	result = sync(correct)
+	if result.Duplicates != 1 || !result.Results[0].Correct || result.Results[0].XP != services.DefaultXPPerQuestion {
+		t.Errorf("Expected duplicate with the original result, got %+v", result)
+	}
+
+	// 早于在线作答的离线作答只计入次数
+	handler.QuizService.RecordQuestionAttempt(user.ID, question.ID, false)
+	result = sync(dto.OfflineAttempt{ClientID: "a5", QuestionID: question.ID, Answer: true, AnsweredAt: now.Add(-time.Hour)})
+	if !result.Results[0].Superseded {
+		t.Errorf("Expected attempt to be superseded, got %+v", result.Results[0])
+	}
+	attempt, _ = handler.QuizService.GetQuestionAttempt(user.ID, question.ID)
+	if attempt.Attempts != 4 || attempt.Wrong != 2 || attempt.LastScore != 0 || attempt.ConsecutiveCorrect != 0 {
+		t.Errorf("Expected latest online answer to be kept, got %+v", attempt)
+	}
+
+	// 伪造不同日期重放同一道题不能多得经验值，也不能补出连续天数
+	var replay []dto.OfflineAttempt
+	for day := 1; day <= 29; day++ {
+		replay = append(replay, dto.OfflineAttempt{ClientID: fmt.Sprintf("replay-%d", day), QuestionID: blank.ID,
+			Answer: []string{"Paris"}, AnsweredAt: now.AddDate(0, 0, -day)})
+	}
+	result = sync(replay...)
+	xp := 0
+	for _, item := range result.Results {
+		xp += item.XP
+	}
+	stats, _ := handler.QuizService.GetGamificationStats(user.ID)
+	if result.Applied != 29 || xp != services.DefaultXPPerQuestion || stats.CurrentStreak != 1 {
+		t.Errorf("Expected backdated replay to earn XP once without a streak, got %d XP, streak %d", xp, stats.CurrentStreak)
+	}
+
+	// 同一批次内重复的作答照抄首次出现的结果
+	repeated := dto.OfflineAttempt{ClientID: "b1", QuestionID: blank.ID, Answer: []string{"Paris"}, AnsweredAt: now.Add(-time.Minute)}
+	result = sync(repeated, repeated)
+	if result.Applied != 1 || result.Duplicates != 1 || result.Results[1].Status != services.SyncStatusDuplicate ||
+		result.Results[1].Correct != result.Results[0].Correct || result.Results[1].Score != result.Results[0].Score ||
+		result.Results[1].XP != result.Results[0].XP || !result.Results[1].Correct {
+		t.Errorf("Expected the repeated attempt to carry the first result, got %+v", result.Results)
+	}
+
+	// 不反馈的题库不返回对错、得分和经验值，重复上传时也一样
+	examBank, _ := handler.QuizService.CreateQuestionBank("Exam", models.FeedbackNone)
+	examQuestion, _ := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  examBank.ID,
+		Content:         "Is 13 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	exam := dto.OfflineAttempt{ClientID: "e1", QuestionID: examQuestion.ID, Answer: true, AnsweredAt: now.Add(-time.Minute)}
+	for _, result := range []dto.SyncAttemptsResponse{sync(exam, exam), sync(exam)} {
+		for _, item := range result.Results {
+			if !item.Withheld || item.Correct || item.Score != 0 || item.XP != 0 {
+				t.Errorf("Expected grading to be withheld for a no-feedback bank, got %+v", item)
+			}
+		}
+	}
+
+	if w := do("student", http.MethodPost, "/sync/attempts", dto.SyncAttemptsRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected empty batch to be rejected, got %v", w.Code)
+	}
+}
@@ -0,0 +1,266 @@
+// api/oidc.go
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// oidcStateCookie 保存发起授权时的 state，回调时必须与查询参数一致，防止把别人的授权结果带进当前浏览器
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler 处理通过外部身份提供方（OpenID Connect）登录和绑定账号
+type OIDCHandler struct {
+	OIDCService *services.OIDCService
+	AuthService *services.AuthService
+}
+
+func (h *OIDCHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/auth/oidc/login", http.MethodGet, h.Login, "", "跳转到身份提供方登录"},
+		{"/auth/oidc/callback", http.MethodGet, h.Callback, "", "身份提供方登录回调"},
+		{"/auth/oidc/token", http.MethodPost, h.ExchangeToken, "", "用一次性代码换取令牌"},
+		{"/auth/oidc/link", http.MethodPost, h.Link, "auth:oidc", "绑定身份提供方账号"},
+		{"/auth/oidc/identities", http.MethodGet, h.GetIdentities, "auth:oidc", "查看绑定的身份提供方账号"},
+		{"/auth/oidc/identities/{id}", http.MethodDelete, h.Unlink, "auth:oidc", "解除绑定身份提供方账号"},
+	}
+}
+
+// Login 跳转到身份提供方登录
+// @Summary 通过身份提供方登录
+// @Description 使用授权码模式（PKCE）跳转到身份提供方，登录完成后回调 /auth/oidc/callback。
+// @Description 授权请求的 state 同时写入 HttpOnly Cookie，回调时校验，必须在同一浏览器中完成登录
+// @Tags Auth
+// @Success 302 "跳转到身份提供方"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.OIDCService.AuthorizationURL(0)
+	if err != nil {
+		Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	h.setStateCookie(w, state, int(services.OIDCStateDuration.Seconds()))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback 身份提供方登录回调
+// @Summary 身份提供方登录回调
+// @Description 校验授权结果后跳转回前端页面。登录成功时附带一次性代码 code，前端用它调用 /auth/oidc/token 换取令牌；
+// @Description 绑定成功时附带 linked=true；失败时附带 error，取值为 invalid_state、account_exists、not_provisioned、identity_linked、user_inactive、access_denied 或 login_failed
+// @Tags Auth
+// @Param state query string true "授权请求的 state"
+// @Param code query string false "授权码"
+// @Param error query string false "身份提供方返回的错误"
+// @Success 302 "跳转回前端页面"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cookie, err := r.Cookie(oidcStateCookie)
+	h.setStateCookie(w, "", -1)
+	if query.Get("error") != "" {
+		h.redirectToFrontend(w, r, url.Values{"error": {"access_denied"}})
+		return
+	}
+	// 先校验 Cookie 再使用 state，其他浏览器带来的回调不会消耗掉授权请求
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		h.redirectToFrontend(w, r, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	result, err := h.OIDCService.HandleCallback(r.Context(), query.Get("state"), query.Get("code"))
+	if err != nil {
+		h.redirectToFrontend(w, r, url.Values{"error": {oidcErrorCode(err)}})
+		return
+	}
+	if result.Linked {
+		h.redirectToFrontend(w, r, url.Values{"linked": {"true"}})
+		return
+	}
+	h.redirectToFrontend(w, r, url.Values{"code": {result.Code}})
+}
+
+// ExchangeToken 用一次性代码换取令牌
+// @Summary 用一次性代码换取令牌
+// @Description 代码一分钟内有效且只能使用一次。结果与 /auth/login 相同，需要两步验证时返回 mfa_token
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.OIDCTokenRequest true "一次性代码"
+// @Success 200 {object} Response[dto.LoginResponse] "登录成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "代码无效或已过期"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/oidc/token [post]
+func (h *OIDCHandler) ExchangeToken(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.OIDCTokenRequest](w, r)
+	if !ok {
+		return
+	}
+	user, err := h.OIDCService.ExchangeLoginCode(req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOIDCCode) || errors.Is(err, services.ErrOIDCUserInactive) {
+			Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		Error(w, "Failed to exchange login code", http.StatusInternalServerError)
+		return
+	}
+	completeLogin(w, r, h.AuthService, user, req.DeviceName)
+}
+
+// Link 绑定身份提供方账号
+// @Summary 绑定身份提供方账号
+// @Description 返回身份提供方的授权地址，前端跳转过去登录后，回调会把该账号绑定到当前用户。
+// @Description 响应会设置校验 state 的 HttpOnly Cookie，跨域调用时需要携带凭据（credentials: include）
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[dto.OIDCAuthorizationResponse] "授权地址"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/oidc/link [post]
+func (h *OIDCHandler) Link(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	authURL, state, err := h.OIDCService.AuthorizationURL(user.ID)
+	if err != nil {
+		Error(w, "Failed to start linking", http.StatusInternalServerError)
+		return
+	}
+	h.setStateCookie(w, state, int(services.OIDCStateDuration.Seconds()))
+	Success(w, dto.OIDCAuthorizationResponse{AuthorizationURL: authURL}, nil, http.StatusOK)
+}
+
+// GetIdentities 获取当前用户绑定的身份提供方账号
+// @Summary 获取绑定的身份提供方账号
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.UserIdentityResponse] "获取成功"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/oidc/identities [get]
+func (h *OIDCHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	identities, err := h.OIDCService.GetIdentities(user.ID)
+	if err != nil {
+		Error(w, "Failed to get identities", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]dto.UserIdentityResponse, len(identities))
+	for i, identity := range identities {
+		resp[i] = dto.UserIdentityResponse{
+			ID:          identity.ID,
+			Issuer:      identity.Issuer,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			Username:    identity.Username,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		}
+	}
+	Success(w, resp, nil, http.StatusOK)
+}
+
+// Unlink 解除绑定身份提供方账号
+// @Summary 解除绑定身份提供方账号
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Param id path int true "绑定 ID"
+// @Success 204 "解除成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 404 {object} ErrorResponse "绑定不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/oidc/identities/{id} [delete]
+func (h *OIDCHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	identityID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid identity ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.OIDCService.UnlinkIdentity(user.ID, identityID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Identity not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to unlink identity", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setStateCookie 写入或清除（maxAge 为负数时）state Cookie。Cookie 只发往回调地址；
+// 身份提供方跳转回来是跨站的顶层导航，所以使用 SameSite=Lax
+func (h *OIDCHandler) setStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	cookie := &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if redirectURL, err := url.Parse(h.OIDCService.RedirectURL()); err == nil {
+		if redirectURL.Path != "" {
+			cookie.Path = redirectURL.Path
+		}
+		cookie.Secure = strings.EqualFold(redirectURL.Scheme, "https")
+	}
+	http.SetCookie(w, cookie)
+}
+
+// redirectToFrontend 带上查询参数跳转回前端页面
+func (h *OIDCHandler) redirectToFrontend(w http.ResponseWriter, r *http.Request, params url.Values) {
+	target, err := url.Parse(h.OIDCService.FrontendURL())
+	if err != nil {
+		Error(w, "Invalid frontend URL", http.StatusInternalServerError)
+		return
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// oidcErrorCode 将回调错误转换为交给前端的错误码
+func oidcErrorCode(err error) string {
+	switch {
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		return "invalid_state"
+	case errors.Is(err, services.ErrOIDCAccountExists):
+		return "account_exists"
+	case errors.Is(err, services.ErrOIDCNotProvisioned):
+		return "not_provisioned"
+	case errors.Is(err, services.ErrOIDCIdentityLinked):
+		return "identity_linked"
+	case errors.Is(err, services.ErrOIDCUserInactive):
+		return "user_inactive"
+	default:
+		log.Printf("OIDC login failed: %v", err)
+		return "login_failed"
+	}
+}
@@ -0,0 +1,322 @@
+// api/oidc_test.go
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/gorilla/mux"
+)
+
+// mockOIDCIdentity 是模拟身份提供方中的一个账号
+type mockOIDCIdentity struct {
+	Subject  string
+	Username string
+	Groups   []string
+}
+
+// mockOIDCProvider 是本地的模拟身份提供方，提供发现文档、JWKS 和令牌端点
+type mockOIDCProvider struct {
+	t       *testing.T
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	mu      sync.Mutex
+	pending map[string]mockOIDCGrant
+}
+
+type mockOIDCGrant struct {
+	challenge string
+	nonce     string
+	identity  mockOIDCIdentity
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	p := &mockOIDCProvider{t: t, key: key, pending: map[string]mockOIDCGrant{}}
+	handler := http.NewServeMux()
+	handler.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	handler.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &p.key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	handler.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(handler)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize 模拟用户在身份提供方登录：校验授权地址并返回回调地址
+func (p *mockOIDCProvider) authorize(authURL string, identity mockOIDCIdentity) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatalf("Invalid authorization URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" || query.Get("nonce") == "" {
+		p.t.Fatalf("Expected PKCE and nonce in authorization URL: %s", authURL)
+	}
+	code := fmt.Sprintf("code-%s-%d", identity.Subject, time.Now().UnixNano())
+	p.mu.Lock()
+	p.pending[code] = mockOIDCGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), identity: identity}
+	p.mu.Unlock()
+	return "/auth/oidc/callback?" + url.Values{"state": {query.Get("state")}, "code": {code}}.Encode()
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	p.mu.Lock()
+	grant, ok := p.pending[r.Form.Get("code")]
+	delete(p.pending, r.Form.Get("code"))
+	p.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: p.key, KeyID: "test"}}, nil)
+	if err != nil {
+		p.t.Fatalf("Failed to create signer: %v", err)
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss":                p.server.URL,
+		"aud":                "learn",
+		"sub":                grant.identity.Subject,
+		"nonce":              grant.nonce,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": grant.identity.Username,
+		"email":              grant.identity.Username + "@example.edu",
+		"groups":             grant.identity.Groups,
+	})
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		p.t.Fatalf("Failed to sign id_token: %v", err)
+	}
+	idToken, _ := signed.CompactSerialize()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func TestOIDC(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	provider := newMockOIDCProvider(t)
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	oidcService, err := services.NewOIDCService(context.Background(), db, authService, services.OIDCSettings{
+		Issuer:        provider.server.URL,
+		ClientID:      "learn",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://learn.test/auth/oidc/callback",
+		FrontendURL:   "http://learn.test/login/oidc",
+		RolesClaim:    "groups",
+		RoleMapping:   map[string]string{"teachers": "teacher"},
+		DefaultRole:   "student",
+		AutoProvision: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create OIDC service: %v", err)
+	}
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(
+		&api.AuthHandler{AuthService: authService},
+		&api.OIDCHandler{OIDCService: oidcService, AuthService: authService},
+	); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	for _, roleName := range []string{"student", "teacher"} {
+		role, _ := authService.CreateRole(roleName)
+		req := dto.RoleUpdateRequest{Name: roleName}
+		for _, permission := range permissions {
+			if permission.Name == "auth:oidc" {
+				req.Permissions = append(req.Permissions, int(permission.ID))
+			}
+		}
+		if err := authService.UpdateRole(role.ID, req); err != nil {
+			t.Fatalf("Failed to grant permissions: %v", err)
+		}
+	}
+	if _, err := authService.CreateUser("carol", "password", []string{"student"}, models.StatusActive); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	send := func(method, path, token string, body interface{}, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// callback 完成身份提供方登录，浏览器带着发起授权时设置的 Cookie 回调，返回跳转到前端时附带的参数
+	callback := func(authURL string, identity mockOIDCIdentity, cookies []*http.Cookie) url.Values {
+		w := send(http.MethodGet, provider.authorize(authURL, identity), "", nil, cookies...)
+		location, err := url.Parse(w.Header().Get("Location"))
+		if w.Code != http.StatusFound || err != nil || location.Path != "/login/oidc" {
+			t.Fatalf("Expected redirect to frontend, got %v %s", w.Code, w.Header().Get("Location"))
+		}
+		return location.Query()
+	}
+	oidcLogin := func(identity mockOIDCIdentity) url.Values {
+		w := send(http.MethodGet, "/auth/oidc/login", "", nil)
+		if w.Code != http.StatusFound {
+			t.Fatalf("Expected redirect to provider, got %v %s", w.Code, w.Body)
+		}
+		return callback(w.Header().Get("Location"), identity, w.Result().Cookies())
+	}
+	exchange := func(code string) (int, dto.LoginResponse) {
+		w := send(http.MethodPost, "/auth/oidc/token", "", dto.OIDCTokenRequest{Code: code})
+		var resp api.Response[dto.LoginResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	roleNames := func(username string) []string {
+		var user models.User
+		if err := db.Preload("Roles").Where("username = ?", username).First(&user).Error; err != nil {
+			t.Fatalf("Expected user %s to exist: %v", username, err)
+		}
+		var names []string
+		for _, role := range user.Roles {
+			names = append(names, role.Name)
+		}
+		return names
+	}
+
+	// 首次登录自动创建用户，分组映射为本地角色
+	dave := mockOIDCIdentity{Subject: "sub-dave", Username: "dave", Groups: []string{"Teachers"}}
+	params := oidcLogin(dave)
+	status, tokens := exchange(params.Get("code"))
+	if status != http.StatusOK || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("Failed to exchange login code: %v %+v", status, tokens)
+	}
+	if status, _ := exchange(params.Get("code")); status != http.StatusUnauthorized {
+		t.Errorf("Expected login code to be single use, got %v", status)
+	}
+	if roles := roleNames("dave"); !slices.Equal(roles, []string{"teacher"}) {
+		t.Errorf("Expected mapped role, got %v", roles)
+	}
+	oidcLogin(mockOIDCIdentity{Subject: "sub-erin", Username: "erin"})
+	if roles := roleNames("erin"); !slices.Equal(roles, []string{"student"}) {
+		t.Errorf("Expected default role, got %v", roles)
+	}
+
+	// 再次登录使用同一账号，并按 IdP 的分组收回角色
+	dave.Groups = nil
+	status, _ = exchange(oidcLogin(dave).Get("code"))
+	var count int64
+	db.Model(&models.User{}).Where("username = ?", "dave").Count(&count)
+	if status != http.StatusOK || count != 1 {
+		t.Errorf("Expected existing user to be reused, got %v %d", status, count)
+	}
+	if roles := roleNames("dave"); len(roles) != 0 {
+		t.Errorf("Expected mapped role to be removed, got %v", roles)
+	}
+
+	// state 无效或与本地账号重名时拒绝登录
+	w := send(http.MethodGet, "/auth/oidc/login", "", nil)
+	authURL, _ := url.Parse(w.Header().Get("Location"))
+	query := authURL.Query()
+	query.Set("state", "forged")
+	authURL.RawQuery = query.Encode()
+	if params := callback(authURL.String(), dave, w.Result().Cookies()); params.Get("error") != "invalid_state" {
+		t.Errorf("Expected invalid_state, got %v", params)
+	}
+
+	// state 绑定发起登录的浏览器，在其他浏览器完成的回调被拒绝，且不会消耗掉授权请求
+	w = send(http.MethodGet, "/auth/oidc/login", "", nil)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Path != "/auth/oidc/callback" {
+		t.Fatalf("Expected an HttpOnly state cookie for the callback, got %+v", cookies)
+	}
+	location := w.Header().Get("Location")
+	if params := callback(location, dave, nil); params.Get("error") != "invalid_state" {
+		t.Errorf("Expected invalid_state without the state cookie, got %v", params)
+	}
+	if params := callback(location, dave, []*http.Cookie{{Name: cookies[0].Name, Value: "other"}}); params.Get("error") != "invalid_state" {
+		t.Errorf("Expected invalid_state with another browser's cookie, got %v", params)
+	}
+	if params := callback(location, dave, cookies); params.Get("code") == "" {
+		t.Errorf("Expected login to complete in the original browser, got %v", params)
+	}
+	carolIdentity := mockOIDCIdentity{Subject: "sub-carol", Username: "carol"}
+	if params := oidcLogin(carolIdentity); params.Get("error") != "account_exists" {
+		t.Errorf("Expected account_exists, got %v", params)
+	}
+
+	// 本地用户登录后绑定 IdP 账号，之后可以通过 IdP 登录
+	w = send(http.MethodPost, "/auth/login", "", dto.LoginRequest{Username: "carol", Password: "password"})
+	var carol api.Response[dto.LoginResponse]
+	json.NewDecoder(w.Body).Decode(&carol)
+	w = send(http.MethodPost, "/auth/oidc/link", carol.Data.AccessToken, nil)
+	var link api.Response[dto.OIDCAuthorizationResponse]
+	json.NewDecoder(w.Body).Decode(&link)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to start linking: %v %s", w.Code, w.Body)
+	}
+	if params := callback(link.Data.AuthorizationURL, carolIdentity, w.Result().Cookies()); params.Get("linked") != "true" {
+		t.Fatalf("Expected identity to be linked, got %v", params)
+	}
+	if status, _ := exchange(oidcLogin(carolIdentity).Get("code")); status != http.StatusOK {
+		t.Errorf("Expected linked identity to log in, got %v", status)
+	}
+	if roles := roleNames("carol"); !slices.Equal(roles, []string{"student"}) {
+		t.Errorf("Expected unmapped roles to be kept, got %v", roles)
+	}
+	w = send(http.MethodGet, "/auth/oidc/identities", carol.Data.AccessToken, nil)
+	var identities api.Response[[]dto.UserIdentityResponse]
+	json.NewDecoder(w.Body).Decode(&identities)
+	if len(identities.Data) != 1 || identities.Data[0].Subject != "sub-carol" {
+		t.Fatalf("Unexpected identities: %s", w.Body)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("/auth/oidc/identities/%d", identities.Data[0].ID), carol.Data.AccessToken, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Failed to unlink identity: %v %s", w.Code, w.Body)
+	}
+	if params := oidcLogin(carolIdentity); params.Get("error") != "account_exists" {
+		t.Errorf("Expected unlinked identity to be rejected, got %v", params)
+	}
+}
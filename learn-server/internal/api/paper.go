@@ -0,0 +1,344 @@
+// api/paper.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type PaperHandler struct {
+	PaperService *services.PaperService
+}
+
+func (h *PaperHandler) GetApiEndpoints() []APIEndpoint {
+	return []APIEndpoint{
+		{"/papers", "GET", h.GetPapers, "paper:read", "查看试卷"},
+		{"/papers", "POST", h.CreatePaper, "paper:edit", "创建试卷"},
+		{"/papers/{id}", "GET", h.GetPaper, "paper:read", "查看试卷详情"},
+		{"/papers/{id}", "PUT", h.UpdatePaper, "paper:edit", "修改试卷"},
+		{"/papers/{id}", "DELETE", h.DeletePaper, "paper:edit", "删除试卷"},
+		{"/papers/{id}/preview", "GET", h.PreviewPaper, "paper:edit", "预览试卷"},
+		{"/papers/{id}/freeze", "POST", h.FreezePaper, "paper:edit", "冻结试卷"},
+		{"/papers/{id}/version", "GET", h.GetPaperVersion, "paper:take", "获取学员试卷"},
+	}
+}
+
+// GetPapers 获取试卷列表
+// @Summary 获取试卷列表
+// @Description 获取全部试卷，不含分节
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.PaperResponse] "试卷列表"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /papers [get]
+func (h *PaperHandler) GetPapers(w http.ResponseWriter, r *http.Request) {
+	papers, err := h.PaperService.GetPapers()
+	if err != nil {
+		Error(w, "Failed to retrieve papers", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.PaperResponse, len(papers))
+	for i := range papers {
+		response[i] = newPaperResponse(&papers[i])
+	}
+	Success(w, response, nil, http.StatusOK)
+}
+
+// CreatePaper 创建试卷
+// @Summary 创建试卷
+// @Description 创建草稿试卷。每个分节可以手动挑选题目，也可以按组卷规则（题库、题型、标签、难度、数量）抽题，并设置每道题的分值
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param paper body dto.PaperRequest true "试卷信息"
+// @Success 201 {object} Response[dto.PaperResponse] "创建成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /papers [post]
+func (h *PaperHandler) CreatePaper(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.PaperRequest](w, r)
+	if !ok {
+		return
+	}
+
+	paper, err := h.PaperService.CreatePaper(user.ID, *req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPaper) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to create paper", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, newPaperResponse(paper), nil, http.StatusCreated)
+}
+
+// GetPaper 获取试卷详情
+// @Summary 获取试卷详情
+// @Description 获取试卷的分节、手动挑选的题目和组卷规则
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Success 200 {object} Response[dto.PaperResponse] "试卷详情"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Router /papers/{id} [get]
+func (h *PaperHandler) GetPaper(w http.ResponseWriter, r *http.Request) {
+	paper, ok := h.loadPaper(w, r)
+	if !ok {
+		return
+	}
+
+	Success(w, newPaperResponse(paper), nil, http.StatusOK)
+}
+
+// UpdatePaper 修改试卷
+// @Summary 修改试卷
+// @Description 修改草稿试卷，分节整体替换；冻结后的试卷不能修改
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Param paper body dto.PaperRequest true "试卷信息"
+// @Success 200 {object} Response[dto.PaperResponse] "修改成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Failure 409 {object} ErrorResponse "试卷已冻结"
+// @Router /papers/{id} [put]
+func (h *PaperHandler) UpdatePaper(w http.ResponseWriter, r *http.Request) {
+	paperID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid paper ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.PaperRequest](w, r)
+	if !ok {
+		return
+	}
+
+	paper, err := h.PaperService.UpdatePaper(paperID, *req)
+	if err != nil {
+		writePaperError(w, err, "Failed to update paper")
+		return
+	}
+
+	Success(w, newPaperResponse(paper), nil, http.StatusOK)
+}
+
+// DeletePaper 删除试卷
+// @Summary 删除试卷
+// @Description 删除草稿试卷；冻结后的试卷已经下发给学员，不能删除
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Success 200 {object} Response[string] "删除成功"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Failure 409 {object} ErrorResponse "试卷已冻结"
+// @Router /papers/{id} [delete]
+func (h *PaperHandler) DeletePaper(w http.ResponseWriter, r *http.Request) {
+	paperID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid paper ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.PaperService.DeletePaper(paperID); err != nil {
+		writePaperError(w, err, "Failed to delete paper")
+		return
+	}
+
+	Success(w, "Paper deleted", nil, http.StatusOK)
+}
+
+// PreviewPaper 预览试卷
+// @Summary 预览试卷
+// @Description 按出题人视角预览一份具体试卷，含答案和总分。草稿试卷每次从题库中重新抽题，冻结的试卷按第一名学员的抽题结果展示
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Success 200 {object} Response[dto.PaperVersionResponse] "试卷预览"
+// @Failure 400 {object} ErrorResponse "题库中没有足够满足规则的题目"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Router /papers/{id}/preview [get]
+func (h *PaperHandler) PreviewPaper(w http.ResponseWriter, r *http.Request) {
+	paper, ok := h.loadPaper(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := h.PaperService.GetVersion(paper, 0)
+	if err != nil {
+		writePaperError(w, err, "Failed to preview paper")
+		return
+	}
+
+	Success(w, newPaperVersionResponse(version, services.AnswerPolicyFor(services.ViewAuthor)), nil, http.StatusOK)
+}
+
+// FreezePaper 冻结试卷
+// @Summary 冻结试卷
+// @Description 冻结后试卷不能再修改。同一份模式下按规则抽题并固定，所有学员拿到同一份试卷；等价模式下固定候选题，每名学员各自抽到等价的一份
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Success 200 {object} Response[dto.PaperResponse] "冻结成功"
+// @Failure 400 {object} ErrorResponse "题库中没有足够满足规则的题目"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Failure 409 {object} ErrorResponse "试卷已冻结"
+// @Router /papers/{id}/freeze [post]
+func (h *PaperHandler) FreezePaper(w http.ResponseWriter, r *http.Request) {
+	paperID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid paper ID", http.StatusBadRequest)
+		return
+	}
+
+	paper, err := h.PaperService.FreezePaper(paperID)
+	if err != nil {
+		writePaperError(w, err, "Failed to freeze paper")
+		return
+	}
+
+	Success(w, newPaperResponse(paper), nil, http.StatusOK)
+}
+
+// GetPaperVersion 获取学员的试卷
+// @Summary 获取学员试卷
+// @Description 获取当前用户拿到的具体试卷，题目不含答案。只有冻结的试卷可以作答，同一学员每次拿到同一份
+// @Tags Paper
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "试卷 ID"
+// @Success 200 {object} Response[dto.PaperVersionResponse] "学员试卷"
+// @Failure 404 {object} ErrorResponse "试卷不存在"
+// @Failure 409 {object} ErrorResponse "试卷尚未冻结"
+// @Router /papers/{id}/version [get]
+func (h *PaperHandler) GetPaperVersion(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	paper, ok := h.loadPaper(w, r)
+	if !ok {
+		return
+	}
+	if !paper.Frozen {
+		Error(w, "Paper is not frozen yet", http.StatusConflict)
+		return
+	}
+
+	version, err := h.PaperService.GetVersion(paper, user.ID)
+	if err != nil {
+		writePaperError(w, err, "Failed to retrieve paper")
+		return
+	}
+
+	Success(w, newPaperVersionResponse(version, services.AnswerPolicyFor(services.ViewExam)), nil, http.StatusOK)
+}
+
+// loadPaper 加载路径中的试卷
+func (h *PaperHandler) loadPaper(w http.ResponseWriter, r *http.Request) (*models.Paper, bool) {
+	paperID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid paper ID", http.StatusBadRequest)
+		return nil, false
+	}
+	paper, err := h.PaperService.GetPaper(paperID)
+	if err != nil {
+		writePaperError(w, err, "Failed to retrieve paper")
+		return nil, false
+	}
+	return paper, true
+}
+
+// writePaperError 将试卷服务的错误转换为对应的状态码
+func writePaperError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		Error(w, "Paper not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidPaper):
+		Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrPaperFrozen):
+		Error(w, err.Error(), http.StatusConflict)
+	default:
+		Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// newPaperResponse 将试卷转换为响应，冻结时记录的候选题不对外返回
+func newPaperResponse(paper *models.Paper) dto.PaperResponse {
+	response := dto.PaperResponse{
+		ID:          paper.ID,
+		Title:       paper.Title,
+		Description: paper.Description,
+		Mode:        paper.Mode,
+		AuthorID:    paper.AuthorID,
+		Frozen:      paper.Frozen,
+		FrozenAt:    paper.FrozenAt,
+		CreatedAt:   paper.CreatedAt,
+		UpdatedAt:   paper.UpdatedAt,
+	}
+	for _, section := range paper.Sections {
+		sectionResponse := dto.PaperSectionResponse{Title: section.Title}
+		for _, question := range section.Questions {
+			sectionResponse.Questions = append(sectionResponse.Questions, dto.PaperQuestion{
+				QuestionID: question.QuestionID,
+				Points:     question.Points,
+				Drawn:      question.Drawn,
+			})
+		}
+		for _, rule := range section.Rules {
+			sectionResponse.Rules = append(sectionResponse.Rules, dto.BlueprintRule{
+				QuestionBankID: rule.QuestionBankID,
+				QuestionType:   rule.QuestionType,
+				Tag:            rule.Tag,
+				Difficulty:     rule.Difficulty,
+				Count:          rule.Count,
+				Points:         rule.Points,
+			})
+		}
+		response.Sections = append(response.Sections, sectionResponse)
+	}
+	return response
+}
+
+// newPaperVersionResponse 按可见性策略将具体试卷转换为响应
+func newPaperVersionResponse(version *services.PaperVersion, policy services.AnswerPolicy) dto.PaperVersionResponse {
+	response := dto.PaperVersionResponse{
+		PaperID:     version.Paper.ID,
+		Title:       version.Paper.Title,
+		Description: version.Paper.Description,
+		TotalPoints: version.TotalPoints(),
+	}
+	for _, section := range version.Sections {
+		sectionResponse := dto.PaperVersionSection{Title: section.Title}
+		for _, question := range section.Questions {
+			sectionResponse.Points += question.Points
+			sectionResponse.Questions = append(sectionResponse.Questions, dto.PaperVersionQuestion{
+				Points:   question.Points,
+				Question: services.NewQuestionResponse(question.Question, policy),
+			})
+		}
+		response.Sections = append(response.Sections, sectionResponse)
+	}
+	return response
+}
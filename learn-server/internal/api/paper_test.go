@@ -0,0 +1,281 @@
+// api/paper_test.go
+package api_test
+
+import (
+	"encoding/json"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPaper(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{},
+		&models.QuestionBank{}, &models.Question{}, &models.TrueFalseAnswer{}, &models.Tag{},
+		&models.Paper{}, &models.PaperSection{}, &models.PaperQuestion{}, &models.BlueprintRule{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	handler := &api.PaperHandler{PaperService: services.NewPaperService(db, quizService)}
+
+	teacher, _ := createTestUser(authService, "teacher")
+	alice, _ := createTestUser(authService, "alice")
+	bob, _ := createTestUser(authService, "bob")
+	do := newUserRouter(map[string]models.User{"teacher": *teacher, "alice": *alice, "bob": *bob}, handler)
+
+	// 第一道题手动挑选，其余五道带 prime 标签，其中两道难度为 2
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	var questionIDs []uint
+	for i := 0; i < 6; i++ {
+		question := models.Question{
+			QuestionBankID:  bank.ID,
+			Content:         "Question " + strconv.Itoa(i),
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		}
+		if i > 0 {
+			question.Tags = []models.Tag{{Name: "prime"}}
+		}
+		if i == 1 || i == 2 {
+			question.Difficulty = 2
+		}
+		created, err := quizService.CreateQuestion(question)
+		if err != nil {
+			t.Fatalf("Failed to create question: %v", err)
+		}
+		questionIDs = append(questionIDs, created.ID)
+	}
+
+	request := func(mode models.PaperMode, count int) dto.PaperRequest {
+		return dto.PaperRequest{
+			Title: "Midterm",
+			Mode:  mode,
+			Sections: []dto.PaperSectionRequest{
+				{Title: "Part I", Questions: []dto.PaperQuestion{{QuestionID: questionIDs[0], Points: 5}}},
+				{Title: "Part II", Rules: []dto.BlueprintRule{
+					{Tag: "prime", Difficulty: 2, Count: 1, Points: 2},
+					{Tag: "prime", Count: count},
+				}},
+			},
+		}
+	}
+	create := func(req dto.PaperRequest) (int, dto.PaperResponse) {
+		w := do("teacher", http.MethodPost, "/papers", req)
+		var resp api.Response[dto.PaperResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	version := func(as, url string) (int, dto.PaperVersionResponse) {
+		w := do(as, http.MethodGet, url, nil)
+		var resp api.Response[dto.PaperVersionResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	questionsOf := func(v dto.PaperVersionResponse) []uint {
+		var ids []uint
+		for _, section := range v.Sections {
+			for _, question := range section.Questions {
+				ids = append(ids, question.Question.ID)
+			}
+		}
+		return ids
+	}
+
+	if code, _ := create(dto.PaperRequest{Title: "Empty"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a paper without sections, got %v", code)
+	}
+	invalid := request(models.PaperModeSame, 2)
+	invalid.Sections[0].Questions = append(invalid.Sections[0].Questions, dto.PaperQuestion{QuestionID: questionIDs[0]})
+	if code, _ := create(invalid); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a duplicated question, got %v", code)
+	}
+
+	code, paper := create(request(models.PaperModeEquivalent, 10))
+	if code != http.StatusCreated {
+		t.Fatalf("Failed to create paper, status code: %v", code)
+	}
+	paperURL := "/papers/" + strconv.Itoa(int(paper.ID))
+
+	// 题库中没有足够的题目时预览失败，改为两道后可以预览
+	if code, _ := version("teacher", paperURL+"/preview"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 when the pool is too small, got %v", code)
+	}
+	if w := do("teacher", http.MethodPut, paperURL, request(models.PaperModeEquivalent, 2)); w.Code != http.StatusOK {
+		t.Fatalf("Failed to update paper, status code: %v", w.Code)
+	}
+	code, preview := version("teacher", paperURL+"/preview")
+	if code != http.StatusOK || preview.TotalPoints != 9 || len(questionsOf(preview)) != 4 {
+		t.Fatalf("Unexpected preview: %v %+v", code, preview)
+	}
+	if preview.Sections[1].Questions[0].Question.Difficulty != 2 || preview.Sections[1].Questions[0].Points != 2 {
+		t.Errorf("Expected first drawn question to follow the difficulty rule, got %+v", preview.Sections[1].Questions[0])
+	}
+	if preview.Sections[0].Questions[0].Question.TrueFalseAnswer == nil {
+		t.Errorf("Expected preview to include answers")
+	}
+
+	if code, _ := version("alice", paperURL+"/version"); code != http.StatusConflict {
+		t.Errorf("Expected 409 before the paper is frozen, got %v", code)
+	}
+	if w := do("teacher", http.MethodPost, paperURL+"/freeze", nil); w.Code != http.StatusOK {
+		t.Fatalf("Failed to freeze paper, status code: %v", w.Code)
+	}
+	if w := do("teacher", http.MethodPut, paperURL, request(models.PaperModeEquivalent, 2)); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when updating a frozen paper, got %v", w.Code)
+	}
+	if w := do("teacher", http.MethodDelete, paperURL, nil); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when deleting a frozen paper, got %v", w.Code)
+	}
+
+	// 等价模式下同一学员每次拿到同一份试卷，题目不含答案也不重复
+	_, first := version("alice", paperURL+"/version")
+	_, again := version("alice", paperURL+"/version")
+	if len(questionsOf(first)) != 4 || first.TotalPoints != 9 {
+		t.Fatalf("Unexpected version: %+v", first)
+	}
+	seen := map[uint]bool{}
+	for i, questionID := range questionsOf(first) {
+		if seen[questionID] || questionID != questionsOf(again)[i] {
+			t.Errorf("Expected a stable version without duplicates, got %v and %v", questionsOf(first), questionsOf(again))
+			break
+		}
+		seen[questionID] = true
+	}
+	if first.Sections[0].Questions[0].Question.TrueFalseAnswer != nil {
+		t.Errorf("Expected version to hide answers")
+	}
+
+	// 同一份模式冻结时抽题并固定，所有学员拿到同一份试卷
+	_, same := create(request(models.PaperModeSame, 2))
+	sameURL := "/papers/" + strconv.Itoa(int(same.ID))
+	w := do("teacher", http.MethodPost, sameURL+"/freeze", nil)
+	var frozen api.Response[dto.PaperResponse]
+	json.NewDecoder(w.Body).Decode(&frozen)
+	if w.Code != http.StatusOK || !frozen.Data.Frozen || len(frozen.Data.Sections[1].Questions) != 3 || !frozen.Data.Sections[1].Questions[0].Drawn {
+		t.Fatalf("Unexpected frozen paper: %v %+v", w.Code, frozen.Data)
+	}
+	_, aliceVersion := version("alice", sameURL+"/version")
+	_, bobVersion := version("bob", sameURL+"/version")
+	for i, questionID := range questionsOf(aliceVersion) {
+		if questionID != questionsOf(bobVersion)[i] {
+			t.Errorf("Expected every student to get the same paper, got %v and %v", questionsOf(aliceVersion), questionsOf(bobVersion))
+			break
+		}
+	}
+}
+
+func TestDeleteReferencedQuestion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.Permission{},
+		&models.QuestionBank{}, &models.Question{}, &models.TrueFalseAnswer{}, &models.Tag{},
+		&models.RelatedQuestion{}, &models.QuestionAttempt{}, &models.NotebookEntry{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{},
+		&models.Paper{}, &models.PaperSection{}, &models.PaperQuestion{}, &models.BlueprintRule{},
+		&models.Class{}, &models.ClassMember{}, &models.Assignment{}, &models.AssignmentQuestion{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizService := services.NewQuizService(db)
+	paperService := services.NewPaperService(db, quizService)
+	assignmentService := services.NewAssignmentService(db, quizService)
+	teacher, _ := createTestUser(authService, "teacher")
+	do := newUserRouter(map[string]models.User{"teacher": *teacher}, &api.QuizHandler{QuizService: quizService, AuthService: authService})
+
+	bank, _ := quizService.CreateQuestionBank("Math", models.FeedbackFull)
+	newQuestion := func(tag string) uint {
+		question := models.Question{
+			QuestionBankID:  bank.ID,
+			Content:         "Is 7 prime?",
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		}
+		if tag != "" {
+			question.Tags = []models.Tag{{Name: tag}}
+		}
+		created, err := quizService.CreateQuestion(question)
+		if err != nil {
+			t.Fatalf("Failed to create question: %v", err)
+		}
+		return created.ID
+	}
+	deleteQuestion := func(questionID uint) int {
+		return do("teacher", http.MethodDelete, "/quiz/questions/"+strconv.Itoa(int(questionID)), nil).Code
+	}
+
+	picked, candidate, assigned, draft := newQuestion(""), newQuestion("prime"), newQuestion(""), newQuestion("")
+	frozen, err := paperService.CreatePaper(teacher.ID, dto.PaperRequest{
+		Title: "Midterm",
+		Mode:  models.PaperModeEquivalent,
+		Sections: []dto.PaperSectionRequest{{
+			Title:     "Part I",
+			Questions: []dto.PaperQuestion{{QuestionID: picked}},
+			Rules:     []dto.BlueprintRule{{Tag: "prime", Count: 1}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create paper: %v", err)
+	}
+	if _, err := paperService.FreezePaper(frozen.ID); err != nil {
+		t.Fatalf("Failed to freeze paper: %v", err)
+	}
+	if _, err := assignmentService.CreateAssignment(1, teacher.ID, dto.CreateAssignmentRequest{
+		Title: "Homework", QuestionIDs: []uint{assigned}, DueAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("Failed to create assignment: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	closed, err := assignmentService.CreateAssignment(1, teacher.ID, dto.CreateAssignmentRequest{
+		Title: "Closed", QuestionIDs: []uint{draft}, OpenAt: past.Add(-time.Hour), DueAt: past,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create assignment: %v", err)
+	}
+	unfrozen, _ := paperService.CreatePaper(teacher.ID, dto.PaperRequest{
+		Title:    "Draft",
+		Sections: []dto.PaperSectionRequest{{Title: "Part I", Questions: []dto.PaperQuestion{{QuestionID: draft}}}},
+	})
+
+	// 冻结的试卷（包括冻结的候选题）和仍可提交的作业引用的题目不能删除
+	for name, questionID := range map[string]uint{"picked": picked, "candidate": candidate, "assigned": assigned} {
+		if code := deleteQuestion(questionID); code != http.StatusConflict {
+			t.Errorf("Expected 409 when deleting the %s question, got %v", name, code)
+		}
+	}
+
+	// 其余引用随题目一并清理
+	quizService.RecordQuestionAttempt(teacher.ID, draft, false)
+	if code := deleteQuestion(draft); code != http.StatusNoContent {
+		t.Fatalf("Failed to delete question, status code: %v", code)
+	}
+	if code := deleteQuestion(draft); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted question, got %v", code)
+	}
+	if paper, _ := paperService.GetPaper(unfrozen.ID); len(paper.Sections[0].Questions) != 0 {
+		t.Errorf("Expected the question to be removed from the draft paper, got %+v", paper.Sections[0].Questions)
+	}
+	if assignment, _ := assignmentService.GetAssignment(closed.ID); len(assignment.Questions) != 0 {
+		t.Errorf("Expected the question to be removed from the closed assignment, got %+v", assignment.Questions)
+	}
+	var attempts int64
+	db.Model(&models.QuestionAttempt{}).Where("question_id = ?", draft).Count(&attempts)
+	if attempts != 0 {
+		t.Errorf("Expected attempts of the deleted question to be removed, got %v", attempts)
+	}
+}
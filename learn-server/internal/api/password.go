@@ -0,0 +1,117 @@
+// api/password.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/services"
+	"log"
+	"net/http"
+)
+
+// ChangePassword 修改当前用户的密码
+// @Summary 修改密码
+// @Description 校验当前密码后设置新密码。所有已签发的令牌和登录设备随即失效，响应中返回当前设备的新令牌。
+// @Description 当前密码错误与登录失败共用限流和临时停用
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ChangePasswordRequest true "当前密码和新密码"
+// @Success 200 {object} Response[dto.TokenPairResponse] "修改成功"
+// @Failure 400 {object} ErrorResponse "当前密码错误或新密码不符合要求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 429 {object} ErrorResponse "失败次数过多，Retry-After 秒后再试"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/password [post]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.ChangePasswordRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.ChangePassword(user.ID, req.CurrentPassword, req.NewPassword, clientIP(r)); err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrIncorrectPassword) || errors.Is(err, services.ErrWeakPassword) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	// TokenVersion 已经递增，重新加载用户后为当前设备签发新令牌
+	updated, err := h.AuthService.GetUserByIDWithRoles(user.ID)
+	if err != nil {
+		Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		return
+	}
+	accessToken, refreshToken, err := h.AuthService.GenerateTokens(updated, clientDevice(r, req.DeviceName))
+	if err != nil {
+		Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		return
+	}
+	Success(w, dto.TokenPairResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil, http.StatusOK)
+}
+
+// ForgotPassword 申请找回密码
+// @Summary 找回密码
+// @Description 向用户名或邮箱对应账号的邮箱发送重置密码的链接。为避免泄露账号是否存在，无论账号是否存在都返回 202
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ForgotPasswordRequest true "用户名或邮箱"
+// @Success 202 "已受理"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Failure 503 {object} ErrorResponse "未配置邮件发送"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.ForgotPasswordRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.RequestPasswordReset(req.Login); err != nil {
+		if errors.Is(err, services.ErrPasswordResetUnavailable) {
+			Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		log.Printf("Failed to request password reset: %v", err)
+		Error(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+// @Summary 重置密码
+// @Description 令牌只能使用一次。重置后所有已签发的令牌和登录设备失效，因登录失败造成的临时停用也一并解除
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ResetPasswordRequest true "令牌和新密码"
+// @Success 204 "重置成功"
+// @Failure 400 {object} ErrorResponse "令牌无效或新密码不符合要求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.ResetPasswordRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.ResetPassword(req.Token, req.NewPassword, clientIP(r)); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) || errors.Is(err, services.ErrWeakPassword) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
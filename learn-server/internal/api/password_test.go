@@ -0,0 +1,290 @@
+// api/password_test.go
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// capturedMail 是本地 SMTP 服务器收到的一封邮件
+type capturedMail struct {
+	From, To string
+	Subject  string
+	Body     string
+}
+
+// startSMTPCapture 启动一个只支持最基本命令的 SMTP 服务器，收到的邮件写入返回的 channel
+func startSMTPCapture(t *testing.T) (int, <-chan capturedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan capturedMail, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- capturedMail) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ESMTP")
+	var captured capturedMail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			captured = capturedMail{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			captured.To = strings.Trim(line[len("RCPT TO:"):], "<> ")
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data bytes.Buffer
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			if msg, err := mail.ReadMessage(&data); err == nil {
+				captured.Subject, _ = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+				body, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+				captured.Body = string(body)
+			}
+			messages <- captured
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestPasswordChangeAndReset(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	port, mails := startSMTPCapture(t)
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	authService.Notifier = &services.SMTPNotifier{Host: "127.0.0.1", Port: port, From: "noreply@example.edu"}
+	authService.PasswordResetURL = "https://learn.example.edu/reset?lang=zh"
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	role, _ := authService.CreateRole("member")
+	update := dto.RoleUpdateRequest{Name: "member"}
+	for _, permission := range permissions {
+		if slices.Contains([]string{"auth:password", "auth:sessions"}, permission.Name) {
+			update.Permissions = append(update.Permissions, int(permission.ID))
+		}
+	}
+	if err := authService.UpdateRole(role.ID, update); err != nil {
+		t.Fatalf("Failed to grant permissions: %v", err)
+	}
+	alice, err := authService.CreateUserWithEmail("alice", "password", "Alice@Example.edu", []string{"member"}, models.StatusActive)
+	if err != nil || alice.Email == nil || *alice.Email != "alice@example.edu" {
+		t.Fatalf("Failed to create user with email: %v %v", alice.Email, err)
+	}
+	authService.CreateUser("bob", "password", []string{"member"}, models.StatusActive)
+	if _, err := authService.CreateUserWithEmail("carol", "password", "ALICE@example.edu", nil, models.StatusActive); err != services.ErrEmailTaken {
+		t.Errorf("Expected duplicate email to be rejected, got %v", err)
+	}
+	if _, err := authService.CreateUserWithEmail("carol", "password", "Carol <carol@example.edu>", nil, models.StatusActive); err != services.ErrInvalidEmail {
+		t.Errorf("Expected display name to be rejected, got %v", err)
+	}
+
+	send := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username, password string) (int, dto.TokenPairResponse) {
+		w := send("/auth/login", "", dto.LoginRequest{Username: username, Password: password})
+		var resp api.Response[dto.TokenPairResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	sessionsStatus := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/auth/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 修改密码需要当前密码，成功后旧令牌失效并返回新令牌
+	_, tokens := login("alice", "password")
+	if w := send("/auth/password", tokens.AccessToken, dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new password 1"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected wrong current password to be rejected, got %v", w.Code)
+	}
+
+	// 当前密码错误与登录失败共用限流，并记录审计日志
+	for i := 0; i < services.DefaultLoginThrottle.AccountFreeAttempts; i++ {
+		send("/auth/password", tokens.AccessToken, dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new password 1"})
+	}
+	if w := send("/auth/password", tokens.AccessToken, dto.ChangePasswordRequest{CurrentPassword: "password", NewPassword: "new password 1"}); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected password change to be throttled, got %v", w.Code)
+	}
+	if code, _ := login("alice", "password"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected login to share the throttle, got %v", code)
+	}
+	var failed int64
+	db.Model(&models.AuditLog{}).Where("action = ? AND user_id = ?", services.AuditPasswordChangeFailed, alice.ID).Count(&failed)
+	if failed != int64(services.DefaultLoginThrottle.AccountFreeAttempts+1) {
+		t.Errorf("Expected failed password changes to be audited, got %d", failed)
+	}
+	db.Model(&models.LoginFailure{}).Where("1 = 1").Update("blocked_until", time.Now().Add(-time.Second))
+	if w := send("/auth/password", tokens.AccessToken, dto.ChangePasswordRequest{CurrentPassword: "password", NewPassword: "short"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected weak password to be rejected, got %v", w.Code)
+	}
+	w := send("/auth/password", tokens.AccessToken, dto.ChangePasswordRequest{CurrentPassword: "password", NewPassword: "new password 1"})
+	var changed api.Response[dto.TokenPairResponse]
+	json.NewDecoder(w.Body).Decode(&changed)
+	if w.Code != http.StatusOK || changed.Data.AccessToken == "" {
+		t.Fatalf("Failed to change password: %v %s", w.Code, w.Body)
+	}
+	if code := sessionsStatus(tokens.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("Expected old access token to be rejected, got %v", code)
+	}
+	if code := sessionsStatus(changed.Data.AccessToken); code != http.StatusOK {
+		t.Errorf("Expected new access token to work, got %v", code)
+	}
+	if w := send("/auth/refresh", "", dto.RefreshTokenRequest{Token: tokens.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected old refresh token to be rejected, got %v", w.Code)
+	}
+	if code, _ := login("alice", "password"); code != http.StatusUnauthorized {
+		t.Errorf("Expected old password to be rejected, got %v", code)
+	}
+
+	// 找回密码：邮箱不区分大小写，邮件中的链接保留原有参数
+	if w := send("/auth/password/forgot", "", dto.ForgotPasswordRequest{Login: "ALICE@example.edu"}); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected reset request to be accepted, got %v %s", w.Code, w.Body)
+	}
+	var message capturedMail
+	select {
+	case message = <-mails:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for reset email")
+	}
+	if message.To != "alice@example.edu" || message.From != "noreply@example.edu" || message.Subject != "重置密码" {
+		t.Errorf("Unexpected email: %+v", message)
+	}
+	var link *url.URL
+	for _, line := range strings.Split(message.Body, "\n") {
+		if strings.HasPrefix(line, "https://") {
+			link, _ = url.Parse(strings.TrimSpace(line))
+		}
+	}
+	if link == nil || link.Query().Get("lang") != "zh" || link.Query().Get("token") == "" {
+		t.Fatalf("Expected reset link in email, got %q", message.Body)
+	}
+	token := link.Query().Get("token")
+
+	// 再次申请时一分钟内不重复发送
+	send("/auth/password/forgot", "", dto.ForgotPasswordRequest{Login: "alice"})
+	// 不存在的用户和没有邮箱的用户同样返回 202，但不发送邮件
+	for _, login := range []string{"nobody", "bob"} {
+		if w := send("/auth/password/forgot", "", dto.ForgotPasswordRequest{Login: login}); w.Code != http.StatusAccepted {
+			t.Errorf("Expected %s to get the same response, got %v", login, w.Code)
+		}
+	}
+
+	if w := send("/auth/password/reset", "", dto.ResetPasswordRequest{Token: "bogus", NewPassword: "reset password 2"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown token to be rejected, got %v", w.Code)
+	}
+	if w := send("/auth/password/reset", "", dto.ResetPasswordRequest{Token: token, NewPassword: "alice"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected weak password to be rejected, got %v", w.Code)
+	}
+	if w := send("/auth/password/reset", "", dto.ResetPasswordRequest{Token: token, NewPassword: "reset password 2"}); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to reset password: %v %s", w.Code, w.Body)
+	}
+	if code := sessionsStatus(changed.Data.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("Expected tokens to be invalidated by reset, got %v", code)
+	}
+	if w := send("/auth/password/reset", "", dto.ResetPasswordRequest{Token: token, NewPassword: "reset password 3"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected token to be single use, got %v", w.Code)
+	}
+	if code, _ := login("alice", "reset password 2"); code != http.StatusOK {
+		t.Errorf("Expected login with the new password, got %v", code)
+	}
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("user_id = ? AND action IN ?", alice.ID,
+		[]string{services.AuditPasswordChanged, services.AuditPasswordReset}).Count(&audits)
+	if audits != 2 {
+		t.Errorf("Expected password changes to be audited, got %d", audits)
+	}
+
+	// 过期的令牌不能使用
+	db.Model(&models.PasswordResetToken{}).Where("1 = 1").Update("created_at", time.Now().Add(-time.Hour))
+	send("/auth/password/forgot", "", dto.ForgotPasswordRequest{Login: "alice"})
+	select {
+	case message = <-mails:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for second reset email")
+	}
+	db.Model(&models.PasswordResetToken{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Second))
+	_, rest, _ := strings.Cut(message.Body, "token=")
+	expired, _ := url.QueryUnescape(strings.Fields(rest)[0])
+	if w := send("/auth/password/reset", "", dto.ResetPasswordRequest{Token: expired, NewPassword: "reset password 3"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected expired token to be rejected, got %v", w.Code)
+	}
+
+	select {
+	case extra := <-mails:
+		t.Errorf("Unexpected email: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
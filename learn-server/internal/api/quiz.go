@@ -3,6 +3,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"learn/internal/dto"
 	"learn/internal/models"
 	"learn/internal/services"
@@ -10,16 +11,19 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 type QuizHandler struct {
 	QuizService *services.QuizService
+	AuthService *services.AuthService
 }
 
 func (h *QuizHandler) GetApiEndpoints() []APIEndpoint {
 	return []APIEndpoint{
 		{"/quiz/question_banks", "GET", h.GetQuestionBanks, "quiz:read", "查看题库"},
 		{"/quiz/question_banks", "POST", h.CreateQuestionBank, "quiz:edit", "创建题库"},
+		{"/quiz/question_banks/{id}", "PUT", h.UpdateQuestionBank, "quiz:edit", "修改题库设置"},
 		{"/quiz/question_banks/{id}/questions", "GET", h.GetQuestions, "quiz:read", "查看题目"},
 		{"/quiz/question_banks/{id}/questions", "POST", h.CreateQuestion, "quiz:edit", "创建题目"},
 		{"/quiz/questions/{id}", "GET", h.GetQuestionDetail, "quiz:read", "获取问题详细信息"},
@@ -27,8 +31,18 @@ func (h *QuizHandler) GetApiEndpoints() []APIEndpoint {
 		{"/quiz/questions/{id}", "DELETE", h.DeleteQuestion, "quiz:edit", "删除问题"},
 		{"/quiz/question_banks/{id}/random_questions", "GET", h.GetRandomQuestions, "", "随机获取题目"},
 
-		{"/quiz/question_attempts", "POST", h.RecordQuestionAttempt, "quiz:edit", "记录答题尝试"},
-		{"/quiz/question_attempts/{user_id}/{question_bank_id}", "GET", h.GetQuestionAttempts, "quiz:read", "获取用户的答题尝试情况"},
+		{"/quiz/question_attempts", "POST", h.RecordQuestionAttempt, "quiz:attempt", "记录答题尝试"},
+		{"/quiz/question_attempts/batch", "POST", h.RecordQuestionAttemptBatch, "quiz:attempt", "批量提交作答"},
+		{"/quiz/question_banks/{id}/attempts", "GET", h.GetMyQuestionAttempts, "quiz:attempt", "查看自己的答题情况"},
+		{"/quiz/question_attempts/{user_id}/{question_bank_id}", "GET", h.GetQuestionAttempts, "quiz:read_all_attempts", "查看任意用户的答题情况"},
+
+		{"/notebook", "GET", h.GetNotebook, "notebook:read", "查看错题本"},
+		{"/notebook/practice", "GET", h.GetNotebookPractice, "notebook:read", "错题练习"},
+		{"/notebook/{question_id}", "PUT", h.UpdateNotebookEntry, "notebook:edit", "收藏错题或修改笔记"},
+		{"/notebook/{question_id}", "DELETE", h.RemoveNotebookEntry, "notebook:edit", "移出错题本"},
+
+		{"/sync/practice_package", "GET", h.GetPracticePackage, "sync:read", "下载离线练习包"},
+		{"/sync/attempts", "POST", h.SyncAttempts, "sync:write", "上传离线作答"},
 	}
 }
 
@@ -51,7 +65,7 @@ func (h *QuizHandler) GetQuestionBanks(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]dto.QuestionBankResponse, len(questionBanks))
 	for i, bank := range questionBanks {
-		response[i] = dto.QuestionBankResponse{ID: bank.ID, Name: bank.Name}
+		response[i] = newQuestionBankResponse(&bank)
 	}
 
 	Success(w, response, nil, http.StatusOK)
@@ -76,13 +90,68 @@ func (h *QuizHandler) CreateQuestionBank(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	questionBank, err := h.QuizService.CreateQuestionBank(req.Name)
+	if req.FeedbackMode.String() == "" {
+		Error(w, "Invalid feedback mode", http.StatusBadRequest)
+		return
+	}
+
+	questionBank, err := h.QuizService.CreateQuestionBank(req.Name, req.FeedbackMode)
 	if err != nil {
 		Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	Success(w, dto.QuestionBankResponse{ID: questionBank.ID, Name: questionBank.Name}, nil, http.StatusCreated)
+	Success(w, newQuestionBankResponse(questionBank), nil, http.StatusCreated)
+}
+
+// UpdateQuestionBank 修改题库名称和设置
+// @Summary 修改题库设置
+// @Description 修改题库名称和作答反馈方式，考试用的题库可设置为不反馈
+// @Tags QuestionBank
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "题库 ID"
+// @Param questionBank body dto.UpdateQuestionBankRequest true "修改题库请求"
+// @Success 200 {object} Response[dto.QuestionBankResponse] "修改成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /quiz/question_banks/{id} [put]
+func (h *QuizHandler) UpdateQuestionBank(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bankID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		Error(w, "Invalid bank ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateQuestionBankRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || req.FeedbackMode.String() == "" {
+		Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	questionBank, err := h.QuizService.UpdateQuestionBank(uint(bankID), req.Name, req.FeedbackMode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Question bank not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to update question bank", http.StatusInternalServerError)
+		return
+	}
+
+	Success(w, newQuestionBankResponse(questionBank), nil, http.StatusOK)
+}
+
+// newQuestionBankResponse 将题库转换为响应
+func newQuestionBankResponse(bank *models.QuestionBank) dto.QuestionBankResponse {
+	return dto.QuestionBankResponse{ID: bank.ID, Name: bank.Name, FeedbackMode: bank.FeedbackMode}
 }
 
 // GetQuestions 获取题库中的问题基本信息（支持分页和标签过滤）
@@ -130,17 +199,10 @@ func (h *QuizHandler) GetQuestions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	policy := services.AnswerPolicyFor(h.defaultQuestionView(r))
 	var questionResponses []dto.QuestionResponse
-	for _, q := range questions {
-		questionResponse := dto.QuestionResponse{
-			ID:             q.ID,
-			QuestionBankID: q.QuestionBankID,
-			QuestionType:   q.QuestionType,
-			Content:        q.Content,
-			CreatedAt:      q.CreatedAt,
-			AuthorID:       q.AuthorID,
-		}
-		questionResponses = append(questionResponses, questionResponse)
+	for i := range questions {
+		questionResponses = append(questionResponses, services.NewQuestionResponse(&questions[i], policy))
 	}
 
 	Success(w, questionResponses, &PaginationMeta{
@@ -185,32 +247,11 @@ func (h *QuizHandler) GetRandomQuestions(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// 随机练习属于作答前的场景，不下发答案和解析
+	policy := services.AnswerPolicyFor(services.ViewPractice)
 	var questionResponses []dto.QuestionResponse
-	for _, q := range questions {
-		questionResponse := dto.QuestionResponse{
-			ID:             q.ID,
-			QuestionBankID: q.QuestionBankID,
-			QuestionType:   q.QuestionType,
-			Content:        q.Content,
-			CreatedAt:      q.CreatedAt,
-			AuthorID:       q.AuthorID,
-		}
-		switch q.QuestionType {
-		case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
-			for _, option := range q.AnswerOptions {
-				questionResponse.AnswerOptions = append(questionResponse.AnswerOptions, dto.AnswerOption{
-					ID:         option.ID,
-					OptionText: option.OptionText,
-				})
-			}
-		case models.QuestionTypeFillInTheBlank:
-			for range q.FillInTheBlanks {
-				questionResponse.FillInTheBlanks = append(questionResponse.FillInTheBlanks, dto.FillInTheBlankAnswer{
-					BlankText: "",
-				})
-			}
-		}
-		questionResponses = append(questionResponses, questionResponse)
+	for i := range questions {
+		questionResponses = append(questionResponses, services.NewQuestionResponse(&questions[i], policy))
 	}
 
 	Success(w, questionResponses, nil, http.StatusOK)
@@ -218,13 +259,16 @@ func (h *QuizHandler) GetRandomQuestions(w http.ResponseWriter, r *http.Request)
 
 // GetQuestionDetail 获取问题详情
 // @Summary 获取问题详情
-// @Description 获取指定问题的详细信息，包括答案和标签
+// @Description 获取指定问题的详细信息和标签；答案和解析是否可见取决于调用方权限和查看场景
 // @Tags Question
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "问题 ID"
+// @Param view query string false "查看场景：practice、exam、review、author"
 // @Success 200 {object} Response[dto.QuestionResponse] "问题详情"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 403 {object} ErrorResponse "无权查看答案"
 // @Failure 500 {object} ErrorResponse "内部服务器错误"
 // @Router /quiz/questions/{id} [get]
 func (h *QuizHandler) GetQuestionDetail(w http.ResponseWriter, r *http.Request) {
@@ -237,6 +281,16 @@ func (h *QuizHandler) GetQuestionDetail(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	view, err := h.requestedQuestionView(r, uint(questionID))
+	if err != nil {
+		if errors.Is(err, errViewForbidden) {
+			Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// 从 QuizService 获取问题详细信息（包括答案和标签）
 	question, err := h.QuizService.GetQuestionDetail(uint(questionID))
 	if err != nil {
@@ -244,52 +298,7 @@ func (h *QuizHandler) GetQuestionDetail(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// 构建 dto.QuestionResponse（包括答案和标签）
-	questionResponse := dto.QuestionResponse{
-		ID:             question.ID,
-		QuestionBankID: question.QuestionBankID,
-		QuestionType:   question.QuestionType,
-		Content:        question.Content,
-		Explanation:    question.Explanation,
-		CreatedAt:      question.CreatedAt,
-		AuthorID:       question.AuthorID,
-		AuthorName:     question.Author.Username,
-	}
-
-	// 填充标签
-	for _, tag := range question.Tags {
-		questionResponse.Tags = append(questionResponse.Tags, tag.Name)
-	}
-
-	// 根据题目类型填充不同的答案选项
-	switch question.QuestionType {
-	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
-		for _, option := range question.AnswerOptions {
-			questionResponse.AnswerOptions = append(questionResponse.AnswerOptions, dto.AnswerOption{
-				ID:         option.ID,
-				OptionText: option.OptionText,
-				IsCorrect:  option.IsCorrect,
-			})
-		}
-	case models.QuestionTypeTrueFalse:
-		if question.TrueFalseAnswer != nil {
-			questionResponse.TrueFalseAnswer = &dto.TrueFalseAnswer{
-				IsTrue: question.TrueFalseAnswer.IsTrue,
-			}
-		}
-	case models.QuestionTypeWrittenAnswer:
-		if question.WrittenAnswer != nil {
-			questionResponse.WrittenAnswer = &dto.WrittenAnswer{
-				AnswerText: question.WrittenAnswer.AnswerText,
-			}
-		}
-	case models.QuestionTypeFillInTheBlank:
-		for _, blank := range question.FillInTheBlanks {
-			questionResponse.FillInTheBlanks = append(questionResponse.FillInTheBlanks, dto.FillInTheBlankAnswer{
-				BlankText: blank.BlankText,
-			})
-		}
-	}
+	questionResponse := services.NewQuestionResponse(question, services.AnswerPolicyFor(view))
 
 	Success(w, questionResponse, nil, http.StatusOK)
 }
@@ -326,40 +335,17 @@ func (h *QuizHandler) CreateQuestion(w http.ResponseWriter, r *http.Request) {
 		Content:        req.Content,
 		QuestionType:   req.QuestionType,
 		Explanation:    req.Explanation,
+		Difficulty:     req.Difficulty,
 		AuthorID:       req.AuthorID,
 	}
 
 	// 根据题目类型处理答案
-	switch req.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		var options []models.AnswerOption
-		for _, option := range req.AnswerOptions {
-			options = append(options, models.AnswerOption{
-				OptionText: option.OptionText,
-				IsCorrect:  option.IsCorrect,
-			})
-		}
-		question.AnswerOptions = options
-
-	case models.QuestionTypeTrueFalse:
-		question.TrueFalseAnswer = &models.TrueFalseAnswer{
-			IsTrue: *req.TrueFalse,
-		}
-
-	case models.QuestionTypeWrittenAnswer:
-		question.WrittenAnswer = &models.WrittenAnswer{
-			AnswerText: req.AnswerText,
-		}
-
-	case models.QuestionTypeFillInTheBlank:
-		var blanks []models.FillInTheBlankAnswer
-		for _, blank := range req.Blanks {
-			blanks = append(blanks, models.FillInTheBlankAnswer{
-				BlankText: blank.BlankText,
-			})
-		}
-		question.FillInTheBlanks = blanks
+	questionType, err := services.GetQuestionType(req.QuestionType)
+	if err != nil {
+		Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	questionType.Build(&question, req.Answers())
 
 	// 处理标签
 	for _, tagName := range req.Tags {
@@ -369,47 +355,26 @@ func (h *QuizHandler) CreateQuestion(w http.ResponseWriter, r *http.Request) {
 
 	createdQuestion, err := h.QuizService.CreateQuestion(question)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidQuestion) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		Error(w, "Failed to create question", http.StatusInternalServerError)
 		return
 	}
 
-	response := dto.QuestionResponse{
-		ID:             createdQuestion.ID,
-		QuestionBankID: createdQuestion.QuestionBankID,
-		QuestionType:   createdQuestion.QuestionType,
-		Content:        createdQuestion.Content,
-		Explanation:    createdQuestion.Explanation,
-		AuthorID:       createdQuestion.AuthorID,
-		AuthorName:     createdQuestion.Author.Username,
-		CreatedAt:      createdQuestion.CreatedAt,
-	}
-	switch createdQuestion.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		for _, option := range createdQuestion.AnswerOptions {
-			response.AnswerOptions = append(response.AnswerOptions, dto.AnswerOption{
-				ID:         option.ID,
-				OptionText: option.OptionText,
-				IsCorrect:  option.IsCorrect,
-			})
-		}
-	case models.QuestionTypeTrueFalse:
-		response.TrueFalseAnswer = &dto.TrueFalseAnswer{
-			IsTrue: createdQuestion.TrueFalseAnswer.IsTrue,
-		}
-	case models.QuestionTypeWrittenAnswer:
-		response.WrittenAnswer = &dto.WrittenAnswer{
-			AnswerText: createdQuestion.WrittenAnswer.AnswerText,
-		}
-	case models.QuestionTypeFillInTheBlank:
-		for _, blank := range createdQuestion.FillInTheBlanks {
-			response.FillInTheBlanks = append(response.FillInTheBlanks, dto.FillInTheBlankAnswer{
-				BlankText: blank.BlankText,
-			})
+	if len(req.RelatedIDs) > 0 {
+		if err := h.QuizService.SetRelatedQuestions(createdQuestion.ID, req.RelatedIDs); err != nil {
+			if errors.Is(err, services.ErrInvalidQuestion) {
+				Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Error(w, "Failed to save related questions", http.StatusInternalServerError)
+			return
 		}
 	}
-	for _, tag := range createdQuestion.Tags {
-		response.Tags = append(response.Tags, tag.Name)
-	}
+
+	response := services.NewQuestionResponse(createdQuestion, services.AnswerPolicyFor(services.ViewAuthor))
 
 	Success(w, response, nil, http.StatusCreated)
 }
@@ -447,40 +412,17 @@ func (h *QuizHandler) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
 		Content:        req.Content,
 		QuestionType:   req.QuestionType,
 		Explanation:    req.Explanation,
+		Difficulty:     req.Difficulty,
 		AuthorID:       req.AuthorID,
 	}
 
 	// 根据题目类型处理答案更新
-	switch req.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		var options []models.AnswerOption
-		for _, option := range req.AnswerOptions {
-			options = append(options, models.AnswerOption{
-				OptionText: option.OptionText,
-				IsCorrect:  option.IsCorrect,
-			})
-		}
-		question.AnswerOptions = options
-
-	case models.QuestionTypeTrueFalse:
-		question.TrueFalseAnswer = &models.TrueFalseAnswer{
-			IsTrue: *req.TrueFalse,
-		}
-
-	case models.QuestionTypeWrittenAnswer:
-		question.WrittenAnswer = &models.WrittenAnswer{
-			AnswerText: req.AnswerText,
-		}
-
-	case models.QuestionTypeFillInTheBlank:
-		var blanks []models.FillInTheBlankAnswer
-		for _, blank := range req.Blanks {
-			blanks = append(blanks, models.FillInTheBlankAnswer{
-				BlankText: blank.BlankText,
-			})
-		}
-		question.FillInTheBlanks = blanks
+	questionType, err := services.GetQuestionType(req.QuestionType)
+	if err != nil {
+		Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	questionType.Build(&question, req.Answers())
 
 	// 处理标签
 	var tags []models.Tag
@@ -492,33 +434,41 @@ func (h *QuizHandler) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
 
 	updatedQuestion, err := h.QuizService.UpdateQuestion(question)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidQuestion) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		Error(w, "Failed to update question", http.StatusInternalServerError)
 		return
 	}
 
-	response := dto.QuestionResponse{
-		ID:             updatedQuestion.ID,
-		QuestionBankID: updatedQuestion.QuestionBankID,
-		QuestionType:   updatedQuestion.QuestionType,
-		Content:        updatedQuestion.Content,
-		Explanation:    updatedQuestion.Explanation,
-		AuthorID:       updatedQuestion.AuthorID,
-		AuthorName:     updatedQuestion.Author.Username,
-		CreatedAt:      updatedQuestion.CreatedAt,
+	if req.RelatedIDs != nil {
+		if err := h.QuizService.SetRelatedQuestions(updatedQuestion.ID, req.RelatedIDs); err != nil {
+			if errors.Is(err, services.ErrInvalidQuestion) {
+				Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Error(w, "Failed to save related questions", http.StatusInternalServerError)
+			return
+		}
 	}
 
+	response := services.NewQuestionResponse(updatedQuestion, services.AnswerPolicyFor(services.ViewAuthor))
+
 	Success(w, response, nil, http.StatusOK)
 }
 
 // DeleteQuestion 删除问题
 // @Summary 删除问题
-// @Description 删除指定问题
+// @Description 删除指定问题；被冻结的试卷或仍可提交的作业引用的问题不能删除
 // @Tags Question
 // @Security ApiKeyAuth
 // @Produce  json
 // @Param id path int true "问题 ID"
 // @Success 204 "删除成功"
 // @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "问题不存在"
+// @Failure 409 {object} ErrorResponse "问题被冻结的试卷或仍可提交的作业引用"
 // @Failure 500 {object} ErrorResponse "内部服务器错误"
 // @Router /quiz/questions/{id} [delete]
 func (h *QuizHandler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
@@ -532,7 +482,14 @@ func (h *QuizHandler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.QuizService.DeleteQuestion(uint(questionID)); err != nil {
-		Error(w, "Failed to delete question", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, services.ErrQuestionNotFound):
+			Error(w, "Question not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrQuestionInUse):
+			Error(w, err.Error(), http.StatusConflict)
+		default:
+			Error(w, "Failed to delete question", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -541,7 +498,8 @@ func (h *QuizHandler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
 
 // RecordQuestionAttempt 记录用户的答题尝试
 // @Summary 记录用户的答题尝试
-// @Description 记录用户对特定问题的答题情况
+// @Description 记录当前用户对特定问题的答题情况，并按题库设置返回对错、正确答案、解析和相关题目。
+// @Description 题库设置为不反馈时不返回答错次数、得分、经验值和徽章
 // @Tags QuestionAttempt
 // @Security ApiKeyAuth
 // @Accept  json
@@ -549,35 +507,239 @@ func (h *QuizHandler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
 // @Param input body dto.QuestionAttemptRequest true "答题尝试信息"
 // @Success 200 {object} Response[dto.QuestionAttemptResponse] "记录成功"
 // @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "问题不存在"
 // @Failure 500 {object} ErrorResponse "内部服务器错误"
 // @Router /quiz/question_attempts [post]
 func (h *QuizHandler) RecordQuestionAttempt(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	var req dto.QuestionAttemptRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	attempt, err := h.QuizService.RecordQuestionAttempt(req.UserID, req.QuestionID, req.Answer)
+	attempt, err := h.QuizService.RecordQuestionAttempt(user.ID, req.QuestionID, req.Answer)
 	if err != nil {
-		Error(w, "Failed to record question attempt", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, services.ErrInvalidAnswer):
+			Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrQuestionNotFound):
+			Error(w, err.Error(), http.StatusNotFound)
+		default:
+			Error(w, "Failed to record question attempt", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	response := dto.QuestionAttemptResponse{
-		QuestionID:         attempt.QuestionID,
-		Attempts:           attempt.Attempts,
-		Wrong:              attempt.Wrong,
-		ConsecutiveCorrect: attempt.ConsecutiveCorrect,
-		LastAnswerAt:       attempt.LastAnswerAt,
+	response := newQuestionAttemptResponse(*attempt)
+	visible, err := h.QuizService.GradingVisible(attempt.Question.QuestionBankID)
+	if err != nil {
+		Error(w, "Failed to build feedback", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		withholdGrading(&response)
+	}
+	if response.Feedback, err = h.QuizService.PracticeFeedback(attempt); err != nil {
+		Error(w, "Failed to build feedback", http.StatusInternalServerError)
+		return
 	}
 
 	Success(w, response, nil, http.StatusOK)
 }
 
+// 批量作答中单题的错误码
+const (
+	AttemptErrorQuestionNotFound = "question_not_found"
+	AttemptErrorInvalidAnswer    = "invalid_answer"
+	AttemptErrorDuplicate        = "duplicate_question"
+)
+
+// RecordQuestionAttemptBatch 批量提交作答
+// @Summary 批量提交作答
+// @Description 一次提交整套题的作答，为当前用户判分并在同一事务中记录。单题出错不影响其他题目，
+// @Description 结果中的 error_code 为 question_not_found、invalid_answer 或 duplicate_question；汇总得分时出错的题按 0 分计。
+// @Description 每题的反馈按所属题库的设置返回，题库设置为不反馈的题目不返回对错和得分，也不计入汇总
+// @Tags QuestionAttempt
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param input body dto.BatchAttemptRequest true "整套题的作答"
+// @Success 200 {object} Response[dto.BatchAttemptResponse] "各题结果和汇总得分"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /quiz/question_attempts/batch [post]
+func (h *QuizHandler) RecordQuestionAttemptBatch(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.BatchAttemptRequest](w, r)
+	if !ok {
+		return
+	}
+
+	answers := make([]services.QuestionAnswer, len(req.Answers))
+	for i, answer := range req.Answers {
+		answers[i] = services.QuestionAnswer{QuestionID: answer.QuestionID, Answer: answer.Answer}
+	}
+	items, err := h.QuizService.RecordQuestionAttemptBatch(user.ID, answers)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBatch) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Error(w, "Failed to record question attempts", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.BatchAttemptResponse{Results: make([]dto.BatchAttemptItem, len(items)), Total: len(items)}
+	visibleBanks := map[uint]bool{}
+	for i, item := range items {
+		result := &response.Results[i]
+		result.QuestionID = answers[i].QuestionID
+		if item.Err != nil {
+			result.ErrorCode, result.Error = attemptErrorCode(item.Err), item.Err.Error()
+			continue
+		}
+		attempt := newQuestionAttemptResponse(*item.Result)
+		if attempt.Feedback, err = h.QuizService.PracticeFeedback(item.Result); err != nil {
+			Error(w, "Failed to build feedback", http.StatusInternalServerError)
+			return
+		}
+		result.Attempt = &attempt
+
+		// 不反馈的题库逐题和汇总都不计入对错和得分，否则逐题提交就能试出答案
+		bankID := item.Result.Question.QuestionBankID
+		visible, ok := visibleBanks[bankID]
+		if !ok {
+			if visible, err = h.QuizService.GradingVisible(bankID); err != nil {
+				Error(w, "Failed to build feedback", http.StatusInternalServerError)
+				return
+			}
+			visibleBanks[bankID] = visible
+		}
+		if !visible {
+			withholdGrading(&attempt)
+			response.Withheld++
+			continue
+		}
+		response.Graded++
+		response.Score += item.Result.Grade.Score
+		response.XP += item.Result.XP
+		if item.Result.Grade.Correct {
+			response.Correct++
+		}
+	}
+	if scored := response.Total - response.Withheld; scored > 0 {
+		response.Percentage = response.Score / float64(scored) * 100
+	}
+
+	Success(w, response, nil, http.StatusOK)
+}
+
+// attemptErrorCode 返回批量作答中单题错误对应的错误码
+func attemptErrorCode(err error) string {
+	switch {
+	case errors.Is(err, services.ErrQuestionNotFound):
+		return AttemptErrorQuestionNotFound
+	case errors.Is(err, services.ErrDuplicateAnswer):
+		return AttemptErrorDuplicate
+	default:
+		return AttemptErrorInvalidAnswer
+	}
+}
+
+// newQuestionAttemptResponse 将作答结果转换为响应，组合题附带各小题的作答情况
+func newQuestionAttemptResponse(result services.AttemptResult) dto.QuestionAttemptResponse {
+	response := dto.QuestionAttemptResponse{
+		QuestionID:         result.Attempt.QuestionID,
+		Attempts:           result.Attempt.Attempts,
+		Wrong:              result.Attempt.Wrong,
+		ConsecutiveCorrect: result.Attempt.ConsecutiveCorrect,
+		LastScore:          result.Attempt.LastScore,
+		LastAnswerAt:       result.Attempt.LastAnswerAt,
+		XP:                 result.XP,
+	}
+	for _, badge := range result.Badges {
+		response.Badges = append(response.Badges, services.NewBadgeResponse(badge))
+	}
+	for _, child := range result.Children {
+		response.Children = append(response.Children, newQuestionAttemptResponse(child))
+	}
+	return response
+}
+
+// withholdGrading 去掉作答响应中能推断对错的字段，只保留作答次数和时间
+func withholdGrading(response *dto.QuestionAttemptResponse) {
+	response.Wrong = 0
+	response.ConsecutiveCorrect = 0
+	response.LastScore = 0
+	response.XP = 0
+	response.Badges = nil
+	for i := range response.Children {
+		withholdGrading(&response.Children[i])
+	}
+}
+
+// GetMyQuestionAttempts 获取当前用户的答题情况
+// @Summary 获取自己的答题情况
+// @Description 获取当前用户在题库中尚未连续答对 3 次的题目的答题情况，题库设置为不反馈时只返回作答次数和时间
+// @Tags QuestionAttempt
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "题库 ID"
+// @Success 200 {object} Response[[]dto.QuestionAttemptResponse] "答题尝试列表"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 404 {object} ErrorResponse "题库不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /quiz/question_banks/{id}/attempts [get]
+func (h *QuizHandler) GetMyQuestionAttempts(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	questionBankID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid question bank ID", http.StatusBadRequest)
+		return
+	}
+
+	visible, err := h.QuizService.GradingVisible(questionBankID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		Error(w, "Question bank not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		Error(w, "Failed to get question attempts", http.StatusInternalServerError)
+		return
+	}
+	attempts, err := h.QuizService.GetQuestionAttempts(user.ID, questionBankID, 3)
+	if err != nil {
+		Error(w, "Failed to get question attempts", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		for i := range attempts {
+			withholdGrading(&attempts[i])
+		}
+	}
+
+	Success(w, attempts, nil, http.StatusOK)
+}
+
 // GetQuestionAttempts 获取用户的答题尝试情况
-// @Summary 获取用户的答题尝试情况
-// @Description 获取用户在特定题库中的答题情况
+// @Summary 获取任意用户的答题尝试情况
+// @Description 获取指定用户在题库中尚未连续答对 3 次的题目的答题情况，供教师和管理员使用，需要 quiz:read_all_attempts 权限。
+// @Description 学员查看自己的答题情况请使用 /quiz/question_banks/{id}/attempts
 // @Tags QuestionAttempt
 // @Security ApiKeyAuth
 // @Produce  json
@@ -612,3 +774,55 @@ func (h *QuizHandler) GetQuestionAttempts(w http.ResponseWriter, r *http.Request
 
 	Success(w, attempts, nil, http.StatusOK)
 }
+
+var errViewForbidden = errors.New("not allowed to view answers in this context")
+
+// defaultQuestionView 返回未指定场景时的默认场景：出题人查看完整题目，其他人按练习场景处理
+func (h *QuizHandler) defaultQuestionView(r *http.Request) services.QuestionView {
+	if h.canEditQuiz(r) {
+		return services.ViewAuthor
+	}
+	return services.ViewPractice
+}
+
+// requestedQuestionView 解析并校验请求的查看场景：author 场景需要 quiz:edit 权限；
+// review 场景要求调用方是出题人，或按题库的反馈设置可以回顾该题（见 QuizService.CanReviewQuestion）
+func (h *QuizHandler) requestedQuestionView(r *http.Request, questionID uint) (services.QuestionView, error) {
+	name := r.URL.Query().Get("view")
+	if name == "" {
+		return h.defaultQuestionView(r), nil
+	}
+	view, ok := services.ParseQuestionView(name)
+	if !ok {
+		return view, errors.New("invalid view")
+	}
+
+	switch view {
+	case services.ViewAuthor:
+		if !h.canEditQuiz(r) {
+			return view, errViewForbidden
+		}
+	case services.ViewReview:
+		if h.canEditQuiz(r) {
+			return view, nil
+		}
+		user, ok := CurrentUser(r)
+		if !ok {
+			return view, errViewForbidden
+		}
+		allowed, err := h.QuizService.CanReviewQuestion(user.ID, questionID)
+		if err != nil || !allowed {
+			return view, errViewForbidden
+		}
+	}
+	return view, nil
+}
+
+// canEditQuiz 判断当前用户是否拥有编辑题目的权限
+func (h *QuizHandler) canEditQuiz(r *http.Request) bool {
+	user, ok := CurrentUser(r)
+	if !ok || h.AuthService == nil {
+		return false
+	}
+	return h.AuthService.HasPermission(r.Context(), user, "quiz:edit")
+}
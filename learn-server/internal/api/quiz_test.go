@@ -3,15 +3,22 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"learn/internal/api"
+	"learn/internal/consts/contextkeys"
 	"learn/internal/dto"
 	"learn/internal/models"
+	"learn/internal/routes"
 	"learn/internal/services"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"gorm.io/driver/sqlite"
@@ -27,15 +34,16 @@ func setupTestQuizHandler() (*api.QuizHandler, *services.AuthService, error) {
 
 	err = db.AutoMigrate(&models.QuestionBank{}, &models.Question{},
 		&models.AnswerOption{}, &models.TrueFalseAnswer{}, &models.WrittenAnswer{},
-		&models.FillInTheBlankAnswer{}, &models.Tag{},
-		&models.User{}, &models.QuestionAttempt{})
+		&models.FillInTheBlankAnswer{}, &models.QuestionStimulus{}, &models.Tag{},
+		&models.RelatedQuestion{}, &models.NotebookEntry{}, &models.User{}, &models.Role{}, &models.Permission{}, &models.QuestionAttempt{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{}, &models.SyncedAttempt{})
 	if err != nil {
 		return nil, nil, err
 	}
 
 	quizService := services.NewQuizService(db)
-	authService := services.NewAuthService(db, "jwt_secret", 60, 3600, 86400)
-	quizHandler := &api.QuizHandler{QuizService: quizService}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	quizHandler := &api.QuizHandler{QuizService: quizService, AuthService: authService}
 
 	return quizHandler, authService, nil
 }
@@ -49,6 +57,13 @@ func createTestUser(authService *services.AuthService, username string) (*models
 	return &user, nil
 }
 
+// withUser 模拟 AuthMiddleware，将用户写入请求上下文
+func withUser(user models.User, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), contextkeys.User, user)))
+	}
+}
+
 func TestCreateMultipleChoiceQuestion(t *testing.T) {
 	handler, authService, err := setupTestQuizHandler()
 	if err != nil {
@@ -59,7 +74,7 @@ func TestCreateMultipleChoiceQuestion(t *testing.T) {
 	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
 
 	// 使用 QuizService 创建题库
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -124,7 +139,7 @@ func TestCreateTrueFalseQuestion(t *testing.T) {
 	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
 
 	// 使用 QuizService 创建题库
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -181,7 +196,7 @@ func TestCreateWrittenQuestion(t *testing.T) {
 	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
 
 	// 使用 QuizService 创建题库
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -237,7 +252,7 @@ func TestCreateFillInTheBlankQuestion(t *testing.T) {
 	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
 
 	// 使用 QuizService 创建题库
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -296,7 +311,6 @@ func TestRecordQuestionAttempt(t *testing.T) {
 	}
 
 	router := mux.NewRouter()
-	router.HandleFunc("/quiz/question_attempts", handler.RecordQuestionAttempt).Methods("POST")
 	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
 
 	// Using AuthService to create a user
@@ -304,9 +318,10 @@ func TestRecordQuestionAttempt(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
+	router.HandleFunc("/quiz/question_attempts", withUser(*user, handler.RecordQuestionAttempt)).Methods("POST")
 
 	// Using QuizService to create a question bank
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -341,7 +356,6 @@ func TestRecordQuestionAttempt(t *testing.T) {
 
 	// Record an attempt for the single choice question with the correct answer
 	reqBody := dto.QuestionAttemptRequest{
-		UserID:     user.ID,
 		QuestionID: questionID,
 		Answer:     []uint{createQuestionResponse.Data.AnswerOptions[1].ID}, // Selecting the correct option
 	}
@@ -418,7 +432,6 @@ func TestRecordQuestionAttempt(t *testing.T) {
 
 	// Record an attempt for the true/false question with the correct answer
 	reqBody = dto.QuestionAttemptRequest{
-		UserID:     user.ID,
 		QuestionID: trueFalseQuestionID,
 		Answer:     true,
 	}
@@ -492,7 +505,6 @@ func TestRecordQuestionAttempt(t *testing.T) {
 
 	// Record an attempt for the written question without an answer
 	reqBody = dto.QuestionAttemptRequest{
-		UserID:     user.ID,
 		QuestionID: writtenQuestionID,
 		Answer:     "", // No answer provided
 	}
@@ -559,7 +571,7 @@ func TestGetQuestionAttempts(t *testing.T) {
 	}
 
 	// Using QuizService to create a question bank
-	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
 	if err != nil {
 		t.Fatalf("Failed to create question bank: %v", err)
 	}
@@ -623,3 +635,712 @@ func TestGetQuestionAttempts(t *testing.T) {
 		t.Errorf("Expected QuestionID %v, got %v", questionID, response.Data[0].QuestionID)
 	}
 }
+
+func TestCreateQuestionRejectsInvalidAnswers(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
+
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
+	if err != nil {
+		t.Fatalf("Failed to create question bank: %v", err)
+	}
+
+	user, err := createTestUser(authService, "testuser")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	requests := map[string]dto.CreateQuestionRequest{
+		"true/false without answer": {
+			Content:      "Is 5 > 3?",
+			QuestionType: models.QuestionTypeTrueFalse,
+			AuthorID:     user.ID,
+		},
+		"single choice without correct option": {
+			Content:      "What is 2+2?",
+			QuestionType: models.QuestionTypeSingleChoice,
+			AnswerOptions: []dto.AnswerOption{
+				{OptionText: "3"},
+				{OptionText: "5"},
+			},
+			AuthorID: user.ID,
+		},
+		"unknown question type": {
+			Content:      "What is 2+2?",
+			QuestionType: models.QuestionType(99),
+			AuthorID:     user.ID,
+		},
+	}
+
+	for name, request := range requests {
+		requestBody, _ := json.Marshal(request)
+		req := httptest.NewRequest(http.MethodPost, "/quiz/question_banks/"+strconv.Itoa(int(questionBank.ID))+"/questions", bytes.NewBuffer(requestBody))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %v, got %v", name, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestGroupQuestion(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
+	router.HandleFunc("/quiz/question_banks/{id}/random_questions", handler.GetRandomQuestions).Methods("GET")
+
+	questionBank, err := handler.QuizService.CreateQuestionBank("Reading", models.FeedbackFull)
+	if err != nil {
+		t.Fatalf("Failed to create question bank: %v", err)
+	}
+
+	user, err := createTestUser(authService, "testuser")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	router.HandleFunc("/quiz/question_attempts", withUser(*user, handler.RecordQuestionAttempt)).Methods("POST")
+
+	// 创建一道包含两道小题的阅读理解
+	trueValue := true
+	requestBody, _ := json.Marshal(dto.CreateQuestionRequest{
+		Content:      "Read the passage and answer the questions.",
+		QuestionType: models.QuestionTypeGroup,
+		Stimulus:     &dto.Stimulus{Text: "Tom has a cat. The cat is black."},
+		Children: []dto.ChildQuestionRequest{
+			{
+				Content:      "What colour is the cat?",
+				QuestionType: models.QuestionTypeSingleChoice,
+				AnswerOptions: []dto.AnswerOption{
+					{OptionText: "White"},
+					{OptionText: "Black", IsCorrect: true},
+				},
+			},
+			{
+				Content:      "Tom has a cat.",
+				QuestionType: models.QuestionTypeTrueFalse,
+				TrueFalse:    &trueValue,
+			},
+		},
+		AuthorID: user.ID,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/quiz/question_banks/"+strconv.Itoa(int(questionBank.ID))+"/questions", bytes.NewBuffer(requestBody))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %v, got %v: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var created api.Response[dto.QuestionResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Data.Stimulus == nil || len(created.Data.Children) != 2 {
+		t.Fatalf("Expected stimulus and 2 children, got %+v", created.Data)
+	}
+
+	// 随机抽题时组合题整体下发，小题不单独出现，且不包含正确答案
+	req = httptest.NewRequest(http.MethodGet, "/quiz/question_banks/"+strconv.Itoa(int(questionBank.ID))+"/random_questions?limit=10", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var random api.Response[[]dto.QuestionResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&random); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(random.Data) != 1 {
+		t.Fatalf("Expected 1 random question, got %v", len(random.Data))
+	}
+	group := random.Data[0]
+	if group.Stimulus == nil || group.Stimulus.Text == "" {
+		t.Errorf("Expected stimulus to be delivered, got %+v", group.Stimulus)
+	}
+	if len(group.Children) != 2 || group.Children[0].Position != 1 || group.Children[1].Position != 2 {
+		t.Fatalf("Expected 2 ordered children, got %+v", group.Children)
+	}
+	for _, option := range group.Children[0].AnswerOptions {
+		if option.IsCorrect {
+			t.Errorf("Expected correct option to be redacted")
+		}
+	}
+	if group.Children[1].TrueFalseAnswer != nil {
+		t.Errorf("Expected true/false answer to be redacted")
+	}
+
+	// 第一小题答对，第二小题答错
+	choiceID := strconv.Itoa(int(group.Children[0].ID))
+	trueFalseID := strconv.Itoa(int(group.Children[1].ID))
+	attemptBody, _ := json.Marshal(dto.QuestionAttemptRequest{
+		QuestionID: group.ID,
+		Answer: map[string]interface{}{
+			choiceID:    []uint{created.Data.Children[0].AnswerOptions[1].ID},
+			trueFalseID: false,
+		},
+	})
+	req = httptest.NewRequest(http.MethodPost, "/quiz/question_attempts", bytes.NewBuffer(attemptBody))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var attempt api.Response[dto.QuestionAttemptResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&attempt); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if attempt.Data.LastScore != 0.5 {
+		t.Errorf("Expected aggregated score 0.5, got %v", attempt.Data.LastScore)
+	}
+	if attempt.Data.ConsecutiveCorrect != 0 {
+		t.Errorf("Expected group to be graded wrong, got %v consecutive correct", attempt.Data.ConsecutiveCorrect)
+	}
+	if len(attempt.Data.Children) != 2 {
+		t.Fatalf("Expected 2 child results, got %v", len(attempt.Data.Children))
+	}
+	if attempt.Data.Children[0].ConsecutiveCorrect != 1 || attempt.Data.Children[1].Wrong != 1 {
+		t.Errorf("Expected per-child grading, got %+v", attempt.Data.Children)
+	}
+
+	// 小题也有独立的答题记录
+	childAttempt, err := handler.QuizService.GetQuestionAttempt(user.ID, group.Children[0].ID)
+	if err != nil || childAttempt == nil {
+		t.Fatalf("Expected child attempt record, got %v, %v", childAttempt, err)
+	}
+	// 编辑时带 ID 的小题原地更新并保留作答记录，只删除去掉的小题
+	router.HandleFunc("/quiz/questions/{id}", handler.UpdateQuestion).Methods("PUT")
+	requestBody, _ = json.Marshal(dto.UpdateQuestionRequest{
+		QuestionBankID: questionBank.ID,
+		Content:        "Read the passage again.",
+		QuestionType:   models.QuestionTypeGroup,
+		Stimulus:       &dto.Stimulus{Text: "Tom has a dog. The dog is brown."},
+		Children: []dto.ChildQuestionRequest{
+			{ID: 999, Content: "Tom has a dog.", QuestionType: models.QuestionTypeTrueFalse, TrueFalse: &trueValue},
+			{
+				ID:           group.Children[0].ID,
+				Content:      "What colour is the dog?",
+				QuestionType: models.QuestionTypeSingleChoice,
+				AnswerOptions: []dto.AnswerOption{
+					{OptionText: "Brown", IsCorrect: true},
+					{OptionText: "Black"},
+				},
+			},
+		},
+		AuthorID: user.ID,
+	})
+	req = httptest.NewRequest(http.MethodPut, "/quiz/questions/"+strconv.Itoa(int(group.ID)), bytes.NewBuffer(requestBody))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to update group question: %v %s", rr.Code, rr.Body)
+	}
+	updated, err := handler.QuizService.GetQuestionDetail(group.ID)
+	if err != nil || len(updated.Children) != 2 {
+		t.Fatalf("Expected 2 children after update, got %+v %v", updated, err)
+	}
+	var kept models.Question
+	for _, child := range updated.Children {
+		if child.Position == 2 {
+			kept = child
+		}
+	}
+	if kept.ID != group.Children[0].ID || kept.Content != "What colour is the dog?" || len(kept.AnswerOptions) != 2 {
+		t.Errorf("Expected existing child to be updated in place, got %+v", kept)
+	}
+	for _, child := range updated.Children {
+		if child.ID == group.Children[1].ID || child.ID == 999 {
+			t.Errorf("Expected removed child to be deleted and unknown ID to create a new child, got %d", child.ID)
+		}
+	}
+	if childAttempt, err := handler.QuizService.GetQuestionAttempt(user.ID, kept.ID); err != nil || childAttempt == nil {
+		t.Errorf("Expected attempt on the kept child to survive, got %v", err)
+	}
+}
+
+func TestGetQuestionDetailAnswerVisibility(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+
+	questionBank, err := handler.QuizService.CreateQuestionBank("Sample Bank", models.FeedbackFull)
+	if err != nil {
+		t.Fatalf("Failed to create question bank: %v", err)
+	}
+	learner, err := createTestUser(authService, "learner")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	author := models.User{BaseModel: models.BaseModel{ID: 100}, Roles: []models.Role{{Name: "admin"}}}
+
+	question, err := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID: questionBank.ID,
+		Content:        "What is 2+2?",
+		Explanation:    "2+2 equals 4.",
+		Difficulty:     2,
+		Tags:           []models.Tag{{Name: "addition"}},
+		QuestionType:   models.QuestionTypeSingleChoice,
+		AnswerOptions:  []models.AnswerOption{{OptionText: "3"}, {OptionText: "4", IsCorrect: true}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create question: %v", err)
+	}
+
+	getDetail := func(user models.User, view string) (int, dto.QuestionResponse) {
+		router := mux.NewRouter()
+		router.HandleFunc("/quiz/questions/{id}", withUser(user, handler.GetQuestionDetail)).Methods("GET")
+		url := "/quiz/questions/" + strconv.Itoa(int(question.ID))
+		if view != "" {
+			url += "?view=" + view
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+
+		var response api.Response[dto.QuestionResponse]
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr.Code, response.Data
+	}
+	hasCorrectOption := func(resp dto.QuestionResponse) bool {
+		for _, option := range resp.AnswerOptions {
+			if option.IsCorrect {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 学员默认看不到答案和解析
+	code, resp := getDetail(*learner, "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status code %v, got %v", http.StatusOK, code)
+	}
+	if hasCorrectOption(resp) || resp.Explanation != "" {
+		t.Errorf("Expected learner to get a redacted question, got %+v", resp)
+	}
+
+	// 学员不能请求出题人场景，未作答前也不能进入回顾场景
+	if code, _ := getDetail(*learner, "author"); code != http.StatusForbidden {
+		t.Errorf("Expected status code %v for author view, got %v", http.StatusForbidden, code)
+	}
+	if code, _ := getDetail(*learner, "review"); code != http.StatusForbidden {
+		t.Errorf("Expected status code %v for review before attempt, got %v", http.StatusForbidden, code)
+	}
+
+	// 作答后可以回顾答案和解析
+	if _, err := handler.QuizService.RecordQuestionAttempt(learner.ID, question.ID, []interface{}{float64(question.AnswerOptions[0].ID)}); err != nil {
+		t.Fatalf("Failed to record attempt: %v", err)
+	}
+	code, resp = getDetail(*learner, "review")
+	if code != http.StatusOK || !hasCorrectOption(resp) || resp.Explanation == "" {
+		t.Errorf("Expected review to include answers, got %v %+v", code, resp)
+	}
+
+	// 出题人默认看到完整题目，但在练习场景下同样不下发答案
+	_, resp = getDetail(author, "")
+	if !hasCorrectOption(resp) || resp.Explanation == "" {
+		t.Errorf("Expected author to get full question, got %+v", resp)
+	}
+	_, resp = getDetail(author, "practice")
+	if hasCorrectOption(resp) {
+		t.Errorf("Expected practice view to be redacted for authors, got %+v", resp)
+	}
+
+	// 考试场景还隐藏标签和难度，练习场景保留
+	_, resp = getDetail(*learner, "exam")
+	if hasCorrectOption(resp) || resp.Explanation != "" || len(resp.Tags) != 0 || resp.Difficulty != 0 {
+		t.Errorf("Expected exam view to hide answers, tags and difficulty, got %+v", resp)
+	}
+	_, resp = getDetail(*learner, "practice")
+	if len(resp.Tags) != 1 || resp.Difficulty != 2 {
+		t.Errorf("Expected practice view to keep tags and difficulty, got %+v", resp)
+	}
+
+	// 角色名含大写字母时同样按权限判断
+	authService.EnsurePermissionExists("quiz:edit", "")
+	editorRole, _ := authService.CreateRole("Editor")
+	permissions, _ := authService.GetPermissions()
+	for _, permission := range permissions {
+		if permission.Name == "quiz:edit" {
+			authService.UpdateRole(editorRole.ID, dto.RoleUpdateRequest{Name: "Editor", Permissions: []int{int(permission.ID)}})
+		}
+	}
+	editor := models.User{BaseModel: models.BaseModel{ID: 101}, Roles: []models.Role{{Name: "Editor"}}}
+	if code, resp := getDetail(editor, "author"); code != http.StatusOK || !hasCorrectOption(resp) {
+		t.Errorf("Expected role with capital letters to get the author view, got %v", code)
+	}
+
+	if code, _ := getDetail(author, "unknown"); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %v for unknown view, got %v", http.StatusBadRequest, code)
+	}
+}
+
+func TestReviewFollowsFeedbackMode(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.QuestionBank{}, &models.Question{}, &models.AnswerOption{},
+		&models.User{}, &models.Role{}, &models.Permission{}, &models.QuestionAttempt{}, &models.NotebookEntry{},
+		&models.XPEvent{}, &models.LearnerStats{}, &models.Badge{}, &models.UserBadge{},
+		&models.Assignment{}, &models.AssignmentQuestion{}, &models.AssignmentSubmission{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	handler := &api.QuizHandler{QuizService: services.NewQuizService(db), AuthService: authService}
+	learner, _ := createTestUser(authService, "learner")
+
+	bank, _ := handler.QuizService.CreateQuestionBank("Final exam", models.FeedbackNone)
+	question, err := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID: bank.ID,
+		Content:        "What is 2+2?",
+		Explanation:    "2+2 equals 4.",
+		QuestionType:   models.QuestionTypeSingleChoice,
+		AnswerOptions:  []models.AnswerOption{{OptionText: "3"}, {OptionText: "4", IsCorrect: true}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create question: %v", err)
+	}
+	review := func() int {
+		router := mux.NewRouter()
+		router.HandleFunc("/quiz/questions/{id}", withUser(*learner, handler.GetQuestionDetail)).Methods("GET")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/quiz/questions/%d?view=review", question.ID), nil))
+		return rr.Code
+	}
+
+	// 不反馈的题库随便作答一次也拿不到答案
+	if _, err := handler.QuizService.RecordQuestionAttempt(learner.ID, question.ID, []interface{}{float64(question.AnswerOptions[0].ID)}); err != nil {
+		t.Fatalf("Failed to record attempt: %v", err)
+	}
+	if code := review(); code != http.StatusForbidden {
+		t.Errorf("Expected review of a no-feedback bank to be forbidden, got %v", code)
+	}
+
+	// 包含该题的作业提交后仍可再提交时不能回顾，截止后可以
+	assignment := models.Assignment{ClassID: 1, Title: "Exam", OpenAt: time.Now().Add(-time.Hour), DueAt: time.Now().Add(time.Hour),
+		Questions: []models.AssignmentQuestion{{QuestionID: question.ID}}}
+	db.Create(&assignment)
+	db.Create(&models.AssignmentSubmission{AssignmentID: assignment.ID, UserID: learner.ID, Attempt: 1, SubmittedAt: time.Now()})
+	if code := review(); code != http.StatusForbidden {
+		t.Errorf("Expected review to wait until the assignment closes, got %v", code)
+	}
+	db.Model(&assignment).Update("due_at", time.Now().Add(-time.Minute))
+	if code := review(); code != http.StatusOK {
+		t.Errorf("Expected review after the assignment closed, got %v", code)
+	}
+}
+
+func TestRecordQuestionAttemptFeedback(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/quiz/question_banks/{id}", handler.UpdateQuestionBank).Methods("PUT")
+	router.HandleFunc("/quiz/question_banks/{id}/questions", handler.CreateQuestion).Methods("POST")
+
+	user, err := createTestUser(authService, "student")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	router.HandleFunc("/quiz/question_attempts", withUser(*user, handler.RecordQuestionAttempt)).Methods("POST")
+	questionBank, err := handler.QuizService.CreateQuestionBank("Geography", models.FeedbackFull)
+	if err != nil {
+		t.Fatalf("Failed to create question bank: %v", err)
+	}
+	bankURL := "/quiz/question_banks/" + strconv.Itoa(int(questionBank.ID))
+
+	createQuestion := func(req dto.CreateQuestionRequest) dto.QuestionResponse {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, bankURL+"/questions", bytes.NewBuffer(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create question, status code: %v, body: %s", w.Code, w.Body.String())
+		}
+		var resp api.Response[dto.QuestionResponse]
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode create question response: %v", err)
+		}
+		return resp.Data
+	}
+	attempt := func(questionID uint, answer interface{}) dto.QuestionAttemptResponse {
+		body, _ := json.Marshal(dto.QuestionAttemptRequest{QuestionID: questionID, Answer: answer})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/quiz/question_attempts", bytes.NewBuffer(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to record attempt, status code: %v, body: %s", w.Code, w.Body.String())
+		}
+		var resp api.Response[dto.QuestionAttemptResponse]
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode attempt response: %v", err)
+		}
+		return resp.Data
+	}
+
+	related := createQuestion(dto.CreateQuestionRequest{
+		Content:      "Is Berlin the capital of Germany?",
+		QuestionType: models.QuestionTypeTrueFalse,
+		TrueFalse:    new(bool),
+	})
+	question := createQuestion(dto.CreateQuestionRequest{
+		Content:      "___ is the capital of ___.",
+		QuestionType: models.QuestionTypeFillInTheBlank,
+		Explanation:  "Paris has been the capital of France since 987.",
+		Blanks:       []dto.FillInTheBlankAnswer{{BlankText: "Paris"}, {BlankText: "France"}},
+		RelatedIDs:   []uint{related.ID},
+	})
+
+	// 完整反馈：对错、每空结果、正确答案、解析和不含答案的相关题目
+	feedback := attempt(question.ID, []string{"Paris", "Spain"}).Feedback
+	if feedback == nil {
+		t.Fatalf("Expected feedback in full mode")
+	}
+	if feedback.Correct || feedback.Score != 0.5 {
+		t.Errorf("Expected half score, got correct=%v score=%v", feedback.Correct, feedback.Score)
+	}
+	if len(feedback.BlankResults) != 2 || !feedback.BlankResults[0] || feedback.BlankResults[1] {
+		t.Errorf("Expected blank results [true false], got %v", feedback.BlankResults)
+	}
+	if feedback.Question == nil || feedback.Question.Explanation == "" ||
+		len(feedback.Question.FillInTheBlanks) != 2 || feedback.Question.FillInTheBlanks[1].BlankText != "France" {
+		t.Errorf("Expected correct blanks and explanation, got %+v", feedback.Question)
+	}
+	if len(feedback.RelatedQuestions) != 1 || feedback.RelatedQuestions[0].ID != related.ID {
+		t.Fatalf("Expected related question %v, got %+v", related.ID, feedback.RelatedQuestions)
+	}
+	if feedback.RelatedQuestions[0].TrueFalseAnswer != nil {
+		t.Errorf("Expected related question answers to be hidden")
+	}
+
+	updateBank := func(mode models.FeedbackMode) {
+		body, _ := json.Marshal(dto.UpdateQuestionBankRequest{Name: "Geography", FeedbackMode: mode})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, bankURL, bytes.NewBuffer(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to update question bank, status code: %v", w.Code)
+		}
+	}
+
+	// 只反馈对错时不下发答案和解析
+	updateBank(models.FeedbackCorrectness)
+	feedback = attempt(question.ID, []string{"Paris", "France"}).Feedback
+	if feedback == nil || !feedback.Correct {
+		t.Fatalf("Expected correctness feedback, got %+v", feedback)
+	}
+	if feedback.Question != nil || feedback.RelatedQuestions != nil {
+		t.Errorf("Expected answers to be withheld, got %+v", feedback)
+	}
+
+	// 考试题库不返回任何反馈
+	updateBank(models.FeedbackNone)
+	result := attempt(question.ID, []string{"Paris", "France"})
+	if result.Feedback != nil {
+		t.Errorf("Expected no feedback in exam mode, got %+v", result.Feedback)
+	}
+	// 答对次数、得分和经验值同样能推断对错，也不返回
+	if result.Attempts == 0 || result.ConsecutiveCorrect != 0 || result.LastScore != 0 || result.XP != 0 {
+		t.Errorf("Expected grading to be withheld in exam mode, got %+v", result)
+	}
+	router.HandleFunc("/quiz/question_banks/{id}/attempts", withUser(*user, handler.GetMyQuestionAttempts)).Methods("GET")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, bankURL+"/attempts", nil))
+	var mine api.Response[[]dto.QuestionAttemptResponse]
+	json.NewDecoder(w.Body).Decode(&mine)
+	for _, attempt := range mine.Data {
+		if attempt.Wrong != 0 || attempt.ConsecutiveCorrect != 0 || attempt.LastScore != 0 {
+			t.Errorf("Expected own attempts in exam mode to omit grading, got %+v", attempt)
+		}
+	}
+}
+
+func TestRecordQuestionAttemptBatch(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+	user, _ := createTestUser(authService, "student")
+	do := newUserRouter(map[string]models.User{"student": *user}, handler)
+
+	questionBank, _ := handler.QuizService.CreateQuestionBank("Math", models.FeedbackCorrectness)
+	newQuestion := func(isTrue bool) uint {
+		question, err := handler.QuizService.CreateQuestion(models.Question{
+			QuestionBankID:  questionBank.ID,
+			Content:         "Is it true?",
+			QuestionType:    models.QuestionTypeTrueFalse,
+			TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: isTrue},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create question: %v", err)
+		}
+		return question.ID
+	}
+	q1, q2, q3 := newQuestion(true), newQuestion(false), newQuestion(true)
+
+	w := do("student", http.MethodPost, "/quiz/question_attempts/batch", dto.BatchAttemptRequest{Answers: []dto.BatchAnswer{
+		{QuestionID: q1, Answer: true},
+		{QuestionID: q2, Answer: true},
+		{QuestionID: q3, Answer: "yes"},
+		{QuestionID: 9999, Answer: true},
+		{QuestionID: q1, Answer: false},
+	}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to submit batch: %v %s", w.Code, w.Body)
+	}
+	var resp api.Response[dto.BatchAttemptResponse]
+	json.NewDecoder(w.Body).Decode(&resp)
+	batch := resp.Data
+	if batch.Total != 5 || batch.Graded != 2 || batch.Correct != 1 || batch.Score != 1 || batch.Percentage != 20 {
+		t.Errorf("Unexpected aggregate: %+v", batch)
+	}
+	codes := []string{}
+	for _, result := range batch.Results {
+		codes = append(codes, result.ErrorCode)
+	}
+	expected := []string{"", "", api.AttemptErrorInvalidAnswer, api.AttemptErrorQuestionNotFound, api.AttemptErrorDuplicate}
+	if strings.Join(codes, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected error codes %v, got %v", expected, codes)
+	}
+	if attempt := batch.Results[1].Attempt; attempt == nil || attempt.Feedback == nil || attempt.Feedback.Correct {
+		t.Errorf("Expected feedback for the wrong answer, got %+v", attempt)
+	}
+
+	// 出错的题不记录，其余题目照常记录
+	if attempt, _ := handler.QuizService.GetQuestionAttempt(user.ID, q1); attempt == nil || attempt.Attempts != 1 {
+		t.Errorf("Expected one attempt for the first question, got %+v", attempt)
+	}
+	if attempt, _ := handler.QuizService.GetQuestionAttempt(user.ID, q3); attempt != nil {
+		t.Errorf("Expected invalid answer not to be recorded, got %+v", attempt)
+	}
+
+	// 不反馈的题库逐题和汇总都不下发得分
+	exam, _ := handler.QuizService.CreateQuestionBank("Final", models.FeedbackNone)
+	examQuestion, _ := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  exam.ID,
+		Content:         "Is it true?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	w = do("student", http.MethodPost, "/quiz/question_attempts/batch", dto.BatchAttemptRequest{Answers: []dto.BatchAnswer{
+		{QuestionID: q2, Answer: false},
+		{QuestionID: examQuestion.ID, Answer: true},
+	}})
+	var examResp api.Response[dto.BatchAttemptResponse]
+	json.NewDecoder(w.Body).Decode(&examResp)
+	batch = examResp.Data
+	if batch.Graded != 1 || batch.Withheld != 1 || batch.Correct != 1 || batch.Score != 1 || batch.Percentage != 100 {
+		t.Errorf("Expected the exam question to stay out of the aggregate, got %+v", batch)
+	}
+	if attempt := batch.Results[1].Attempt; attempt == nil || attempt.Feedback != nil || attempt.LastScore != 0 || attempt.ConsecutiveCorrect != 0 {
+		t.Errorf("Expected exam question grading to be withheld, got %+v", attempt)
+	}
+
+	if w := do("student", http.MethodPost, "/quiz/question_attempts/batch", dto.BatchAttemptRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected empty batch to be rejected, got %v", w.Code)
+	}
+}
+
+func TestQuestionAttemptsAreScopedToUser(t *testing.T) {
+	handler, authService, err := setupTestQuizHandler()
+	if err != nil {
+		t.Fatalf("Failed to setup quiz handler: %v", err)
+	}
+	// 通过真实的路由、令牌和 Casbin 权限检查发起请求
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(handler); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	grant := func(roleName string, names ...string) {
+		role, err := authService.CreateRole(roleName)
+		if err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+		req := dto.RoleUpdateRequest{Name: roleName}
+		for _, permission := range permissions {
+			if slices.Contains(names, permission.Name) {
+				req.Permissions = append(req.Permissions, int(permission.ID))
+			}
+		}
+		if err := authService.UpdateRole(role.ID, req); err != nil {
+			t.Fatalf("Failed to grant permissions: %v", err)
+		}
+	}
+	grant("student", "quiz:attempt")
+	grant("teacher", "quiz:attempt", "quiz:read_all_attempts")
+
+	tokens := map[string]string{}
+	users := map[string]models.User{}
+	for name, role := range map[string]string{"alice": "student", "bob": "student", "teacher": "teacher"} {
+		user, err := authService.CreateUser(name, "password", []string{role}, models.StatusActive)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users[name] = user
+		tokens[name], _ = authService.GenerateAccessToken(user)
+	}
+	do := func(as, method, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tokens[as])
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	attempts := func(w *httptest.ResponseRecorder) []dto.QuestionAttemptResponse {
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to get attempts: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[[]dto.QuestionAttemptResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	questionBank, _ := handler.QuizService.CreateQuestionBank("Math", models.FeedbackFull)
+	question, _ := handler.QuizService.CreateQuestion(models.Question{
+		QuestionBankID:  questionBank.ID,
+		Content:         "Is 7 prime?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+
+	// 请求体中的 user_id 被忽略，作答总是记在登录用户名下
+	body := fmt.Sprintf(`{"user_id": %d, "question_id": %d, "answer": false}`, users["bob"].ID, question.ID)
+	if w := do("alice", http.MethodPost, "/quiz/question_attempts", body); w.Code != http.StatusOK {
+		t.Fatalf("Failed to record attempt: %v %s", w.Code, w.Body)
+	}
+	if attempt, _ := handler.QuizService.GetQuestionAttempt(users["bob"].ID, question.ID); attempt != nil {
+		t.Errorf("Expected no attempt recorded for bob, got %+v", attempt)
+	}
+
+	mine := fmt.Sprintf("/quiz/question_banks/%d/attempts", questionBank.ID)
+	if got := attempts(do("alice", http.MethodGet, mine, "")); len(got) != 1 {
+		t.Errorf("Expected alice to see her attempt, got %+v", got)
+	}
+	if got := attempts(do("bob", http.MethodGet, mine, "")); len(got) != 0 {
+		t.Errorf("Expected bob not to see alice's attempt, got %+v", got)
+	}
+
+	// 查看其他用户的答题情况需要 quiz:read_all_attempts 权限
+	aliceAttempts := fmt.Sprintf("/quiz/question_attempts/%d/%d", users["alice"].ID, questionBank.ID)
+	if w := do("bob", http.MethodGet, aliceAttempts, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected bob to be forbidden, got %v", w.Code)
+	}
+	if got := attempts(do("teacher", http.MethodGet, aliceAttempts, "")); len(got) != 1 {
+		t.Errorf("Expected teacher to see alice's attempt, got %+v", got)
+	}
+	bobAttempts := fmt.Sprintf("/quiz/question_attempts/%d/%d", users["bob"].ID, questionBank.ID)
+	if got := attempts(do("teacher", http.MethodGet, bobAttempts, "")); len(got) != 0 {
+		t.Errorf("Expected bob to have no attempts, got %+v", got)
+	}
+}
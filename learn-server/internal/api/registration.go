@@ -0,0 +1,211 @@
+// api/registration.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// VerifyEmail 使用验证邮件中的令牌验证邮箱
+// @Summary 验证邮箱
+// @Description 令牌只能使用一次。邮箱域名匹配自动通过规则时注册申请同时通过；申请已通过时账号随即启用
+// @Tags Auth
+// @Accept  json
+// @Param request body dto.VerifyEmailRequest true "验证令牌"
+// @Success 204 "验证成功"
+// @Failure 400 {object} ErrorResponse "令牌无效或已过期"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/register/verify_email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.VerifyEmailRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.VerifyEmail(req.Token, clientIP(r)); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to verify email: %v", err)
+		Error(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendEmailVerification 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 为避免泄露账号是否存在，无论账号是否存在都返回 202；一分钟内只发送一次
+// @Tags Auth
+// @Accept  json
+// @Param request body dto.ResendVerificationRequest true "用户名或邮箱"
+// @Success 202 "已受理"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Failure 503 {object} ErrorResponse "未配置邮件发送"
+// @Router /auth/register/resend_verification [post]
+func (h *AuthHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	req, ok := DecodeJSONBody[dto.ResendVerificationRequest](w, r)
+	if !ok {
+		return
+	}
+	if err := h.AuthService.ResendEmailVerification(req.Login); err != nil {
+		if errors.Is(err, services.ErrVerificationUnavailable) {
+			Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		log.Printf("Failed to resend verification email: %v", err)
+		Error(w, "Failed to resend verification email", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// GetRegistrations 查询注册申请
+// @Summary 查询注册申请
+// @Description 按提交顺序分页返回注册申请，默认只返回待审核的申请
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param status query string false "pending（默认）、approved、rejected 或 all"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response[[]dto.RegistrationResponse] "获取成功"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /registrations [get]
+func (h *AuthHandler) GetRegistrations(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := GetPaginationParams(r)
+	filter := services.RegistrationFilter{Status: r.URL.Query().Get("status")}
+	switch filter.Status {
+	case "":
+		filter.Status = models.RegistrationPending
+	case "all":
+		filter.Status = ""
+	}
+	registrations, total, err := h.AuthService.GetRegistrations(filter, page, pageSize)
+	if err != nil {
+		Error(w, "Failed to retrieve registrations", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]dto.RegistrationResponse, len(registrations))
+	for i, registration := range registrations {
+		resp[i] = toRegistrationResponse(registration)
+	}
+	Success(w, resp, &PaginationMeta{TotalRecords: total, CurrentPage: page, PageSize: pageSize}, http.StatusOK)
+}
+
+// ApproveRegistration 通过注册申请
+// @Summary 通过注册申请
+// @Description 通过申请并授予角色，并通过邮件通知用户。需要验证邮箱但尚未验证时，账号在验证后启用
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "申请 ID"
+// @Param request body dto.ApproveRegistrationRequest true "授予的角色"
+// @Success 200 {object} Response[dto.RegistrationResponse] "审核成功"
+// @Failure 400 {object} ErrorResponse "无效请求或角色不存在"
+// @Failure 404 {object} ErrorResponse "申请不存在"
+// @Failure 409 {object} ErrorResponse "申请已经审核过"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /registrations/{id}/approve [post]
+func (h *AuthHandler) ApproveRegistration(w http.ResponseWriter, r *http.Request) {
+	reviewer, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid registration ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.ApproveRegistrationRequest](w, r)
+	if !ok {
+		return
+	}
+	registration, err := h.AuthService.ApproveRegistration(id, req.Roles, reviewer, clientIP(r))
+	if err != nil {
+		writeRegistrationError(w, err)
+		return
+	}
+	Success(w, toRegistrationResponse(registration), nil, http.StatusOK)
+}
+
+// RejectRegistration 拒绝注册申请
+// @Summary 拒绝注册申请
+// @Description 拒绝申请，账号保持停用，并通过邮件把原因告知用户
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "申请 ID"
+// @Param request body dto.RejectRegistrationRequest true "拒绝原因"
+// @Success 200 {object} Response[dto.RegistrationResponse] "审核成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "申请不存在"
+// @Failure 409 {object} ErrorResponse "申请已经审核过"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /registrations/{id}/reject [post]
+func (h *AuthHandler) RejectRegistration(w http.ResponseWriter, r *http.Request) {
+	reviewer, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid registration ID", http.StatusBadRequest)
+		return
+	}
+	req, ok := DecodeJSONBody[dto.RejectRegistrationRequest](w, r)
+	if !ok {
+		return
+	}
+	if len(req.Reason) > 500 {
+		Error(w, "Reason is too long", http.StatusBadRequest)
+		return
+	}
+	registration, err := h.AuthService.RejectRegistration(id, req.Reason, reviewer, clientIP(r))
+	if err != nil {
+		writeRegistrationError(w, err)
+		return
+	}
+	Success(w, toRegistrationResponse(registration), nil, http.StatusOK)
+}
+
+func writeRegistrationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		Error(w, "Registration not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrRegistrationNotPending):
+		Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrUnknownRole):
+		Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Failed to review registration: %v", err)
+		Error(w, "Failed to review registration", http.StatusInternalServerError)
+	}
+}
+
+func toRegistrationResponse(registration models.Registration) dto.RegistrationResponse {
+	return dto.RegistrationResponse{
+		ID:            registration.ID,
+		UserID:        registration.UserID,
+		Username:      registration.User.Username,
+		Email:         stringValue(registration.User.Email),
+		EmailVerified: registration.User.EmailVerifiedAt != nil,
+		Status:        registration.Status,
+		ApprovedBy:    registration.ApprovedBy,
+		Reason:        registration.Reason,
+		ReviewerID:    registration.ReviewerID,
+		CreatedAt:     registration.CreatedAt,
+		DecidedAt:     registration.DecidedAt,
+	}
+}
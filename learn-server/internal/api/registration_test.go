@@ -0,0 +1,209 @@
+// api/registration_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegistrationWorkflow(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	port, mails := startSMTPCapture(t)
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	authService.Notifier = &services.SMTPNotifier{Host: "127.0.0.1", Port: port, From: "noreply@example.edu"}
+	authService.Registration = services.RegistrationSettings{
+		RequireEmailVerification:  true,
+		VerifyEmailURL:            "https://learn.example.edu/verify",
+		VerificationTokenDuration: time.Hour,
+		AutoApprove: []services.AutoApproveRule{
+			{InviteCode: "TEACH-2025", Roles: []string{"teacher"}},
+			{EmailDomain: "students.example.edu", Roles: []string{"student"}},
+		},
+	}
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	for _, name := range []string{"admin", "teacher", "student"} {
+		authService.CreateRole(name)
+	}
+	authService.CreateUser("root", "password", []string{"admin"}, models.StatusActive)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username string) (int, string) {
+		w := send(http.MethodPost, "/auth/login", "", dto.LoginRequest{Username: username, Password: "password"})
+		var resp api.Response[dto.TokenPairResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data.AccessToken
+	}
+	register := func(req dto.RegisterUserRequest) (int, dto.RegisterUserResponse) {
+		req.Password = "password"
+		w := send(http.MethodPost, "/auth/register", "", req)
+		var resp api.Response[dto.RegisterUserResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+	nextMail := func(to string) capturedMail {
+		t.Helper()
+		select {
+		case message := <-mails:
+			if message.To != to {
+				t.Fatalf("Expected email to %s, got %+v", to, message)
+			}
+			return message
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for email to %s", to)
+			return capturedMail{}
+		}
+	}
+	verificationToken := func(to string) string {
+		t.Helper()
+		message := nextMail(to)
+		_, rest, found := strings.Cut(message.Body, "token=")
+		if message.Subject != "验证邮箱" || !found {
+			t.Fatalf("Expected verification email, got %+v", message)
+		}
+		token, _ := url.QueryUnescape(strings.Fields(rest)[0])
+		return token
+	}
+	verify := func(token string) int {
+		return send(http.MethodPost, "/auth/register/verify_email", "", dto.VerifyEmailRequest{Token: token}).Code
+	}
+	roles := func(username string) []string {
+		var user models.User
+		db.Preload("Roles").Where("username = ?", username).First(&user)
+		return user.GetRoles()
+	}
+	_, rootToken := login("root")
+
+	if code, _ := register(dto.RegisterUserRequest{Username: "nomail"}); code != http.StatusBadRequest {
+		t.Errorf("Expected email to be required, got %v", code)
+	}
+	if code, _ := register(dto.RegisterUserRequest{Username: "badcode", Email: "badcode@example.com", InviteCode: "WRONG"}); code != http.StatusBadRequest {
+		t.Errorf("Expected invalid invite code to be rejected, got %v", code)
+	}
+
+	// 人工审核：审核通过后仍需验证邮箱才能登录
+	code, resp := register(dto.RegisterUserRequest{Username: "dave", Email: "dave@example.com"})
+	if code != http.StatusCreated || resp.Status != "pending" || resp.Approved || !resp.EmailVerificationRequired {
+		t.Fatalf("Unexpected registration result: %v %+v", code, resp)
+	}
+	daveToken := verificationToken("dave@example.com")
+	if code, _ := login("dave"); code != http.StatusUnauthorized {
+		t.Errorf("Expected pending user to be rejected, got %v", code)
+	}
+	w := send(http.MethodGet, "/registrations", rootToken, nil)
+	var queue api.Response[[]dto.RegistrationResponse]
+	json.NewDecoder(w.Body).Decode(&queue)
+	if w.Code != http.StatusOK || len(queue.Data) != 1 || queue.Data[0].Username != "dave" || queue.Data[0].EmailVerified {
+		t.Fatalf("Expected dave in the approval queue, got %v %+v", w.Code, queue)
+	}
+	dave := queue.Data[0]
+	if w := send(http.MethodPost, fmt.Sprintf("/registrations/%d/approve", dave.ID), rootToken, dto.ApproveRegistrationRequest{Roles: []string{"student"}}); w.Code != http.StatusOK {
+		t.Fatalf("Failed to approve registration: %v %s", w.Code, w.Body)
+	}
+	if message := nextMail("dave@example.com"); message.Subject != "注册申请已通过" || !strings.Contains(message.Body, "验证邮箱") {
+		t.Errorf("Expected approval notice asking for verification, got %+v", message)
+	}
+	if code, _ := login("dave"); code != http.StatusUnauthorized {
+		t.Errorf("Expected unverified user to be rejected, got %v", code)
+	}
+	if code := verify(daveToken); code != http.StatusNoContent {
+		t.Fatalf("Failed to verify email: %v", code)
+	}
+	if code, _ := login("dave"); code != http.StatusOK || !slices.Equal(roles("dave"), []string{"student"}) {
+		t.Errorf("Expected dave to log in as student, got %v %v", code, roles("dave"))
+	}
+	if code := verify(daveToken); code != http.StatusBadRequest {
+		t.Errorf("Expected verification token to be single use, got %v", code)
+	}
+	if w := send(http.MethodPost, fmt.Sprintf("/registrations/%d/approve", dave.ID), rootToken, dto.ApproveRegistrationRequest{}); w.Code != http.StatusConflict {
+		t.Errorf("Expected decided registration to conflict, got %v", w.Code)
+	}
+
+	// 邮箱域名匹配时验证后自动通过
+	register(dto.RegisterUserRequest{Username: "erin", Email: "Erin@Students.Example.edu"})
+	if code := verify(verificationToken("erin@students.example.edu")); code != http.StatusNoContent {
+		t.Fatalf("Failed to verify email: %v", code)
+	}
+	if code, _ := login("erin"); code != http.StatusOK || !slices.Equal(roles("erin"), []string{"student"}) {
+		t.Errorf("Expected erin to be approved by domain, got %v %v", code, roles("erin"))
+	}
+
+	// 邀请码立即通过，验证邮箱后启用
+	code, resp = register(dto.RegisterUserRequest{Username: "frank", Email: "frank@example.com", InviteCode: "TEACH-2025"})
+	if code != http.StatusCreated || !resp.Approved || resp.Status != "pending" {
+		t.Fatalf("Expected invite code to approve registration, got %v %+v", code, resp)
+	}
+	if code := verify(verificationToken("frank@example.com")); code != http.StatusNoContent {
+		t.Fatalf("Failed to verify email: %v", code)
+	}
+	if code, _ := login("frank"); code != http.StatusOK || !slices.Equal(roles("frank"), []string{"teacher"}) {
+		t.Errorf("Expected frank to log in as teacher, got %v %v", code, roles("frank"))
+	}
+
+	// 拒绝申请并通知原因；角色不存在时不做任何修改
+	register(dto.RegisterUserRequest{Username: "gina", Email: "gina@example.com"})
+	verificationToken("gina@example.com")
+	var gina models.Registration
+	db.Joins("User").Where("username = ?", "gina").First(&gina)
+	if w := send(http.MethodPost, fmt.Sprintf("/registrations/%d/approve", gina.ID), rootToken, dto.ApproveRegistrationRequest{Roles: []string{"wizard"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown role to be rejected, got %v", w.Code)
+	}
+	w = send(http.MethodPost, fmt.Sprintf("/registrations/%d/reject", gina.ID), rootToken, dto.RejectRegistrationRequest{Reason: "不是本校学生"})
+	var rejected api.Response[dto.RegistrationResponse]
+	json.NewDecoder(w.Body).Decode(&rejected)
+	if w.Code != http.StatusOK || rejected.Data.Status != models.RegistrationRejected || rejected.Data.Reason != "不是本校学生" {
+		t.Fatalf("Failed to reject registration: %v %s", w.Code, w.Body)
+	}
+	if message := nextMail("gina@example.com"); message.Subject != "注册申请未通过" || !strings.Contains(message.Body, "不是本校学生") {
+		t.Errorf("Expected rejection notice with reason, got %+v", message)
+	}
+	if code, _ := login("gina"); code != http.StatusUnauthorized {
+		t.Errorf("Expected rejected user to be rejected, got %v", code)
+	}
+
+	var audits int64
+	db.Model(&models.AuditLog{}).Where("action IN ?", []string{services.AuditRegistrationApproved, services.AuditRegistrationRejected}).Count(&audits)
+	if audits != 4 {
+		t.Errorf("Expected every decision to be audited, got %d", audits)
+	}
+	w = send(http.MethodGet, "/registrations?status=all", rootToken, nil)
+	json.NewDecoder(w.Body).Decode(&queue)
+	if queue.Meta == nil || queue.Meta.TotalRecords != 4 {
+		t.Errorf("Expected all registrations to be listed, got %+v", queue.Meta)
+	}
+	w = send(http.MethodGet, "/registrations", rootToken, nil)
+	queue = api.Response[[]dto.RegistrationResponse]{}
+	json.NewDecoder(w.Body).Decode(&queue)
+	if len(queue.Data) != 0 {
+		t.Errorf("Expected empty approval queue, got %+v", queue.Data)
+	}
+}
@@ -0,0 +1,141 @@
+// api/session.go
+package api
+
+import (
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GetMySessions 获取当前用户的登录设备
+// @Summary 获取我的登录设备
+// @Description 列出当前用户仍然有效的登录会话，最近活动的排在前面，current 标记发起请求的会话
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} Response[[]dto.SessionResponse] "获取成功"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/sessions [get]
+func (h *AuthHandler) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.writeSessions(w, r, user.ID)
+}
+
+// RevokeMySession 注销当前用户的某个登录设备
+// @Summary 注销我的登录设备
+// @Description 吊销当前用户的某个会话，该设备的 Access Token 和 Refresh Token 立即失效
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Param id path int true "会话 ID"
+// @Success 204 "注销成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 401 {object} ErrorResponse "未登录"
+// @Failure 404 {object} ErrorResponse "会话不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	user, ok := CurrentUser(r)
+	if !ok {
+		Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+	h.revokeSession(w, user.ID, sessionID)
+}
+
+// GetUserSessions 获取用户的登录设备
+// @Summary 获取用户的登录设备
+// @Description 列出用户仍然有效的登录会话
+// @Tags User
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} Response[[]dto.SessionResponse] "获取成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/sessions [get]
+func (h *AuthHandler) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	h.writeSessions(w, r, userID)
+}
+
+// RevokeUserSession 踢出用户的某个登录设备
+// @Summary 踢出用户的某个登录设备
+// @Description 吊销用户的某个会话，不影响用户在其他设备上的登录
+// @Tags User
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Param session_id path int true "会话 ID"
+// @Success 204 "吊销成功"
+// @Failure 400 {object} ErrorResponse "无效请求"
+// @Failure 404 {object} ErrorResponse "会话不存在"
+// @Failure 500 {object} ErrorResponse "内部服务器错误"
+// @Router /users/{id}/sessions/{session_id} [delete]
+func (h *AuthHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ParseUintParam(r, "id")
+	if !ok {
+		Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	sessionID, ok := ParseUintParam(r, "session_id")
+	if !ok {
+		Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+	h.revokeSession(w, userID, sessionID)
+}
+
+func (h *AuthHandler) writeSessions(w http.ResponseWriter, r *http.Request, userID uint) {
+	sessions, err := h.AuthService.GetUserSessions(userID)
+	if err != nil {
+		Error(w, "Failed to get sessions", http.StatusInternalServerError)
+		return
+	}
+	currentID, _ := CurrentSessionID(r)
+	resp := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		resp[i] = newSessionResponse(session, currentID)
+	}
+	Success(w, resp, nil, http.StatusOK)
+}
+
+func (h *AuthHandler) revokeSession(w http.ResponseWriter, userID, sessionID uint) {
+	if err := h.AuthService.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newSessionResponse(session models.Session, currentID uint) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:         session.ID,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentID,
+	}
+}
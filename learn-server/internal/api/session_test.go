@@ -0,0 +1,139 @@
+// api/session_test.go
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"learn/internal/api"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/routes"
+	"learn/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSessions(t *testing.T) {
+	db, err := setupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup database: %v", err)
+	}
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
+	router := mux.NewRouter()
+	if err := routes.NewRoutesRegister(router, authService).RegisterRoutes(&api.AuthHandler{AuthService: authService}); err != nil {
+		t.Fatalf("Failed to register routes: %v", err)
+	}
+	permissions, _ := authService.GetPermissions()
+	grant := func(roleName string, names ...string) {
+		role, _ := authService.CreateRole(roleName)
+		req := dto.RoleUpdateRequest{Name: roleName}
+		for _, permission := range permissions {
+			if slices.Contains(names, permission.Name) {
+				req.Permissions = append(req.Permissions, int(permission.ID))
+			}
+		}
+		if err := authService.UpdateRole(role.ID, req); err != nil {
+			t.Fatalf("Failed to grant permissions: %v", err)
+		}
+	}
+	grant("member", "auth:sessions")
+	grant("admin", "auth:sessions", "users:read", "users:logout")
+	users := map[string]models.User{}
+	for name, role := range map[string]string{"alice": "member", "bob": "member", "root": "admin"} {
+		users[name], _ = authService.CreateUser(name, "password", []string{role}, models.StatusActive)
+	}
+
+	send := func(method, path, token, userAgent string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(name, device string) dto.TokenPairResponse {
+		w := send(http.MethodPost, "/auth/login", "", device+"-agent", dto.LoginRequest{Username: name, Password: "password", DeviceName: device})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to login: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[dto.TokenPairResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+	sessions := func(token, path string) []dto.SessionResponse {
+		w := send(http.MethodGet, path, token, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to list sessions: %v %s", w.Code, w.Body)
+		}
+		var resp api.Response[[]dto.SessionResponse]
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	phone := login("alice", "phone")
+	laptop := login("alice", "laptop")
+	bob := login("bob", "tablet")
+	root := login("root", "desktop")
+
+	// 刷新令牌会更新会话的设备信息
+	w := send(http.MethodPost, "/auth/refresh", "", "phone-agent/2", dto.RefreshTokenRequest{Token: phone.RefreshToken})
+	var refreshed api.Response[dto.TokenPairResponse]
+	json.NewDecoder(w.Body).Decode(&refreshed)
+	phone = refreshed.Data
+
+	list := sessions(phone.AccessToken, "/auth/sessions")
+	if len(list) != 2 || list[0].DeviceName != "phone" || !list[0].Current || list[0].UserAgent != "phone-agent/2" ||
+		list[1].DeviceName != "laptop" || list[1].Current || list[1].IP == "" {
+		t.Fatalf("Unexpected sessions: %+v", list)
+	}
+	laptopID := list[1].ID
+
+	// 不能注销别人的会话
+	if w := send(http.MethodDelete, fmt.Sprintf("/auth/sessions/%d", laptopID), bob.AccessToken, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected other user's session to be hidden, got %v", w.Code)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("/auth/sessions/%d", laptopID), phone.AccessToken, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to revoke session: %v %s", w.Code, w.Body)
+	}
+	// 被注销设备的 Access Token 和 Refresh Token 立即失效，其他设备不受影响
+	if w := send(http.MethodGet, "/auth/sessions", laptop.AccessToken, "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked session's access token to be rejected, got %v", w.Code)
+	}
+	if w := send(http.MethodPost, "/auth/refresh", "", "", dto.RefreshTokenRequest{Token: laptop.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked session's refresh token to be rejected, got %v", w.Code)
+	}
+	if list := sessions(phone.AccessToken, "/auth/sessions"); len(list) != 1 {
+		t.Errorf("Expected 1 remaining session, got %+v", list)
+	}
+
+	// 管理员踢出指定会话
+	adminPath := fmt.Sprintf("/users/%d/sessions", users["alice"].ID)
+	list = sessions(root.AccessToken, adminPath)
+	if len(list) != 1 || list[0].Current {
+		t.Fatalf("Unexpected sessions for admin: %+v", list)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("/users/%d/sessions/%d", users["bob"].ID, list[0].ID), root.AccessToken, "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected mismatched user to be rejected, got %v", w.Code)
+	}
+	if w := send(http.MethodDelete, fmt.Sprintf("%s/%d", adminPath, list[0].ID), root.AccessToken, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Failed to revoke session as admin: %v %s", w.Code, w.Body)
+	}
+	if w := send(http.MethodGet, "/auth/sessions", phone.AccessToken, "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked session to be rejected, got %v", w.Code)
+	}
+	if list := sessions(bob.AccessToken, "/auth/sessions"); len(list) != 1 {
+		t.Errorf("Expected other users' sessions to be kept, got %+v", list)
+	}
+	if w := send(http.MethodGet, adminPath, bob.AccessToken, "", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected member to be forbidden from admin endpoint, got %v", w.Code)
+	}
+}
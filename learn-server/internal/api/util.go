@@ -3,6 +3,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"learn/internal/consts/contextkeys"
+	"learn/internal/models"
+	"learn/internal/services"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -98,3 +104,47 @@ func parseQueryParamInt(r *http.Request, key string, defaultValue int) int {
 	}
 	return value
 }
+
+// CurrentUser returns the authenticated user stored in the request context by AuthMiddleware.
+func CurrentUser(r *http.Request) (models.User, bool) {
+	user, ok := r.Context().Value(contextkeys.User).(models.User)
+	return user, ok
+}
+
+// CurrentSessionID returns the session bound to the request's access token, if any.
+func CurrentSessionID(r *http.Request) (uint, bool) {
+	sessionID, ok := r.Context().Value(contextkeys.SessionID).(uint)
+	return sessionID, ok
+}
+
+// clientDevice collects the device details recorded on a session.
+func clientDevice(r *http.Request, name string) services.SessionDevice {
+	return services.SessionDevice{Name: name, UserAgent: r.UserAgent(), IP: clientIP(r)}
+}
+
+// stringValue returns the string s points to, or "" if s is nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// clientIP returns the address of the connection, so behind a reverse proxy it is the proxy's address.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// writeLoginThrottled 在 err 是 *services.LoginThrottledError 时返回 429 和 Retry-After，返回是否已写入响应
+func writeLoginThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *services.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return true
+}
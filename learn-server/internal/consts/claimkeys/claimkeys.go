@@ -5,5 +5,6 @@ const (
 	UserName     = "user_name"
 	Role         = "roles"
 	TokenVersion = "token_version"
+	SessionID    = "sid"
 	Exp          = "exp"
 )
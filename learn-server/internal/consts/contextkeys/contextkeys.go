@@ -9,4 +9,10 @@ var (
 	DBKey = contextKey("db")
 
 	User = contextKey("user")
+	// SessionID 是 Access Token 绑定的会话 ID
+	SessionID = contextKey("session_id")
+	// APIKeyID 是使用 API Key 认证时密钥的 ID
+	APIKeyID = contextKey("api_key_id")
+	// APIKeyScopes 是使用 API Key 认证时密钥允许访问的权限
+	APIKeyScopes = contextKey("api_key_scopes")
 )
@@ -17,7 +17,37 @@ func Migrate(db *gorm.DB) error {
 		&models.TrueFalseAnswer{},
 		&models.WrittenAnswer{},
 		&models.FillInTheBlankAnswer{},
+		&models.QuestionStimulus{},
 		&models.RelatedQuestion{},
+		&models.NotebookEntry{},
+		&models.Class{},
+		&models.ClassMember{},
+		&models.Assignment{},
+		&models.AssignmentQuestion{},
+		&models.AssignmentSubmission{},
+		&models.Paper{},
+		&models.PaperSection{},
+		&models.PaperQuestion{},
+		&models.BlueprintRule{},
+		&models.XPEvent{},
+		&models.LearnerStats{},
+		&models.Badge{},
+		&models.UserBadge{},
+		&models.SyncedAttempt{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.UserMFA{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.OIDCExchangeCode{},
+		&models.LoginFailure{},
+		&models.AuditLog{},
+		&models.PasswordResetToken{},
+		&models.Registration{},
+		&models.EmailVerificationToken{},
+		&models.APIKey{},
+		&models.SigningKey{},
 		&models.User{},
 		&models.Role{},
 		&models.Permission{},
@@ -0,0 +1,32 @@
+// dto/api_key.go
+package dto
+
+import "time"
+
+// CreateAPIKeyRequest 定义了创建 API Key 的请求
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`          // 允许访问的权限，必须是用户拥有的权限
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // 为空表示不过期
+	// 创建自己的 API Key 时需要提供当前密码或两步验证码（也可以是恢复码）之一，管理员代为创建时不需要
+	CurrentPassword string `json:"current_password,omitempty"`
+	Code            string `json:"code,omitempty"`
+}
+
+// APIKeyResponse 是一个 API Key，不包含密钥本身
+type APIKeyResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"` // 密钥开头的几个字符
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse 是新建的 API Key，Key 只返回这一次
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
@@ -0,0 +1,84 @@
+// dto/assignment.go
+package dto
+
+import (
+	"learn/internal/models"
+	"time"
+)
+
+// CreateAssignmentRequest 定义了布置作业请求的结构体，题库和题目二选一
+type CreateAssignmentRequest struct {
+	Title          string            `json:"title" validate:"required"`
+	Description    string            `json:"description,omitempty"`
+	QuestionBankID *uint             `json:"question_bank_id,omitempty"` // 布置整个题库
+	QuestionIDs    []uint            `json:"question_ids,omitempty"`     // 手动挑选的题目，按顺序排列
+	OpenAt         time.Time         `json:"open_at"`                    // 为空时立即开放
+	DueAt          time.Time         `json:"due_at" validate:"required"`
+	MaxAttempts    uint              `json:"max_attempts,omitempty"` // 0 表示不限次数
+	LatePolicy     models.LatePolicy `json:"late_policy,omitempty"`  // 0 不能迟交，1 可以迟交，2 迟交扣分
+	LatePenalty    float64           `json:"late_penalty,omitempty"` // 迟交扣减的得分比例，取值 0~1
+}
+
+// AssignmentResponse 用于返回作业信息，学员查看时附带自己的完成情况
+type AssignmentResponse struct {
+	ID             uint               `json:"id"`
+	ClassID        uint               `json:"class_id"`
+	Title          string             `json:"title"`
+	Description    string             `json:"description,omitempty"`
+	QuestionBankID *uint              `json:"question_bank_id,omitempty"`
+	QuestionIDs    []uint             `json:"question_ids,omitempty"`
+	OpenAt         time.Time          `json:"open_at"`
+	DueAt          time.Time          `json:"due_at"`
+	MaxAttempts    uint               `json:"max_attempts"`
+	LatePolicy     models.LatePolicy  `json:"late_policy"`
+	LatePenalty    float64            `json:"late_penalty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	Submissions    *uint              `json:"submissions,omitempty"` // 当前学员已提交次数
+	BestScore      *float64           `json:"best_score,omitempty"`  // 当前学员的最高得分
+	Questions      []QuestionResponse `json:"questions,omitempty"`   // 查看作业详情时返回，不含答案
+}
+
+// SubmitAssignmentRequest 定义了提交作业的请求，answers 以题目 ID 为键
+type SubmitAssignmentRequest struct {
+	Answers map[string]interface{} `json:"answers"`
+}
+
+// AssignmentSubmissionResponse 用于返回一次作业提交的结果
+type AssignmentSubmissionResponse struct {
+	ID          uint                       `json:"id"`
+	Attempt     uint                       `json:"attempt"`
+	RawScore    float64                    `json:"raw_score"`
+	Score       float64                    `json:"score"`
+	Late        bool                       `json:"late"`
+	SubmittedAt time.Time                  `json:"submitted_at"`
+	Results     []AssignmentQuestionResult `json:"results,omitempty"`
+}
+
+// AssignmentQuestionResult 是作业中一道题的判分结果
+type AssignmentQuestionResult struct {
+	QuestionID uint    `json:"question_id"`
+	Correct    bool    `json:"correct"`
+	Score      float64 `json:"score"`
+}
+
+// GradebookResponse 汇总一次作业的完成情况和得分
+type GradebookResponse struct {
+	AssignmentID   uint             `json:"assignment_id"`
+	Title          string           `json:"title"`
+	DueAt          time.Time        `json:"due_at"`
+	StudentCount   int              `json:"student_count"`
+	CompletedCount int              `json:"completed_count"`
+	AverageScore   float64          `json:"average_score"` // 已完成学员最高得分的平均值
+	Students       []GradebookEntry `json:"students"`
+}
+
+// GradebookEntry 是成绩册中的一名学员
+type GradebookEntry struct {
+	UserID          uint       `json:"user_id"`
+	Username        string     `json:"username"`
+	Submissions     uint       `json:"submissions"`
+	BestScore       float64    `json:"best_score"`
+	Completed       bool       `json:"completed"`
+	Late            bool       `json:"late"` // 最高得分的提交是否迟交
+	LastSubmittedAt *time.Time `json:"last_submitted_at,omitempty"`
+}
@@ -1,18 +1,24 @@
 package dto
 
-import "learn/internal/models"
+import (
+	"learn/internal/models"
+	"time"
+)
 
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"` // 可选，显示在会话列表中
 }
 
 // CreateUserRequest 定义了创建用户请求的结构体
 type CreateUserRequest struct {
-	Username string            `json:"username"`
-	Password string            `json:"password"`
-	Roles    []string          `json:"roles"`  // 用户可以有多个角色
-	Status   models.UserStatus `json:"status"` // 用户状态
+	Username       string            `json:"username"`
+	Password       string            `json:"password"`
+	Email          string            `json:"email,omitempty"`           // 可选，用于找回密码
+	Roles          []string          `json:"roles"`                     // 用户可以有多个角色
+	Status         models.UserStatus `json:"status"`                    // 用户状态
+	ServiceAccount bool              `json:"service_account,omitempty"` // 创建服务账号，忽略密码、邮箱和状态，只能使用 API Key
 }
 
 // CreateUserResponse 定义了创建用户响应的结构体
@@ -22,17 +28,21 @@ type CreateUserResponse struct {
 
 // UserResponse 定义返回的用户信息结构体
 type UserResponse struct {
-	ID           uint   `json:"id"`
-	Username     string `json:"username"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	TokenVersion uint   `json:"token_version"`
-	Status       int    `json:"status"`
+	ID             uint       `json:"id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email,omitempty"`
+	CreatedAt      string     `json:"created_at"`
+	UpdatedAt      string     `json:"updated_at"`
+	TokenVersion   uint       `json:"token_version"`
+	Status         int        `json:"status"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"` // 因登录失败过多被临时停用时的解除时间
+	ServiceAccount bool       `json:"service_account,omitempty"`
 }
 
 type UpdateUserRequest struct {
 	Username string   `json:"username"`
 	Password *string  `json:"password,omitempty"` // 可选的密码字段
+	Email    *string  `json:"email,omitempty"`    // 为空表示不修改，空字符串表示清除
 	Roles    []string `json:"roles"`
 	Status   int      `json:"status"`
 }
@@ -44,8 +54,9 @@ type RoleRequest struct {
 
 // RoleResponse 定义了创建角色响应的结构体
 type RoleResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	RequireMFA bool   `json:"require_mfa"`
 }
 
 // AssignRoleRequest 定义了为用户分配角色的请求
@@ -76,6 +87,18 @@ type RefreshTokenRequest struct {
 	Token string `json:"token"`
 }
 
+// SessionResponse 是用户在某个设备上的登录会话
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+}
+
 // RoleUpdateRequest represents the request payload for updating a role// RoleUpdateRequest represents the request payload for updating a role
 type RoleUpdateRequest struct {
 	Name        string `json:"name"`
@@ -83,8 +106,10 @@ type RoleUpdateRequest struct {
 }
 
 type RegisterUserRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Email      string `json:"email,omitempty"`       // 可选，用于找回密码；开启邮箱验证时必填
+	InviteCode string `json:"invite_code,omitempty"` // 可选，有效的邀请码可以免去人工审核
 }
 
 type MenuItem struct {
@@ -95,3 +120,14 @@ type MenuItem struct {
 	Icon   string `json:"icon,omitempty"`
 	Order  int    `json:"order"`
 }
+
+// AuditLogResponse 是一条审计日志
+type AuditLogResponse struct {
+	ID        uint      `json:"id"`
+	Action    string    `json:"action"`
+	UserID    *uint     `json:"user_id,omitempty"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+// dto/class.go
+package dto
+
+import (
+	"learn/internal/models"
+	"time"
+)
+
+// CreateClassRequest 定义了创建班级请求的结构体
+type CreateClassRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// JoinClassRequest 定义了通过邀请码加入班级的请求
+type JoinClassRequest struct {
+	InviteCode string `json:"invite_code" validate:"required"`
+}
+
+// AddClassMemberRequest 定义了教师添加班级成员的请求
+type AddClassMemberRequest struct {
+	UserID uint             `json:"user_id" validate:"required"`
+	Role   models.ClassRole `json:"role"` // 0 学员，1 教师
+}
+
+// ClassResponse 用于返回班级信息，邀请码只对教师返回
+type ClassResponse struct {
+	ID          uint                  `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	InviteCode  string                `json:"invite_code,omitempty"`
+	CreatorID   uint                  `json:"creator_id"`
+	CreatedAt   time.Time             `json:"created_at"`
+	Members     []ClassMemberResponse `json:"members,omitempty"`
+}
+
+// ClassMemberResponse 用于返回班级成员
+type ClassMemberResponse struct {
+	UserID   uint             `json:"user_id"`
+	Username string           `json:"username"`
+	Role     models.ClassRole `json:"role"`
+	JoinedAt time.Time        `json:"joined_at"`
+}
+
+// InviteCodeResponse 返回新的邀请码
+type InviteCodeResponse struct {
+	InviteCode string `json:"invite_code"`
+}
+
+// StudentProgressResponse 汇总学员的答题情况
+type StudentProgressResponse struct {
+	UserID             uint       `json:"user_id"`
+	Username           string     `json:"username"`
+	AttemptedQuestions int        `json:"attempted_questions"` // 做过的题目数
+	Attempts           uint       `json:"attempts"`            // 累计作答次数
+	Wrong              uint       `json:"wrong"`               // 累计答错次数
+	AverageScore       float64    `json:"average_score"`       // 各题最近一次得分的平均值
+	LastAnswerAt       *time.Time `json:"last_answer_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+// dto/event.go
+package dto
+
+import "time"
+
+// AssignmentEvent 是作业布置和截止提醒事件的内容
+type AssignmentEvent struct {
+	AssignmentID uint      `json:"assignment_id"`
+	ClassID      uint      `json:"class_id"`
+	Title        string    `json:"title"`
+	OpenAt       time.Time `json:"open_at"`
+	DueAt        time.Time `json:"due_at"`
+}
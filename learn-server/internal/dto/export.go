@@ -0,0 +1,15 @@
+// dto/export.go
+package dto
+
+// ExportRequest 定义了导出打印试卷的请求。题目按 question_ids 的顺序导出；
+// 未指定 question_ids 时导出题库中的全部题目，可按标签过滤
+type ExportRequest struct {
+	Title          string `json:"title,omitempty"`
+	Format         string `json:"format"` // pdf 或 docx，默认 pdf
+	QuestionBankID uint   `json:"question_bank_id,omitempty"`
+	Tag            string `json:"tag,omitempty"`
+	QuestionIDs    []uint `json:"question_ids,omitempty"`
+	Variants       int    `json:"variants,omitempty"`   // A/B 卷数量，默认 1；B 卷起打乱题目和选项顺序
+	Seed           int64  `json:"seed,omitempty"`       // 打乱顺序所用的种子，相同的请求得到相同的试卷
+	AnswerKey      bool   `json:"answer_key,omitempty"` // 导出答案和解析，而不是试卷
+}
@@ -0,0 +1,64 @@
+// dto/gamification.go
+package dto
+
+import (
+	"learn/internal/models"
+	"time"
+)
+
+// BadgeRequest 定义了创建徽章规则的请求
+type BadgeRequest struct {
+	Code           string           `json:"code" validate:"required"`
+	Name           string           `json:"name" validate:"required"`
+	Description    string           `json:"description,omitempty"`
+	Kind           models.BadgeKind `json:"kind" validate:"required"` // correct_in_bank、correct_total、streak、total_xp
+	Threshold      uint             `json:"threshold" validate:"required"`
+	QuestionBankID *uint            `json:"question_bank_id,omitempty"` // 仅 correct_in_bank 使用，为空表示任一题库
+}
+
+// BadgeResponse 用于返回徽章规则，查看自己的徽章时附带进度和获得时间
+type BadgeResponse struct {
+	ID             uint             `json:"id"`
+	Code           string           `json:"code"`
+	Name           string           `json:"name"`
+	Description    string           `json:"description,omitempty"`
+	Kind           models.BadgeKind `json:"kind"`
+	Threshold      uint             `json:"threshold"`
+	QuestionBankID *uint            `json:"question_bank_id,omitempty"`
+	Progress       uint             `json:"progress,omitempty"`
+	AwardedAt      *time.Time       `json:"awarded_at,omitempty"`
+}
+
+// GamificationStatsResponse 汇总学员的经验值、连续学习天数和徽章
+type GamificationStatsResponse struct {
+	TotalXP        int             `json:"total_xp"`
+	WeeklyXP       int             `json:"weekly_xp"` // 本周（周一起）获得的经验值
+	CorrectAnswers uint            `json:"correct_answers"`
+	CurrentStreak  uint            `json:"current_streak"` // 昨天和今天都没有作答时为 0
+	LongestStreak  uint            `json:"longest_streak"`
+	LastActiveOn   string          `json:"last_active_on,omitempty"`
+	Badges         []BadgeResponse `json:"badges"` // 所有徽章及进度
+}
+
+// LeaderboardEntry 是排行榜中的一名学员，经验值相同的名次相同
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	XP       int    `json:"xp"`
+}
+
+// LeaderboardResponse 用于返回排行榜
+type LeaderboardResponse struct {
+	Period  string             `json:"period"`          // week 或 all
+	Since   *time.Time         `json:"since,omitempty"` // 本周排行榜的起始时间
+	Entries []LeaderboardEntry `json:"entries"`
+	Me      *LeaderboardEntry  `json:"me,omitempty"` // 当前用户的名次，未上榜时为空
+}
+
+// BadgeEarnedEvent 是获得徽章事件的内容
+type BadgeEarnedEvent struct {
+	BadgeID uint   `json:"badge_id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+}
@@ -0,0 +1,91 @@
+// dto/live_quiz.go
+package dto
+
+import "time"
+
+// CreateLiveSessionRequest 定义了创建现场答题的请求
+type CreateLiveSessionRequest struct {
+	QuestionBankID  uint `json:"question_bank_id" validate:"required"`
+	QuestionSeconds int  `json:"question_seconds,omitempty"` // 每道题的倒计时秒数，默认 20
+	QuestionCount   int  `json:"question_count,omitempty"`   // 随机抽取的题数，0 表示题库全部题目
+}
+
+// LiveSessionResponse 用于返回现场答题的状态
+type LiveSessionResponse struct {
+	PIN             string       `json:"pin"`
+	HostID          uint         `json:"host_id"`
+	Title           string       `json:"title"`
+	State           string       `json:"state"` // lobby、question、reveal 或 finished
+	QuestionCount   int          `json:"question_count"`
+	QuestionSeconds int          `json:"question_seconds"`
+	Players         []LivePlayer `json:"players"`
+}
+
+// LivePlayer 是现场答题中的一名学员及其得分
+type LivePlayer struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Score    int    `json:"score"`
+	Correct  int    `json:"correct"`
+}
+
+// LiveCommand 是客户端通过 WebSocket 发送的指令。
+// 主持人发送 start、reveal、next、end，学员发送 answer
+type LiveCommand struct {
+	Type   string      `json:"type"`
+	Answer interface{} `json:"answer,omitempty"`
+}
+
+// LiveEvent 是服务端通过 WebSocket 推送的事件，Data 的结构由 Type 决定
+type LiveEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// LiveLobbyEvent 在学员加入或离开等候室时推送
+type LiveLobbyEvent struct {
+	Players []LivePlayer `json:"players"`
+}
+
+// LiveQuestionEvent 推送当前题目，题目不含答案
+type LiveQuestionEvent struct {
+	Index    int              `json:"index"` // 从 0 开始
+	Total    int              `json:"total"`
+	Seconds  int              `json:"seconds"`
+	Deadline time.Time        `json:"deadline"`
+	Question QuestionResponse `json:"question"`
+}
+
+// LiveAnswerCountEvent 在有学员作答时推送已作答人数
+type LiveAnswerCountEvent struct {
+	Answered int `json:"answered"`
+	Players  int `json:"players"`
+}
+
+// LiveAnswerResultEvent 只推送给作答的学员
+type LiveAnswerResultEvent struct {
+	QuestionID  uint    `json:"question_id"`
+	Correct     bool    `json:"correct"`
+	Score       float64 `json:"score"`  // 判分结果，0~1
+	Points      int     `json:"points"` // 本题得分，答得越快越高
+	TotalPoints int     `json:"total_points"`
+}
+
+// LiveRevealEvent 在倒计时结束或全员作答后推送答案和排行榜
+type LiveRevealEvent struct {
+	Index       int              `json:"index"`
+	Question    QuestionResponse `json:"question"`
+	Answered    int              `json:"answered"`
+	Correct     int              `json:"correct"`
+	Leaderboard []LivePlayer     `json:"leaderboard"`
+}
+
+// LiveFinishedEvent 在答题结束时推送最终排行榜
+type LiveFinishedEvent struct {
+	Leaderboard []LivePlayer `json:"leaderboard"`
+}
+
+// LiveErrorEvent 推送指令处理失败的原因
+type LiveErrorEvent struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,60 @@
+// dto/mfa.go
+package dto
+
+// LoginResponse 是登录结果。启用了两步验证或角色要求两步验证时不返回令牌，
+// 而是返回短期有效的 MFAToken，用于完成验证或绑定验证器
+type LoginResponse struct {
+	AccessToken           string `json:"access_token,omitempty"`
+	RefreshToken          string `json:"refresh_token,omitempty"`
+	MFARequired           bool   `json:"mfa_required,omitempty"`            // 需要调用 /auth/login/mfa 提交验证码
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"` // 角色要求两步验证但尚未绑定
+	MFAToken              string `json:"mfa_token,omitempty"`
+}
+
+// MFATokenRequest 携带登录时下发的 MFAToken
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+// MFALoginRequest 定义了登录第二步的请求，Code 可以是验证码或恢复码
+type MFALoginRequest struct {
+	MFAToken   string `json:"mfa_token"`
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+// MFACodeRequest 携带验证器上的验证码或恢复码
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// MFAEnrollmentResponse 返回待绑定的 TOTP 密钥
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// 地址
+	QRCode          string `json:"qr_code"`          // 二维码 PNG 的 data URI
+}
+
+// RecoveryCodesResponse 返回新生成的恢复码，恢复码只显示这一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAEnrollmentLoginResponse 是登录时完成绑定的结果
+type MFAEnrollmentLoginResponse struct {
+	AccessToken   string   `json:"access_token"`
+	RefreshToken  string   `json:"refresh_token"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatusResponse 是当前用户的两步验证状态
+type MFAStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // 角色要求启用两步验证
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// RoleMFAPolicyRequest 设置角色是否要求两步验证
+type RoleMFAPolicyRequest struct {
+	RequireMFA bool `json:"require_mfa"`
+}
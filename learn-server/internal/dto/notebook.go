@@ -0,0 +1,22 @@
+// dto/notebook.go
+package dto
+
+import "time"
+
+// UpdateNotebookEntryRequest 用于收藏题目或修改笔记，省略的字段保持不变
+type UpdateNotebookEntryRequest struct {
+	Starred *bool   `json:"starred,omitempty"`
+	Note    *string `json:"note,omitempty"`
+}
+
+// NotebookEntryResponse 用于返回错题本中的一道题
+type NotebookEntryResponse struct {
+	QuestionID         uint             `json:"question_id"`
+	Question           QuestionResponse `json:"question"` // 不含答案
+	Starred            bool             `json:"starred"`
+	Note               string           `json:"note,omitempty"`
+	WrongCount         uint             `json:"wrong_count"`
+	ConsecutiveCorrect uint             `json:"consecutive_correct"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+}
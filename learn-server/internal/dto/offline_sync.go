@@ -0,0 +1,51 @@
+// dto/offline_sync.go
+package dto
+
+import "time"
+
+// PracticePackageResponse 是供设备离线练习的题目包。
+// 题目不含答案，AnswerHashes 以题目 ID（含组合题小题）为键，值为标准答案逐项的散列：
+// hex(sha256(salt + ":" + 题目 ID + ":" + 答案))。单选和多选题的答案是按 ID 升序排列的选项 JSON 数组，
+// 例如 [3,5]；判断题是 true 或 false；填空题每一空单独散列。没有散列的题目只能联网判分
+type PracticePackageResponse struct {
+	QuestionBankID uint               `json:"question_bank_id"`
+	Tag            string             `json:"tag,omitempty"`
+	IssuedAt       time.Time          `json:"issued_at"`
+	Salt           string             `json:"salt"`
+	Questions      []QuestionResponse `json:"questions"`
+	AnswerHashes   map[uint][]string  `json:"answer_hashes,omitempty"` // 只有完整反馈的题库才下发
+}
+
+// OfflineAttempt 是设备离线时的一次作答
+type OfflineAttempt struct {
+	ClientID   string      `json:"client_id" validate:"required"` // 设备生成的唯一 ID，重复上传时据此去重
+	QuestionID uint        `json:"question_id" validate:"required"`
+	Answer     interface{} `json:"answer"` // 格式与在线作答相同
+	AnsweredAt time.Time   `json:"answered_at" validate:"required"`
+}
+
+// SyncAttemptsRequest 定义了上传离线作答的请求
+type SyncAttemptsRequest struct {
+	Attempts []OfflineAttempt `json:"attempts" validate:"required"`
+}
+
+// OfflineAttemptResult 是一次离线作答的同步结果
+type OfflineAttemptResult struct {
+	ClientID   string  `json:"client_id"`
+	QuestionID uint    `json:"question_id"`
+	Status     string  `json:"status"` // applied、duplicate 或 rejected
+	Error      string  `json:"error,omitempty"`
+	Correct    bool    `json:"correct"`
+	Score      float64 `json:"score"`
+	XP         int     `json:"xp,omitempty"`
+	Superseded bool    `json:"superseded,omitempty"` // 早于服务端已有的最近作答，只计入作答次数
+	Withheld   bool    `json:"withheld,omitempty"`   // 题库设置为不反馈，不返回对错、得分和经验值
+}
+
+// SyncAttemptsResponse 汇总离线作答的同步结果，Results 与请求中的作答一一对应
+type SyncAttemptsResponse struct {
+	Applied    int                    `json:"applied"`
+	Duplicates int                    `json:"duplicates"`
+	Rejected   int                    `json:"rejected"`
+	Results    []OfflineAttemptResult `json:"results"`
+}
@@ -0,0 +1,26 @@
+// dto/oidc.go
+package dto
+
+import "time"
+
+// OIDCTokenRequest 用 OIDC 回调交给前端的一次性代码换取令牌
+type OIDCTokenRequest struct {
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+// OIDCAuthorizationResponse 返回身份提供方的授权地址，前端跳转到该地址完成绑定
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// UserIdentityResponse 是用户关联的 IdP 账号
+type UserIdentityResponse struct {
+	ID          uint      `json:"id"`
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	Username    string    `json:"username"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
@@ -0,0 +1,82 @@
+// dto/paper.go
+package dto
+
+import (
+	"learn/internal/models"
+	"time"
+)
+
+// PaperRequest 定义了创建或修改试卷的请求，分节和题目按数组顺序排列
+type PaperRequest struct {
+	Title       string                `json:"title" validate:"required"`
+	Description string                `json:"description,omitempty"`
+	Mode        models.PaperMode      `json:"mode"` // 0 所有学员同一份，1 每名学员抽取等价的一份
+	Sections    []PaperSectionRequest `json:"sections" validate:"required"`
+}
+
+// PaperSectionRequest 定义了试卷的一个分节
+type PaperSectionRequest struct {
+	Title     string          `json:"title"`
+	Questions []PaperQuestion `json:"questions,omitempty"` // 手动挑选的题目
+	Rules     []BlueprintRule `json:"rules,omitempty"`     // 组卷规则
+}
+
+// PaperQuestion 是分节中的一道题及其分值，分值为 0 时按 1 分计
+type PaperQuestion struct {
+	QuestionID uint    `json:"question_id"`
+	Points     float64 `json:"points"`
+	Drawn      bool    `json:"drawn,omitempty"` // 冻结时按规则抽出的题
+}
+
+// BlueprintRule 是组卷规则：从满足条件的题目中抽取 count 道，零值条件表示不限
+type BlueprintRule struct {
+	QuestionBankID uint                 `json:"question_bank_id,omitempty"`
+	QuestionType   *models.QuestionType `json:"question_type,omitempty"`
+	Tag            string               `json:"tag,omitempty"`
+	Difficulty     int                  `json:"difficulty,omitempty"`
+	Count          int                  `json:"count"`
+	Points         float64              `json:"points"` // 每道题的分值，为 0 时按 1 分计
+}
+
+// PaperResponse 用于返回试卷的定义
+type PaperResponse struct {
+	ID          uint                   `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description,omitempty"`
+	Mode        models.PaperMode       `json:"mode"`
+	AuthorID    uint                   `json:"author_id"`
+	Frozen      bool                   `json:"frozen"`
+	FrozenAt    *time.Time             `json:"frozen_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	Sections    []PaperSectionResponse `json:"sections"`
+}
+
+// PaperSectionResponse 用于返回试卷分节的定义
+type PaperSectionResponse struct {
+	Title     string          `json:"title"`
+	Questions []PaperQuestion `json:"questions,omitempty"`
+	Rules     []BlueprintRule `json:"rules,omitempty"`
+}
+
+// PaperVersionResponse 是抽题后的一份具体试卷
+type PaperVersionResponse struct {
+	PaperID     uint                  `json:"paper_id"`
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	TotalPoints float64               `json:"total_points"`
+	Sections    []PaperVersionSection `json:"sections"`
+}
+
+// PaperVersionSection 是具体试卷中的一个分节
+type PaperVersionSection struct {
+	Title     string                 `json:"title"`
+	Points    float64                `json:"points"`
+	Questions []PaperVersionQuestion `json:"questions"`
+}
+
+// PaperVersionQuestion 是具体试卷中的一道题
+type PaperVersionQuestion struct {
+	Points   float64          `json:"points"`
+	Question QuestionResponse `json:"question"`
+}
@@ -0,0 +1,20 @@
+// dto/password.go
+package dto
+
+// ChangePasswordRequest 定义了已登录用户修改密码的请求
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	DeviceName      string `json:"device_name,omitempty"` // 修改后重新签发令牌时使用的设备名称
+}
+
+// ForgotPasswordRequest 定义了找回密码的请求，Login 可以是用户名或邮箱
+type ForgotPasswordRequest struct {
+	Login string `json:"login"`
+}
+
+// ResetPasswordRequest 使用邮件中的令牌设置新密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
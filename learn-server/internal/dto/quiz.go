@@ -8,7 +8,14 @@ import (
 
 // CreateQuestionBankRequest 定义了创建题库请求的结构体
 type CreateQuestionBankRequest struct {
-	Name string `json:"name" validate:"required"`
+	Name         string              `json:"name" validate:"required"`
+	FeedbackMode models.FeedbackMode `json:"feedback_mode,omitempty"` // 0 完整反馈，1 只反馈对错，2 不反馈
+}
+
+// UpdateQuestionBankRequest 定义了更新题库设置请求的结构体
+type UpdateQuestionBankRequest struct {
+	Name         string              `json:"name" validate:"required"`
+	FeedbackMode models.FeedbackMode `json:"feedback_mode"` // 0 完整反馈，1 只反馈对错，2 不反馈
 }
 
 // CreateQuestionRequest 定义了创建问题请求的结构体
@@ -16,12 +23,16 @@ type CreateQuestionRequest struct {
 	Content       string                 `json:"content" validate:"required"`
 	QuestionType  models.QuestionType    `json:"question_type" validate:"required"`
 	Explanation   string                 `json:"explanation,omitempty"`
-	AnswerOptions []AnswerOption         `json:"answer_options,omitempty"` // 仅选择题使用
-	TrueFalse     *bool                  `json:"true_false,omitempty"`     // 判断题使用
-	AnswerText    string                 `json:"answer_text,omitempty"`    // 问答题使用
-	Blanks        []FillInTheBlankAnswer `json:"blanks,omitempty"`         // 填空题使用
-	Tags          []string               `json:"tags,omitempty"`           // 标签列表
-	AuthorID      uint                   `json:"author_id"`                // 问题的作者 ID
+	Difficulty    int                    `json:"difficulty,omitempty"`           // 难度 1~5，0 表示未设置
+	AnswerOptions []AnswerOption         `json:"answer_options,omitempty"`       // 仅选择题使用
+	TrueFalse     *bool                  `json:"true_false,omitempty"`           // 判断题使用
+	AnswerText    string                 `json:"answer_text,omitempty"`          // 问答题使用
+	Blanks        []FillInTheBlankAnswer `json:"blanks,omitempty"`               // 填空题使用
+	Stimulus      *Stimulus              `json:"stimulus,omitempty"`             // 组合题使用
+	Children      []ChildQuestionRequest `json:"children,omitempty"`             // 组合题使用
+	Tags          []string               `json:"tags,omitempty"`                 // 标签列表
+	RelatedIDs    []uint                 `json:"related_question_ids,omitempty"` // 相关题目，作答反馈中推荐
+	AuthorID      uint                   `json:"author_id"`                      // 问题的作者 ID
 }
 
 // UpdateQuestionRequest 用于更新问题的请求体
@@ -30,18 +41,79 @@ type UpdateQuestionRequest struct {
 	Content        string                 `json:"content" validate:"required"`
 	QuestionType   models.QuestionType    `json:"question_type" validate:"required"`
 	Explanation    string                 `json:"explanation,omitempty"`
-	AnswerOptions  []AnswerOption         `json:"answer_options,omitempty"` // 仅选择题使用
-	TrueFalse      *bool                  `json:"true_false,omitempty"`     // 判断题使用
-	AnswerText     string                 `json:"answer_text,omitempty"`    // 问答题使用
-	Blanks         []FillInTheBlankAnswer `json:"blanks,omitempty"`         // 填空题使用
-	Tags           []string               `json:"tags,omitempty"`           // 标签列表
-	AuthorID       uint                   `json:"author_id"`                // 问题的作者 ID
+	Difficulty     int                    `json:"difficulty,omitempty"`           // 难度 1~5，0 表示未设置
+	AnswerOptions  []AnswerOption         `json:"answer_options,omitempty"`       // 仅选择题使用
+	TrueFalse      *bool                  `json:"true_false,omitempty"`           // 判断题使用
+	AnswerText     string                 `json:"answer_text,omitempty"`          // 问答题使用
+	Blanks         []FillInTheBlankAnswer `json:"blanks,omitempty"`               // 填空题使用
+	Stimulus       *Stimulus              `json:"stimulus,omitempty"`             // 组合题使用
+	Children       []ChildQuestionRequest `json:"children,omitempty"`             // 组合题使用
+	Tags           []string               `json:"tags,omitempty"`                 // 标签列表
+	RelatedIDs     []uint                 `json:"related_question_ids,omitempty"` // 相关题目，省略时保持不变
+	AuthorID       uint                   `json:"author_id"`                      // 问题的作者 ID
+}
+
+// ChildQuestionRequest 定义了组合题中的一道小题，按数组顺序排列
+type ChildQuestionRequest struct {
+	ID            uint                   `json:"id,omitempty"` // 编辑时传入已有小题的 ID 以保留其作答记录，省略时新建
+	Content       string                 `json:"content" validate:"required"`
+	QuestionType  models.QuestionType    `json:"question_type"`
+	Explanation   string                 `json:"explanation,omitempty"`
+	AnswerOptions []AnswerOption         `json:"answer_options,omitempty"`
+	TrueFalse     *bool                  `json:"true_false,omitempty"`
+	AnswerText    string                 `json:"answer_text,omitempty"`
+	Blanks        []FillInTheBlankAnswer `json:"blanks,omitempty"`
+}
+
+// QuestionAnswers 汇总了创建/更新题目请求中与题型相关的答案字段
+type QuestionAnswers struct {
+	AnswerOptions []AnswerOption
+	TrueFalse     *bool
+	AnswerText    string
+	Blanks        []FillInTheBlankAnswer
+	Stimulus      *Stimulus
+	Children      []ChildQuestionRequest
+}
+
+// Answers 返回请求中与题型相关的答案字段
+func (r *CreateQuestionRequest) Answers() QuestionAnswers {
+	return QuestionAnswers{
+		AnswerOptions: r.AnswerOptions,
+		TrueFalse:     r.TrueFalse,
+		AnswerText:    r.AnswerText,
+		Blanks:        r.Blanks,
+		Stimulus:      r.Stimulus,
+		Children:      r.Children,
+	}
+}
+
+// Answers 返回请求中与题型相关的答案字段
+func (r *UpdateQuestionRequest) Answers() QuestionAnswers {
+	return QuestionAnswers{
+		AnswerOptions: r.AnswerOptions,
+		TrueFalse:     r.TrueFalse,
+		AnswerText:    r.AnswerText,
+		Blanks:        r.Blanks,
+		Stimulus:      r.Stimulus,
+		Children:      r.Children,
+	}
+}
+
+// Answers 返回小题的答案字段
+func (r *ChildQuestionRequest) Answers() QuestionAnswers {
+	return QuestionAnswers{
+		AnswerOptions: r.AnswerOptions,
+		TrueFalse:     r.TrueFalse,
+		AnswerText:    r.AnswerText,
+		Blanks:        r.Blanks,
+	}
 }
 
 // QuestionBankResponse 用于返回题库的信息
 type QuestionBankResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID           uint                `json:"id"`
+	Name         string              `json:"name"`
+	FeedbackMode models.FeedbackMode `json:"feedback_mode"`
 }
 
 // QuestionResponse 用于返回问题的信息
@@ -51,10 +123,14 @@ type QuestionResponse struct {
 	QuestionType    models.QuestionType    `json:"question_type"`
 	Content         string                 `json:"content"`
 	Explanation     string                 `json:"explanation,omitempty"`
+	Difficulty      int                    `json:"difficulty,omitempty"`
 	AnswerOptions   []AnswerOption         `json:"answer_options,omitempty"`
 	TrueFalseAnswer *TrueFalseAnswer       `json:"true_false_answer,omitempty"`
 	WrittenAnswer   *WrittenAnswer         `json:"written_answer,omitempty"`
 	FillInTheBlanks []FillInTheBlankAnswer `json:"fill_in_the_blanks,omitempty"`
+	Stimulus        *Stimulus              `json:"stimulus,omitempty"`
+	Children        []QuestionResponse     `json:"children,omitempty"`
+	Position        int                    `json:"position,omitempty"`
 	Tags            []string               `json:"tags,omitempty"` // 返回标签
 	AuthorID        uint                   `json:"author_id"`
 	AuthorName      string                 `json:"author_name"` // 用户名
@@ -83,16 +159,70 @@ type FillInTheBlankAnswer struct {
 	BlankText string `json:"blank_text" validate:"required"`
 }
 
+// Stimulus 表示组合题的共享材料
+type Stimulus struct {
+	Text      string `json:"text,omitempty"`
+	MediaURL  string `json:"media_url,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+}
+
+// QuestionAttemptRequest 定义了作答请求，作答人取自登录用户
 type QuestionAttemptRequest struct {
-	UserID     uint        `json:"user_id"`
 	QuestionID uint        `json:"question_id"`
-	Answer     interface{} `json:"answer"` // Stores the user's answer, can be string, []string, bool, etc.
+	Answer     interface{} `json:"answer"` // Stores the user's answer, can be string, []string, bool, or an object keyed by child question ID for group questions
 }
 
 type QuestionAttemptResponse struct {
-	QuestionID         uint      `json:"question_id"`
-	Attempts           uint      `json:"attempts"`
-	Wrong              uint      `json:"wrong"`
-	ConsecutiveCorrect uint      `json:"consecutive_correct"`
-	LastAnswerAt       time.Time `json:"last_answer_at"`
+	QuestionID         uint                      `json:"question_id"`
+	Attempts           uint                      `json:"attempts"`
+	Wrong              uint                      `json:"wrong"`
+	ConsecutiveCorrect uint                      `json:"consecutive_correct"`
+	LastScore          float64                   `json:"last_score"`
+	LastAnswerAt       time.Time                 `json:"last_answer_at"`
+	Children           []QuestionAttemptResponse `json:"children,omitempty"` // 组合题各小题的作答情况
+	Feedback           *PracticeFeedback         `json:"feedback,omitempty"` // 作答反馈，题库设置为不反馈时为空
+	XP                 int                       `json:"xp,omitempty"`       // 本次获得的经验值
+	Badges             []BadgeResponse           `json:"badges,omitempty"`   // 本次新获得的徽章
+}
+
+// BatchAttemptRequest 定义了一次提交整套题作答的请求
+type BatchAttemptRequest struct {
+	Answers []BatchAnswer `json:"answers" validate:"required"`
+}
+
+// BatchAnswer 是批量作答中的一道题
+type BatchAnswer struct {
+	QuestionID uint        `json:"question_id" validate:"required"`
+	Answer     interface{} `json:"answer"`
+}
+
+// BatchAttemptItem 是批量作答中一道题的结果，出错时 Attempt 为空
+type BatchAttemptItem struct {
+	QuestionID uint                     `json:"question_id"`
+	ErrorCode  string                   `json:"error_code,omitempty"` // question_not_found、invalid_answer 或 duplicate_question
+	Error      string                   `json:"error,omitempty"`
+	Attempt    *QuestionAttemptResponse `json:"attempt,omitempty"`
+}
+
+// BatchAttemptResponse 汇总批量作答的结果，Results 与请求中的作答一一对应
+type BatchAttemptResponse struct {
+	Results    []BatchAttemptItem `json:"results"`
+	Total      int                `json:"total"`      // 提交的题目数
+	Graded     int                `json:"graded"`     // 成功判分并下发得分的题目数
+	Withheld   int                `json:"withheld"`   // 已记录但题库设置为不反馈、不下发得分的题目数
+	Correct    int                `json:"correct"`    // 答对的题目数
+	Score      float64            `json:"score"`      // 各题得分之和
+	Percentage float64            `json:"percentage"` // 得分率，不含不下发得分的题，未能判分的题按 0 分计
+	XP         int                `json:"xp"`         // 本次获得的经验值
+}
+
+// PracticeFeedback 是作答后的即时反馈
+type PracticeFeedback struct {
+	QuestionID       uint               `json:"question_id"`
+	Correct          bool               `json:"correct"`
+	Score            float64            `json:"score"`
+	BlankResults     []bool             `json:"blank_results,omitempty"`     // 填空题每一空是否正确
+	Question         *QuestionResponse  `json:"question,omitempty"`          // 含正确答案和解析的题目，仅完整反馈时返回
+	RelatedQuestions []QuestionResponse `json:"related_questions,omitempty"` // 相关题目，不含答案
+	Children         []PracticeFeedback `json:"children,omitempty"`          // 组合题各小题的反馈
 }
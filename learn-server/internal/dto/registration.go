@@ -0,0 +1,46 @@
+// dto/registration.go
+package dto
+
+import "time"
+
+// RegisterUserResponse 是注册结果
+type RegisterUserResponse struct {
+	Status                    string `json:"status"`                      // 账号状态：active 表示已经可以登录，pending 表示等待审核或验证邮箱
+	Approved                  bool   `json:"approved"`                    // 申请是否已经通过，例如填写了有效的邀请码
+	EmailVerificationRequired bool   `json:"email_verification_required"` // 需要先点击验证邮件中的链接
+}
+
+// VerifyEmailRequest 携带验证邮件中的令牌
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest 定义了重新发送验证邮件的请求，Login 可以是用户名或邮箱
+type ResendVerificationRequest struct {
+	Login string `json:"login"`
+}
+
+// RegistrationResponse 是一条注册申请
+type RegistrationResponse struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"user_id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	Status        string     `json:"status"`                // pending、approved 或 rejected
+	ApprovedBy    string     `json:"approved_by,omitempty"` // admin、invite_code 或 email_domain
+	Reason        string     `json:"reason,omitempty"`      // 拒绝原因
+	ReviewerID    *uint      `json:"reviewer_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+}
+
+// ApproveRegistrationRequest 定义了通过注册申请的请求
+type ApproveRegistrationRequest struct {
+	Roles []string `json:"roles"` // 授予的角色
+}
+
+// RejectRegistrationRequest 定义了拒绝注册申请的请求
+type RejectRegistrationRequest struct {
+	Reason string `json:"reason"` // 会通过邮件告知用户
+}
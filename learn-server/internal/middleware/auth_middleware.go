@@ -7,16 +7,36 @@ import (
 	"learn/internal/consts/contextkeys"
 	"learn/internal/models"
 	"learn/internal/services"
+	"net"
 	"net/http"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
 )
 
 func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := apiKeyFromRequest(r); key != "" {
+				user, apiKey, err := authService.AuthenticateAPIKey(key, remoteIP(r))
+				if err != nil {
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				}
+				ctx := context.WithValue(r.Context(), contextkeys.User, user)
+				ctx = context.WithValue(ctx, contextkeys.APIKeyID, apiKey.ID)
+				ctx = context.WithValue(ctx, contextkeys.APIKeyScopes, apiKey.Scopes())
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" && queryTokenAllowed(r) {
+				// 浏览器的 WebSocket 和 EventSource 无法设置请求头，令牌通过查询参数传递
+				if token := r.URL.Query().Get("access_token"); token != "" {
+					authHeader = "Bearer " + token
+				}
+			}
 			if authHeader == "" {
 				http.Error(w, "Authorization header missing", http.StatusUnauthorized)
 				return
@@ -25,21 +45,12 @@ func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.H
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 			// 验证 JWT 并解析用户信息
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				return []byte(authService.JwtSecret()), nil
-			})
-
-			if err != nil || !token.Valid {
+			claims, err := authService.ParseAccessToken(tokenString)
+			if err != nil {
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
 
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok || !token.Valid {
-				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-				return
-			}
-
 			// 验证 TokenVersion 是否匹配
 			userID := uint(claims[claimkeys.UserId].(float64))
 			tokenVersion := uint(claims[claimkeys.TokenVersion].(float64))
@@ -55,6 +66,17 @@ func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.H
 				return
 			}
 
+			// 绑定会话的令牌在会话吊销后立即失效
+			ctx := r.Context()
+			if sid, ok := claims[claimkeys.SessionID].(float64); ok {
+				sessionID := uint(sid)
+				if err := authService.ValidateSession(userID, sessionID); err != nil {
+					http.Error(w, "Session is no longer valid", http.StatusUnauthorized)
+					return
+				}
+				ctx = context.WithValue(ctx, contextkeys.SessionID, sessionID)
+			}
+
 			if roles, ok := claims[claimkeys.Role].([]any); ok {
 				for _, role := range roles {
 					user.Roles = append(user.Roles, models.Role{Name: role.(string)})
@@ -62,13 +84,50 @@ func AuthMiddleware(authService *services.AuthService) func(http.Handler) http.H
 			}
 
 			// 设置用户上下文，继续处理请求
-			ctx := context.WithValue(r.Context(), contextkeys.User, user)
+			ctx = context.WithValue(ctx, contextkeys.User, user)
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// queryTokenRoutes 是允许通过 access_token 查询参数传递令牌的路由。
+// URL 中的令牌会出现在代理和访问日志里，只对事件流和现场答题的 WebSocket 开放
+var queryTokenRoutes = map[string]bool{
+	"/events":                 true,
+	"/live/sessions/{pin}/ws": true,
+}
+
+// queryTokenAllowed 按匹配到的路由判断能否从查询参数读取令牌，不依赖客户端可以伪造的请求头
+func queryTokenAllowed(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	template, err := route.GetPathTemplate()
+	return err == nil && queryTokenRoutes[template]
+}
+
+// apiKeyFromRequest 从 X-API-Key 或 Authorization: ApiKey 请求头中读取 API Key
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+// remoteIP 返回连接的对端地址，与 api 包记录会话 IP 的方式一致
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
 // func RequirePermission(authService *services.AuthService, permission string) func(http.Handler) http.Handler {
 // 	// 确保权限存在于数据库中
 // 	if err := authService.EnsurePermissionExists(permission); err != nil {
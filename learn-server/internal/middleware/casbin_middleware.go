@@ -5,6 +5,8 @@ import (
 	"learn/internal/consts/contextkeys"
 	"learn/internal/models"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gorilla/mux"
@@ -49,14 +51,22 @@ func CasbinMiddlewareFunc(e *casbin.Enforcer, permission string) mux.MiddlewareF
 			}
 
 			// Casbin 权限检查
+			allowed := false
 			for _, role := range user.Roles {
-				if ok, _ := e.Enforce(role.Name, permission, ""); ok {
-					next.ServeHTTP(w, r)
-					return
-				} else {
-					http.Error(w, "Forbidden", http.StatusForbidden)
+				if ok, _ := e.Enforce(strings.ToLower(role.Name), permission, ""); ok {
+					allowed = true
+					break
 				}
 			}
+			// 使用 API Key 时还要检查密钥的权限范围
+			if scopes, ok := r.Context().Value(contextkeys.APIKeyScopes).([]string); ok && !slices.Contains(scopes, permission) {
+				allowed = false
+			}
+			if !allowed {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
 		})
 	}
 }
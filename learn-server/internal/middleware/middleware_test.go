@@ -22,7 +22,7 @@ func SetupTestEnv(t *testing.T) (*services.AuthService, *gorm.DB) {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour, 24*time.Hour)
+	authService := services.NewAuthService(db, "jwt_secret", time.Hour, 7*24*time.Hour)
 	return authService, db
 }
 
@@ -0,0 +1,29 @@
+// models/api_key.go
+package models
+
+import "time"
+
+// APIKey 是供脚本和服务调用接口使用的长期凭据，只保存散列。
+// 使用时同时受所属用户的角色和 Permissions 限制
+type APIKey struct {
+	ID          uint         `gorm:"primaryKey"`
+	UserID      uint         `gorm:"index;not null"`
+	Name        string       `gorm:"size:100;not null"`
+	Prefix      string       `gorm:"size:16;not null"` // 密钥开头的几个字符，便于用户辨认
+	KeyHash     string       `gorm:"uniqueIndex;size:64;not null"`
+	Permissions []Permission `gorm:"many2many:api_key_permissions;"` // 允许访问的权限，是所属用户权限的子集
+	ExpiresAt   *time.Time   // 为空表示不过期
+	LastUsedAt  *time.Time
+	LastUsedIP  string `gorm:"size:45"`
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Scopes 返回密钥允许访问的权限名称
+func (k *APIKey) Scopes() []string {
+	scopes := make([]string, len(k.Permissions))
+	for i, permission := range k.Permissions {
+		scopes[i] = permission.Name
+	}
+	return scopes
+}
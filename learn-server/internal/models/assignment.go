@@ -0,0 +1,67 @@
+// models/assignment.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LatePolicy 决定截止后提交的处理方式
+type LatePolicy int
+
+const (
+	LateNotAllowed LatePolicy = iota // 截止后不能提交
+	LateAllowed                      // 可以提交，标记为迟交
+	LatePenalized                    // 可以提交，得分按比例扣减
+)
+
+func (p LatePolicy) String() string {
+	switch p {
+	case LateNotAllowed:
+		return "not_allowed"
+	case LateAllowed:
+		return "allowed"
+	case LatePenalized:
+		return "penalized"
+	}
+	return ""
+}
+
+// Assignment 是布置给班级的一组题目，可以是整个题库，也可以是手动挑选的题目
+type Assignment struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ClassID        uint       `gorm:"index;not null" json:"class_id"`
+	Title          string     `gorm:"not null" json:"title"`
+	Description    string     `json:"description"`
+	QuestionBankID *uint      `json:"question_bank_id,omitempty"` // 布置整个题库时设置
+	OpenAt         time.Time  `json:"open_at"`
+	DueAt          time.Time  `json:"due_at"`
+	MaxAttempts    uint       `json:"max_attempts"` // 0 表示不限次数
+	LatePolicy     LatePolicy `gorm:"default:0" json:"late_policy"`
+	LatePenalty    float64    `json:"late_penalty"` // 迟交扣减的得分比例，取值 0~1
+	CreatorID      uint       `json:"creator_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RemindedAt     *time.Time `json:"-"` // 已推送截止提醒的时间
+
+	Questions []AssignmentQuestion `gorm:"foreignKey:AssignmentID" json:"questions,omitempty"`
+}
+
+// AssignmentQuestion 是作业中手动挑选的一道题
+type AssignmentQuestion struct {
+	AssignmentID uint `gorm:"primaryKey" json:"assignment_id"`
+	QuestionID   uint `gorm:"primaryKey" json:"question_id"`
+	Position     int  `json:"position"`
+}
+
+// AssignmentSubmission 是学员的一次作业提交
+type AssignmentSubmission struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	AssignmentID uint            `gorm:"index;uniqueIndex:idx_submission_attempt;not null" json:"assignment_id"`
+	UserID       uint            `gorm:"index;uniqueIndex:idx_submission_attempt;not null" json:"user_id"`
+	Attempt      uint            `gorm:"uniqueIndex:idx_submission_attempt" json:"attempt"` // 第几次提交
+	RawScore     float64         `json:"raw_score"`                                         // 扣减前的得分比例
+	Score        float64         `json:"score"`                                             // 最终得分比例
+	Late         bool            `json:"late"`
+	Answers      json.RawMessage `json:"answers"`
+	SubmittedAt  time.Time       `json:"submitted_at"`
+}
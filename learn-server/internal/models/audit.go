@@ -0,0 +1,15 @@
+// models/audit.go
+package models
+
+import "time"
+
+// AuditLog 记录与账号安全相关的事件，例如因登录失败过多被临时锁定
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Action    string    `gorm:"size:64;index;not null" json:"action"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
+	Username  string    `gorm:"size:255" json:"username"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	Detail    string    `gorm:"size:1024" json:"detail"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
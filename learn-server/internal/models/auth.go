@@ -37,17 +37,22 @@ type BaseModel struct {
 
 type User struct {
 	BaseModel
-	Username     string     `gorm:"unique;not null"`
-	Password     string     `gorm:"not null"`
-	Roles        []Role     `gorm:"many2many:user_roles;"`
-	TokenVersion uint       `gorm:"default:1"`          // 添加 TokenVersion 字段
-	Status       UserStatus `gorm:"not null,default:0"` // 新增字段，用于表示用户是否激活
+	Username        string     `gorm:"unique;not null"`
+	Password        string     `gorm:"not null"`
+	Email           *string    `gorm:"uniqueIndex;size:255"` // 可选，用于找回密码等通知，保存为小写
+	EmailVerifiedAt *time.Time // 用户通过邮件验证邮箱的时间，邮箱改变后清空
+	Roles           []Role     `gorm:"many2many:user_roles;"`
+	TokenVersion    uint       `gorm:"default:1"`          // 添加 TokenVersion 字段
+	Status          UserStatus `gorm:"not null,default:0"` // 新增字段，用于表示用户是否激活
+	LockedUntil     *time.Time // 登录失败过多被临时停用（StatusSuspended）时的解除时间，管理员停用时为空
+	ServiceAccount  bool       `gorm:"not null;default:false"` // 服务账号不能用密码登录，只能使用 API Key
 }
 
 type Role struct {
 	ID          uint         `gorm:"primarykey"`
 	Name        string       `gorm:"unique;not null"`
 	Permissions []Permission `gorm:"many2many:roles_permissions;"`
+	RequireMFA  bool         `gorm:"not null;default:false"` // 拥有该角色的用户必须启用两步验证
 }
 
 // Permission represents a permission in the system
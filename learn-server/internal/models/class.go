@@ -0,0 +1,44 @@
+// models/class.go
+package models
+
+import "time"
+
+// ClassRole 是用户在班级中的身份
+type ClassRole int
+
+const (
+	ClassRoleStudent ClassRole = iota
+	ClassRoleTeacher
+)
+
+func (r ClassRole) String() string {
+	switch r {
+	case ClassRoleStudent:
+		return "student"
+	case ClassRoleTeacher:
+		return "teacher"
+	}
+	return ""
+}
+
+// Class 是由教师管理的一组学员，学员通过邀请码加入
+type Class struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description"`
+	InviteCode  string    `gorm:"uniqueIndex;not null" json:"invite_code"`
+	CreatorID   uint      `json:"creator_id"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Members []ClassMember `gorm:"foreignKey:ClassID" json:"members,omitempty"`
+}
+
+// ClassMember 记录用户在班级中的身份
+type ClassMember struct {
+	ClassID  uint      `gorm:"primaryKey" json:"class_id"`
+	UserID   uint      `gorm:"primaryKey" json:"user_id"`
+	Role     ClassRole `gorm:"not null;default:0" json:"role"`
+	JoinedAt time.Time `gorm:"autoCreateTime" json:"joined_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
@@ -0,0 +1,57 @@
+// models/gamification.go
+package models
+
+import "time"
+
+// XPEvent 记录学员一次作答获得的经验值，排行榜按时间段和题库汇总
+type XPEvent struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"index:idx_xp_user_time;not null" json:"user_id"`
+	QuestionBankID uint      `gorm:"index;not null" json:"question_bank_id"`
+	QuestionID     uint      `gorm:"not null" json:"question_id"`
+	Points         int       `json:"points"`
+	Correct        bool      `json:"correct"`
+	CreatedAt      time.Time `gorm:"index:idx_xp_user_time;index" json:"created_at"`
+}
+
+// LearnerStats 汇总学员的经验值和每日连续学习天数
+type LearnerStats struct {
+	UserID         uint      `gorm:"primaryKey" json:"user_id"`
+	TotalXP        int       `json:"total_xp"`
+	CorrectAnswers uint      `json:"correct_answers"`
+	CurrentStreak  uint      `json:"current_streak"`
+	LongestStreak  uint      `json:"longest_streak"`
+	LastActiveOn   string    `json:"last_active_on"` // 最近一次作答的日期，格式 2006-01-02
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BadgeKind 是徽章的达成条件
+type BadgeKind string
+
+const (
+	BadgeCorrectInBank BadgeKind = "correct_in_bank" // 在题库中累计答对指定次数，未指定题库时任一题库即可
+	BadgeCorrectTotal  BadgeKind = "correct_total"   // 累计答对指定次数
+	BadgeStreak        BadgeKind = "streak"          // 连续学习指定天数
+	BadgeTotalXP       BadgeKind = "total_xp"        // 累计获得指定经验值
+)
+
+// Badge 是一条徽章规则，作答后由服务端判断是否达成
+type Badge struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Code           string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name           string    `gorm:"not null" json:"name"`
+	Description    string    `json:"description"`
+	Kind           BadgeKind `gorm:"not null" json:"kind"`
+	Threshold      uint      `gorm:"not null" json:"threshold"`
+	QuestionBankID *uint     `json:"question_bank_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// UserBadge 记录学员获得的徽章
+type UserBadge struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	BadgeID   uint      `gorm:"primaryKey" json:"badge_id"`
+	AwardedAt time.Time `gorm:"autoCreateTime" json:"awarded_at"`
+
+	Badge Badge `gorm:"foreignKey:BadgeID" json:"-"`
+}
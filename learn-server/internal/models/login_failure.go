@@ -0,0 +1,14 @@
+// models/login_failure.go
+package models
+
+import "time"
+
+// LoginFailure 记录某个账号或 IP 最近连续登录失败的次数，用于限流
+type LoginFailure struct {
+	ID            uint   `gorm:"primaryKey"`
+	Scope         string `gorm:"uniqueIndex:idx_login_failure_scope_target;size:16;not null"` // account 或 ip
+	Target        string `gorm:"uniqueIndex:idx_login_failure_scope_target;size:255;not null"`
+	Failures      int    `gorm:"not null;default:0"`
+	LastFailureAt time.Time
+	BlockedUntil  time.Time // 在此之前拒绝登录请求
+}
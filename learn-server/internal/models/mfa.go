@@ -0,0 +1,25 @@
+// models/mfa.go
+package models
+
+import "time"
+
+// UserMFA 保存用户的 TOTP 两步验证设置。开始绑定时生成密钥，验证通过后才启用
+type UserMFA struct {
+	UserID       uint       `gorm:"primaryKey" json:"user_id"`
+	Secret       string     `gorm:"size:64;not null" json:"-"` // Base32 编码的 TOTP 密钥
+	Enabled      bool       `gorm:"not null;default:false" json:"enabled"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-"`                           // 最近一次通过验证的时间步，同一验证码不能重复使用
+	FailedCount  int        `gorm:"not null;default:0" json:"-"` // 连续验证失败次数
+	LockedUntil  *time.Time `json:"-"`                           // 连续失败过多时暂停验证
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode 是丢失验证器时使用的一次性恢复码，只保存散列
+type RecoveryCode struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	UserID   uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash string     `gorm:"size:64;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
@@ -0,0 +1,19 @@
+// models/notebook.go
+package models
+
+import "time"
+
+// NotebookEntry 是学员错题本中的一道题。答错的题自动加入，学员也可以手动收藏题目；
+// 未收藏的题连续答对达到阈值后自动移出错题本。
+type NotebookEntry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"uniqueIndex:idx_notebook_user_question;not null" json:"user_id"`
+	QuestionID uint      `gorm:"uniqueIndex:idx_notebook_user_question;not null" json:"question_id"`
+	Starred    bool      `gorm:"default:false" json:"starred"` // 手动收藏的题不会被自动移出
+	Note       string    `json:"note"`                         // 学员的笔记
+	WrongCount uint      `json:"wrong_count"`                  // 加入错题本后答错的次数
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	Question Question `gorm:"foreignKey:QuestionID" json:"question"`
+}
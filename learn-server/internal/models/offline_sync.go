@@ -0,0 +1,18 @@
+// models/offline_sync.go
+package models
+
+import "time"
+
+// SyncedAttempt 记录已同步的离线作答，按客户端生成的 ID 去重，重复上传时返回首次同步的结果
+type SyncedAttempt struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"uniqueIndex:idx_synced_user_client;not null" json:"user_id"`
+	ClientID   string    `gorm:"uniqueIndex:idx_synced_user_client;size:64;not null" json:"client_id"`
+	QuestionID uint      `gorm:"not null" json:"question_id"`
+	AnsweredAt time.Time `json:"answered_at"` // 设备上的作答时间
+	Correct    bool      `json:"correct"`
+	Score      float64   `json:"score"`
+	XP         int       `json:"xp"`
+	Superseded bool      `json:"superseded"` // 早于服务端已有的最近作答，只计入次数
+	CreatedAt  time.Time `json:"created_at"` // 同步时间
+}
@@ -0,0 +1,35 @@
+// models/oidc.go
+package models
+
+import "time"
+
+// UserIdentity 将身份提供方（IdP）中的账号关联到本地用户，按 Issuer 和 Subject 唯一确定
+type UserIdentity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	Issuer      string    `gorm:"uniqueIndex:idx_identity_issuer_subject;size:255;not null" json:"issuer"`
+	Subject     string    `gorm:"uniqueIndex:idx_identity_issuer_subject;size:255;not null" json:"subject"`
+	Email       string    `gorm:"size:255" json:"email"`
+	Username    string    `gorm:"size:255" json:"username"` // IdP 中的用户名
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// OIDCLoginState 保存一次尚未完成的 OIDC 授权请求，回调时校验并删除
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	State        string    `gorm:"uniqueIndex;size:64;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"` // PKCE code_verifier
+	LinkUserID   *uint     // 不为空时表示为该用户绑定账号，而不是登录
+	ExpiresAt    time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// OIDCExchangeCode 是 OIDC 登录完成后交给前端的一次性代码，用于换取令牌，只保存散列
+type OIDCExchangeCode struct {
+	ID        uint      `gorm:"primaryKey"`
+	CodeHash  string    `gorm:"uniqueIndex;size:64;not null"`
+	UserID    uint      `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
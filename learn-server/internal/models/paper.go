@@ -0,0 +1,77 @@
+// models/paper.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PaperMode 决定冻结后的试卷如何分发给学员
+type PaperMode int
+
+const (
+	PaperModeSame       PaperMode = iota // 冻结时按规则抽题，所有学员拿到同一份试卷
+	PaperModeEquivalent                  // 冻结时固定候选题，每名学员按规则抽到等价的一份试卷
+)
+
+func (m PaperMode) String() string {
+	switch m {
+	case PaperModeSame:
+		return "same"
+	case PaperModeEquivalent:
+		return "equivalent"
+	}
+	return ""
+}
+
+// Paper 是由若干分节组成的试卷，题目可以手动挑选，也可以按组卷规则抽取。
+// 冻结后的试卷不能再修改。
+type Paper struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Title       string     `gorm:"not null" json:"title"`
+	Description string     `json:"description"`
+	Mode        PaperMode  `gorm:"default:0" json:"mode"`
+	AuthorID    uint       `json:"author_id"`
+	Frozen      bool       `gorm:"default:false" json:"frozen"`
+	FrozenAt    *time.Time `json:"frozen_at,omitempty"`
+	Seed        int64      `json:"-"` // 冻结时生成，用于复现抽题结果
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Sections []PaperSection `gorm:"foreignKey:PaperID" json:"sections,omitempty"`
+}
+
+// PaperSection 是试卷中的一个分节，例如“一、单选题”
+type PaperSection struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	PaperID  uint   `gorm:"index;not null" json:"paper_id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+
+	Questions []PaperQuestion `gorm:"foreignKey:SectionID" json:"questions,omitempty"`
+	Rules     []BlueprintRule `gorm:"foreignKey:SectionID" json:"rules,omitempty"`
+}
+
+// PaperQuestion 是分节中的一道题及其分值
+type PaperQuestion struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	SectionID  uint    `gorm:"index;not null" json:"section_id"`
+	QuestionID uint    `json:"question_id"`
+	Position   int     `json:"position"`
+	Points     float64 `json:"points"`
+	Drawn      bool    `gorm:"default:false" json:"drawn"` // 冻结时按规则抽出的题
+}
+
+// BlueprintRule 是组卷规则：从满足条件的题目中抽取指定数量，零值条件表示不限
+type BlueprintRule struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	SectionID      uint            `gorm:"index;not null" json:"section_id"`
+	Position       int             `json:"position"`
+	QuestionBankID uint            `json:"question_bank_id"`
+	QuestionType   *QuestionType   `json:"question_type,omitempty"`
+	Tag            string          `json:"tag"`
+	Difficulty     int             `json:"difficulty"`
+	Count          int             `json:"count"`
+	Points         float64         `json:"points"`               // 每道题的分值
+	Candidates     json.RawMessage `json:"candidates,omitempty"` // 冻结时满足条件的题目 ID
+}
@@ -0,0 +1,14 @@
+// models/password_reset.go
+package models
+
+import "time"
+
+// PasswordResetToken 是找回密码时发给用户的一次性令牌，只保存散列
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
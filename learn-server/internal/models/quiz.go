@@ -14,6 +14,7 @@ const (
 	QuestionTypeTrueFalse
 	QuestionTypeWrittenAnswer
 	QuestionTypeFillInTheBlank // 新增填空题类型
+	QuestionTypeGroup          // 组合题：共享材料下的多道小题
 )
 
 func (q QuestionType) String() string {
@@ -28,13 +29,37 @@ func (q QuestionType) String() string {
 		return "问答题"
 	case QuestionTypeFillInTheBlank:
 		return "填空题"
+	case QuestionTypeGroup:
+		return "组合题"
+	}
+	return ""
+}
+
+// FeedbackMode 决定学员作答后立即得到的反馈内容
+type FeedbackMode int
+
+const (
+	FeedbackFull        FeedbackMode = iota // 对错、正确答案、解析和相关题目
+	FeedbackCorrectness                     // 只反馈对错和每空结果
+	FeedbackNone                            // 不反馈，用于考试
+)
+
+func (m FeedbackMode) String() string {
+	switch m {
+	case FeedbackFull:
+		return "full"
+	case FeedbackCorrectness:
+		return "correctness"
+	case FeedbackNone:
+		return "none"
 	}
 	return ""
 }
 
 type QuestionBank struct {
-	ID   uint   `gorm:"primaryKey" json:"id"`
-	Name string `gorm:"unique;not null" json:"name"`
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	Name         string       `gorm:"unique;not null" json:"name"`
+	FeedbackMode FeedbackMode `gorm:"default:0" json:"feedback_mode"` // 作答后的反馈方式
 }
 
 type Question struct {
@@ -46,6 +71,9 @@ type Question struct {
 	AuthorID       uint         `json:"author_id"` // 用户ID，关联到用户表
 	CreatedAt      time.Time    `json:"created_at" gorm:"autoCreateTime"`
 	AutoGenerated  bool         `gorm:"default:false" json:"auto_generated"`
+	Difficulty     int          `gorm:"default:0" json:"difficulty"`      // 难度 1~5，0 表示未设置
+	ParentID       *uint        `gorm:"index" json:"parent_id,omitempty"` // 组合题小题所属的题目
+	Position       int          `json:"position"`                         // 小题在组合题中的顺序
 
 	// 定义关联
 	Author          User                   `gorm:"foreignKey:AuthorID" json:"author"` // 使用外键关联用户表
@@ -54,6 +82,8 @@ type Question struct {
 	WrittenAnswer   *WrittenAnswer         `json:"written_answer,omitempty" gorm:"foreignKey:QuestionID"`
 	FillInTheBlanks []FillInTheBlankAnswer `json:"fill_in_the_blanks,omitempty" gorm:"foreignKey:QuestionID"` // 新增填空题关联
 	Tags            []Tag                  `json:"tags,omitempty" gorm:"many2many:question_tags;"`            // 关联标签
+	Stimulus        *QuestionStimulus      `json:"stimulus,omitempty" gorm:"foreignKey:QuestionID"`           // 组合题的共享材料
+	Children        []Question             `json:"children,omitempty" gorm:"foreignKey:ParentID"`             // 组合题的小题
 }
 
 // 选择题和多选题的选项存储
@@ -83,6 +113,14 @@ type FillInTheBlankAnswer struct {
 	BlankText  string `gorm:"not null" json:"blank_text"` // 填空的正确答案
 }
 
+// 组合题的共享材料，可以是文字，也可以是图片、音频等媒体
+type QuestionStimulus struct {
+	QuestionID uint   `gorm:"primaryKey" json:"question_id"`
+	Text       string `json:"text"`
+	MediaURL   string `json:"media_url"`
+	MediaType  string `json:"media_type"` // 例如 image、audio、video
+}
+
 type Tag struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"unique;not null" json:"name"`
@@ -101,6 +139,7 @@ type QuestionAttempt struct {
 	Wrong              uint            `json:"wrong"`
 	ConsecutiveCorrect uint            `json:"consecutive_correct"`
 	LastAnswer         json.RawMessage `json:"last_answer"` // Used to store the last answer
+	LastScore          float64         `json:"last_score"`  // Score of the last answer, from 0 to 1
 	LastAnswerAt       time.Time       `json:"last_answer_at"`
 	CreatedAt          time.Time       `json:"created_at"`
 	UpdatedAt          time.Time       `json:"updated_at"`
@@ -118,3 +157,11 @@ func (qa *QuestionAttempt) UpdateAnswer(answer []byte, isCorrect bool) {
 	}
 	qa.LastAnswerAt = time.Now()
 }
+
+// CountEarlierAnswer 记录一次早于最近作答的作答（例如离线同步的作答），只累计次数，不改变最近作答的状态
+func (qa *QuestionAttempt) CountEarlierAnswer(isCorrect bool) {
+	qa.Attempts++
+	if !isCorrect {
+		qa.Wrong++
+	}
+}
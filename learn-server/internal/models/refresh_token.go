@@ -0,0 +1,17 @@
+// models/refresh_token.go
+package models
+
+import "time"
+
+// RefreshToken 是签发给某个设备的刷新令牌，只保存令牌的 SHA-256 散列。
+// 每次刷新都换发新令牌，同一次登录换发出的令牌共用 FamilyID
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"index;size:64;not null" json:"family_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"` // 会话的过期时间，换发时保持不变
+	UsedAt    *time.Time `json:"used_at"`                    // 已换发新令牌，再次使用视为重放
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
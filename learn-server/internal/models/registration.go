@@ -0,0 +1,38 @@
+// models/registration.go
+package models
+
+import "time"
+
+// 注册申请的状态
+const (
+	RegistrationPending  = "pending"
+	RegistrationApproved = "approved"
+	RegistrationRejected = "rejected"
+)
+
+// Registration 是用户自助注册后等待审核的申请。
+// 审核通过且（需要时）邮箱已验证后账号才会启用
+type Registration struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"uniqueIndex;not null"`
+	User       User   `gorm:"constraint:OnDelete:CASCADE;"`
+	Status     string `gorm:"size:16;index;not null"`
+	ApprovedBy string `gorm:"size:32"`  // 审核方式：admin、invite_code 或 email_domain
+	InviteCode string `gorm:"size:64"`  // 注册时填写的邀请码
+	Reason     string `gorm:"size:500"` // 拒绝原因
+	ReviewerID *uint
+	DecidedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// EmailVerificationToken 是验证邮箱时发给用户的一次性令牌，只保存散列
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	Email     string    `gorm:"size:255;not null"` // 发送时的邮箱，之后邮箱改变则令牌失效
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,23 @@
+// models/session.go
+package models
+
+import "time"
+
+// Session 是用户在某个设备上的一次登录，与同一令牌族的 Refresh Token 一一对应
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID   string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	DeviceName string     `gorm:"size:100" json:"device_name"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"` // 最近一次登录或刷新令牌的时间
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// Active 判断会话是否仍然有效
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,15 @@
+// models/signing_key.go
+package models
+
+import "time"
+
+// SigningKey 是签发 Access Token 的非对称密钥。
+// 新密钥在 ActivatesAt 之前只通过 JWKS 发布公钥，之后才用于签名；被新密钥取代后在宽限期内仍可用于验证
+type SigningKey struct {
+	ID          uint      `gorm:"primaryKey"`
+	KID         string    `gorm:"column:kid;uniqueIndex;size:64;not null"` // 公钥的 JWK 指纹，写入令牌头部的 kid
+	Algorithm   string    `gorm:"size:16;not null"`                        // RS256 或 EdDSA
+	PrivateKey  string    `gorm:"type:text;not null"`                      // PKCS#8 PEM
+	ActivatesAt time.Time `gorm:"index;not null"`
+	CreatedAt   time.Time
+}
@@ -11,6 +11,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -101,7 +102,7 @@ func (rr *RoutesRegister) getMenu(w http.ResponseWriter, r *http.Request) {
 			menu = append(menu, m)
 		} else {
 			for _, role := range user.Roles {
-				if ok, _ := rr.authService.CasbinEnforcer().Enforce(role.Name, m.Permission, ""); ok {
+				if ok, _ := rr.authService.CasbinEnforcer().Enforce(strings.ToLower(role.Name), m.Permission, ""); ok {
 					menu = append(menu, m)
 				}
 			}
@@ -0,0 +1,88 @@
+// services/answer_policy.go
+package services
+
+import (
+	"learn/internal/dto"
+	"learn/internal/models"
+)
+
+// QuestionView 表示调用方查看题目时所处的场景
+type QuestionView int
+
+const (
+	ViewPractice QuestionView = iota // 学员练习，作答前
+	ViewExam                         // 考试进行中
+	ViewReview                       // 作答或交卷后回顾
+	ViewAuthor                       // 出题人编辑题目
+)
+
+func (v QuestionView) String() string {
+	switch v {
+	case ViewPractice:
+		return "practice"
+	case ViewExam:
+		return "exam"
+	case ViewReview:
+		return "review"
+	case ViewAuthor:
+		return "author"
+	}
+	return ""
+}
+
+// ParseQuestionView 解析查询参数中的场景名称
+func ParseQuestionView(name string) (QuestionView, bool) {
+	for _, view := range []QuestionView{ViewPractice, ViewExam, ViewReview, ViewAuthor} {
+		if view.String() == name {
+			return view, true
+		}
+	}
+	return ViewPractice, false
+}
+
+// AnswerPolicy 决定题目响应中哪些答案相关字段可见
+type AnswerPolicy struct {
+	ShowAnswers     bool // 正确选项、判断题答案、参考答案和填空答案
+	ShowExplanation bool // 题目解析
+	ShowMetadata    bool // 标签和难度
+}
+
+// AnswerPolicyFor 返回场景对应的可见性策略，作答前的场景一律不下发答案和解析；
+// 考试中还隐藏标签和难度，标签常常点明考查的知识点
+func AnswerPolicyFor(view QuestionView) AnswerPolicy {
+	switch view {
+	case ViewReview, ViewAuthor:
+		return AnswerPolicy{ShowAnswers: true, ShowExplanation: true, ShowMetadata: true}
+	case ViewExam:
+		return AnswerPolicy{}
+	default:
+		return AnswerPolicy{ShowMetadata: true}
+	}
+}
+
+// NewQuestionResponse 按可见性策略构建题目响应，所有题目接口都通过它输出题目
+func NewQuestionResponse(question *models.Question, policy AnswerPolicy) dto.QuestionResponse {
+	response := dto.QuestionResponse{
+		ID:             question.ID,
+		QuestionBankID: question.QuestionBankID,
+		QuestionType:   question.QuestionType,
+		Content:        question.Content,
+		Position:       question.Position,
+		AuthorID:       question.AuthorID,
+		AuthorName:     question.Author.Username,
+		CreatedAt:      question.CreatedAt,
+	}
+	if policy.ShowExplanation {
+		response.Explanation = question.Explanation
+	}
+	if policy.ShowMetadata {
+		response.Difficulty = question.Difficulty
+		for _, tag := range question.Tags {
+			response.Tags = append(response.Tags, tag.Name)
+		}
+	}
+	if questionType, err := GetQuestionType(question.QuestionType); err == nil {
+		questionType.FillResponse(&response, question, policy)
+	}
+	return response
+}
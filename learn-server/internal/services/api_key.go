@@ -0,0 +1,179 @@
+// services/api_key.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// APIKeyPrefix 是所有 API Key 的开头，便于在日志和代码仓库中识别泄露的密钥
+	APIKeyPrefix = "lsk_"
+	// apiKeyTouchInterval 内重复使用同一密钥不再更新最近使用时间，避免每个请求都写数据库
+	apiKeyTouchInterval = time.Minute
+)
+
+// 审计日志的事件类型
+const (
+	AuditAPIKeyCreated = "api_key_created"
+	AuditAPIKeyRevoked = "api_key_revoked"
+	// AuditReauthenticationFailed 记录敏感操作前重新校验身份时输错当前密码
+	AuditReauthenticationFailed = "reauthentication_failed"
+)
+
+var (
+	ErrInvalidAPIKey            = errors.New("invalid or expired API key")
+	ErrAPIKeyNameRequired       = errors.New("API key name is required")
+	ErrAPIKeyScopeRequired      = errors.New("API key needs at least one permission")
+	ErrAPIKeyScopeNotAllowed    = errors.New("permission is not granted to the user")
+	ErrAPIKeyExpiry             = errors.New("API key expiry must be in the future")
+	ErrReauthenticationRequired = errors.New("current password or verification code is required")
+)
+
+// Reauthenticate 在敏感操作前重新校验用户身份：提供验证码时校验两步验证码或恢复码，否则校验当前密码。
+// 避免被盗用的访问令牌直接换成长期有效的凭据
+func (s *AuthService) Reauthenticate(userID uint, password, code, ip string) error {
+	if strings.TrimSpace(code) != "" {
+		return s.VerifyMFACode(userID, code)
+	}
+	if password == "" {
+		return ErrReauthenticationRequired
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.verifyCurrentPassword(user, password, ip, AuditReauthenticationFailed)
+}
+
+// CreateAPIKey 为用户创建 API Key，scopes 必须是用户当前拥有的权限。
+// 返回的明文密钥只有这一次可以看到
+func (s *AuthService) CreateAPIKey(userID uint, name string, scopes []string, expiresAt *time.Time, ip string) (models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return models.APIKey{}, "", ErrAPIKeyNameRequired
+	}
+	if len(scopes) == 0 {
+		return models.APIKey{}, "", ErrAPIKeyScopeRequired
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return models.APIKey{}, "", ErrAPIKeyExpiry
+	}
+	user, err := s.GetUserByIDWithRoles(userID)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	var permissions []models.Permission
+	if err := s.db.Where("name IN ?", scopes).Find(&permissions).Error; err != nil {
+		return models.APIKey{}, "", err
+	}
+	for _, scope := range scopes {
+		known := slices.ContainsFunc(permissions, func(p models.Permission) bool { return p.Name == scope })
+		if !known || !s.HasPermission(context.Background(), user, scope) {
+			return models.APIKey{}, "", fmt.Errorf("%w: %s", ErrAPIKeyScopeNotAllowed, scope)
+		}
+	}
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	key := APIKeyPrefix + secret
+	apiKey := models.APIKey{
+		UserID:      user.ID,
+		Name:        name,
+		Prefix:      key[:len(APIKeyPrefix)+8],
+		KeyHash:     hashToken(key),
+		Permissions: permissions,
+		ExpiresAt:   expiresAt,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&apiKey).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditAPIKeyCreated, &user, ip,
+			fmt.Sprintf("%s (%s), permissions: %s", apiKey.Name, apiKey.Prefix, strings.Join(apiKey.Scopes(), ", ")))
+	})
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	return apiKey, key, nil
+}
+
+// GetAPIKeys 返回用户未吊销的 API Key，最新创建的排在前面
+func (s *AuthService) GetAPIKeys(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.db.Preload("Permissions").Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey 吊销用户的某个 API Key，密钥不存在或已吊销时返回 gorm.ErrRecordNotFound
+func (s *AuthService) RevokeAPIKey(userID, keyID uint, ip string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var apiKey models.APIKey
+		if err := tx.Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).First(&apiKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&apiKey).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditAPIKeyRevoked, &user, ip, fmt.Sprintf("%s (%s)", apiKey.Name, apiKey.Prefix))
+	})
+}
+
+// AuthenticateAPIKey 校验 API Key，返回所属用户（包含角色）和密钥，并记录最近使用时间和 IP
+func (s *AuthService) AuthenticateAPIKey(key, ip string) (models.User, models.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return models.User{}, models.APIKey{}, ErrInvalidAPIKey
+	}
+	var apiKey models.APIKey
+	if err := s.db.Preload("Permissions").Where("key_hash = ?", hashToken(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, models.APIKey{}, ErrInvalidAPIKey
+		}
+		return models.User{}, models.APIKey{}, err
+	}
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) {
+		return models.User{}, models.APIKey{}, ErrInvalidAPIKey
+	}
+	user, err := s.GetUserByIDWithRoles(apiKey.UserID)
+	if err != nil {
+		return models.User{}, models.APIKey{}, err
+	}
+	if user.Status != models.StatusActive {
+		return models.User{}, models.APIKey{}, ErrUserInactive
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval || apiKey.LastUsedIP != ip {
+		if err := s.db.Model(&apiKey).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error; err != nil {
+			return models.User{}, models.APIKey{}, err
+		}
+	}
+	return user, apiKey, nil
+}
+
+// CreateServiceAccount 创建服务账号。服务账号使用随机密码且不能用密码登录，只能通过 API Key 调用接口
+func (s *AuthService) CreateServiceAccount(username string, roles []string) (models.User, error) {
+	password, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	return s.createUser(username, password, "", roles, models.StatusActive, func(tx *gorm.DB, user *models.User) error {
+		user.ServiceAccount = true
+		return tx.Model(user).Update("service_account", true).Error
+	})
+}
@@ -0,0 +1,370 @@
+// services/assignment_service.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAssignment   = errors.New("invalid assignment")
+	ErrAssignmentNotOpen   = errors.New("assignment is not open yet")
+	ErrAssignmentClosed    = errors.New("assignment is past due")
+	ErrAttemptLimitReached = errors.New("attempt limit reached")
+	ErrSubmissionConflict  = errors.New("another submission is in progress")
+)
+
+type AssignmentService struct {
+	db          *gorm.DB
+	quizService *QuizService
+	// Events 不为空时推送作业布置、截止提醒和判分结果
+	Events *EventBus
+}
+
+func NewAssignmentService(db *gorm.DB, quizService *QuizService) *AssignmentService {
+	return &AssignmentService{db: db, quizService: quizService}
+}
+
+// CreateAssignment 为班级布置作业，题目可以是整个题库或按顺序挑选的题目
+func (s *AssignmentService) CreateAssignment(classID, creatorID uint, req dto.CreateAssignmentRequest) (*models.Assignment, error) {
+	assignment := models.Assignment{
+		ClassID:        classID,
+		Title:          strings.TrimSpace(req.Title),
+		Description:    req.Description,
+		QuestionBankID: req.QuestionBankID,
+		OpenAt:         req.OpenAt,
+		DueAt:          req.DueAt,
+		MaxAttempts:    req.MaxAttempts,
+		LatePolicy:     req.LatePolicy,
+		LatePenalty:    req.LatePenalty,
+		CreatorID:      creatorID,
+	}
+	if assignment.OpenAt.IsZero() {
+		assignment.OpenAt = time.Now()
+	}
+	for i, questionID := range req.QuestionIDs {
+		assignment.Questions = append(assignment.Questions, models.AssignmentQuestion{QuestionID: questionID, Position: i + 1})
+	}
+	if err := s.validateAssignment(&assignment); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAssignment, err)
+	}
+
+	if err := s.db.Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+
+	students, err := s.classStudents(classID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(EventAssignmentPublished, newAssignmentEvent(&assignment), students...)
+	return &assignment, nil
+}
+
+// GetAssignment 返回作业及其挑选的题目
+func (s *AssignmentService) GetAssignment(assignmentID uint) (*models.Assignment, error) {
+	var assignment models.Assignment
+	if err := s.db.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).First(&assignment, assignmentID).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// GetClassAssignments 返回班级的作业，截止时间早的排在前面
+func (s *AssignmentService) GetClassAssignments(classID uint) ([]models.Assignment, error) {
+	var assignments []models.Assignment
+	if err := s.db.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("class_id = ?", classID).Order("due_at").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// GetUserAssignments 返回用户所在班级的全部作业
+func (s *AssignmentService) GetUserAssignments(userID uint) ([]models.Assignment, error) {
+	var assignments []models.Assignment
+	if err := s.db.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Joins("JOIN class_members cm ON cm.class_id = assignments.class_id").
+		Where("cm.user_id = ?", userID).Order("assignments.due_at").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// GetAssignmentQuestions 按作业顺序返回题目及答案；布置整个题库时按题目 ID 排序
+func (s *AssignmentService) GetAssignmentQuestions(assignment *models.Assignment) ([]models.Question, error) {
+	var questionIDs []uint
+	if assignment.QuestionBankID != nil {
+		if err := s.db.Model(&models.Question{}).
+			Where("question_bank_id = ? AND parent_id IS NULL", *assignment.QuestionBankID).
+			Order("id").Pluck("id", &questionIDs).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		for _, question := range assignment.Questions {
+			questionIDs = append(questionIDs, question.QuestionID)
+		}
+	}
+
+	questions := make([]models.Question, len(questionIDs))
+	for i, questionID := range questionIDs {
+		question, err := s.quizService.GetQuestionDetail(questionID)
+		if err != nil {
+			return nil, err
+		}
+		questions[i] = *question
+	}
+	return questions, nil
+}
+
+// GetSubmissions 返回学员在作业中的全部提交
+func (s *AssignmentService) GetSubmissions(assignmentID, userID uint) ([]models.AssignmentSubmission, error) {
+	var submissions []models.AssignmentSubmission
+	if err := s.db.Where("assignment_id = ? AND user_id = ?", assignmentID, userID).
+		Order("attempt").Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// Submit 为学员的作答判分并记录提交。每道题的作答同时计入答题记录和错题本，
+// 未作答的题按错误计；截止后的提交按作业的迟交规则处理
+func (s *AssignmentService) Submit(assignment *models.Assignment, userID uint, answers map[string]interface{}) (*models.AssignmentSubmission, []*AttemptResult, error) {
+	now := time.Now()
+	if now.Before(assignment.OpenAt) {
+		return nil, nil, ErrAssignmentNotOpen
+	}
+	late := now.After(assignment.DueAt)
+	if late && assignment.LatePolicy == models.LateNotAllowed {
+		return nil, nil, ErrAssignmentClosed
+	}
+
+	questions, err := s.GetAssignmentQuestions(assignment)
+	if err != nil {
+		return nil, nil, err
+	}
+	questionAnswers := make([]QuestionAnswer, len(questions))
+	for i, question := range questions {
+		key := strconv.FormatUint(uint64(question.ID), 10)
+		questionAnswers[i] = QuestionAnswer{QuestionID: question.ID, Answer: answers[key]}
+	}
+	graded, err := s.quizService.gradeAnswers(questionAnswers, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := firstGradeError(graded); err != nil {
+		return nil, nil, err
+	}
+	answersJSON, err := json.Marshal(answers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 次数检查、答题记录和提交在同一事务中，提交失败时不留下答题记录和经验值
+	submission := models.AssignmentSubmission{
+		AssignmentID: assignment.ID,
+		UserID:       userID,
+		Late:         late,
+		Answers:      answersJSON,
+		SubmittedAt:  now,
+	}
+	var results []*AttemptResult
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var previous int64
+		if err := tx.Model(&models.AssignmentSubmission{}).
+			Where("assignment_id = ? AND user_id = ?", assignment.ID, userID).Count(&previous).Error; err != nil {
+			return err
+		}
+		if assignment.MaxAttempts > 0 && uint(previous) >= assignment.MaxAttempts {
+			return ErrAttemptLimitReached
+		}
+		submission.Attempt = uint(previous) + 1
+
+		if results, err = s.quizService.recordGradedAttempts(tx, userID, graded, now); err != nil {
+			return err
+		}
+		for _, result := range results {
+			submission.RawScore += result.Grade.Score
+		}
+		if len(results) > 0 {
+			submission.RawScore /= float64(len(results))
+		}
+		submission.Score = submission.RawScore
+		if late && assignment.LatePolicy == models.LatePenalized {
+			submission.Score = submission.RawScore * (1 - assignment.LatePenalty)
+		}
+		return tx.Create(&submission).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrAttemptLimitReached) {
+			return nil, nil, err
+		}
+		// 并发提交占用了同一个次数时唯一索引拒绝插入
+		var taken int64
+		if s.db.Model(&models.AssignmentSubmission{}).Where("assignment_id = ? AND user_id = ? AND attempt = ?",
+			assignment.ID, userID, submission.Attempt).Count(&taken); taken > 0 {
+			return nil, nil, ErrSubmissionConflict
+		}
+		return nil, nil, err
+	}
+	s.quizService.publishBadges(userID, results)
+	return &submission, results, nil
+}
+
+// GetGradebook 汇总班级每名学员在作业中的提交次数和最高得分
+func (s *AssignmentService) GetGradebook(assignment *models.Assignment) (*dto.GradebookResponse, error) {
+	var students []models.ClassMember
+	if err := s.db.Preload("User").Where("class_id = ? AND role = ?", assignment.ClassID, models.ClassRoleStudent).
+		Order("user_id").Find(&students).Error; err != nil {
+		return nil, err
+	}
+
+	gradebook := &dto.GradebookResponse{
+		AssignmentID: assignment.ID,
+		Title:        assignment.Title,
+		DueAt:        assignment.DueAt,
+		StudentCount: len(students),
+		Students:     make([]dto.GradebookEntry, len(students)),
+	}
+	var totalScore float64
+	for i, student := range students {
+		submissions, err := s.GetSubmissions(assignment.ID, student.UserID)
+		if err != nil {
+			return nil, err
+		}
+		entry := dto.GradebookEntry{
+			UserID:      student.UserID,
+			Username:    student.User.Username,
+			Submissions: uint(len(submissions)),
+			Completed:   len(submissions) > 0,
+		}
+		for j, submission := range submissions {
+			if j == 0 || submission.Score > entry.BestScore {
+				entry.BestScore = submission.Score
+				entry.Late = submission.Late
+			}
+			submittedAt := submission.SubmittedAt
+			entry.LastSubmittedAt = &submittedAt
+		}
+		if entry.Completed {
+			gradebook.CompletedCount++
+			totalScore += entry.BestScore
+		}
+		gradebook.Students[i] = entry
+	}
+	if gradebook.CompletedCount > 0 {
+		gradebook.AverageScore = totalScore / float64(gradebook.CompletedCount)
+	}
+	return gradebook, nil
+}
+
+// NotifyDueSoon 提醒尚未提交的学员：作业将在 lead 时间内截止。每份作业只提醒一次，返回提醒的作业数
+func (s *AssignmentService) NotifyDueSoon(lead time.Duration) (int, error) {
+	now := time.Now()
+	var assignments []models.Assignment
+	if err := s.db.Where("reminded_at IS NULL AND due_at > ? AND due_at <= ?", now, now.Add(lead)).
+		Find(&assignments).Error; err != nil {
+		return 0, err
+	}
+
+	for i := range assignments {
+		assignment := &assignments[i]
+		var pending []uint
+		if err := s.db.Model(&models.ClassMember{}).
+			Where("class_id = ? AND role = ?", assignment.ClassID, models.ClassRoleStudent).
+			Where("user_id NOT IN (?)", s.db.Model(&models.AssignmentSubmission{}).
+				Select("user_id").Where("assignment_id = ?", assignment.ID)).
+			Pluck("user_id", &pending).Error; err != nil {
+			return i, err
+		}
+		if err := s.db.Model(assignment).Update("reminded_at", now).Error; err != nil {
+			return i, err
+		}
+		s.publish(EventAssignmentDueSoon, newAssignmentEvent(assignment), pending...)
+	}
+	return len(assignments), nil
+}
+
+// classStudents 返回班级学员的用户 ID
+func (s *AssignmentService) classStudents(classID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := s.db.Model(&models.ClassMember{}).Where("class_id = ? AND role = ?", classID, models.ClassRoleStudent).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (s *AssignmentService) publish(eventType string, data interface{}, userIDs ...uint) {
+	if s.Events != nil && len(userIDs) > 0 {
+		s.Events.Publish(eventType, data, userIDs...)
+	}
+}
+
+func newAssignmentEvent(assignment *models.Assignment) dto.AssignmentEvent {
+	return dto.AssignmentEvent{
+		AssignmentID: assignment.ID,
+		ClassID:      assignment.ClassID,
+		Title:        assignment.Title,
+		OpenAt:       assignment.OpenAt,
+		DueAt:        assignment.DueAt,
+	}
+}
+
+// validateAssignment 校验作业的题目来源、时间和迟交规则
+func (s *AssignmentService) validateAssignment(assignment *models.Assignment) error {
+	if assignment.Title == "" {
+		return errors.New("title is required")
+	}
+	if assignment.DueAt.IsZero() || !assignment.DueAt.After(assignment.OpenAt) {
+		return errors.New("due date must be after the open date")
+	}
+	if assignment.LatePolicy.String() == "" {
+		return errors.New("unknown late policy")
+	}
+	if assignment.LatePenalty < 0 || assignment.LatePenalty > 1 {
+		return errors.New("late penalty must be between 0 and 1")
+	}
+
+	if (assignment.QuestionBankID != nil) == (len(assignment.Questions) > 0) {
+		return errors.New("either a question bank or a list of questions is required")
+	}
+	if assignment.QuestionBankID != nil {
+		if err := s.db.First(&models.QuestionBank{}, *assignment.QuestionBankID).Error; err != nil {
+			return errors.New("question bank not found")
+		}
+		return nil
+	}
+
+	seen := map[uint]bool{}
+	var questionIDs []uint
+	for _, question := range assignment.Questions {
+		if seen[question.QuestionID] {
+			return fmt.Errorf("question %d is listed twice", question.QuestionID)
+		}
+		seen[question.QuestionID] = true
+		questionIDs = append(questionIDs, question.QuestionID)
+	}
+	// 组合题的小题只能随组合题一起布置
+	var count int64
+	if err := s.db.Model(&models.Question{}).
+		Where("id IN ? AND parent_id IS NULL", questionIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(questionIDs) {
+		return errors.New("question not found")
+	}
+	return nil
+}
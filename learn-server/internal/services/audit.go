@@ -0,0 +1,48 @@
+// services/audit.go
+package services
+
+import (
+	"learn/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 审计日志的事件类型
+const (
+	AuditAccountLocked   = "account_locked"
+	AuditAccountUnlocked = "account_unlocked"
+)
+
+// AuditLogFilter 是查询审计日志的条件，零值表示不过滤
+type AuditLogFilter struct {
+	Action string
+	UserID uint
+}
+
+// GetAuditLogs 按时间倒序分页查询审计日志
+func (s *AuthService) GetAuditLogs(filter AuditLogFilter, page, pageSize int) ([]models.AuditLog, int64, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []models.AuditLog
+	err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error
+	return logs, total, err
+}
+
+// recordAudit 写入一条审计日志，user 为空时表示与具体用户无关
+func recordAudit(tx *gorm.DB, action string, user *models.User, ip, detail string) error {
+	entry := models.AuditLog{Action: action, IP: ip, Detail: detail}
+	if user != nil {
+		entry.UserID = &user.ID
+		entry.Username = user.Username
+	}
+	return tx.Create(&entry).Error
+}
@@ -1,11 +1,17 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"learn/internal/consts/claimkeys"
+	"learn/internal/consts/contextkeys"
 	"learn/internal/dto"
 	"learn/internal/models"
+	"learn/pkg/utils"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,16 +28,35 @@ func VerifyPassword(hashedPassword, password string) error {
 }
 
 type AuthService struct {
-	db                        *gorm.DB
-	casbinEnforcer            *casbin.Enforcer
-	jwtSecret                 string
-	accessTokenDuration       time.Duration
-	refreshTokenDuration      time.Duration
-	refreshTokenSlidingWindow time.Duration
+	db                   *gorm.DB
+	casbinEnforcer       *casbin.Enforcer
+	jwtSecret            string
+	signingKeys          *signingKeyring // 为空时使用 jwtSecret 以 HS256 签名
+	accessTokenDuration  time.Duration
+	refreshTokenDuration time.Duration // 一次登录的会话有效期，刷新不会延长
+	policyLoaders        []PolicyLoader
+	authenticators       []Authenticator
+
+	MFAIssuer                  string                // 验证器中显示的服务名称
+	PasswordPolicy             PasswordPolicy        // 创建用户和修改密码时检查
+	LoginThrottle              LoginThrottleSettings // 登录失败后的限流和临时锁定
+	Notifier                   Notifier              // 发送找回密码等通知，为空时不能找回密码
+	PasswordResetURL           string                // 找回密码邮件中的链接，令牌作为 token 参数附加
+	PasswordResetTokenDuration time.Duration         // 找回密码令牌的有效期
+	Registration               RegistrationSettings  // 自助注册的审核和邮箱验证
 }
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+)
+
+// PolicyLoader 向 enforcer 写入角色权限以外的策略，例如班级成员关系。
+// 每次重新加载策略时都会被调用。
+type PolicyLoader func(enforcer *casbin.Enforcer) error
+
 func NewAuthService(db *gorm.DB, jwtSecret string,
-	accessTokenDuration, refreshTokenDuration, refreshTokenSlidingWindow time.Duration) *AuthService {
+	accessTokenDuration, refreshTokenDuration time.Duration) *AuthService {
 	// 加载 Casbin 模型
 	m, err := model.NewModelFromString(`
 		[request_definition]
@@ -60,13 +85,19 @@ func NewAuthService(db *gorm.DB, jwtSecret string,
 	}
 
 	s := &AuthService{
-		db:                        db,
-		casbinEnforcer:            enforcer,
-		jwtSecret:                 jwtSecret,
-		accessTokenDuration:       accessTokenDuration,
-		refreshTokenDuration:      refreshTokenDuration,
-		refreshTokenSlidingWindow: refreshTokenSlidingWindow,
+		db:                   db,
+		casbinEnforcer:       enforcer,
+		jwtSecret:            jwtSecret,
+		accessTokenDuration:  accessTokenDuration,
+		refreshTokenDuration: refreshTokenDuration,
+		MFAIssuer:            DefaultMFAIssuer,
+		PasswordPolicy:       PasswordPolicy{MinLength: DefaultPasswordMinLength},
+		LoginThrottle:        DefaultLoginThrottle,
+
+		PasswordResetTokenDuration: DefaultPasswordResetTokenDuration,
+		Registration:               RegistrationSettings{VerificationTokenDuration: DefaultEmailVerificationTokenDuration},
 	}
+	s.authenticators = []Authenticator{&localAuthenticator{db: db}}
 
 	s.loadCasbinEnforcer()
 
@@ -90,9 +121,20 @@ func (s *AuthService) loadCasbinEnforcer() error {
 			s.casbinEnforcer.AddPolicy(roleName, permission.Name, "")
 		}
 	}
+	for _, loader := range s.policyLoaders {
+		if err := loader(s.casbinEnforcer); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// AddPolicyLoader 注册策略加载器并立即加载一次
+func (s *AuthService) AddPolicyLoader(loader PolicyLoader) error {
+	s.policyLoaders = append(s.policyLoaders, loader)
+	return loader(s.casbinEnforcer)
+}
+
 func (s *AuthService) CasbinEnforcer() *casbin.Enforcer {
 	return s.casbinEnforcer
 }
@@ -102,8 +144,27 @@ func (s *AuthService) JwtSecret() string {
 }
 
 func (s *AuthService) CreateUser(username, password string, roles []string, status models.UserStatus) (models.User, error) {
+	return s.CreateUserWithEmail(username, password, "", roles, status)
+}
+
+// CreateUserWithEmail 创建用户并设置邮箱，邮箱为空表示不设置
+func (s *AuthService) CreateUserWithEmail(username, password, email string, roles []string, status models.UserStatus) (models.User, error) {
+	return s.createUser(username, password, email, roles, status, nil)
+}
+
+// createUser 创建用户，then 不为空时在同一事务中接着执行
+func (s *AuthService) createUser(username, password, email string, roles []string, status models.UserStatus,
+	then func(tx *gorm.DB, user *models.User) error) (models.User, error) {
 	var user models.User
 
+	if err := s.PasswordPolicy.Validate(username, password); err != nil {
+		return models.User{}, err
+	}
+	emailAddress, err := s.checkEmail(email, 0)
+	if err != nil {
+		return models.User{}, err
+	}
+
 	// 哈希密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -114,6 +175,7 @@ func (s *AuthService) CreateUser(username, password string, roles []string, stat
 	user = models.User{
 		Username:     username,
 		Password:     string(hashedPassword),
+		Email:        emailAddress,
 		TokenVersion: 1, // 初始化 TokenVersion
 		Status:       status,
 	}
@@ -135,6 +197,9 @@ func (s *AuthService) CreateUser(username, password string, roles []string, stat
 			}
 		}
 
+		if then != nil {
+			return then(tx, &user)
+		}
 		return nil
 	})
 
@@ -150,7 +215,7 @@ func (s *AuthService) GetUsers() ([]models.User, error) {
 	var users []models.User
 
 	// 只选择需要的字段进行查询
-	err := s.db.Select("id, username, created_at, updated_at, status").Find(&users).Error
+	err := s.db.Select("id, username, email, created_at, updated_at, status, locked_until, service_account").Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +232,28 @@ func (s *AuthService) UpdateUser(userID uint, req dto.UpdateUserRequest) error {
 	// 更新用户名和状态
 	user.Username = req.Username
 	user.Status = models.UserStatus(req.Status)
+	if user.Status != models.StatusSuspended {
+		// 管理员恢复账号时同时解除临时锁定
+		user.LockedUntil = nil
+	}
+
+	if req.Email != nil {
+		email, err := s.checkEmail(*req.Email, user.ID)
+		if err != nil {
+			return err
+		}
+		if email == nil || user.Email == nil || *email != *user.Email {
+			// 新邮箱需要重新验证
+			user.EmailVerifiedAt = nil
+		}
+		user.Email = email
+	}
 
 	// 如果密码不为空，则更新密码
 	if req.Password != nil && *req.Password != "" {
+		if err := s.PasswordPolicy.Validate(user.Username, *req.Password); err != nil {
+			return err
+		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return err
@@ -197,7 +281,8 @@ func (s *AuthService) UpdateUser(userID uint, req dto.UpdateUserRequest) error {
 }
 
 func (s *AuthService) ActivateUser(userID uint) error {
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("status", models.StatusActive).Error
+	return s.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"status": models.StatusActive, "locked_until": nil}).Error
 }
 
 func (s *AuthService) DeactivateUser(userID uint) error {
@@ -238,20 +323,20 @@ func (s *AuthService) GetUserByIDWithRoles(userID uint) (models.User, error) {
 	return user, nil
 }
 
-// 检查用户是否具有指定权限
-// func (s *AuthService) HasPermission(user *models.User, requiredPermission string) bool {
-// 	for _, role := range user.Roles {
-// 		var permissions []models.Permission
-// 		s.db.Model(&role).Association("Permissions").Find(&permissions)
-
-// 		for _, permission := range permissions {
-// 			if permission.Name == requiredPermission {
-// 				return true
-// 			}
-// 		}
-// 	}
-// 	return false
-// }
+// HasPermission 检查用户的任一角色是否具有指定权限。
+// 请求使用 API Key 认证时（ctx 中有 contextkeys.APIKeyScopes），权限还必须在密钥的权限范围内
+func (s *AuthService) HasPermission(ctx context.Context, user models.User, permission string) bool {
+	if scopes, ok := ctx.Value(contextkeys.APIKeyScopes).([]string); ok && !slices.Contains(scopes, permission) {
+		return false
+	}
+	for _, role := range user.Roles {
+		// 策略中的角色名是小写的，见 loadCasbinEnforcer
+		if ok, _ := s.casbinEnforcer.Enforce(strings.ToLower(role.Name), permission, ""); ok {
+			return true
+		}
+	}
+	return false
+}
 
 // EnsurePermissionExists 检查权限是否存在，如果不存在则添加
 func (s *AuthService) EnsurePermissionExists(permissionName, description string) error {
@@ -263,6 +348,9 @@ func (s *AuthService) EnsurePermissionExists(permissionName, description string)
 			if err := s.db.Create(&permission).Error; err != nil {
 				return err
 			}
+			if err := s.grantSelfServicePermission(permission); err != nil {
+				return err
+			}
 		} else {
 			return err
 		}
@@ -276,6 +364,46 @@ func (s *AuthService) EnsurePermissionExists(permissionName, description string)
 	return nil
 }
 
+// selfServicePermissions 是后来加上的学员自助权限及其来源权限。这些权限首次创建时授予已拥有任一来源权限的角色，
+// 原来能使用这些功能的角色升级后不会失去它们；只在创建时授予一次，之后管理员对角色的调整不会被覆盖。
+// 账号安全相关的权限不在此列，由管理员按需授予，见 UPGRADING.md
+var selfServicePermissions = map[string][]string{
+	"quiz:attempt":      {"quiz:edit"}, // 作答原来使用 quiz:edit
+	"notebook:read":     {"quiz:read", "quiz:edit"},
+	"notebook:edit":     {"quiz:read", "quiz:edit"},
+	"live:play":         {"quiz:read", "quiz:edit"},
+	"events:read":       {"quiz:read", "quiz:edit"},
+	"gamification:read": {"quiz:read", "quiz:edit"},
+	"sync:read":         {"quiz:read", "quiz:edit"},
+	"sync:write":        {"quiz:read", "quiz:edit"},
+	"auth:password":     {"quiz:read", "quiz:edit"},
+}
+
+// grantSelfServicePermission 将新创建的自助权限授予拥有来源权限的已有角色
+func (s *AuthService) grantSelfServicePermission(permission models.Permission) error {
+	sources, ok := selfServicePermissions[permission.Name]
+	if !ok {
+		return nil
+	}
+	var roles []models.Role
+	holders := s.db.Table("roles_permissions").Select("roles_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = roles_permissions.permission_id").
+		Where("permissions.name IN ?", sources)
+	if err := s.db.Where("id IN (?)", holders).Find(&roles).Error; err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	for i := range roles {
+		if err := s.db.Model(&roles[i]).Association("Permissions").Append(&permission); err != nil {
+			return err
+		}
+		log.Printf("Granted new permission %s to role %s", permission.Name, roles[i].Name)
+	}
+	return s.loadCasbinEnforcer()
+}
+
 func (s *AuthService) CreateRole(roleName string) (models.Role, error) {
 	var role models.Role
 
@@ -382,28 +510,24 @@ func (s *AuthService) GetPermissions() ([]models.Permission, error) {
 }
 
 func (s *AuthService) InvalidateUserTokens(userID uint) error {
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("token_version", gorm.Expr("token_version + 1")).Error
+	return s.InvalidateUserToken(userID)
 }
 
+// Authenticate 依次尝试本地密码和注册的其他认证方式，全部失败时返回 ErrInvalidCredentials
 func (s *AuthService) Authenticate(username, password string) (models.User, error) {
-	var user models.User
-
-	// 查询用户信息
-	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
-		return models.User{}, errors.New("user not found")
-	}
-
-	// 检查用户状态是否为 Active
-	if user.Status != models.StatusActive {
-		return models.User{}, errors.New("user is not active")
-	}
-
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return models.User{}, errors.New("invalid credentials")
+	for _, authenticator := range s.authenticators {
+		user, err := authenticator.Authenticate(username, password)
+		if errors.Is(err, ErrInvalidCredentials) {
+			continue
+		}
+		return user, err
 	}
+	return models.User{}, ErrInvalidCredentials
+}
 
-	return user, nil
+// AddAuthenticator 注册一种认证方式，在本地密码校验失败后按注册顺序尝试
+func (s *AuthService) AddAuthenticator(authenticator Authenticator) {
+	s.authenticators = append(s.authenticators, authenticator)
 }
 
 func (s *AuthService) LoadUserRoles(user *models.User) error {
@@ -414,18 +538,25 @@ func (s *AuthService) LoadUserRoles(user *models.User) error {
 }
 
 func (s *AuthService) GenerateAccessToken(user models.User) (string, error) {
+	return s.generateAccessToken(user, 0)
+}
+
+// generateAccessToken 签发 Access Token，sessionID 不为 0 时令牌绑定该会话，会话吊销后立即失效
+func (s *AuthService) generateAccessToken(user models.User, sessionID uint) (string, error) {
 	if user.Roles == nil {
 		s.LoadUserRoles(&user)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		claimkeys.UserId:       user.ID,
 		claimkeys.UserName:     user.Username,
 		claimkeys.Role:         user.GetRoles(),
 		claimkeys.TokenVersion: user.TokenVersion,
 		claimkeys.Exp:          time.Now().Add(s.accessTokenDuration).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	}
+	if sessionID != 0 {
+		claims[claimkeys.SessionID] = sessionID
+	}
+	tokenString, err := s.signAccessToken(claims)
 	if err != nil {
 		return "", err
 	}
@@ -433,84 +564,210 @@ func (s *AuthService) GenerateAccessToken(user models.User) (string, error) {
 	return tokenString, nil
 }
 
-func (s *AuthService) GenerateTokens(user models.User) (string, string, error) {
-	// 生成 Access Token
-	accessToken, err := s.GenerateAccessToken(user)
+// GenerateTokens 为一次新登录创建会话，签发 Access Token 和不透明的 Refresh Token。
+// Refresh Token 开启一个新的令牌族，会话在 refreshTokenDuration 后过期
+func (s *AuthService) GenerateTokens(user models.User, device SessionDevice) (string, string, error) {
+	familyID, err := utils.GenerateSecureToken(16)
 	if err != nil {
 		return "", "", err
 	}
-
-	// 生成 Refresh Token，使用较长的有效期
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		claimkeys.UserId:       user.ID,
-		claimkeys.TokenVersion: user.TokenVersion,
-		claimkeys.Exp:          time.Now().Add(s.refreshTokenDuration).Unix(),
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		DeviceName: truncate(device.Name, 100),
+		UserAgent:  truncate(device.UserAgent, 255),
+		IP:         device.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTokenDuration),
+	}
+	var refreshToken string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		refreshToken, err = s.issueRefreshToken(tx, user.ID, familyID, session.ExpiresAt)
+		return err
 	})
+	if err != nil {
+		return "", "", err
+	}
 
-	refreshTokenString, err := refreshToken.SignedString([]byte(s.jwtSecret))
+	accessToken, err := s.generateAccessToken(user, session.ID)
 	if err != nil {
 		return "", "", err
 	}
 
-	return accessToken, refreshTokenString, nil
+	return accessToken, refreshToken, nil
 }
 
-func (s *AuthService) RefreshTokens(refreshTokenString string) (string, string, error) {
-	token, err := jwt.Parse(refreshTokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// RefreshTokens 用 Refresh Token 换取新的 Access Token 和 Refresh Token，旧的 Refresh Token 随即失效，
+// 同时更新会话的最近活动时间和设备信息。
+// 已换发过的 Refresh Token 再次使用时视为泄露，吊销整个令牌族
+func (s *AuthService) RefreshTokens(refreshTokenString string, device SessionDevice) (string, string, error) {
+	var record models.RefreshToken
+	if err := s.db.Where("token_hash = ?", hashToken(refreshTokenString)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", ErrInvalidRefreshToken
 		}
-		return []byte(s.jwtSecret), nil
-	})
+		return "", "", err
+	}
 
-	if err != nil {
+	now := time.Now()
+	switch {
+	case record.RevokedAt != nil:
+		return "", "", ErrInvalidRefreshToken
+	case record.UsedAt != nil:
+		return "", "", s.revokeReusedFamily(record.FamilyID)
+	case now.After(record.ExpiresAt):
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	// 从数据库获取用户
+	var user models.User
+	if err := s.db.First(&user, record.UserID).Error; err != nil {
 		return "", "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := uint(claims[claimkeys.UserId].(float64))
-		tokenVersion := uint(claims[claimkeys.TokenVersion].(float64))
-		expirationTime := int64(claims[claimkeys.Exp].(float64))
-		currentTime := time.Now().Unix()
+	// 检查用户状态是否为 Active
+	if user.Status != models.StatusActive {
+		return "", "", errors.New("user is not active")
+	}
 
-		// 从数据库获取用户
-		var user models.User
-		if err := s.db.First(&user, userID).Error; err != nil {
-			return "", "", err
+	var session models.Session
+	var newRefreshToken string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 按条件更新，并发的两次刷新只有一次能换发成功
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", record.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
 		}
-
-		// 检查 TokenVersion 是否匹配
-		if user.TokenVersion != tokenVersion {
-			return "", "", errors.New("token is no longer valid")
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
 		}
-
-		// 检查用户状态是否为 Active
-		if user.Status != models.StatusActive {
-			return "", "", errors.New("user is not active")
+		if err := tx.Where("family_id = ?", record.FamilyID).First(&session).Error; err != nil {
+			return err
 		}
-
-		// 检查是否需要更新 Refresh Token
-		if expirationTime-currentTime < int64(s.refreshTokenSlidingWindow.Seconds()) {
-			// 生成新的 Access Token 和 Refresh Token
-			newAccessToken, newRefreshToken, err := s.GenerateTokens(user)
-			if err != nil {
-				return "", "", err
-			}
-			return newAccessToken, newRefreshToken, nil
+		updates := map[string]interface{}{"last_seen_at": now}
+		if device.UserAgent != "" {
+			updates["user_agent"] = truncate(device.UserAgent, 255)
 		}
-
-		// 只生成新的 Access Token
-		newAccessToken, err := s.GenerateAccessToken(user)
-		if err != nil {
-			return "", "", err
+		if device.IP != "" {
+			updates["ip"] = device.IP
 		}
+		if err := tx.Model(&session).Updates(updates).Error; err != nil {
+			return err
+		}
+		var err error
+		newRefreshToken, err = s.issueRefreshToken(tx, user.ID, record.FamilyID, record.ExpiresAt)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return "", "", s.revokeReusedFamily(record.FamilyID)
+	}
+	if err != nil {
+		return "", "", err
+	}
 
-		return newAccessToken, refreshTokenString, nil
+	newAccessToken, err := s.generateAccessToken(user, session.ID)
+	if err != nil {
+		return "", "", err
 	}
 
-	return "", "", errors.New("invalid token")
+	return newAccessToken, newRefreshToken, nil
 }
 
+// InvalidateUserToken 使用户所有已签发的令牌失效，包括各设备的 Refresh Token 和 API Key
 func (s *AuthService) InvalidateUserToken(userID uint) error {
-	return s.db.Model(&models.User{}).Where("id = ?", userID).Update("token_version", gorm.Expr("token_version + ?", 1)).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return invalidateUserTokens(tx, userID, time.Now())
+	})
+}
+
+// invalidateUserTokens 递增 TokenVersion 并吊销用户的所有会话、Refresh Token 和 API Key
+func invalidateUserTokens(tx *gorm.DB, userID uint, now time.Time) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("token_version", gorm.Expr("token_version + ?", 1)).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeRefreshTokenFamily 吊销一次登录换发出的所有 Refresh Token 及其会话
+func (s *AuthService) RevokeRefreshTokenFamily(familyID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return revokeFamily(tx, familyID, time.Now())
+	})
+}
+
+// PurgeExpiredRefreshTokens 删除已过期会话的 Refresh Token 和会话记录，返回删除的 Refresh Token 数
+func (s *AuthService) PurgeExpiredRefreshTokens() (int64, error) {
+	now := time.Now()
+	result := s.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.Session{}).Error; err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
+
+// revokeReusedFamily 在检测到 Refresh Token 重放时吊销令牌族，并返回 ErrRefreshTokenReused
+func (s *AuthService) revokeReusedFamily(familyID string) error {
+	if err := s.RevokeRefreshTokenFamily(familyID); err != nil {
+		return err
+	}
+	log.Printf("Refresh token reuse detected, revoked token family %s", familyID)
+	return ErrRefreshTokenReused
+}
+
+// revokeFamily 在事务中吊销令牌族的所有 Refresh Token 及其会话
+func revokeFamily(tx *gorm.DB, familyID string, now time.Time) error {
+	if err := tx.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// issueRefreshToken 在令牌族中签发一个新的 Refresh Token，只保存其散列
+func (s *AuthService) issueRefreshToken(tx *gorm.DB, userID uint, familyID string, expiresAt time.Time) (string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	record := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashToken 计算 Refresh Token、恢复码等令牌的 SHA-256 散列
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,51 @@
+// services/authenticator.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"learn/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserInactive       = errors.New("user is not active")
+
+	errServiceAccountLogin = fmt.Errorf("%w: service accounts can only use API keys", ErrUserInactive)
+)
+
+// Authenticator 校验用户名和密码并返回对应的本地用户。
+// 用户名或密码不匹配时返回 ErrInvalidCredentials，AuthService 会继续尝试下一种认证方式；
+// 返回其他错误时认证立即失败
+type Authenticator interface {
+	Authenticate(username, password string) (models.User, error)
+}
+
+// localAuthenticator 使用本地保存的密码散列认证
+type localAuthenticator struct {
+	db *gorm.DB
+}
+
+func (a *localAuthenticator) Authenticate(username, password string) (models.User, error) {
+	var user models.User
+	if err := a.db.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, err
+	}
+	// 服务账号只能使用 API Key，直接拒绝，不再尝试其他认证方式
+	if user.ServiceAccount {
+		return models.User{}, errServiceAccountLogin
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+	if user.Status != models.StatusActive {
+		return models.User{}, ErrUserInactive
+	}
+	return user, nil
+}
@@ -0,0 +1,351 @@
+// services/class_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"log"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"gorm.io/gorm"
+)
+
+// 班级内的操作，通过 Casbin 按班级授权
+const (
+	ClassActionView   = "view"   // 查看班级信息和成员
+	ClassActionManage = "manage" // 管理成员、查看学员答题情况
+)
+
+// PermissionAllClasses 允许不是班级成员的用户（例如管理员）管理所有班级
+const PermissionAllClasses = "class:all"
+
+const inviteCodeLength = 8
+
+var (
+	ErrInvalidInviteCode = errors.New("invalid invite code")
+	ErrNotClassMember    = errors.New("user is not a member of the class")
+	ErrLastTeacher       = errors.New("class must keep at least one teacher")
+	ErrInviteRequired    = errors.New("new members must join with the invite code")
+)
+
+type ClassService struct {
+	db          *gorm.DB
+	authService *AuthService
+}
+
+// NewClassService 创建班级服务，并把班级成员关系注册为 Casbin 策略
+func NewClassService(db *gorm.DB, authService *AuthService) *ClassService {
+	s := &ClassService{db: db, authService: authService}
+	if err := authService.AddPolicyLoader(s.loadPolicies); err != nil {
+		log.Printf("failed to load class policies: %v", err)
+	}
+	return s
+}
+
+// CanAccessClass 判断用户能否在班级内执行指定操作，ctx 用于限制 API Key 的权限范围
+func (s *ClassService) CanAccessClass(ctx context.Context, user models.User, classID uint, action string) bool {
+	if s.authService.HasPermission(ctx, user, PermissionAllClasses) {
+		return true
+	}
+	ok, _ := s.authService.CasbinEnforcer().Enforce(userSubject(user.ID), classObject(classID), action)
+	return ok
+}
+
+// CreateClass 创建班级，创建者成为班级教师
+func (s *ClassService) CreateClass(creatorID uint, name, description string) (*models.Class, error) {
+	inviteCode, err := utils.GenerateInviteCode(inviteCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	class := models.Class{
+		Name:        name,
+		Description: description,
+		InviteCode:  inviteCode,
+		CreatorID:   creatorID,
+		Members:     []models.ClassMember{{UserID: creatorID, Role: models.ClassRoleTeacher}},
+	}
+	if err := s.db.Create(&class).Error; err != nil {
+		return nil, err
+	}
+
+	enforcer := s.authService.CasbinEnforcer()
+	addClassPolicies(enforcer, class.ID)
+	addMemberPolicy(enforcer, class.Members[0])
+	return &class, nil
+}
+
+// GetClass 返回班级及其成员
+func (s *ClassService) GetClass(classID uint) (*models.Class, error) {
+	var class models.Class
+	if err := s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("role DESC, joined_at")
+	}).Preload("Members.User").First(&class, classID).Error; err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+// GetUserClasses 返回用户所在的班级
+func (s *ClassService) GetUserClasses(userID uint) ([]models.Class, error) {
+	var classes []models.Class
+	if err := s.db.Joins("JOIN class_members cm ON cm.class_id = classes.id").
+		Where("cm.user_id = ?", userID).Order("classes.id").Find(&classes).Error; err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
+
+// JoinClass 通过邀请码以学员身份加入班级，已是成员时保持原有身份
+func (s *ClassService) JoinClass(userID uint, inviteCode string) (*models.Class, error) {
+	var class models.Class
+	code := strings.ToUpper(strings.TrimSpace(inviteCode))
+	if err := s.db.Where("invite_code = ?", code).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInviteCode
+		}
+		return nil, err
+	}
+	if _, err := s.saveMember(class.ID, userID, models.ClassRoleStudent, true); err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+// AddMember 调整班级成员的身份。班级教师只能调整已有成员，
+// 直接把新用户加入班级需要 class:all 权限，其他人只能通过邀请码加入
+func (s *ClassService) AddMember(ctx context.Context, actor models.User, classID, userID uint, role models.ClassRole) (*models.ClassMember, error) {
+	return s.saveMember(classID, userID, role, s.authService.HasPermission(ctx, actor, PermissionAllClasses))
+}
+
+// saveMember 更新成员身份，enroll 为 true 时用户不在班级中则将其加入
+func (s *ClassService) saveMember(classID, userID uint, role models.ClassRole, enroll bool) (*models.ClassMember, error) {
+	var member models.ClassMember
+	err := s.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&member).Error
+	switch {
+	case err == nil:
+		if member.Role == role {
+			return &member, nil
+		}
+		if member.Role == models.ClassRoleTeacher {
+			if err := s.ensureOtherTeacher(classID, userID); err != nil {
+				return nil, err
+			}
+		}
+		removeMemberPolicy(s.authService.CasbinEnforcer(), member)
+		member.Role = role
+		if err := s.db.Model(&member).Update("role", role).Error; err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !enroll {
+			return nil, ErrInviteRequired
+		}
+		if err := s.db.First(&models.User{}, userID).Error; err != nil {
+			return nil, err
+		}
+		member = models.ClassMember{ClassID: classID, UserID: userID, Role: role}
+		if err := s.db.Create(&member).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	addMemberPolicy(s.authService.CasbinEnforcer(), member)
+	return &member, nil
+}
+
+// RemoveMember 将用户移出班级，班级至少保留一名教师
+func (s *ClassService) RemoveMember(classID, userID uint) error {
+	var member models.ClassMember
+	if err := s.db.Where("class_id = ? AND user_id = ?", classID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotClassMember
+		}
+		return err
+	}
+	if member.Role == models.ClassRoleTeacher {
+		if err := s.ensureOtherTeacher(classID, userID); err != nil {
+			return err
+		}
+	}
+	if err := s.db.Delete(&member).Error; err != nil {
+		return err
+	}
+	removeMemberPolicy(s.authService.CasbinEnforcer(), member)
+	return nil
+}
+
+// RegenerateInviteCode 重新生成邀请码，旧邀请码随即失效
+func (s *ClassService) RegenerateInviteCode(classID uint) (string, error) {
+	inviteCode, err := utils.GenerateInviteCode(inviteCodeLength)
+	if err != nil {
+		return "", err
+	}
+	result := s.db.Model(&models.Class{}).Where("id = ?", classID).Update("invite_code", inviteCode)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return inviteCode, nil
+}
+
+// GetClassProgress 汇总班级内每名学员的答题情况，可按题库筛选
+func (s *ClassService) GetClassProgress(classID, questionBankID uint) ([]dto.StudentProgressResponse, error) {
+	var students []models.ClassMember
+	if err := s.db.Preload("User").Where("class_id = ? AND role = ?", classID, models.ClassRoleStudent).
+		Order("user_id").Find(&students).Error; err != nil {
+		return nil, err
+	}
+
+	progress := make([]dto.StudentProgressResponse, len(students))
+	for i, student := range students {
+		attempts, err := s.studentAttempts(classID, student.UserID, questionBankID)
+		if err != nil {
+			return nil, err
+		}
+		p := dto.StudentProgressResponse{UserID: student.UserID, Username: student.User.Username}
+		var totalScore float64
+		for _, attempt := range attempts {
+			p.AttemptedQuestions++
+			p.Attempts += attempt.Attempts
+			p.Wrong += attempt.Wrong
+			totalScore += attempt.LastScore
+			if p.LastAnswerAt == nil || attempt.LastAnswerAt.After(*p.LastAnswerAt) {
+				lastAnswerAt := attempt.LastAnswerAt
+				p.LastAnswerAt = &lastAnswerAt
+			}
+		}
+		if p.AttemptedQuestions > 0 {
+			p.AverageScore = totalScore / float64(p.AttemptedQuestions)
+		}
+		progress[i] = p
+	}
+	return progress, nil
+}
+
+// GetStudentAttempts 返回班级内某名学员的答题记录，可按题库筛选
+func (s *ClassService) GetStudentAttempts(classID, userID, questionBankID uint) ([]dto.QuestionAttemptResponse, error) {
+	var count int64
+	if err := s.db.Model(&models.ClassMember{}).
+		Where("class_id = ? AND user_id = ? AND role = ?", classID, userID, models.ClassRoleStudent).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrNotClassMember
+	}
+
+	attempts, err := s.studentAttempts(classID, userID, questionBankID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.QuestionAttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		result[i] = dto.QuestionAttemptResponse{
+			QuestionID:         attempt.QuestionID,
+			Attempts:           attempt.Attempts,
+			Wrong:              attempt.Wrong,
+			ConsecutiveCorrect: attempt.ConsecutiveCorrect,
+			LastScore:          attempt.LastScore,
+			LastAnswerAt:       attempt.LastAnswerAt,
+		}
+	}
+	return result, nil
+}
+
+// studentAttempts 查询学员在班级作业题目上的答题记录，组合题的小题不单独统计。
+// 只统计布置给该班级的题库和题目，学员在其他地方的练习对班级教师不可见
+func (s *ClassService) studentAttempts(classID, userID, questionBankID uint) ([]models.QuestionAttempt, error) {
+	assignedBanks := s.db.Model(&models.Assignment{}).Select("question_bank_id").
+		Where("class_id = ? AND question_bank_id IS NOT NULL", classID)
+	assignedQuestions := s.db.Model(&models.AssignmentQuestion{}).Select("assignment_questions.question_id").
+		Joins("JOIN assignments ON assignments.id = assignment_questions.assignment_id").
+		Where("assignments.class_id = ?", classID)
+	query := s.db.Joins("JOIN questions ON questions.id = question_attempts.question_id").
+		Where("question_attempts.user_id = ? AND questions.parent_id IS NULL", userID).
+		Where("(questions.question_bank_id IN (?) OR questions.id IN (?))", assignedBanks, assignedQuestions)
+	if questionBankID != 0 {
+		query = query.Where("questions.question_bank_id = ?", questionBankID)
+	}
+	var attempts []models.QuestionAttempt
+	if err := query.Order("question_attempts.question_id").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// ensureOtherTeacher 确认班级中除指定用户外还有其他教师
+func (s *ClassService) ensureOtherTeacher(classID, userID uint) error {
+	var count int64
+	if err := s.db.Model(&models.ClassMember{}).
+		Where("class_id = ? AND role = ? AND user_id <> ?", classID, models.ClassRoleTeacher, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastTeacher
+	}
+	return nil
+}
+
+// loadPolicies 按数据库中的班级和成员关系重建班级策略
+func (s *ClassService) loadPolicies(enforcer *casbin.Enforcer) error {
+	var classIDs []uint
+	if err := s.db.Model(&models.Class{}).Pluck("id", &classIDs).Error; err != nil {
+		return err
+	}
+	for _, classID := range classIDs {
+		addClassPolicies(enforcer, classID)
+	}
+
+	var members []models.ClassMember
+	if err := s.db.Find(&members).Error; err != nil {
+		return err
+	}
+	for _, member := range members {
+		addMemberPolicy(enforcer, member)
+	}
+	return nil
+}
+
+// 班级策略的形式：
+//
+//	p, class:1:teacher, class:1, manage
+//	p, class:1:teacher, class:1, view
+//	p, class:1:student, class:1, view
+//	g, user:7, class:1:student
+func addClassPolicies(enforcer *casbin.Enforcer, classID uint) {
+	teacher := classRoleSubject(classID, models.ClassRoleTeacher)
+	student := classRoleSubject(classID, models.ClassRoleStudent)
+	enforcer.AddPolicy(teacher, classObject(classID), ClassActionManage)
+	enforcer.AddPolicy(teacher, classObject(classID), ClassActionView)
+	enforcer.AddPolicy(student, classObject(classID), ClassActionView)
+}
+
+func addMemberPolicy(enforcer *casbin.Enforcer, member models.ClassMember) {
+	enforcer.AddGroupingPolicy(userSubject(member.UserID), classRoleSubject(member.ClassID, member.Role))
+}
+
+func removeMemberPolicy(enforcer *casbin.Enforcer, member models.ClassMember) {
+	enforcer.RemoveGroupingPolicy(userSubject(member.UserID), classRoleSubject(member.ClassID, member.Role))
+}
+
+func userSubject(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func classObject(classID uint) string {
+	return fmt.Sprintf("class:%d", classID)
+}
+
+func classRoleSubject(classID uint, role models.ClassRole) string {
+	return fmt.Sprintf("class:%d:%s", classID, role)
+}
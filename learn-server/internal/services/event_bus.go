@@ -0,0 +1,115 @@
+// services/event_bus.go
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// 推送给用户的事件类型。考试时间提醒、自动交卷和人工批改完成的通知
+// 需要限时考试和人工批改流程，目前还没有，届时在这里补充
+const (
+	EventAssignmentPublished = "assignment.published" // 班级布置了新作业
+	EventAssignmentDueSoon   = "assignment.due_soon"  // 作业即将截止且尚未提交
+	EventBadgeEarned         = "badge.earned"         // 获得了新徽章
+)
+
+const (
+	// eventHistorySize 是每名用户保留的最近事件数，断线重连时据此补发
+	eventHistorySize = 50
+	// eventBuffer 是每个订阅待发送事件的缓冲数，写满说明客户端太慢，直接断开
+	eventBuffer = 32
+)
+
+// Event 是发布给某个用户的事件，ID 全局递增
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	UserID    uint        `json:"-"`
+	Data      interface{} `json:"data,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// EventBus 是进程内的发布订阅总线，服务发布事件，连接按用户订阅
+type EventBus struct {
+	mu            sync.Mutex
+	nextID        uint64
+	subscriptions map[uint]map[*EventSubscription]bool
+	history       map[uint][]Event
+}
+
+// EventSubscription 是一个连接的订阅，取消订阅或消费太慢时 Events 会被关闭
+type EventSubscription struct {
+	UserID uint
+	Events chan Event
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscriptions: map[uint]map[*EventSubscription]bool{},
+		history:       map[uint][]Event{},
+	}
+}
+
+// Publish 向每名用户发布一个事件
+func (b *EventBus) Publish(eventType string, data interface{}, userIDs ...uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	for _, userID := range userIDs {
+		b.nextID++
+		event := Event{ID: b.nextID, Type: eventType, UserID: userID, Data: data, CreatedAt: now}
+
+		history := append(b.history[userID], event)
+		if len(history) > eventHistorySize {
+			history = history[len(history)-eventHistorySize:]
+		}
+		b.history[userID] = history
+
+		for subscription := range b.subscriptions[userID] {
+			select {
+			case subscription.Events <- event:
+			default:
+				b.unsubscribe(subscription)
+			}
+		}
+	}
+}
+
+// Subscribe 订阅用户的事件，lastEventID 非零时先补发之后的历史事件
+func (b *EventBus) Subscribe(userID uint, lastEventID uint64) *EventSubscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscription := &EventSubscription{UserID: userID, Events: make(chan Event, eventBuffer+eventHistorySize)}
+	if lastEventID > 0 {
+		for _, event := range b.history[userID] {
+			if event.ID > lastEventID {
+				subscription.Events <- event
+			}
+		}
+	}
+	if b.subscriptions[userID] == nil {
+		b.subscriptions[userID] = map[*EventSubscription]bool{}
+	}
+	b.subscriptions[userID][subscription] = true
+	return subscription
+}
+
+// Unsubscribe 取消订阅并关闭 Events
+func (b *EventBus) Unsubscribe(subscription *EventSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unsubscribe(subscription)
+}
+
+func (b *EventBus) unsubscribe(subscription *EventSubscription) {
+	subscriptions := b.subscriptions[subscription.UserID]
+	if !subscriptions[subscription] {
+		return
+	}
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(b.subscriptions, subscription.UserID)
+	}
+	close(subscription.Events)
+}
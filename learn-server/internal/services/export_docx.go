@@ -0,0 +1,105 @@
+// services/export_docx.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+</Types>`
+
+const docxRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+// docxWriter 直接生成只含 document.xml 的最小 WordprocessingML 文档，便于教师再编辑
+type docxWriter struct {
+	body bytes.Buffer
+}
+
+func newDOCXWriter() *docxWriter {
+	return &docxWriter{}
+}
+
+func (w *docxWriter) Title(text string) {
+	w.paragraph(`<w:jc w:val="center"/><w:spacing w:after="240"/>`, `<w:b/><w:sz w:val="32"/>`, text)
+}
+
+func (w *docxWriter) Heading(text string) {
+	w.paragraph(`<w:spacing w:before="120"/>`, `<w:b/>`, text)
+}
+
+func (w *docxWriter) Paragraph(text string) {
+	w.paragraph("", "", text)
+}
+
+func (w *docxWriter) Indented(text string) {
+	w.paragraph(`<w:ind w:left="567"/>`, "", text)
+}
+
+func (w *docxWriter) BlankLines(n int) {
+	for i := 0; i < n; i++ {
+		w.paragraph(`<w:pBdr><w:bottom w:val="single" w:sz="4" w:space="1" w:color="auto"/></w:pBdr><w:spacing w:before="240"/>`, "", "")
+	}
+}
+
+func (w *docxWriter) PageBreak() {
+	w.body.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+}
+
+// paragraph 写入一个段落，文本中的换行转为段内换行
+func (w *docxWriter) paragraph(paragraphProps, runProps, text string) {
+	w.body.WriteString("<w:p>")
+	if paragraphProps != "" {
+		w.body.WriteString("<w:pPr>" + paragraphProps + "</w:pPr>")
+	}
+	w.body.WriteString("<w:r>")
+	if runProps != "" {
+		w.body.WriteString("<w:rPr>" + runProps + "</w:rPr>")
+	}
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if i > 0 {
+			w.body.WriteString("<w:br/>")
+		}
+		w.body.WriteString(`<w:t xml:space="preserve">`)
+		xml.EscapeText(&w.body, []byte(line))
+		w.body.WriteString("</w:t>")
+	}
+	w.body.WriteString("</w:r></w:p>")
+}
+
+func (w *docxWriter) Bytes() ([]byte, error) {
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		w.body.String() +
+		`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="0" w:footer="0" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRelationships},
+		{"word/document.xml", document},
+	} {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
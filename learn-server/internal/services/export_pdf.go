@@ -0,0 +1,78 @@
+// services/export_pdf.go
+package services
+
+import (
+	"bytes"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// pdfWriter 用 gofpdf 排版 A4 试卷
+type pdfWriter struct {
+	pdf       *gofpdf.Fpdf
+	font      string
+	boldStyle string
+	translate func(string) string
+}
+
+func newPDFWriter(fontPath string) *pdfWriter {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+
+	w := &pdfWriter{pdf: pdf, font: "Helvetica", boldStyle: "B"}
+	if fontPath != "" {
+		// UTF-8 字体只注册了常规字形，标题靠字号区分
+		pdf.AddUTF8Font("body", "", fontPath)
+		w.font, w.boldStyle = "body", ""
+		w.translate = func(text string) string { return text }
+	} else {
+		w.translate = pdf.UnicodeTranslatorFromDescriptor("")
+	}
+	pdf.AddPage()
+	return w
+}
+
+func (w *pdfWriter) Title(text string) {
+	w.pdf.SetFont(w.font, w.boldStyle, 16)
+	w.pdf.MultiCell(0, 9, w.translate(text), "", "C", false)
+	w.pdf.Ln(4)
+}
+
+func (w *pdfWriter) Heading(text string) {
+	w.pdf.Ln(2)
+	w.pdf.SetFont(w.font, w.boldStyle, 11)
+	w.pdf.MultiCell(0, 6, w.translate(text), "", "L", false)
+}
+
+func (w *pdfWriter) Paragraph(text string) {
+	w.pdf.SetFont(w.font, "", 11)
+	w.pdf.MultiCell(0, 6, w.translate(text), "", "L", false)
+}
+
+func (w *pdfWriter) Indented(text string) {
+	left, _, _, _ := w.pdf.GetMargins()
+	w.pdf.SetFont(w.font, "", 11)
+	w.pdf.SetLeftMargin(left + 8)
+	w.pdf.SetX(left + 8)
+	w.pdf.MultiCell(0, 6, w.translate(text), "", "L", false)
+	w.pdf.SetLeftMargin(left)
+}
+
+func (w *pdfWriter) BlankLines(n int) {
+	for i := 0; i < n; i++ {
+		w.pdf.CellFormat(0, 9, "", "B", 1, "L", false, 0, "")
+	}
+}
+
+func (w *pdfWriter) PageBreak() {
+	w.pdf.AddPage()
+}
+
+func (w *pdfWriter) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
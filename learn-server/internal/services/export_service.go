@@ -0,0 +1,301 @@
+// services/export_service.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"math/rand"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidExport = errors.New("invalid export request")
+
+const (
+	ExportFormatPDF  = "pdf"
+	ExportFormatDOCX = "docx"
+
+	// MaxExportVariants 是一次导出的最多卷数，卷名从 A 开始依次编号
+	MaxExportVariants = 6
+)
+
+// documentWriter 是打印文档的排版接口，PDF 和 DOCX 各自实现
+type documentWriter interface {
+	Title(text string)
+	Heading(text string)
+	Paragraph(text string)
+	Indented(text string)
+	BlankLines(n int) // 供学员书写的横线
+	PageBreak()
+	Bytes() ([]byte, error)
+}
+
+type ExportService struct {
+	db          *gorm.DB
+	quizService *QuizService
+	// FontPath 是 PDF 使用的 UTF-8 TrueType 字体，题目含中文时需要配置；
+	// 为空时使用内置字体，只能显示西文字符
+	FontPath string
+}
+
+func NewExportService(db *gorm.DB, quizService *QuizService) *ExportService {
+	return &ExportService{db: db, quizService: quizService}
+}
+
+// exportVariant 是一份试卷（A 卷、B 卷……）中题目和选项的排列
+type exportVariant struct {
+	Name      string
+	Questions []*models.Question
+}
+
+// Export 按请求导出试卷或答案，返回文档内容
+func (s *ExportService) Export(req dto.ExportRequest) ([]byte, error) {
+	format := req.Format
+	if format == "" {
+		format = ExportFormatPDF
+	}
+	var doc documentWriter
+	switch format {
+	case ExportFormatPDF:
+		doc = newPDFWriter(s.FontPath)
+	case ExportFormatDOCX:
+		doc = newDOCXWriter()
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidExport, req.Format)
+	}
+	if req.Variants < 0 || req.Variants > MaxExportVariants {
+		return nil, fmt.Errorf("%w: variants must be between 1 and %d", ErrInvalidExport, MaxExportVariants)
+	}
+
+	questions, err := s.exportQuestions(req)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "Exam"
+	}
+
+	variants := buildVariants(questions, req.Variants, req.Seed)
+	if req.AnswerKey {
+		writeAnswerKey(doc, title, variants)
+	} else {
+		writePaper(doc, title, variants)
+	}
+	return doc.Bytes()
+}
+
+// exportQuestions 按请求挑选题目并加载答案
+func (s *ExportService) exportQuestions(req dto.ExportRequest) ([]*models.Question, error) {
+	questionIDs := req.QuestionIDs
+	if len(questionIDs) == 0 {
+		if req.QuestionBankID == 0 && req.Tag == "" {
+			return nil, fmt.Errorf("%w: question_bank_id, tag or question_ids is required", ErrInvalidExport)
+		}
+		query := s.db.Model(&models.Question{}).Where("questions.parent_id IS NULL")
+		if req.QuestionBankID != 0 {
+			query = query.Where("questions.question_bank_id = ?", req.QuestionBankID)
+		}
+		if req.Tag != "" {
+			query = query.Joins("JOIN question_tags qt ON qt.question_id = questions.id").
+				Joins("JOIN tags t ON t.id = qt.tag_id").Where("t.name = ?", req.Tag)
+		}
+		if err := query.Order("questions.id").Pluck("questions.id", &questionIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(questionIDs) == 0 {
+			return nil, fmt.Errorf("%w: no questions match", ErrInvalidExport)
+		}
+	}
+
+	questions := make([]*models.Question, len(questionIDs))
+	for i, questionID := range questionIDs {
+		question, err := s.quizService.GetQuestionDetail(questionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: question %d not found", ErrInvalidExport, questionID)
+			}
+			return nil, err
+		}
+		if question.ParentID != nil {
+			return nil, fmt.Errorf("%w: question %d is a child question", ErrInvalidExport, questionID)
+		}
+		questions[i] = question
+	}
+	return questions, nil
+}
+
+// buildVariants 生成各卷的题目排列。A 卷保持原有顺序，其余各卷按种子打乱题目和选项顺序，
+// 组合题的小题顺序不变
+func buildVariants(questions []*models.Question, count int, seed int64) []exportVariant {
+	if count == 0 {
+		count = 1
+	}
+	variants := make([]exportVariant, count)
+	for i := range variants {
+		variants[i].Name = string(rune('A' + i))
+		variants[i].Questions = make([]*models.Question, len(questions))
+		for j, question := range questions {
+			copied := *question
+			variants[i].Questions[j] = &copied
+		}
+		if i == 0 {
+			continue
+		}
+
+		rng := rand.New(rand.NewSource(seed + int64(i)))
+		rng.Shuffle(len(questions), func(a, b int) {
+			variants[i].Questions[a], variants[i].Questions[b] = variants[i].Questions[b], variants[i].Questions[a]
+		})
+		for _, question := range variants[i].Questions {
+			shuffleOptions(question, rng)
+		}
+	}
+	return variants
+}
+
+func shuffleOptions(question *models.Question, rng *rand.Rand) {
+	options := append([]models.AnswerOption(nil), question.AnswerOptions...)
+	rng.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+	question.AnswerOptions = options
+
+	children := append([]models.Question(nil), question.Children...)
+	for i := range children {
+		shuffleOptions(&children[i], rng)
+	}
+	question.Children = children
+}
+
+// writePaper 排版试卷，多份卷时每份另起一页
+func writePaper(doc documentWriter, title string, variants []exportVariant) {
+	for i, variant := range variants {
+		if i > 0 {
+			doc.PageBreak()
+		}
+		doc.Title(variantTitle(title, variant, len(variants)))
+		doc.Paragraph("Name: ____________________    Class: ____________    Score: ________")
+		for j, question := range variant.Questions {
+			writeQuestion(doc, fmt.Sprintf("%d", j+1), question)
+		}
+	}
+}
+
+func writeQuestion(doc documentWriter, number string, question *models.Question) {
+	switch question.QuestionType {
+	case models.QuestionTypeSingleChoice:
+		doc.Heading(number + ". " + question.Content)
+		writeOptions(doc, question)
+	case models.QuestionTypeMultipleChoice:
+		doc.Heading(number + ". " + question.Content + " (Select all that apply)")
+		writeOptions(doc, question)
+	case models.QuestionTypeTrueFalse:
+		doc.Heading(number + ". " + question.Content)
+		doc.Indented("(   ) True        (   ) False")
+	case models.QuestionTypeFillInTheBlank:
+		doc.Heading(number + ". " + question.Content)
+		for i := range question.FillInTheBlanks {
+			doc.Indented(fmt.Sprintf("(%d) ______________________________", i+1))
+		}
+	case models.QuestionTypeWrittenAnswer:
+		doc.Heading(number + ". " + question.Content)
+		doc.BlankLines(5)
+	case models.QuestionTypeGroup:
+		doc.Heading(number + ". " + question.Content)
+		if question.Stimulus != nil {
+			if question.Stimulus.Text != "" {
+				doc.Paragraph(question.Stimulus.Text)
+			}
+			if question.Stimulus.MediaURL != "" {
+				doc.Paragraph(fmt.Sprintf("[%s: %s]", mediaLabel(question.Stimulus.MediaType), question.Stimulus.MediaURL))
+			}
+		}
+		for i, child := range sortedChildren(question) {
+			writeQuestion(doc, fmt.Sprintf("%s.%d", number, i+1), child)
+		}
+	}
+}
+
+func writeOptions(doc documentWriter, question *models.Question) {
+	for i, option := range question.AnswerOptions {
+		doc.Indented(optionLetter(i) + ". " + option.OptionText)
+	}
+}
+
+// writeAnswerKey 排版答案和解析，每份卷的答案按该卷的题目和选项顺序给出
+func writeAnswerKey(doc documentWriter, title string, variants []exportVariant) {
+	doc.Title(title + " - Answer Key")
+	for _, variant := range variants {
+		if len(variants) > 1 {
+			doc.Heading("Variant " + variant.Name)
+		}
+		for j, question := range variant.Questions {
+			writeAnswer(doc, fmt.Sprintf("%d", j+1), question)
+		}
+	}
+}
+
+func writeAnswer(doc documentWriter, number string, question *models.Question) {
+	if question.QuestionType == models.QuestionTypeGroup {
+		for i, child := range sortedChildren(question) {
+			writeAnswer(doc, fmt.Sprintf("%s.%d", number, i+1), child)
+		}
+		return
+	}
+
+	doc.Paragraph(number + ". " + answerText(question))
+	if question.Explanation != "" {
+		doc.Indented("Explanation: " + question.Explanation)
+	}
+}
+
+// answerText 返回题目答案的文字形式，选择题给出当前排列下的选项字母
+func answerText(question *models.Question) string {
+	switch question.QuestionType {
+	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
+		var letters []string
+		for i, option := range question.AnswerOptions {
+			if option.IsCorrect {
+				letters = append(letters, optionLetter(i))
+			}
+		}
+		return strings.Join(letters, ", ")
+	case models.QuestionTypeTrueFalse:
+		if question.TrueFalseAnswer != nil && question.TrueFalseAnswer.IsTrue {
+			return "True"
+		}
+		return "False"
+	case models.QuestionTypeFillInTheBlank:
+		var blanks []string
+		for i, blank := range question.FillInTheBlanks {
+			blanks = append(blanks, fmt.Sprintf("(%d) %s", i+1, blank.BlankText))
+		}
+		return strings.Join(blanks, "; ")
+	case models.QuestionTypeWrittenAnswer:
+		if question.WrittenAnswer != nil && question.WrittenAnswer.AnswerText != "" {
+			return question.WrittenAnswer.AnswerText
+		}
+		return "(open answer)"
+	}
+	return ""
+}
+
+func variantTitle(title string, variant exportVariant, count int) string {
+	if count == 1 {
+		return title
+	}
+	return title + " - Variant " + variant.Name
+}
+
+func optionLetter(i int) string {
+	return string(rune('A' + i))
+}
+
+func mediaLabel(mediaType string) string {
+	if mediaType == "" {
+		return "media"
+	}
+	return mediaType
+}
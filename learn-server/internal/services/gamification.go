@@ -0,0 +1,384 @@
+// services/gamification.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultXPPerQuestion 是答对一道题获得的经验值，部分得分按比例折算
+const DefaultXPPerQuestion = 10
+
+// 排行榜的统计时间段
+const (
+	LeaderboardWeek = "week" // 本周，每周一零点重新计算
+	LeaderboardAll  = "all"  // 全部时间
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInvalidBadge       = errors.New("invalid badge")
+	ErrInvalidLeaderboard = errors.New("invalid leaderboard period")
+)
+
+// defaultBadges 是徽章表为空时初始化的徽章规则
+var defaultBadges = []models.Badge{
+	{Code: "first_correct", Name: "初出茅庐", Description: "第一次答对题目", Kind: models.BadgeCorrectTotal, Threshold: 1},
+	{Code: "bank_100", Name: "题库达人", Description: "在同一个题库中答对 100 次", Kind: models.BadgeCorrectInBank, Threshold: 100},
+	{Code: "streak_7", Name: "坚持一周", Description: "连续 7 天答题", Kind: models.BadgeStreak, Threshold: 7},
+	{Code: "xp_1000", Name: "千锤百炼", Description: "累计获得 1000 经验值", Kind: models.BadgeTotalXP, Threshold: 1000},
+}
+
+// LeaderboardFilter 指定排行榜的时间段和范围，零值表示本周全站排行
+type LeaderboardFilter struct {
+	Period         string
+	QuestionBankID uint
+	ClassID        uint // 只统计班级学员
+	Limit          int
+}
+
+// EnsureDefaultBadges 在没有任何徽章规则时创建默认规则
+func (s *QuizService) EnsureDefaultBadges() error {
+	var count int64
+	if err := s.db.Model(&models.Badge{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	badges := append([]models.Badge(nil), defaultBadges...)
+	return s.db.Create(&badges).Error
+}
+
+// GetBadges 返回所有徽章规则
+func (s *QuizService) GetBadges() ([]models.Badge, error) {
+	var badges []models.Badge
+	if err := s.db.Order("id").Find(&badges).Error; err != nil {
+		return nil, err
+	}
+	return badges, nil
+}
+
+// CreateBadge 创建徽章规则，已达成条件的学员在下次作答时获得
+func (s *QuizService) CreateBadge(req dto.BadgeRequest) (*models.Badge, error) {
+	switch req.Kind {
+	case models.BadgeCorrectInBank, models.BadgeCorrectTotal, models.BadgeStreak, models.BadgeTotalXP:
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidBadge, req.Kind)
+	}
+	if req.Code == "" || req.Name == "" || req.Threshold == 0 {
+		return nil, fmt.Errorf("%w: code, name and threshold are required", ErrInvalidBadge)
+	}
+	if req.QuestionBankID != nil {
+		if req.Kind != models.BadgeCorrectInBank {
+			return nil, fmt.Errorf("%w: question_bank_id only applies to %s", ErrInvalidBadge, models.BadgeCorrectInBank)
+		}
+		if _, err := s.GetQuestionBank(*req.QuestionBankID); err != nil {
+			return nil, err
+		}
+	}
+	var count int64
+	if err := s.db.Model(&models.Badge{}).Where("code = ?", req.Code).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: code %q already exists", ErrInvalidBadge, req.Code)
+	}
+
+	badge := models.Badge{
+		Code:           req.Code,
+		Name:           req.Name,
+		Description:    req.Description,
+		Kind:           req.Kind,
+		Threshold:      req.Threshold,
+		QuestionBankID: req.QuestionBankID,
+	}
+	if err := s.db.Create(&badge).Error; err != nil {
+		return nil, err
+	}
+	return &badge, nil
+}
+
+// DeleteBadge 删除徽章规则，学员已获得的该徽章一并删除
+func (s *QuizService) DeleteBadge(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("badge_id = ?", id).Delete(&models.UserBadge{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Badge{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetGamificationStats 返回学员的经验值、连续学习天数和所有徽章的进度
+func (s *QuizService) GetGamificationStats(userID uint) (*dto.GamificationStatsResponse, error) {
+	stats, err := loadLearnerStats(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	response := &dto.GamificationStatsResponse{
+		TotalXP:        stats.TotalXP,
+		CorrectAnswers: stats.CorrectAnswers,
+		CurrentStreak:  stats.CurrentStreak,
+		LongestStreak:  stats.LongestStreak,
+		LastActiveOn:   stats.LastActiveOn,
+		Badges:         []dto.BadgeResponse{},
+	}
+	// 昨天和今天都没有作答时连续天数已经中断
+	if stats.LastActiveOn != now.Format(dateLayout) && stats.LastActiveOn != now.AddDate(0, 0, -1).Format(dateLayout) {
+		response.CurrentStreak = 0
+	}
+	if err := s.db.Model(&models.XPEvent{}).Select("COALESCE(SUM(points), 0)").
+		Where("user_id = ? AND created_at >= ?", userID, weekStart(now)).
+		Scan(&response.WeeklyXP).Error; err != nil {
+		return nil, err
+	}
+
+	badges, err := s.GetBadges()
+	if err != nil {
+		return nil, err
+	}
+	var awarded []models.UserBadge
+	if err := s.db.Where("user_id = ?", userID).Find(&awarded).Error; err != nil {
+		return nil, err
+	}
+	awardedAt := make(map[uint]time.Time, len(awarded))
+	for _, userBadge := range awarded {
+		awardedAt[userBadge.BadgeID] = userBadge.AwardedAt
+	}
+	for _, badge := range badges {
+		badgeResponse := NewBadgeResponse(badge)
+		if badgeResponse.Progress, err = badgeProgress(s.db, userID, stats, badge); err != nil {
+			return nil, err
+		}
+		if at, ok := awardedAt[badge.ID]; ok {
+			badgeResponse.AwardedAt = &at
+		}
+		response.Badges = append(response.Badges, badgeResponse)
+	}
+	return response, nil
+}
+
+// GetLeaderboard 按经验值排名，可限定题库或班级学员；userID 用于返回当前用户的名次
+func (s *QuizService) GetLeaderboard(userID uint, filter LeaderboardFilter) (*dto.LeaderboardResponse, error) {
+	response := &dto.LeaderboardResponse{Period: filter.Period, Entries: []dto.LeaderboardEntry{}}
+	query := s.db.Table("xp_events").
+		Select("xp_events.user_id, users.username, SUM(xp_events.points) AS xp").
+		Joins("JOIN users ON users.id = xp_events.user_id").
+		Group("xp_events.user_id, users.username").
+		Order("xp DESC, xp_events.user_id")
+	switch filter.Period {
+	case "", LeaderboardWeek:
+		since := weekStart(time.Now())
+		response.Period, response.Since = LeaderboardWeek, &since
+		query = query.Where("xp_events.created_at >= ?", since)
+	case LeaderboardAll:
+	default:
+		return nil, ErrInvalidLeaderboard
+	}
+	if filter.QuestionBankID != 0 {
+		query = query.Where("xp_events.question_bank_id = ?", filter.QuestionBankID)
+	}
+	if filter.ClassID != 0 {
+		students := s.db.Model(&models.ClassMember{}).Select("user_id").
+			Where("class_id = ? AND role = ?", filter.ClassID, models.ClassRoleStudent)
+		query = query.Where("xp_events.user_id IN (?)", students)
+	}
+
+	var entries []dto.LeaderboardEntry
+	if err := query.Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if i > 0 && entries[i].XP == entries[i-1].XP {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+		if entries[i].UserID == userID {
+			me := entries[i]
+			response.Me = &me
+		}
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	response.Entries = append(response.Entries, entries...)
+	return response, nil
+}
+
+// NewBadgeResponse 将徽章规则转换为响应
+func NewBadgeResponse(badge models.Badge) dto.BadgeResponse {
+	return dto.BadgeResponse{
+		ID:             badge.ID,
+		Code:           badge.Code,
+		Name:           badge.Name,
+		Description:    badge.Description,
+		Kind:           badge.Kind,
+		Threshold:      badge.Threshold,
+		QuestionBankID: badge.QuestionBankID,
+	}
+}
+
+// updateGamification 为一次作答发放经验值、更新连续学习天数，并颁发新达成的徽章。
+// 同一道题同一天重复答对不再获得经验值，也不计入答对次数。
+// 经验值和连续天数一律按服务端当前时间计算，离线作答的设备时间可以伪造，不能用来补记往日的经验值和连续天数
+func (s *QuizService) updateGamification(tx *gorm.DB, userID uint, question *models.Question, grade *GradeResult) (int, []models.Badge, error) {
+	answeredAt := time.Now()
+	stats, err := loadLearnerStats(tx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	points := 0
+	if grade.Score > 0 {
+		day := startOfDay(answeredAt)
+		var count int64
+		if err := tx.Model(&models.XPEvent{}).
+			Where("user_id = ? AND question_id = ? AND points > 0 AND created_at >= ? AND created_at < ?",
+				userID, question.ID, day, day.AddDate(0, 0, 1)).
+			Count(&count).Error; err != nil {
+			return 0, nil, err
+		}
+		if count == 0 {
+			points = int(math.Round(float64(s.XPPerQuestion) * grade.Score))
+		}
+	}
+	if points > 0 {
+		event := models.XPEvent{
+			UserID:         userID,
+			QuestionBankID: question.QuestionBankID,
+			QuestionID:     question.ID,
+			Points:         points,
+			Correct:        grade.Correct,
+			CreatedAt:      answeredAt,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return 0, nil, err
+		}
+		stats.TotalXP += points
+		if grade.Correct {
+			stats.CorrectAnswers++
+		}
+	}
+
+	// 任意作答都算当天学习过，早于最近学习日的作答不影响连续天数
+	today := answeredAt.Format(dateLayout)
+	switch stats.LastActiveOn {
+	case today:
+	case answeredAt.AddDate(0, 0, -1).Format(dateLayout):
+		stats.CurrentStreak++
+	default:
+		if today < stats.LastActiveOn {
+			today = stats.LastActiveOn
+			break
+		}
+		stats.CurrentStreak = 1
+	}
+	stats.LastActiveOn = today
+	if stats.CurrentStreak > stats.LongestStreak {
+		stats.LongestStreak = stats.CurrentStreak
+	}
+	if err := tx.Save(stats).Error; err != nil {
+		return 0, nil, err
+	}
+
+	var pending []models.Badge
+	awarded := tx.Model(&models.UserBadge{}).Select("badge_id").Where("user_id = ?", userID)
+	if err := tx.Where("id NOT IN (?)", awarded).Order("id").Find(&pending).Error; err != nil {
+		return 0, nil, err
+	}
+	var earned []models.Badge
+	for _, badge := range pending {
+		progress, err := badgeProgress(tx, userID, stats, badge)
+		if err != nil {
+			return 0, nil, err
+		}
+		if progress < badge.Threshold {
+			continue
+		}
+		if err := tx.Create(&models.UserBadge{UserID: userID, BadgeID: badge.ID}).Error; err != nil {
+			return 0, nil, err
+		}
+		earned = append(earned, badge)
+	}
+	return points, earned, nil
+}
+
+// publishBadges 通知学员获得了新徽章
+func (s *QuizService) publishBadges(userID uint, results []*AttemptResult) {
+	if s.Events == nil {
+		return
+	}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		for _, badge := range result.Badges {
+			s.Events.Publish(EventBadgeEarned, dto.BadgeEarnedEvent{BadgeID: badge.ID, Code: badge.Code, Name: badge.Name}, userID)
+		}
+	}
+}
+
+// badgeProgress 计算学员在徽章规则上的当前进度
+func badgeProgress(db *gorm.DB, userID uint, stats *models.LearnerStats, badge models.Badge) (uint, error) {
+	switch badge.Kind {
+	case models.BadgeCorrectTotal:
+		return stats.CorrectAnswers, nil
+	case models.BadgeStreak:
+		return stats.CurrentStreak, nil
+	case models.BadgeTotalXP:
+		return uint(max(stats.TotalXP, 0)), nil
+	case models.BadgeCorrectInBank:
+		query := db.Model(&models.XPEvent{}).Where("user_id = ? AND correct", userID)
+		if badge.QuestionBankID != nil {
+			var count int64
+			err := query.Where("question_bank_id = ?", *badge.QuestionBankID).Count(&count).Error
+			return uint(count), err
+		}
+		// 未指定题库时取答对次数最多的题库
+		var counts []int64
+		err := query.Group("question_bank_id").Order("count DESC").Limit(1).Pluck("COUNT(*) AS count", &counts).Error
+		if err != nil || len(counts) == 0 {
+			return 0, err
+		}
+		return uint(counts[0]), nil
+	}
+	return 0, nil
+}
+
+// loadLearnerStats 读取学员的统计，没有记录时返回零值
+func loadLearnerStats(db *gorm.DB, userID uint) (*models.LearnerStats, error) {
+	var stats models.LearnerStats
+	err := db.Where("user_id = ?", userID).First(&stats).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	stats.UserID = userID
+	return &stats, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// weekStart 返回 t 所在周的周一零点
+func weekStart(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
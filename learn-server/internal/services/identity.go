@@ -0,0 +1,184 @@
+// services/identity.go
+package services
+
+import (
+	"errors"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIdentityAccountExists   = errors.New("a local account with this username already exists, sign in and link the identity first")
+	ErrIdentityNotProvisioned  = errors.New("no local account is linked to this identity")
+	ErrIdentityLinkedElsewhere = errors.New("this identity is already linked to another account")
+)
+
+// externalIdentity 是外部身份源（OIDC、LDAP）验证过的账号信息
+type externalIdentity struct {
+	Issuer   string // 身份源，OIDC 为 issuer，LDAP 为服务器地址
+	Subject  string // 身份源中不变的账号标识
+	Email    string
+	Username string
+	Roles    []string // 身份源中的角色或分组，按 RoleMapping 映射为本地角色
+}
+
+// externalUserPolicy 决定外部账号如何对应到本地用户
+type externalUserPolicy struct {
+	RoleMapping    map[string]string // 外部角色（不区分大小写）到本地角色名
+	DefaultRole    string            // 自动创建的用户没有匹配到角色时分配
+	AutoProvision  bool              // 没有关联的本地用户时自动创建
+	LinkByUsername bool              // 没有关联时自动关联同名的本地用户
+}
+
+// resolveUser 找到外部账号关联的本地用户，没有关联时按策略关联同名用户或自动创建，并同步映射的角色。
+// 服务账号只能使用 API Key，不能通过外部账号登录
+func (p externalUserPolicy) resolveUser(db *gorm.DB, identity *externalIdentity) (models.User, error) {
+	var user models.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var linked models.UserIdentity
+		err := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).First(&linked).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, linked.UserID).Error; err != nil {
+				return err
+			}
+			if user.ServiceAccount {
+				return errServiceAccountLogin
+			}
+			if err := tx.Model(&linked).Updates(map[string]interface{}{
+				"email": identity.Email, "username": identity.Username, "last_login_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			return p.syncRoles(tx, &user, identity.Roles, false)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		provisioned := false
+		err = tx.Where("username = ?", identity.Username).First(&user).Error
+		switch {
+		case err == nil:
+			if !p.LinkByUsername {
+				return ErrIdentityAccountExists
+			}
+			if user.ServiceAccount {
+				return errServiceAccountLogin
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		case !p.AutoProvision:
+			return ErrIdentityNotProvisioned
+		default:
+			if user, err = provisionExternalUser(tx, identity.Username); err != nil {
+				return err
+			}
+			provisioned = true
+		}
+
+		if err := createUserIdentity(tx, user.ID, identity); err != nil {
+			return err
+		}
+		return p.syncRoles(tx, &user, identity.Roles, provisioned)
+	})
+	return user, err
+}
+
+// syncRoles 按 RoleMapping 同步用户角色：映射中出现的角色以身份源为准，其余角色保持不变。
+// 新创建的用户没有得到任何角色时分配 DefaultRole
+func (p externalUserPolicy) syncRoles(tx *gorm.DB, user *models.User, externalRoles []string, provisioned bool) error {
+	if err := tx.Model(user).Association("Roles").Find(&user.Roles); err != nil {
+		return err
+	}
+	desired := map[string]bool{}
+	managed := map[string]bool{}
+	for source, roleName := range p.RoleMapping {
+		managed[roleName] = true
+		for _, externalRole := range externalRoles {
+			if strings.EqualFold(source, externalRole) {
+				desired[roleName] = true
+			}
+		}
+	}
+
+	var names []string
+	for _, role := range user.Roles {
+		if !managed[role.Name] || desired[role.Name] {
+			names = append(names, role.Name)
+		}
+	}
+	for roleName := range desired {
+		if !slices.Contains(names, roleName) {
+			names = append(names, roleName)
+		}
+	}
+	if provisioned && len(names) == 0 && p.DefaultRole != "" {
+		names = append(names, p.DefaultRole)
+	}
+
+	var roles []models.Role
+	if len(names) == 0 {
+		user.Roles = nil
+		return tx.Model(user).Association("Roles").Clear()
+	}
+	if err := tx.Where("name IN ?", names).Find(&roles).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(user).Association("Roles").Replace(roles); err != nil {
+		return err
+	}
+	user.Roles = roles
+	return nil
+}
+
+// linkExternalIdentity 把外部账号关联到指定用户，已关联到其他用户时返回 ErrIdentityLinkedElsewhere
+func linkExternalIdentity(db *gorm.DB, userID uint, identity *externalIdentity) error {
+	var linked models.UserIdentity
+	err := db.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).First(&linked).Error
+	if err == nil {
+		if linked.UserID != userID {
+			return ErrIdentityLinkedElsewhere
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return createUserIdentity(db, userID, identity)
+}
+
+func createUserIdentity(tx *gorm.DB, userID uint, identity *externalIdentity) error {
+	return tx.Create(&models.UserIdentity{
+		UserID:      userID,
+		Issuer:      identity.Issuer,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		Username:    identity.Username,
+		LastLoginAt: time.Now(),
+	}).Error
+}
+
+// provisionExternalUser 创建只能通过外部身份源登录的本地用户，密码为随机值
+func provisionExternalUser(tx *gorm.DB, username string) (models.User, error) {
+	password, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	user := models.User{
+		Username:     username,
+		Password:     string(hashedPassword),
+		TokenVersion: 1,
+		Status:       models.StatusActive,
+	}
+	return user, tx.Create(&user).Error
+}
@@ -0,0 +1,231 @@
+// services/ldap.go
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLDAPUserFilter        = "(uid=%s)"
+	defaultLDAPUsernameAttribute = "uid"
+	defaultLDAPEmailAttribute    = "mail"
+	defaultLDAPGroupAttribute    = "memberOf"
+	defaultLDAPTimeout           = 5 * time.Second
+)
+
+// LDAPSettings 是 LDAP / Active Directory 认证的配置
+type LDAPSettings struct {
+	URL                string // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // 用于查找用户的服务账号，为空时匿名查找
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string            // 查找用户的过滤器，%s 替换为转义后的用户名，默认 (uid=%s)，AD 通常为 (sAMAccountName=%s)
+	UsernameAttribute  string            // 用作本地用户名的属性，默认 uid
+	EmailAttribute     string            // 默认 mail
+	GroupAttribute     string            // 用户条目中列出所在组的属性，默认 memberOf
+	GroupBaseDN        string            // 设置 GroupFilter 时查找组的位置，默认 BaseDN
+	GroupFilter        string            // 不支持 memberOf 时按成员查找组，%s 替换为转义后的用户 DN，例如 (member=%s)
+	RoleMapping        map[string]string // 组 DN 或 CN（不区分大小写）到本地角色名的映射
+	DefaultRole        string            // 首次登录创建用户时没有匹配到角色时分配
+	LinkByUsername     bool              // 首次登录自动关联同名的本地用户，只有目录中的用户名可信时才应开启
+	Timeout            time.Duration     // 连接和请求超时，默认 5 秒
+}
+
+// LDAPAuthenticator 通过 LDAP 目录认证用户：先用服务账号查找用户条目，再用用户的密码绑定。
+// 认证成功后在本地创建或更新对应的用户，并按组同步角色
+type LDAPAuthenticator struct {
+	db       *gorm.DB
+	settings LDAPSettings
+	policy   externalUserPolicy
+}
+
+// NewLDAPAuthenticator 创建 LDAP 认证器，不会立即连接服务器
+func NewLDAPAuthenticator(db *gorm.DB, settings LDAPSettings) (*LDAPAuthenticator, error) {
+	if settings.URL == "" || settings.BaseDN == "" {
+		return nil, errors.New("ldap url and base_dn are required")
+	}
+	if settings.UserFilter == "" {
+		settings.UserFilter = defaultLDAPUserFilter
+	}
+	if settings.UsernameAttribute == "" {
+		settings.UsernameAttribute = defaultLDAPUsernameAttribute
+	}
+	if settings.EmailAttribute == "" {
+		settings.EmailAttribute = defaultLDAPEmailAttribute
+	}
+	if settings.GroupAttribute == "" {
+		settings.GroupAttribute = defaultLDAPGroupAttribute
+	}
+	if settings.GroupBaseDN == "" {
+		settings.GroupBaseDN = settings.BaseDN
+	}
+	if settings.Timeout == 0 {
+		settings.Timeout = defaultLDAPTimeout
+	}
+	return &LDAPAuthenticator{
+		db:       db,
+		settings: settings,
+		policy: externalUserPolicy{
+			RoleMapping:    settings.RoleMapping,
+			DefaultRole:    settings.DefaultRole,
+			AutoProvision:  true,
+			LinkByUsername: settings.LinkByUsername,
+		},
+	}, nil
+}
+
+func (a *LDAPAuthenticator) Authenticate(username, password string) (models.User, error) {
+	// 空密码会被服务器当作匿名绑定而成功，必须拒绝
+	if username == "" || password == "" {
+		return models.User{}, ErrInvalidCredentials
+	}
+	conn, err := a.connect()
+	if err != nil {
+		return models.User{}, err
+	}
+	defer conn.Close()
+
+	if err := a.bindServiceAccount(conn); err != nil {
+		return models.User{}, err
+	}
+	entry, err := a.findUser(conn, username)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, fmt.Errorf("ldap bind failed: %w", err)
+	}
+	groups := entry.GetAttributeValues(a.settings.GroupAttribute)
+	if a.settings.GroupFilter != "" {
+		// 用户本人可能没有读取组的权限，改回服务账号查找
+		if err := a.bindServiceAccount(conn); err != nil {
+			return models.User{}, err
+		}
+		found, err := a.findGroups(conn, entry.DN)
+		if err != nil {
+			return models.User{}, err
+		}
+		groups = append(groups, found...)
+	}
+
+	identity := &externalIdentity{
+		Issuer:   a.settings.URL,
+		Email:    entry.GetAttributeValue(a.settings.EmailAttribute),
+		Username: entry.GetAttributeValue(a.settings.UsernameAttribute),
+		Roles:    groupRoleNames(groups),
+	}
+	if identity.Username == "" {
+		identity.Username = username
+	}
+	// 用户名比 DN 稳定，条目在目录中移动后仍能对应到同一个本地用户
+	identity.Subject = strings.ToLower(identity.Username)
+
+	user, err := a.policy.resolveUser(a.db, identity)
+	if err != nil {
+		if errors.Is(err, ErrIdentityAccountExists) {
+			log.Printf("LDAP user %s matches an unlinked local account, login rejected", identity.Username)
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, err
+	}
+	if user.Status != models.StatusActive {
+		return models.User{}, ErrUserInactive
+	}
+	return user, nil
+}
+
+func (a *LDAPAuthenticator) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: a.settings.InsecureSkipVerify}
+	conn, err := ldap.DialURL(a.settings.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.settings.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap server: %w", err)
+	}
+	conn.SetTimeout(a.settings.Timeout)
+	if a.settings.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) bindServiceAccount(conn *ldap.Conn) error {
+	if a.settings.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(a.settings.BindDN, a.settings.BindPassword); err != nil {
+		return fmt.Errorf("ldap service account bind failed: %w", err)
+	}
+	return nil
+}
+
+// findUser 查找用户条目，找不到或不唯一时返回 ErrInvalidCredentials
+func (a *LDAPAuthenticator) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.settings.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(a.settings.UserFilter, ldap.EscapeFilter(username)),
+		[]string{a.settings.UsernameAttribute, a.settings.EmailAttribute, a.settings.GroupAttribute},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap user search failed: %w", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return result.Entries[0], nil
+}
+
+// findGroups 按 GroupFilter 查找用户所在的组，返回组的 DN
+func (a *LDAPAuthenticator) findGroups(conn *ldap.Conn, userDN string) ([]string, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.settings.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(a.settings.GroupFilter, ldap.EscapeFilter(userDN)),
+		[]string{"cn"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap group search failed: %w", err)
+	}
+	groups := make([]string, len(result.Entries))
+	for i, entry := range result.Entries {
+		groups[i] = entry.DN
+	}
+	return groups, nil
+}
+
+// groupRoleNames 把组 DN 展开为 DN 和 CN，RoleMapping 中可以使用任意一种写法
+func groupRoleNames(groups []string) []string {
+	var names []string
+	for _, group := range groups {
+		names = append(names, group)
+		dn, err := ldap.ParseDN(group)
+		if err != nil || len(dn.RDNs) == 0 {
+			continue
+		}
+		for _, attribute := range dn.RDNs[0].Attributes {
+			if strings.EqualFold(attribute.Type, "cn") {
+				names = append(names, attribute.Value)
+			}
+		}
+	}
+	return names
+}
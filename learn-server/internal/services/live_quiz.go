@@ -0,0 +1,465 @@
+// services/live_quiz.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidLiveSession  = errors.New("invalid live session")
+	ErrLiveSessionNotFound = errors.New("live session not found")
+	ErrLiveSessionFinished = errors.New("live session has finished")
+	ErrInvalidLiveCommand  = errors.New("invalid live command")
+)
+
+const (
+	DefaultLiveQuestionSeconds = 20
+	MaxLiveQuestionSeconds     = 300
+	// LiveSessionTTL 是现场答题的最长存活时间，超时自动结束，避免无人主持的会话一直占用内存
+	LiveSessionTTL = 2 * time.Hour
+
+	// liveMaxPoints 是一道题的满分，答对后按用时递减，最慢也有一半
+	liveMaxPoints = 1000
+	// liveEventBuffer 是每个连接待发送事件的缓冲数，写满说明客户端太慢，直接断开
+	liveEventBuffer = 32
+)
+
+// 服务端推送的事件类型
+const (
+	LiveEventLobby       = "lobby"
+	LiveEventQuestion    = "question"
+	LiveEventAnswerCount = "answer_count"
+	LiveEventAnswer      = "answer_result"
+	LiveEventReveal      = "reveal"
+	LiveEventFinished    = "finished"
+	LiveEventError       = "error"
+)
+
+// 客户端发送的指令类型
+const (
+	LiveCommandStart  = "start"
+	LiveCommandReveal = "reveal"
+	LiveCommandNext   = "next"
+	LiveCommandEnd    = "end"
+	LiveCommandAnswer = "answer"
+)
+
+// LiveState 是现场答题所处的阶段
+type LiveState int
+
+const (
+	LiveStateLobby    LiveState = iota // 等候学员加入
+	LiveStateQuestion                  // 题目倒计时中
+	LiveStateReveal                    // 公布答案和排行榜
+	LiveStateFinished                  // 已结束
+)
+
+func (s LiveState) String() string {
+	switch s {
+	case LiveStateLobby:
+		return "lobby"
+	case LiveStateQuestion:
+		return "question"
+	case LiveStateReveal:
+		return "reveal"
+	case LiveStateFinished:
+		return "finished"
+	}
+	return ""
+}
+
+// LiveQuizService 管理进行中的现场答题。会话只保存在内存中，作答通过 QuizService 判分并记录
+type LiveQuizService struct {
+	quizService *QuizService
+
+	mu       sync.Mutex
+	sessions map[string]*LiveSession
+}
+
+func NewLiveQuizService(quizService *QuizService) *LiveQuizService {
+	return &LiveQuizService{quizService: quizService, sessions: map[string]*LiveSession{}}
+}
+
+// CreateSession 从题库创建现场答题，返回供学员加入的 PIN
+func (s *LiveQuizService) CreateSession(host models.User, req dto.CreateLiveSessionRequest) (*LiveSession, error) {
+	seconds := req.QuestionSeconds
+	if seconds == 0 {
+		seconds = DefaultLiveQuestionSeconds
+	}
+	if seconds < 0 || seconds > MaxLiveQuestionSeconds {
+		return nil, fmt.Errorf("%w: question_seconds must be between 1 and %d", ErrInvalidLiveSession, MaxLiveQuestionSeconds)
+	}
+	if req.QuestionCount < 0 {
+		return nil, fmt.Errorf("%w: question_count must not be negative", ErrInvalidLiveSession)
+	}
+
+	bank, err := s.quizService.GetQuestionBank(req.QuestionBankID)
+	if err != nil {
+		return nil, err
+	}
+	var questions []models.Question
+	if req.QuestionCount > 0 {
+		questions, err = s.quizService.GetRandomQuestions(bank.ID, req.QuestionCount)
+	} else {
+		questions, err = s.quizService.GetQuestions(bank.ID, "")
+		for i := 0; err == nil && i < len(questions); i++ {
+			err = s.quizService.loadAnswers(&questions[i])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w: question bank has no questions", ErrInvalidLiveSession)
+	}
+
+	session := &LiveSession{
+		HostID:      host.ID,
+		Title:       bank.Name,
+		service:     s,
+		questions:   questions,
+		seconds:     seconds,
+		players:     map[uint]*livePlayer{},
+		subscribers: map[*LiveSubscriber]bool{},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		session.PIN = strconv.Itoa(100000 + rand.Intn(900000))
+		if _, exists := s.sessions[session.PIN]; !exists {
+			break
+		}
+	}
+	s.sessions[session.PIN] = session
+	session.expiry = time.AfterFunc(LiveSessionTTL, func() {
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		session.finish()
+	})
+	return session, nil
+}
+
+// GetSession 按 PIN 查找进行中的现场答题
+func (s *LiveQuizService) GetSession(pin string) (*LiveSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[pin]
+	if !ok {
+		return nil, ErrLiveSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *LiveQuizService) remove(pin string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, pin)
+}
+
+// LiveSession 是一场现场答题。主持人控制进度，题目和排行榜同步推送给所有连接
+type LiveSession struct {
+	PIN    string
+	HostID uint
+	Title  string
+
+	service   *LiveQuizService
+	questions []models.Question
+	seconds   int
+
+	mu          sync.Mutex
+	state       LiveState
+	current     int
+	deadline    time.Time
+	timer       *time.Timer
+	expiry      *time.Timer
+	players     map[uint]*livePlayer
+	answered    map[uint]bool // 当前题目已作答的学员
+	correct     int           // 当前题目答对的人数
+	subscribers map[*LiveSubscriber]bool
+}
+
+type livePlayer struct {
+	dto.LivePlayer
+	connections int
+}
+
+// LiveSubscriber 是一个连接的事件订阅，连接断开或答题结束时 Events 会被关闭
+type LiveSubscriber struct {
+	UserID uint
+	Events chan dto.LiveEvent
+}
+
+// Snapshot 返回会话当前的状态
+func (sess *LiveSession) Snapshot() dto.LiveSessionResponse {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return dto.LiveSessionResponse{
+		PIN:             sess.PIN,
+		HostID:          sess.HostID,
+		Title:           sess.Title,
+		State:           sess.state.String(),
+		QuestionCount:   len(sess.questions),
+		QuestionSeconds: sess.seconds,
+		Players:         sess.leaderboard(),
+	}
+}
+
+// Join 订阅会话事件。主持人以外的用户作为学员加入，中途断线后可以重新连接，分数保留
+func (sess *LiveSession) Join(user models.User) (*LiveSubscriber, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.state == LiveStateFinished {
+		return nil, ErrLiveSessionFinished
+	}
+
+	subscriber := &LiveSubscriber{UserID: user.ID, Events: make(chan dto.LiveEvent, liveEventBuffer)}
+	sess.subscribers[subscriber] = true
+	if user.ID != sess.HostID {
+		player, ok := sess.players[user.ID]
+		if !ok {
+			player = &livePlayer{LivePlayer: dto.LivePlayer{UserID: user.ID, Username: user.Username}}
+			sess.players[user.ID] = player
+		}
+		player.connections++
+	}
+
+	sess.broadcast(dto.LiveEvent{Type: LiveEventLobby, Data: dto.LiveLobbyEvent{Players: sess.leaderboard()}})
+	// 中途加入的连接补发当前题目或答案
+	switch sess.state {
+	case LiveStateQuestion:
+		sess.send(subscriber, sess.questionEvent())
+	case LiveStateReveal:
+		sess.send(subscriber, sess.revealEvent())
+	}
+	return subscriber, nil
+}
+
+// Leave 取消订阅。等候阶段离开的学员从名单中移除，开始后保留分数
+func (sess *LiveSession) Leave(subscriber *LiveSubscriber) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.unsubscribe(subscriber)
+
+	if player, ok := sess.players[subscriber.UserID]; ok && player.connections == 0 && sess.state == LiveStateLobby {
+		delete(sess.players, subscriber.UserID)
+		sess.broadcast(dto.LiveEvent{Type: LiveEventLobby, Data: dto.LiveLobbyEvent{Players: sess.leaderboard()}})
+	}
+}
+
+// Handle 处理连接发来的指令
+func (sess *LiveSession) Handle(userID uint, command dto.LiveCommand) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if command.Type == LiveCommandAnswer {
+		return sess.answer(userID, command.Answer)
+	}
+	if userID != sess.HostID {
+		return fmt.Errorf("%w: only the host can %s", ErrInvalidLiveCommand, command.Type)
+	}
+	switch command.Type {
+	case LiveCommandStart:
+		if sess.state != LiveStateLobby {
+			return fmt.Errorf("%w: game has already started", ErrInvalidLiveCommand)
+		}
+		sess.askQuestion(0)
+	case LiveCommandReveal:
+		if sess.state != LiveStateQuestion {
+			return fmt.Errorf("%w: no question is open", ErrInvalidLiveCommand)
+		}
+		sess.reveal()
+	case LiveCommandNext:
+		if sess.state != LiveStateReveal {
+			return fmt.Errorf("%w: current question has not been revealed", ErrInvalidLiveCommand)
+		}
+		if sess.current+1 < len(sess.questions) {
+			sess.askQuestion(sess.current + 1)
+		} else {
+			sess.finish()
+		}
+	case LiveCommandEnd:
+		sess.finish()
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrInvalidLiveCommand, command.Type)
+	}
+	return nil
+}
+
+// SendError 将指令处理失败的原因推送给发送指令的连接
+func (sess *LiveSession) SendError(subscriber *LiveSubscriber, err error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.subscribers[subscriber] {
+		sess.send(subscriber, dto.LiveEvent{Type: LiveEventError, Data: dto.LiveErrorEvent{Message: err.Error()}})
+	}
+}
+
+// answer 为学员的作答判分，每道题只能作答一次。答对的得分按剩余时间计算
+func (sess *LiveSession) answer(userID uint, answer interface{}) error {
+	player, ok := sess.players[userID]
+	if !ok {
+		return fmt.Errorf("%w: only players can answer", ErrInvalidLiveCommand)
+	}
+	if sess.state != LiveStateQuestion {
+		return fmt.Errorf("%w: no question is open", ErrInvalidLiveCommand)
+	}
+	if sess.answered[userID] {
+		return fmt.Errorf("%w: question has already been answered", ErrInvalidLiveCommand)
+	}
+
+	question := &sess.questions[sess.current]
+	result, err := sess.service.quizService.RecordQuestionAttempt(userID, question.ID, answer)
+	if err != nil {
+		return err
+	}
+	sess.answered[userID] = true
+
+	remaining := time.Until(sess.deadline).Seconds() / float64(sess.seconds)
+	points := int(math.Round(liveMaxPoints * result.Grade.Score * (0.5 + 0.5*math.Max(remaining, 0))))
+	player.Score += points
+	if result.Grade.Correct {
+		player.Correct++
+		sess.correct++
+	}
+
+	sess.sendToUser(userID, dto.LiveEvent{Type: LiveEventAnswer, Data: dto.LiveAnswerResultEvent{
+		QuestionID:  question.ID,
+		Correct:     result.Grade.Correct,
+		Score:       result.Grade.Score,
+		Points:      points,
+		TotalPoints: player.Score,
+	}})
+	sess.broadcast(dto.LiveEvent{Type: LiveEventAnswerCount, Data: dto.LiveAnswerCountEvent{
+		Answered: len(sess.answered),
+		Players:  len(sess.players),
+	}})
+	if len(sess.answered) == len(sess.players) {
+		sess.reveal()
+	}
+	return nil
+}
+
+// askQuestion 推送第 index 道题并开始倒计时，倒计时结束自动公布答案
+func (sess *LiveSession) askQuestion(index int) {
+	sess.state = LiveStateQuestion
+	sess.current = index
+	sess.answered = map[uint]bool{}
+	sess.correct = 0
+	duration := time.Duration(sess.seconds) * time.Second
+	sess.deadline = time.Now().Add(duration)
+	sess.timer = time.AfterFunc(duration, func() {
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		if sess.state == LiveStateQuestion && sess.current == index {
+			sess.reveal()
+		}
+	})
+	sess.broadcast(sess.questionEvent())
+}
+
+func (sess *LiveSession) reveal() {
+	sess.stopTimer()
+	sess.state = LiveStateReveal
+	sess.broadcast(sess.revealEvent())
+}
+
+// finish 推送最终排行榜，关闭所有连接并移除会话
+func (sess *LiveSession) finish() {
+	if sess.state == LiveStateFinished {
+		return
+	}
+	sess.stopTimer()
+	sess.expiry.Stop()
+	sess.state = LiveStateFinished
+	sess.broadcast(dto.LiveEvent{Type: LiveEventFinished, Data: dto.LiveFinishedEvent{Leaderboard: sess.leaderboard()}})
+	for subscriber := range sess.subscribers {
+		sess.unsubscribe(subscriber)
+	}
+	sess.service.remove(sess.PIN)
+}
+
+func (sess *LiveSession) stopTimer() {
+	if sess.timer != nil {
+		sess.timer.Stop()
+		sess.timer = nil
+	}
+}
+
+func (sess *LiveSession) questionEvent() dto.LiveEvent {
+	return dto.LiveEvent{Type: LiveEventQuestion, Data: dto.LiveQuestionEvent{
+		Index:    sess.current,
+		Total:    len(sess.questions),
+		Seconds:  sess.seconds,
+		Deadline: sess.deadline,
+		Question: NewQuestionResponse(&sess.questions[sess.current], AnswerPolicyFor(ViewExam)),
+	}}
+}
+
+func (sess *LiveSession) revealEvent() dto.LiveEvent {
+	return dto.LiveEvent{Type: LiveEventReveal, Data: dto.LiveRevealEvent{
+		Index:       sess.current,
+		Question:    NewQuestionResponse(&sess.questions[sess.current], AnswerPolicyFor(ViewReview)),
+		Answered:    len(sess.answered),
+		Correct:     sess.correct,
+		Leaderboard: sess.leaderboard(),
+	}}
+}
+
+// leaderboard 按得分从高到低返回学员，同分按用户名排序
+func (sess *LiveSession) leaderboard() []dto.LivePlayer {
+	players := make([]dto.LivePlayer, 0, len(sess.players))
+	for _, player := range sess.players {
+		players = append(players, player.LivePlayer)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Score != players[j].Score {
+			return players[i].Score > players[j].Score
+		}
+		return players[i].Username < players[j].Username
+	})
+	return players
+}
+
+func (sess *LiveSession) broadcast(event dto.LiveEvent) {
+	for subscriber := range sess.subscribers {
+		sess.send(subscriber, event)
+	}
+}
+
+func (sess *LiveSession) sendToUser(userID uint, event dto.LiveEvent) {
+	for subscriber := range sess.subscribers {
+		if subscriber.UserID == userID {
+			sess.send(subscriber, event)
+		}
+	}
+}
+
+// send 不阻塞地推送事件，缓冲写满的连接直接断开
+func (sess *LiveSession) send(subscriber *LiveSubscriber, event dto.LiveEvent) {
+	select {
+	case subscriber.Events <- event:
+	default:
+		sess.unsubscribe(subscriber)
+	}
+}
+
+func (sess *LiveSession) unsubscribe(subscriber *LiveSubscriber) {
+	if !sess.subscribers[subscriber] {
+		return
+	}
+	delete(sess.subscribers, subscriber)
+	close(subscriber.Events)
+	if player, ok := sess.players[subscriber.UserID]; ok {
+		player.connections--
+	}
+}
@@ -0,0 +1,252 @@
+// services/login_throttle.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	loginScopeAccount = "account"
+	loginScopeIP      = "ip"
+)
+
+// LoginThrottleSettings 控制登录失败后的限流和临时锁定。
+// 连续失败超过免费次数后，每次失败都要等待一段时间才能再试，等待时间从 BaseDelay 开始逐次翻倍
+type LoginThrottleSettings struct {
+	AccountFreeAttempts int           // 同一账号连续失败多少次之内不限流
+	IPFreeAttempts      int           // 同一 IP 连续失败多少次之内不限流，多人共用出口 IP 时应设大一些
+	BaseDelay           time.Duration // 超出免费次数后第一次需要等待的时间
+	MaxDelay            time.Duration // 等待时间上限
+	ResetAfter          time.Duration // 距上次失败超过这个时间后重新计数
+	LockoutThreshold    int           // 账号连续失败多少次后临时停用，0 表示不停用
+	LockoutDuration     time.Duration // 临时停用的时长
+}
+
+// DefaultLoginThrottle 是默认的登录限流设置
+var DefaultLoginThrottle = LoginThrottleSettings{
+	AccountFreeAttempts: 3,
+	IPFreeAttempts:      20,
+	BaseDelay:           time.Second,
+	MaxDelay:            15 * time.Minute,
+	ResetAfter:          time.Hour,
+	LockoutThreshold:    10,
+	LockoutDuration:     30 * time.Minute,
+}
+
+// LoginThrottledError 表示登录失败次数过多，需要等待 RetryAfter 后再试
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// AuthenticateLogin 在 Authenticate 之外按账号和 IP 限流：失败次数过多时直接返回 *LoginThrottledError，
+// 不再校验密码；账号连续失败达到阈值时临时停用，到期后下次登录自动恢复。
+// 校验密码前先为账号预占一次失败，并发的请求依次计数，不能借并行请求绕过限流
+func (s *AuthService) AuthenticateLogin(username, password, ip string) (models.User, error) {
+	now := time.Now()
+	if wait, err := s.loginRetryAfter(username, ip, now); err != nil {
+		return models.User{}, err
+	} else if wait > 0 {
+		return models.User{}, &LoginThrottledError{RetryAfter: wait}
+	}
+	if err := s.releaseExpiredLockout(username, ip, now); err != nil {
+		return models.User{}, err
+	}
+	failures, err := s.reserveLoginAttempt(username, now)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// 本地密码不匹配后其他认证方式出错（例如目录服务不可用）时也记为失败，否则出错期间可以无限制地猜测本地密码；
+	// ErrUserInactive 表示密码已经校验通过，退还预占的次数
+	user, err := s.Authenticate(username, password)
+	switch {
+	case errors.Is(err, ErrUserInactive):
+		if err := s.refundLoginAttempt(username); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, err
+	case err != nil:
+		if err := s.recordLoginFailure(username, ip, failures, now); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, err
+	}
+	// 只清除账号的失败记录，IP 的记录保留，避免攻击者用自己的账号重置计数
+	if err := s.db.Where("scope = ? AND target = ?", loginScopeAccount, loginAccountKey(username)).
+		Delete(&models.LoginFailure{}).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// PurgeLoginFailures 删除已经过期、不再影响限流的失败记录
+func (s *AuthService) PurgeLoginFailures() (int64, error) {
+	now := time.Now()
+	result := s.db.Where("last_failure_at < ? AND blocked_until < ?", now.Add(-s.LoginThrottle.ResetAfter), now).
+		Delete(&models.LoginFailure{})
+	return result.RowsAffected, result.Error
+}
+
+// loginRetryAfter 返回账号或 IP 还需要等待多久才能再次尝试登录
+func (s *AuthService) loginRetryAfter(username, ip string, now time.Time) (time.Duration, error) {
+	var failures []models.LoginFailure
+	if err := s.db.Where("(scope = ? AND target = ?) OR (scope = ? AND target = ?)",
+		loginScopeAccount, loginAccountKey(username), loginScopeIP, ip).Find(&failures).Error; err != nil {
+		return 0, err
+	}
+	var wait time.Duration
+	for _, failure := range failures {
+		if remaining := failure.BlockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// reserveLoginAttempt 在校验密码前为账号记一次失败，返回当前连续失败次数；账号正在限流时返回 *LoginThrottledError。
+// 计数用条件更新完成，更新会锁住这一行，并发的请求依次计数，前一个请求设置的等待时间对后面的请求立即生效
+func (s *AuthService) reserveLoginAttempt(username string, now time.Time) (int, error) {
+	var failures int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		failure, ok, err := s.incrementLoginFailure(tx, loginScopeAccount, loginAccountKey(username),
+			s.LoginThrottle.AccountFreeAttempts, now, true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &LoginThrottledError{RetryAfter: failure.BlockedUntil.Sub(now)}
+		}
+		failures = failure.Failures
+		return nil
+	})
+	return failures, err
+}
+
+// refundLoginAttempt 退还预占的失败次数。密码已经校验通过，同时解除账号的等待
+func (s *AuthService) refundLoginAttempt(username string) error {
+	return s.db.Model(&models.LoginFailure{}).
+		Where("scope = ? AND target = ? AND failures > 0", loginScopeAccount, loginAccountKey(username)).
+		Updates(map[string]interface{}{"failures": gorm.Expr("failures - 1"), "blocked_until": time.Time{}}).Error
+}
+
+// recordLoginFailure 在密码校验失败后为 IP 记一次失败；账号的失败已经预占，failures 达到阈值时临时停用账号
+func (s *AuthService) recordLoginFailure(username, ip string, failures int, now time.Time) error {
+	settings := s.LoginThrottle
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if ip != "" {
+			if _, _, err := s.incrementLoginFailure(tx, loginScopeIP, ip, settings.IPFreeAttempts, now, false); err != nil {
+				return err
+			}
+		}
+		if settings.LockoutThreshold > 0 && failures >= settings.LockoutThreshold {
+			return s.lockAccount(tx, username, ip, failures, now)
+		}
+		return nil
+	})
+}
+
+// incrementLoginFailure 原子地增加一条失败记录的次数，超出免费次数后按指数退避设置等待时间。
+// unblockedOnly 为 true 时只在没有限流时计数，返回的 bool 表示是否计数
+func (s *AuthService) incrementLoginFailure(tx *gorm.DB, scope, target string, freeAttempts int, now time.Time, unblockedOnly bool) (models.LoginFailure, bool, error) {
+	failure := models.LoginFailure{Scope: scope, Target: target}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&failure).Error; err != nil {
+		return failure, false, err
+	}
+	query := tx.Model(&models.LoginFailure{}).Where("scope = ? AND target = ?", scope, target)
+	if unblockedOnly {
+		query = query.Where("blocked_until <= ?", now)
+	}
+	result := query.Updates(map[string]interface{}{
+		"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-s.LoginThrottle.ResetAfter)),
+		"last_failure_at": now,
+	})
+	if result.Error != nil {
+		return failure, false, result.Error
+	}
+	if err := tx.Where("scope = ? AND target = ?", scope, target).First(&failure).Error; err != nil {
+		return failure, false, err
+	}
+	if result.RowsAffected == 0 {
+		return failure, false, nil
+	}
+	if excess := failure.Failures - freeAttempts; excess > 0 {
+		failure.BlockedUntil = now.Add(s.loginBackoff(excess))
+		if err := tx.Model(&failure).Update("blocked_until", failure.BlockedUntil).Error; err != nil {
+			return failure, false, err
+		}
+	}
+	return failure, true, nil
+}
+
+// loginBackoff 返回超出免费次数 excess 次后需要等待的时间
+func (s *AuthService) loginBackoff(excess int) time.Duration {
+	settings := s.LoginThrottle
+	delay := float64(settings.BaseDelay) * math.Pow(2, float64(excess-1))
+	if delay > float64(settings.MaxDelay) {
+		return settings.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// lockAccount 临时停用账号并记录审计日志。账号不存在或不是启用状态时不做处理
+func (s *AuthService) lockAccount(tx *gorm.DB, username, ip string, failures int, now time.Time) error {
+	var user models.User
+	if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status != models.StatusActive {
+		return nil
+	}
+	lockedUntil := now.Add(s.LoginThrottle.LockoutDuration)
+	if err := tx.Model(&user).Updates(map[string]interface{}{
+		"status": models.StatusSuspended, "locked_until": lockedUntil,
+	}).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, AuditAccountLocked, &user, ip,
+		fmt.Sprintf("%d consecutive failed logins, locked until %s", failures, lockedUntil.Format(time.RFC3339)))
+}
+
+// releaseExpiredLockout 恢复临时停用已到期的账号，并清除账号的失败记录
+func (s *AuthService) releaseExpiredLockout(username, ip string, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Where("username = ? AND status = ? AND locked_until <= ?", username, models.StatusSuspended, now).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"status": models.StatusActive, "locked_until": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scope = ? AND target = ?", loginScopeAccount, loginAccountKey(username)).
+			Delete(&models.LoginFailure{}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditAccountUnlocked, &user, ip, "lockout expired")
+	})
+}
+
+// loginAccountKey 账号的限流不区分大小写，也覆盖不存在的用户名，避免通过限流行为判断账号是否存在
+func loginAccountKey(username string) string {
+	return strings.ToLower(username)
+}
@@ -0,0 +1,380 @@
+// services/mfa.go
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"learn/internal/consts/claimkeys"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultMFAIssuer 是验证器中显示的服务名称
+	DefaultMFAIssuer = "Learn"
+	// MFAChallengeDuration 是登录第二步的 MFAToken 有效期
+	MFAChallengeDuration = 5 * time.Minute
+	// RecoveryCodeCount 是每次生成的恢复码数量
+	RecoveryCodeCount = 10
+	// MaxMFAFailures 是连续验证失败多少次后暂停验证
+	MaxMFAFailures = 5
+	// MFALockDuration 是连续验证失败后暂停验证的时长
+	MFALockDuration = 15 * time.Minute
+
+	totpPeriod       = 30
+	mfaPurposeLogin  = "mfa"
+	mfaPurposeEnroll = "mfa_enroll"
+	mfaPurposeClaim  = "purpose"
+)
+
+var (
+	ErrInvalidMFAToken         = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode          = errors.New("invalid verification code")
+	ErrMFALocked               = errors.New("too many failed verification attempts, try again later")
+	ErrMFAAlreadyEnabled       = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled           = errors.New("two-factor authentication is not enabled")
+	ErrMFAEnrollmentNotStarted = errors.New("two-factor enrollment has not been started")
+	ErrMFARequiredByRole       = errors.New("two-factor authentication is required by the user's role")
+)
+
+// MFAState 返回用户是否已启用两步验证，以及角色是否要求启用
+func (s *AuthService) MFAState(userID uint) (enabled, required bool, err error) {
+	var mfa models.UserMFA
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).Limit(1).Find(&mfa).Error; err != nil {
+		return false, false, err
+	}
+	var count int64
+	err = s.db.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.require_mfa = ?", userID, true).
+		Count(&count).Error
+	return mfa.UserID != 0, count > 0, err
+}
+
+// IssueMFAChallenge 签发登录第二步使用的 MFAToken。enroll 为 true 时只能用于绑定验证器
+func (s *AuthService) IssueMFAChallenge(user models.User, enroll bool) (string, error) {
+	purpose := mfaPurposeLogin
+	if enroll {
+		purpose = mfaPurposeEnroll
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		claimkeys.UserId:       user.ID,
+		claimkeys.TokenVersion: user.TokenVersion,
+		mfaPurposeClaim:        purpose,
+		claimkeys.Exp:          time.Now().Add(MFAChallengeDuration).Unix(),
+	})
+	key, err := s.mfaSigningKey()
+	if err != nil {
+		return "", err
+	}
+	return token.SignedString(key)
+}
+
+// VerifyMFAChallenge 校验 MFAToken 并返回对应的用户
+func (s *AuthService) VerifyMFAChallenge(tokenString string, enroll bool) (models.User, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.mfaSigningKey()
+	})
+	if err != nil || !token.Valid {
+		return models.User{}, ErrInvalidMFAToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	purpose := mfaPurposeLogin
+	if enroll {
+		purpose = mfaPurposeEnroll
+	}
+	if !ok || claims[mfaPurposeClaim] != purpose {
+		return models.User{}, ErrInvalidMFAToken
+	}
+	userID, _ := claims[claimkeys.UserId].(float64)
+	tokenVersion, _ := claims[claimkeys.TokenVersion].(float64)
+
+	user, err := s.GetUserByID(uint(userID))
+	if err != nil || user.TokenVersion != uint(tokenVersion) || user.Status != models.StatusActive {
+		return models.User{}, ErrInvalidMFAToken
+	}
+	return user, nil
+}
+
+// StartMFAEnrollment 为用户生成新的 TOTP 密钥，验证通过前不会启用
+func (s *AuthService) StartMFAEnrollment(user models.User) (*dto.MFAEnrollmentResponse, error) {
+	enabled, _, err := s.MFAState(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: s.MFAIssuer, AccountName: user.Username, Period: totpPeriod})
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserMFA{UserID: user.ID, Secret: key.Secret()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := key.Image(200, 200)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image); err != nil {
+		return nil, err
+	}
+	return &dto.MFAEnrollmentResponse{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ConfirmMFAEnrollment 用验证器上的验证码确认绑定，启用两步验证并返回恢复码
+func (s *AuthService) ConfirmMFAEnrollment(userID uint, code string) ([]string, error) {
+	var mfa models.UserMFA
+	if err := s.db.First(&mfa, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFAEnrollmentNotStarted
+		}
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := s.checkMFALock(&mfa); err != nil {
+		return nil, err
+	}
+	step, ok := validateTOTP(mfa.Secret, code, mfa.LastUsedStep, time.Now())
+	if !ok {
+		return nil, s.recordMFAFailure(&mfa)
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&mfa).Updates(map[string]interface{}{
+			"enabled": true, "enabled_at": now, "last_used_step": step, "failed_count": 0, "locked_until": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyMFACode 校验验证码或恢复码，恢复码使用后失效
+func (s *AuthService) VerifyMFACode(userID uint, code string) error {
+	var mfa models.UserMFA
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+	if err := s.checkMFALock(&mfa); err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, ok := validateTOTP(mfa.Secret, code, mfa.LastUsedStep, time.Now())
+		if ok {
+			// 按条件更新，同一验证码并发提交时只有一次有效
+			result := s.db.Model(&models.UserMFA{}).
+				Where("user_id = ? AND last_used_step < ?", userID, step).
+				Updates(map[string]interface{}{"last_used_step": step, "failed_count": 0, "locked_until": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				return nil
+			}
+		}
+		return s.recordMFAFailure(&mfa)
+	}
+
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return s.recordMFAFailure(&mfa)
+	}
+	return s.db.Model(&mfa).Updates(map[string]interface{}{"failed_count": 0, "locked_until": nil}).Error
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，之前的恢复码全部失效
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := s.VerifyMFACode(userID, code); err != nil {
+		return nil, err
+	}
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableMFA 校验验证码后关闭用户的两步验证，角色要求两步验证时不能关闭
+func (s *AuthService) DisableMFA(userID uint, code string) error {
+	_, required, err := s.MFAState(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+	if err := s.VerifyMFACode(userID, code); err != nil {
+		return err
+	}
+	return s.ResetMFA(userID)
+}
+
+// ResetMFA 删除用户的两步验证设置和恢复码，供管理员处理丢失验证器的用户
+func (s *AuthService) ResetMFA(userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// GetMFAStatus 返回用户的两步验证状态
+func (s *AuthService) GetMFAStatus(userID uint) (*dto.MFAStatusResponse, error) {
+	enabled, required, err := s.MFAState(userID)
+	if err != nil {
+		return nil, err
+	}
+	var remaining int64
+	if err := s.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining).Error; err != nil {
+		return nil, err
+	}
+	return &dto.MFAStatusResponse{Enabled: enabled, Required: required, RecoveryCodesRemaining: int(remaining)}, nil
+}
+
+// SetRoleMFARequired 设置角色是否要求两步验证，对之后的登录生效
+func (s *AuthService) SetRoleMFARequired(roleID uint, required bool) error {
+	var role models.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&role).Update("require_mfa", required).Error
+}
+
+// mfaSigningKey 与 Access Token 使用不同的密钥，MFAToken 不能当作 Access Token 使用。
+// 未配置 jwt.secret 时密钥是公开的 ":mfa"，拒绝签发和验证
+func (s *AuthService) mfaSigningKey() ([]byte, error) {
+	if s.jwtSecret == "" {
+		return nil, ErrMissingJWTSecret
+	}
+	return []byte(s.jwtSecret + ":mfa"), nil
+}
+
+// checkMFALock 在连续失败过多时拒绝验证
+func (s *AuthService) checkMFALock(mfa *models.UserMFA) error {
+	if mfa.LockedUntil != nil && time.Now().Before(*mfa.LockedUntil) {
+		return ErrMFALocked
+	}
+	return nil
+}
+
+// recordMFAFailure 记录一次验证失败，连续失败 MaxMFAFailures 次后暂停验证
+func (s *AuthService) recordMFAFailure(mfa *models.UserMFA) error {
+	updates := map[string]interface{}{"failed_count": gorm.Expr("failed_count + 1")}
+	if mfa.FailedCount+1 >= MaxMFAFailures {
+		updates = map[string]interface{}{"failed_count": 0, "locked_until": time.Now().Add(MFALockDuration)}
+	}
+	if err := s.db.Model(&models.UserMFA{}).Where("user_id = ?", mfa.UserID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+// validateTOTP 校验验证码，允许前后各一个时间步的时钟偏差，已使用过的时间步无效
+func validateTOTP(secret, code string, lastUsedStep int64, now time.Time) (int64, bool) {
+	if !isTOTPCode(code) {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceRecoveryCodes 删除用户原有的恢复码并生成一组新的，返回明文
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := utils.GenerateInviteCode(10)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+		if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: hashToken(code)}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 去掉分隔符并转为大写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
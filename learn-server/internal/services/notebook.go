@@ -0,0 +1,162 @@
+// services/notebook.go
+package services
+
+import (
+	"errors"
+	"learn/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultNotebookClearStreak 是未收藏的错题自动移出错题本所需的连续答对次数
+const DefaultNotebookClearStreak = 3
+
+// NotebookFilter 按题库和标签筛选错题本，零值表示不筛选
+type NotebookFilter struct {
+	QuestionBankID uint
+	Tag            string
+}
+
+// NotebookItem 是错题本中的一道题及其当前的连续答对次数
+type NotebookItem struct {
+	Entry              models.NotebookEntry
+	ConsecutiveCorrect uint
+}
+
+// GetNotebook 分页返回用户错题本中的题目，最近更新的排在前面
+func (s *QuizService) GetNotebook(userID uint, filter NotebookFilter, page int, pageSize int) ([]NotebookItem, int64, error) {
+	var total int64
+	if err := s.notebookQuery(userID, filter).Model(&models.NotebookEntry{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.NotebookEntry
+	if err := s.notebookQuery(userID, filter).Preload("Question").
+		Order("notebook_entries.updated_at DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]NotebookItem, len(entries))
+	for i, entry := range entries {
+		// 组合题需要材料和小题才能显示
+		if err := s.loadAnswers(&entry.Question); err != nil {
+			return nil, 0, err
+		}
+		items[i].Entry = entry
+		attempt, err := s.GetQuestionAttempt(userID, entry.QuestionID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if attempt != nil {
+			items[i].ConsecutiveCorrect = attempt.ConsecutiveCorrect
+		}
+	}
+	return items, total, nil
+}
+
+// GetNotebookPractice 从用户的错题本中随机抽取题目用于练习
+func (s *QuizService) GetNotebookPractice(userID uint, filter NotebookFilter, limit int) ([]models.Question, error) {
+	var questionIDs []uint
+	if err := s.notebookQuery(userID, filter).Model(&models.NotebookEntry{}).
+		Order("RANDOM()").Limit(limit).
+		Pluck("notebook_entries.question_id", &questionIDs).Error; err != nil {
+		return nil, err
+	}
+
+	questions := make([]models.Question, len(questionIDs))
+	for i, questionID := range questionIDs {
+		if err := s.db.First(&questions[i], questionID).Error; err != nil {
+			return nil, err
+		}
+		if err := s.loadAnswers(&questions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return questions, nil
+}
+
+// UpdateNotebookEntry 收藏题目或修改笔记，题目不在错题本中时会先加入；
+// 取消收藏后既没有错误记录也没有笔记的题会被移出
+func (s *QuizService) UpdateNotebookEntry(userID uint, questionID uint, starred *bool, note *string) (*models.NotebookEntry, error) {
+	var question models.Question
+	if err := s.db.First(&question, questionID).Error; err != nil {
+		return nil, err
+	}
+
+	var entry models.NotebookEntry
+	err := s.db.Where("user_id = ? AND question_id = ?", userID, questionID).First(&entry).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	entry.UserID = userID
+	entry.QuestionID = questionID
+	if starred != nil {
+		entry.Starred = *starred
+	}
+	if note != nil {
+		entry.Note = *note
+	}
+
+	if !entry.Starred && entry.WrongCount == 0 && entry.Note == "" {
+		if entry.ID != 0 {
+			if err := s.db.Delete(&entry).Error; err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	if err := s.db.Save(&entry).Error; err != nil {
+		return nil, err
+	}
+	if err := s.loadAnswers(&question); err != nil {
+		return nil, err
+	}
+	entry.Question = question
+	return &entry, nil
+}
+
+// RemoveNotebookEntry 将题目移出用户的错题本
+func (s *QuizService) RemoveNotebookEntry(userID uint, questionID uint) error {
+	return s.db.Where("user_id = ? AND question_id = ?", userID, questionID).
+		Delete(&models.NotebookEntry{}).Error
+}
+
+// notebookQuery 构建用户错题本的筛选条件
+func (s *QuizService) notebookQuery(userID uint, filter NotebookFilter) *gorm.DB {
+	query := s.db.Where("notebook_entries.user_id = ?", userID)
+	if filter.QuestionBankID != 0 || filter.Tag != "" {
+		query = query.Joins("JOIN questions ON questions.id = notebook_entries.question_id")
+	}
+	if filter.QuestionBankID != 0 {
+		query = query.Where("questions.question_bank_id = ?", filter.QuestionBankID)
+	}
+	if filter.Tag != "" {
+		query = query.Joins("JOIN question_tags qt ON qt.question_id = questions.id").
+			Joins("JOIN tags t ON t.id = qt.tag_id").Where("t.name = ?", filter.Tag)
+	}
+	return query
+}
+
+// updateNotebook 根据作答结果维护错题本：答错的题加入错题本，
+// 既未收藏也没有笔记的题连续答对达到阈值后移出
+func updateNotebook(tx *gorm.DB, userID uint, attempt *models.QuestionAttempt, correct bool, clearStreak uint) error {
+	var entry models.NotebookEntry
+	err := tx.Where("user_id = ? AND question_id = ?", userID, attempt.QuestionID).First(&entry).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	found := err == nil
+
+	if !correct {
+		entry.UserID = userID
+		entry.QuestionID = attempt.QuestionID
+		entry.WrongCount++
+		return tx.Save(&entry).Error
+	}
+	if found && !entry.Starred && entry.Note == "" && attempt.ConsecutiveCorrect >= clearStreak {
+		return tx.Delete(&entry).Error
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// services/notifier.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message 是发送给用户的一条通知
+type Message struct {
+	To      string // 收件地址
+	Subject string
+	Body    string // 纯文本
+}
+
+// Notifier 向用户发送通知，例如找回密码的链接
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPNotifier 通过 SMTP 发送邮件，服务器支持 STARTTLS 时自动加密
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string // 为空时不认证
+	Password string
+	From     string
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(n.Host, fmt.Sprint(n.Port))
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, n.From, []string{msg.To}, n.buildMessage(msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildMessage 生成邮件内容，标题按 RFC 2047 编码，正文使用 base64
+func (n *SMTPNotifier) buildMessage(msg Message) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		// 去掉换行，防止注入额外的邮件头
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", n.From)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,271 @@
+// services/offline_sync.go
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxPracticePackageSize 是一个离线练习包最多包含的题目数
+	MaxPracticePackageSize = 500
+	// MaxOfflineBatchSize 是一次最多上传的离线作答数
+	MaxOfflineBatchSize = 500
+	// MaxOfflineAttemptAge 是离线作答最晚可以补交的时间
+	MaxOfflineAttemptAge = 30 * 24 * time.Hour
+)
+
+// 离线作答的同步状态
+const (
+	SyncStatusApplied   = "applied"   // 已判分并记录
+	SyncStatusDuplicate = "duplicate" // 之前已经同步过，返回首次同步的结果
+	SyncStatusRejected  = "rejected"  // 无法记录，见 Error
+)
+
+var ErrInvalidSync = errors.New("invalid sync request")
+
+// GetPracticePackage 生成题库的离线练习包，题目不含答案；完整反馈的题库附带用于本地判分的答案散列
+func (s *QuizService) GetPracticePackage(questionBankID uint, tag string, limit int) (*dto.PracticePackageResponse, error) {
+	bank, err := s.GetQuestionBank(questionBankID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > MaxPracticePackageSize {
+		limit = MaxPracticePackageSize
+	}
+	questions, err := s.GetQuestions(questionBankID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) > limit {
+		questions = questions[:limit]
+	}
+	salt, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &dto.PracticePackageResponse{
+		QuestionBankID: questionBankID,
+		Tag:            tag,
+		IssuedAt:       time.Now(),
+		Salt:           salt,
+		Questions:      make([]dto.QuestionResponse, len(questions)),
+	}
+	// 散列和 salt 一起下发，判断题、选择题和短填空可以离线穷举出答案，
+	// 所以只有本来就会公布答案的完整反馈题库才下发，其他题库的设备只能记录作答，联网同步时判分
+	if bank.FeedbackMode == models.FeedbackFull {
+		pkg.AnswerHashes = map[uint][]string{}
+	}
+	policy := AnswerPolicyFor(ViewPractice)
+	for i := range questions {
+		if err := s.loadAnswers(&questions[i]); err != nil {
+			return nil, err
+		}
+		pkg.Questions[i] = NewQuestionResponse(&questions[i], policy)
+		if pkg.AnswerHashes != nil {
+			addAnswerHashes(pkg.AnswerHashes, salt, &questions[i])
+		}
+	}
+	return pkg, nil
+}
+
+// SyncOfflineAttempts 按作答时间先后重放离线作答，判分、答题记录、错题本和经验值与在线作答相同，
+// 但经验值和连续天数按同步时间计算。
+// 已同步过的作答按 ClientID 去重；早于服务端最近作答的作答只计入作答次数。
+// 单条作答无效时只拒绝该条，其余作答在同一事务中记录。返回结果和在线作答一样按题库的反馈方式隐藏判分
+func (s *QuizService) SyncOfflineAttempts(userID uint, attempts []dto.OfflineAttempt) (*dto.SyncAttemptsResponse, error) {
+	if len(attempts) == 0 || len(attempts) > MaxOfflineBatchSize {
+		return nil, fmt.Errorf("%w: between 1 and %d attempts are required", ErrInvalidSync, MaxOfflineBatchSize)
+	}
+
+	clientIDs := make([]string, 0, len(attempts))
+	for _, attempt := range attempts {
+		clientIDs = append(clientIDs, attempt.ClientID)
+	}
+	var synced []models.SyncedAttempt
+	if err := s.db.Where("user_id = ? AND client_id IN ?", userID, clientIDs).Find(&synced).Error; err != nil {
+		return nil, err
+	}
+	previous := make(map[string]models.SyncedAttempt, len(synced))
+	for _, attempt := range synced {
+		previous[attempt.ClientID] = attempt
+	}
+
+	type pendingAttempt struct {
+		index      int
+		question   *models.Question
+		grade      *GradeResult
+		answeredAt time.Time
+	}
+	var pending []pendingAttempt
+	now := time.Now()
+	response := &dto.SyncAttemptsResponse{Results: make([]dto.OfflineAttemptResult, len(attempts))}
+	// first 记录批次内每个 ClientID 首次出现的位置，重复的作答在记录后照抄首次的结果
+	first := map[string]int{}
+	for i, attempt := range attempts {
+		result := &response.Results[i]
+		result.ClientID, result.QuestionID = attempt.ClientID, attempt.QuestionID
+		if prev, ok := previous[attempt.ClientID]; ok {
+			result.Status = SyncStatusDuplicate
+			result.QuestionID = prev.QuestionID
+			result.Correct, result.Score, result.XP, result.Superseded = prev.Correct, prev.Score, prev.XP, prev.Superseded
+			continue
+		}
+		if _, ok := first[attempt.ClientID]; ok {
+			result.Status = SyncStatusDuplicate
+			continue
+		}
+
+		reject := func(reason string) { result.Status, result.Error = SyncStatusRejected, reason }
+		switch {
+		case attempt.ClientID == "" || len(attempt.ClientID) > 64:
+			reject("client_id must be 1 to 64 characters")
+			continue
+		case attempt.AnsweredAt.IsZero():
+			reject("answered_at is required")
+			continue
+		case now.Sub(attempt.AnsweredAt) > MaxOfflineAttemptAge:
+			reject("attempt is too old to sync")
+			continue
+		}
+		first[attempt.ClientID] = i
+
+		question, grade, err := s.gradeQuestion(attempt.QuestionID, attempt.Answer)
+		if err != nil {
+			if !errors.Is(err, ErrQuestionNotFound) && !errors.Is(err, ErrInvalidAnswer) {
+				return nil, err
+			}
+			reject(err.Error())
+			continue
+		}
+		// 设备时钟可能偏快，晚于当前时间的作答按当前时间记录
+		answeredAt := attempt.AnsweredAt
+		if answeredAt.After(now) {
+			answeredAt = now
+		}
+		pending = append(pending, pendingAttempt{index: i, question: question, grade: grade, answeredAt: answeredAt})
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].answeredAt.Before(pending[j].answeredAt) })
+
+	var recorded []*AttemptResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range pending {
+			attemptResult, err := s.recordAttempt(tx, userID, p.question, p.grade, p.answeredAt)
+			if err != nil {
+				return err
+			}
+			syncedAttempt := models.SyncedAttempt{
+				UserID:     userID,
+				ClientID:   attempts[p.index].ClientID,
+				QuestionID: p.question.ID,
+				AnsweredAt: p.answeredAt,
+				Correct:    p.grade.Correct,
+				Score:      p.grade.Score,
+				XP:         attemptResult.XP,
+				Superseded: attemptResult.Superseded,
+			}
+			if err := tx.Create(&syncedAttempt).Error; err != nil {
+				return err
+			}
+			result := &response.Results[p.index]
+			result.Status = SyncStatusApplied
+			result.Correct, result.Score, result.XP, result.Superseded = p.grade.Correct, p.grade.Score, attemptResult.XP, attemptResult.Superseded
+			recorded = append(recorded, attemptResult)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishBadges(userID, recorded)
+
+	for i, attempt := range attempts {
+		if j, ok := first[attempt.ClientID]; ok && j != i {
+			response.Results[i] = response.Results[j]
+			if response.Results[j].Status == SyncStatusApplied {
+				response.Results[i].Status = SyncStatusDuplicate
+			}
+		}
+	}
+	if err := s.withholdSyncGrading(response.Results); err != nil {
+		return nil, err
+	}
+
+	for _, result := range response.Results {
+		switch result.Status {
+		case SyncStatusApplied:
+			response.Applied++
+		case SyncStatusDuplicate:
+			response.Duplicates++
+		case SyncStatusRejected:
+			response.Rejected++
+		}
+	}
+	return response, nil
+}
+
+// withholdSyncGrading 与在线作答一致，不反馈的题库不返回对错、得分和经验值
+func (s *QuizService) withholdSyncGrading(results []dto.OfflineAttemptResult) error {
+	var questionIDs []uint
+	for _, result := range results {
+		if result.Status != SyncStatusRejected {
+			questionIDs = append(questionIDs, result.QuestionID)
+		}
+	}
+	if len(questionIDs) == 0 {
+		return nil
+	}
+	var hiddenIDs []uint
+	if err := s.db.Model(&models.Question{}).
+		Joins("JOIN question_banks ON question_banks.id = questions.question_bank_id").
+		Where("questions.id IN ? AND question_banks.feedback_mode = ?", questionIDs, models.FeedbackNone).
+		Pluck("questions.id", &hiddenIDs).Error; err != nil {
+		return err
+	}
+	hidden := make(map[uint]bool, len(hiddenIDs))
+	for _, id := range hiddenIDs {
+		hidden[id] = true
+	}
+	for i := range results {
+		if results[i].Status != SyncStatusRejected && hidden[results[i].QuestionID] {
+			results[i].Correct, results[i].Score, results[i].XP = false, 0, 0
+			results[i].Withheld = true
+		}
+	}
+	return nil
+}
+
+// addAnswerHashes 为题目及其小题写入答案散列
+func addAnswerHashes(hashes map[uint][]string, salt string, question *models.Question) {
+	for _, child := range sortedChildren(question) {
+		addAnswerHashes(hashes, salt, child)
+	}
+	questionType, err := GetQuestionType(question.QuestionType)
+	if err != nil {
+		return
+	}
+	provider, ok := questionType.(AnswerKeyProvider)
+	if !ok {
+		return
+	}
+	for _, answer := range provider.AnswerKey(question) {
+		hashes[question.ID] = append(hashes[question.ID], hashAnswer(salt, question.ID, answer))
+	}
+}
+
+// hashAnswer 计算 hex(sha256(salt:questionID:answer))
+func hashAnswer(salt string, questionID uint, answer string) string {
+	sum := sha256.Sum256([]byte(salt + ":" + strconv.FormatUint(uint64(questionID), 10) + ":" + answer))
+	return hex.EncodeToString(sum[:])
+}
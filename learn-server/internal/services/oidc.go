@@ -0,0 +1,305 @@
+// services/oidc.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"slices"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const (
+	// OIDCStateDuration 是发起授权到回调之间允许的最长时间
+	OIDCStateDuration = 10 * time.Minute
+	// OIDCExchangeCodeDuration 是前端用一次性代码换取令牌的有效期
+	OIDCExchangeCodeDuration = time.Minute
+
+	defaultOIDCUsernameClaim = "preferred_username"
+)
+
+var (
+	ErrInvalidOIDCState      = errors.New("invalid or expired oidc state")
+	ErrInvalidOIDCCode       = errors.New("invalid or expired login code")
+	ErrOIDCAccountExists     = ErrIdentityAccountExists
+	ErrOIDCNotProvisioned    = ErrIdentityNotProvisioned
+	ErrOIDCIdentityLinked    = ErrIdentityLinkedElsewhere
+	ErrOIDCUserInactive      = ErrUserInactive
+	ErrOIDCMissingIDToken    = errors.New("token response does not contain an id_token")
+	ErrOIDCInvalidNonce      = errors.New("id_token nonce does not match")
+	ErrOIDCMissingClaimValue = errors.New("id_token does not contain a usable username")
+)
+
+// OIDCSettings 是 OpenID Connect 登录的配置
+type OIDCSettings struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string            // 本服务的回调地址，指向 /auth/oidc/callback
+	FrontendURL   string            // 登录或绑定完成后跳转的前端页面
+	Scopes        []string          // 默认 openid、profile、email
+	UsernameClaim string            // 用作本地用户名的声明，默认 preferred_username
+	RolesClaim    string            // 携带角色或分组的声明，例如 groups，为空时不同步角色
+	RoleMapping   map[string]string // RolesClaim 中的值（不区分大小写）到本地角色名的映射
+	DefaultRole   string            // 自动创建的用户没有匹配到角色时分配的角色
+	AutoProvision bool              // 首次登录时自动创建本地用户
+	// LinkByUsername 为 true 时，首次登录自动关联同名的本地用户。只有 IdP 的用户名可信时才应开启
+	LinkByUsername bool
+}
+
+// OIDCService 实现 OpenID Connect 授权码登录（PKCE），将 IdP 账号关联到本地用户
+type OIDCService struct {
+	db       *gorm.DB
+	auth     *AuthService
+	settings OIDCSettings
+	policy   externalUserPolicy
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// OIDCCallbackResult 是处理授权回调的结果，登录时返回一次性代码，绑定时 Linked 为 true
+type OIDCCallbackResult struct {
+	Code   string
+	Linked bool
+}
+
+// NewOIDCService 通过发现文档连接身份提供方
+func NewOIDCService(ctx context.Context, db *gorm.DB, auth *AuthService, settings OIDCSettings) (*OIDCService, error) {
+	if settings.Issuer == "" || settings.ClientID == "" || settings.RedirectURL == "" || settings.FrontendURL == "" {
+		return nil, errors.New("oidc issuer, client_id, redirect_url and frontend_url are required")
+	}
+	provider, err := oidc.NewProvider(ctx, settings.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+	scopes := settings.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	} else if !slices.Contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+	if settings.UsernameClaim == "" {
+		settings.UsernameClaim = defaultOIDCUsernameClaim
+	}
+	return &OIDCService{
+		db:       db,
+		auth:     auth,
+		settings: settings,
+		policy: externalUserPolicy{
+			RoleMapping:    settings.RoleMapping,
+			DefaultRole:    settings.DefaultRole,
+			AutoProvision:  settings.AutoProvision,
+			LinkByUsername: settings.LinkByUsername,
+		},
+		oauth: oauth2.Config{
+			ClientID:     settings.ClientID,
+			ClientSecret: settings.ClientSecret,
+			RedirectURL:  settings.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: settings.ClientID}),
+	}, nil
+}
+
+// FrontendURL 返回登录或绑定完成后跳转的前端页面
+func (s *OIDCService) FrontendURL() string {
+	return s.settings.FrontendURL
+}
+
+// RedirectURL 返回本服务的回调地址
+func (s *OIDCService) RedirectURL() string {
+	return s.settings.RedirectURL
+}
+
+// AuthorizationURL 生成跳转到身份提供方的授权地址，并返回其中的 state，由调用方绑定到发起请求的浏览器。
+// linkUserID 不为 0 时，回调把 IdP 账号绑定到该用户
+func (s *OIDCService) AuthorizationURL(linkUserID uint) (authURL, state string, err error) {
+	state, err = utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	loginState := models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    time.Now().Add(OIDCStateDuration),
+	}
+	if linkUserID != 0 {
+		loginState.LinkUserID = &linkUserID
+	}
+	// 顺便清理过期的授权请求
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		return "", "", err
+	}
+	if err := s.db.Create(&loginState).Error; err != nil {
+		return "", "", err
+	}
+	return s.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(loginState.CodeVerifier)), state, nil
+}
+
+// HandleCallback 校验授权回调，用授权码换取并验证 ID Token，然后登录或绑定账号。
+// 登录时找到或创建本地用户，同步映射的角色，并返回换取令牌的一次性代码
+func (s *OIDCService) HandleCallback(ctx context.Context, state, code string) (*OIDCCallbackResult, error) {
+	loginState, err := s.consumeState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.oauth.Exchange(ctx, code, oauth2.VerifierOption(loginState.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrOIDCMissingIDToken
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return nil, ErrOIDCInvalidNonce
+	}
+	identity, err := s.extractIdentity(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if loginState.LinkUserID != nil {
+		if err := linkExternalIdentity(s.db, *loginState.LinkUserID, identity); err != nil {
+			return nil, err
+		}
+		return &OIDCCallbackResult{Linked: true}, nil
+	}
+
+	user, err := s.policy.resolveUser(s.db, identity)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != models.StatusActive {
+		return nil, ErrOIDCUserInactive
+	}
+	exchangeCode, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	record := models.OIDCExchangeCode{
+		CodeHash:  hashToken(exchangeCode),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(OIDCExchangeCodeDuration),
+	}
+	// 顺便清理未使用的过期代码
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCExchangeCode{}).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &OIDCCallbackResult{Code: exchangeCode}, nil
+}
+
+// ExchangeLoginCode 用一次性代码换取登录的用户，代码使用后失效
+func (s *OIDCService) ExchangeLoginCode(code string) (models.User, error) {
+	var record models.OIDCExchangeCode
+	if err := s.db.Where("code_hash = ?", hashToken(code)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, ErrInvalidOIDCCode
+		}
+		return models.User{}, err
+	}
+	// 按条件删除，同一代码并发提交时只有一次有效
+	result := s.db.Where("id = ?", record.ID).Delete(&models.OIDCExchangeCode{})
+	if result.Error != nil {
+		return models.User{}, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(record.ExpiresAt) {
+		return models.User{}, ErrInvalidOIDCCode
+	}
+	user, err := s.auth.GetUserByID(record.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.Status != models.StatusActive {
+		return models.User{}, ErrOIDCUserInactive
+	}
+	return user, nil
+}
+
+// GetIdentities 返回用户关联的 IdP 账号
+func (s *OIDCService) GetIdentities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := s.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// UnlinkIdentity 解除用户与 IdP 账号的关联，账号不属于该用户时返回 gorm.ErrRecordNotFound
+func (s *OIDCService) UnlinkIdentity(userID, identityID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", identityID, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// consumeState 取出并删除授权请求，每个 state 只能使用一次
+func (s *OIDCService) consumeState(state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	if err := s.db.Where("state = ?", state).First(&loginState).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+	result := s.db.Where("id = ?", loginState.ID).Delete(&models.OIDCLoginState{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+	return &loginState, nil
+}
+
+// extractIdentity 按配置从 ID Token 中取出用户名、邮箱和角色
+func (s *OIDCService) extractIdentity(idToken *oidc.IDToken) (*externalIdentity, error) {
+	var raw map[string]interface{}
+	if err := idToken.Claims(&raw); err != nil {
+		return nil, err
+	}
+	identity := &externalIdentity{Issuer: idToken.Issuer, Subject: idToken.Subject}
+	identity.Email, _ = raw["email"].(string)
+	identity.Username, _ = raw[s.settings.UsernameClaim].(string)
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+	if identity.Username == "" {
+		return nil, ErrOIDCMissingClaimValue
+	}
+	if s.settings.RolesClaim != "" {
+		switch value := raw[s.settings.RolesClaim].(type) {
+		case string:
+			identity.Roles = []string{value}
+		case []interface{}:
+			for _, item := range value {
+				if role, ok := item.(string); ok {
+					identity.Roles = append(identity.Roles, role)
+				}
+			}
+		}
+	}
+	return identity, nil
+}
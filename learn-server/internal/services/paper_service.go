@@ -0,0 +1,472 @@
+// services/paper_service.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"math/rand"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPaper = errors.New("invalid paper")
+	ErrPaperFrozen  = errors.New("paper is frozen")
+)
+
+type PaperService struct {
+	db          *gorm.DB
+	quizService *QuizService
+}
+
+func NewPaperService(db *gorm.DB, quizService *QuizService) *PaperService {
+	return &PaperService{db: db, quizService: quizService}
+}
+
+// PaperVersion 是按组卷规则抽题后的一份具体试卷
+type PaperVersion struct {
+	Paper    *models.Paper
+	Sections []PaperVersionSection
+}
+
+// PaperVersionSection 是具体试卷中的一个分节
+type PaperVersionSection struct {
+	Title     string
+	Questions []PaperVersionQuestion
+}
+
+// PaperVersionQuestion 是具体试卷中的一道题及其分值
+type PaperVersionQuestion struct {
+	Question *models.Question
+	Points   float64
+}
+
+// TotalPoints 返回试卷总分
+func (v *PaperVersion) TotalPoints() float64 {
+	var total float64
+	for _, section := range v.Sections {
+		for _, question := range section.Questions {
+			total += question.Points
+		}
+	}
+	return total
+}
+
+// CreatePaper 创建草稿状态的试卷
+func (s *PaperService) CreatePaper(authorID uint, req dto.PaperRequest) (*models.Paper, error) {
+	paper := models.Paper{AuthorID: authorID}
+	applyPaperRequest(&paper, req)
+	if err := s.validatePaper(&paper); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaper, err)
+	}
+
+	if err := s.db.Create(&paper).Error; err != nil {
+		return nil, err
+	}
+	return &paper, nil
+}
+
+// UpdatePaper 修改草稿试卷，分节整体替换
+func (s *PaperService) UpdatePaper(paperID uint, req dto.PaperRequest) (*models.Paper, error) {
+	paper, err := s.GetPaper(paperID)
+	if err != nil {
+		return nil, err
+	}
+	if paper.Frozen {
+		return nil, ErrPaperFrozen
+	}
+	applyPaperRequest(paper, req)
+	if err := s.validatePaper(paper); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaper, err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := deletePaperSections(tx, paper.ID); err != nil {
+			return err
+		}
+		if err := tx.Omit("Sections").Save(paper).Error; err != nil {
+			return err
+		}
+		for i := range paper.Sections {
+			paper.Sections[i].PaperID = paper.ID
+			if err := tx.Create(&paper.Sections[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paper, nil
+}
+
+// DeletePaper 删除草稿试卷，冻结的试卷已经下发给学员，不能删除
+func (s *PaperService) DeletePaper(paperID uint) error {
+	var paper models.Paper
+	if err := s.db.First(&paper, paperID).Error; err != nil {
+		return err
+	}
+	if paper.Frozen {
+		return ErrPaperFrozen
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := deletePaperSections(tx, paper.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&paper).Error
+	})
+}
+
+// GetPaper 返回试卷及其分节、题目和组卷规则
+func (s *PaperService) GetPaper(paperID uint) (*models.Paper, error) {
+	var paper models.Paper
+	byPosition := func(db *gorm.DB) *gorm.DB { return db.Order("position") }
+	if err := s.db.Preload("Sections", byPosition).
+		Preload("Sections.Questions", byPosition).
+		Preload("Sections.Rules", byPosition).
+		First(&paper, paperID).Error; err != nil {
+		return nil, err
+	}
+	return &paper, nil
+}
+
+// GetPapers 返回全部试卷，不含分节
+func (s *PaperService) GetPapers() ([]models.Paper, error) {
+	var papers []models.Paper
+	if err := s.db.Order("id DESC").Find(&papers).Error; err != nil {
+		return nil, err
+	}
+	return papers, nil
+}
+
+// FreezePaper 冻结试卷：记录每条规则当前的候选题和随机种子。
+// 同一份模式下直接按规则抽题并固定到分节中；等价模式下每名学员在候选题中各自抽题
+func (s *PaperService) FreezePaper(paperID uint) (*models.Paper, error) {
+	paper, err := s.GetPaper(paperID)
+	if err != nil {
+		return nil, err
+	}
+	if paper.Frozen {
+		return nil, ErrPaperFrozen
+	}
+
+	fixed := fixedPaperQuestions(paper)
+	var checkedRules []*models.BlueprintRule
+	var checkedCandidates [][]uint
+	for i := range paper.Sections {
+		for j := range paper.Sections[i].Rules {
+			rule := &paper.Sections[i].Rules[j]
+			candidates, err := s.ruleCandidates(rule)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkRuleCapacity(rule, candidates, fixed, checkedRules, checkedCandidates); err != nil {
+				return nil, fmt.Errorf("%w: section %q: %v", ErrInvalidPaper, paper.Sections[i].Title, err)
+			}
+			checkedRules = append(checkedRules, rule)
+			checkedCandidates = append(checkedCandidates, candidates)
+			if rule.Candidates, err = json.Marshal(candidates); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	paper.Seed = rand.Int63()
+	var drawn [][]PaperVersionQuestion
+	if paper.Mode == models.PaperModeSame {
+		version, err := s.drawVersion(paper, rand.New(rand.NewSource(paper.Seed)))
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range version.Sections {
+			drawn = append(drawn, section.Questions)
+		}
+	}
+	now := time.Now()
+	paper.Frozen = true
+	paper.FrozenAt = &now
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Sections").Save(paper).Error; err != nil {
+			return err
+		}
+		for i := range paper.Sections {
+			section := &paper.Sections[i]
+			for j := range section.Rules {
+				if err := tx.Model(&section.Rules[j]).Update("candidates", section.Rules[j].Candidates).Error; err != nil {
+					return err
+				}
+			}
+			if drawn == nil {
+				continue
+			}
+			// 抽出的题排在手动挑选的题之后
+			for _, question := range drawn[i][len(section.Questions):] {
+				paperQuestion := models.PaperQuestion{
+					SectionID:  section.ID,
+					QuestionID: question.Question.ID,
+					Position:   len(section.Questions) + 1,
+					Points:     question.Points,
+					Drawn:      true,
+				}
+				if err := tx.Create(&paperQuestion).Error; err != nil {
+					return err
+				}
+				section.Questions = append(section.Questions, paperQuestion)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paper, nil
+}
+
+// GetVersion 返回学员拿到的具体试卷。草稿试卷每次从题库中重新抽题，用于预览；
+// 冻结的试卷在等价模式下按学员固定抽题结果，同一学员每次拿到同一份
+func (s *PaperService) GetVersion(paper *models.Paper, userID uint) (*PaperVersion, error) {
+	if !paper.Frozen {
+		return s.drawVersion(paper, rand.New(rand.NewSource(time.Now().UnixNano())))
+	}
+	return s.drawVersion(paper, rand.New(rand.NewSource(paper.Seed+int64(userID))))
+}
+
+// drawVersion 依次放入每个分节手动挑选的题，再按规则抽题。
+// 已冻结的试卷从冻结时记录的候选题中抽取，同一道题在整份试卷中只出现一次
+func (s *PaperService) drawVersion(paper *models.Paper, rng *rand.Rand) (*PaperVersion, error) {
+	version := &PaperVersion{Paper: paper}
+	used := fixedPaperQuestions(paper)
+	for _, section := range paper.Sections {
+		var questionIDs []uint
+		var points []float64
+		for _, question := range section.Questions {
+			questionIDs = append(questionIDs, question.QuestionID)
+			points = append(points, question.Points)
+		}
+		// 同一份模式冻结后抽出的题已经固定在分节中
+		if !paper.Frozen || paper.Mode != models.PaperModeSame {
+			for i := range section.Rules {
+				rule := &section.Rules[i]
+				candidates, err := s.frozenOrLiveCandidates(paper, rule)
+				if err != nil {
+					return nil, err
+				}
+				drawn, err := drawQuestions(candidates, rule.Count, used, rng)
+				if err != nil {
+					return nil, fmt.Errorf("%w: section %q: %v", ErrInvalidPaper, section.Title, err)
+				}
+				for _, questionID := range drawn {
+					used[questionID] = true
+					questionIDs = append(questionIDs, questionID)
+					points = append(points, rule.Points)
+				}
+			}
+		}
+
+		versionSection := PaperVersionSection{Title: section.Title}
+		for i, questionID := range questionIDs {
+			question, err := s.quizService.GetQuestionDetail(questionID)
+			if err != nil {
+				return nil, err
+			}
+			versionSection.Questions = append(versionSection.Questions, PaperVersionQuestion{Question: question, Points: points[i]})
+		}
+		version.Sections = append(version.Sections, versionSection)
+	}
+	return version, nil
+}
+
+func (s *PaperService) frozenOrLiveCandidates(paper *models.Paper, rule *models.BlueprintRule) ([]uint, error) {
+	if !paper.Frozen {
+		return s.ruleCandidates(rule)
+	}
+	var candidates []uint
+	if err := json.Unmarshal(rule.Candidates, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal candidates: %v", err)
+	}
+	return candidates, nil
+}
+
+// ruleCandidates 返回当前满足规则条件的题目 ID，组合题的小题不单独抽取
+func (s *PaperService) ruleCandidates(rule *models.BlueprintRule) ([]uint, error) {
+	query := s.db.Model(&models.Question{}).Where("questions.parent_id IS NULL")
+	if rule.QuestionBankID != 0 {
+		query = query.Where("questions.question_bank_id = ?", rule.QuestionBankID)
+	}
+	if rule.QuestionType != nil {
+		query = query.Where("questions.question_type = ?", *rule.QuestionType)
+	}
+	if rule.Difficulty != 0 {
+		query = query.Where("questions.difficulty = ?", rule.Difficulty)
+	}
+	if rule.Tag != "" {
+		query = query.Joins("JOIN question_tags qt ON qt.question_id = questions.id").
+			Joins("JOIN tags t ON t.id = qt.tag_id").Where("t.name = ?", rule.Tag)
+	}
+
+	var questionIDs []uint
+	if err := query.Order("questions.id").Pluck("questions.id", &questionIDs).Error; err != nil {
+		return nil, err
+	}
+	return questionIDs, nil
+}
+
+// validatePaper 校验试卷结构和手动挑选的题目
+func (s *PaperService) validatePaper(paper *models.Paper) error {
+	if paper.Title == "" {
+		return errors.New("title is required")
+	}
+	if paper.Mode != models.PaperModeSame && paper.Mode != models.PaperModeEquivalent {
+		return errors.New("unknown paper mode")
+	}
+	if len(paper.Sections) == 0 {
+		return errors.New("at least one section is required")
+	}
+
+	seen := map[uint]bool{}
+	for i, section := range paper.Sections {
+		if len(section.Questions) == 0 && len(section.Rules) == 0 {
+			return fmt.Errorf("section %d has no questions or rules", i+1)
+		}
+		for _, paperQuestion := range section.Questions {
+			if paperQuestion.Points < 0 {
+				return fmt.Errorf("section %d: points must not be negative", i+1)
+			}
+			if seen[paperQuestion.QuestionID] {
+				return fmt.Errorf("question %d is used more than once", paperQuestion.QuestionID)
+			}
+			seen[paperQuestion.QuestionID] = true
+
+			var question models.Question
+			if err := s.db.Select("id", "parent_id").First(&question, paperQuestion.QuestionID).Error; err != nil {
+				return fmt.Errorf("question %d not found", paperQuestion.QuestionID)
+			}
+			if question.ParentID != nil {
+				return fmt.Errorf("question %d is a child question", paperQuestion.QuestionID)
+			}
+		}
+		for _, rule := range section.Rules {
+			if rule.Count <= 0 {
+				return fmt.Errorf("section %d: rule count must be positive", i+1)
+			}
+			if rule.Points < 0 {
+				return fmt.Errorf("section %d: points must not be negative", i+1)
+			}
+			if rule.Difficulty < 0 || rule.Difficulty > 5 {
+				return fmt.Errorf("section %d: difficulty must be between 1 and 5, or 0 for any", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// applyPaperRequest 用请求内容覆盖试卷的基本信息和分节，分值为 0 时按 1 分计
+func applyPaperRequest(paper *models.Paper, req dto.PaperRequest) {
+	paper.Title = strings.TrimSpace(req.Title)
+	paper.Description = req.Description
+	paper.Mode = req.Mode
+	paper.Sections = nil
+	for i, sectionReq := range req.Sections {
+		section := models.PaperSection{Title: sectionReq.Title, Position: i + 1}
+		for j, question := range sectionReq.Questions {
+			section.Questions = append(section.Questions, models.PaperQuestion{
+				QuestionID: question.QuestionID,
+				Position:   j + 1,
+				Points:     defaultPoints(question.Points),
+			})
+		}
+		for j, rule := range sectionReq.Rules {
+			section.Rules = append(section.Rules, models.BlueprintRule{
+				Position:       j + 1,
+				QuestionBankID: rule.QuestionBankID,
+				QuestionType:   rule.QuestionType,
+				Tag:            rule.Tag,
+				Difficulty:     rule.Difficulty,
+				Count:          rule.Count,
+				Points:         defaultPoints(rule.Points),
+			})
+		}
+		paper.Sections = append(paper.Sections, section)
+	}
+}
+
+func defaultPoints(points float64) float64 {
+	if points == 0 {
+		return 1
+	}
+	return points
+}
+
+// deletePaperSections 删除试卷的全部分节及其题目和规则
+func deletePaperSections(tx *gorm.DB, paperID uint) error {
+	sections := tx.Model(&models.PaperSection{}).Select("id").Where("paper_id = ?", paperID)
+	if err := tx.Where("section_id IN (?)", sections).Delete(&models.PaperQuestion{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("section_id IN (?)", sections).Delete(&models.BlueprintRule{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("paper_id = ?", paperID).Delete(&models.PaperSection{}).Error
+}
+
+// fixedPaperQuestions 返回试卷中已经固定的题目，抽题时需要排除
+func fixedPaperQuestions(paper *models.Paper) map[uint]bool {
+	fixed := map[uint]bool{}
+	for _, section := range paper.Sections {
+		for _, question := range section.Questions {
+			fixed[question.QuestionID] = true
+		}
+	}
+	return fixed
+}
+
+// checkRuleCapacity 确认无论前面的规则抽到哪些题，当前规则都还有足够的候选题，
+// 这样等价模式下每名学员都能抽到完整的一份试卷
+func checkRuleCapacity(rule *models.BlueprintRule, candidates []uint, fixed map[uint]bool, previousRules []*models.BlueprintRule, previousCandidates [][]uint) error {
+	available := map[uint]bool{}
+	for _, questionID := range candidates {
+		if !fixed[questionID] {
+			available[questionID] = true
+		}
+	}
+
+	capacity := len(available)
+	for i, other := range previousCandidates {
+		overlap := 0
+		for _, questionID := range other {
+			if available[questionID] {
+				overlap++
+			}
+		}
+		if overlap > previousRules[i].Count {
+			overlap = previousRules[i].Count
+		}
+		capacity -= overlap
+	}
+	if capacity < rule.Count {
+		return fmt.Errorf("rule needs %d questions but only %d are guaranteed to be available", rule.Count, capacity)
+	}
+	return nil
+}
+
+// drawQuestions 从候选题中排除已用的题后随机抽取 count 道
+func drawQuestions(candidates []uint, count int, used map[uint]bool, rng *rand.Rand) ([]uint, error) {
+	var pool []uint
+	for _, questionID := range candidates {
+		if !used[questionID] {
+			pool = append(pool, questionID)
+		}
+	}
+	if len(pool) < count {
+		return nil, fmt.Errorf("rule needs %d questions but only %d match", count, len(pool))
+	}
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	return pool[:count], nil
+}
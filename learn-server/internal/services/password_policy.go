@@ -0,0 +1,86 @@
+// services/password_policy.go
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultPasswordMinLength = 8
+	// PasswordMaxLength 是 bcrypt 实际使用的最大字节数，更长的部分会被忽略
+	PasswordMaxLength = 72
+)
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// PasswordPolicy 是设置密码时检查的规则
+type PasswordPolicy struct {
+	MinLength int                 // 最少字符数
+	breached  map[string]struct{} // 泄露密码的 SHA-1，大写十六进制
+}
+
+// LoadBreachedPasswords 从本地文件读取泄露密码列表，返回读取的条数。
+// 每行一个明文密码，或 Have I Been Pwned 格式的 SHA-1（HASH 或 HASH:次数）；空行和 # 开头的行被忽略
+func (p *PasswordPolicy) LoadBreachedPasswords(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	breached := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			breached[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		breached[passwordSHA1(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	p.breached = breached
+	return len(breached), nil
+}
+
+// Validate 检查密码是否符合规则，不符合时返回包装了 ErrWeakPassword 的错误
+func (p PasswordPolicy) Validate(username, password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: at least %d characters are required", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > PasswordMaxLength {
+		return fmt.Errorf("%w: at most %d bytes are allowed", ErrWeakPassword, PasswordMaxLength)
+	}
+	if strings.EqualFold(password, username) {
+		return fmt.Errorf("%w: password must not match the username", ErrWeakPassword)
+	}
+	if _, ok := p.breached[passwordSHA1(password)]; ok {
+		return fmt.Errorf("%w: password appears in a list of breached passwords", ErrWeakPassword)
+	}
+	return nil
+}
+
+func passwordSHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1Hex(value string) bool {
+	if len(value) != 2*sha1.Size {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
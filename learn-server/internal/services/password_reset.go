@@ -0,0 +1,265 @@
+// services/password_reset.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPasswordResetTokenDuration 是找回密码令牌的默认有效期
+	DefaultPasswordResetTokenDuration = 30 * time.Minute
+	// passwordResetInterval 内同一用户只发送一次找回密码邮件，避免被用来轰炸邮箱
+	passwordResetInterval = time.Minute
+	// notifyTimeout 是发送一条通知的最长时间
+	notifyTimeout = 30 * time.Second
+)
+
+// 审计日志的事件类型
+const (
+	AuditPasswordChanged      = "password_changed"
+	AuditPasswordChangeFailed = "password_change_failed"
+	AuditPasswordReset        = "password_reset"
+)
+
+var (
+	ErrIncorrectPassword        = errors.New("current password is incorrect")
+	ErrInvalidResetToken        = errors.New("invalid or expired password reset token")
+	ErrPasswordResetUnavailable = errors.New("password reset is not configured")
+	ErrInvalidEmail             = errors.New("invalid email address")
+	ErrEmailTaken               = errors.New("email address is already in use")
+)
+
+// NormalizeEmail 校验邮箱地址并转换为小写，空字符串表示不设置邮箱
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(email), nil
+}
+
+// checkEmail 规范化邮箱并确认没有被其他用户使用，userID 为当前用户，新建用户时为 0。返回 nil 表示不设置邮箱
+func (s *AuthService) checkEmail(email string, userID uint) (*string, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil || email == "" {
+		return nil, err
+	}
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrEmailTaken
+	}
+	return &email, nil
+}
+
+// ChangePassword 校验当前密码后修改密码，并让该用户所有已签发的令牌失效。
+// 当前密码错误与登录失败共用限流和临时停用，并记录审计日志，被盗用的令牌不能用来猜测密码
+func (s *AuthService) ChangePassword(userID uint, currentPassword, newPassword, ip string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyCurrentPassword(user, currentPassword, ip, AuditPasswordChangeFailed); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.setPassword(tx, &user, newPassword); err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditPasswordChanged, &user, ip, "changed by the user")
+	})
+}
+
+// verifyCurrentPassword 校验用户的当前密码，失败次数和登录失败一起限流，失败时记录 action 审计事件
+func (s *AuthService) verifyCurrentPassword(user models.User, password, ip, action string) error {
+	now := time.Now()
+	if wait, err := s.loginRetryAfter(user.Username, ip, now); err != nil {
+		return err
+	} else if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	failures, err := s.reserveLoginAttempt(user.Username, now)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if err := s.recordLoginFailure(user.Username, ip, failures, now); err != nil {
+			return err
+		}
+		if err := recordAudit(s.db, action, &user, ip, "incorrect current password"); err != nil {
+			return err
+		}
+		return ErrIncorrectPassword
+	}
+	// 当前密码正确，即使之后的操作失败也清除账号的失败记录
+	return s.db.Where("scope = ? AND target = ?", loginScopeAccount, loginAccountKey(user.Username)).
+		Delete(&models.LoginFailure{}).Error
+}
+
+// RequestPasswordReset 按用户名或邮箱查找用户，向其邮箱发送找回密码的链接。
+// 为避免泄露账号是否存在，用户不存在、没有邮箱或未启用时同样返回 nil；邮件在后台发送
+func (s *AuthService) RequestPasswordReset(login string) error {
+	if s.Notifier == nil || s.PasswordResetURL == "" {
+		return ErrPasswordResetUnavailable
+	}
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return nil
+	}
+	var user models.User
+	err := s.db.Where("username = ? OR email = ?", login, strings.ToLower(login)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// 临时锁定的账号也可以找回密码，重置后解除锁定
+	if user.Email == nil || (user.Status != models.StatusActive && user.LockedUntil == nil) {
+		return nil
+	}
+
+	now := time.Now()
+	var recent int64
+	if err := s.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", user.ID, now.Add(-passwordResetInterval)).Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent > 0 {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 只有最新的令牌有效
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(s.PasswordResetTokenDuration),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.PasswordResetURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	s.notify(Message{
+		To:      *user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置你账号密码的请求。请在 %d 分钟内打开下面的链接设置新密码：\n\n%s\n\n如果这不是你本人的操作，请忽略这封邮件，你的密码不会改变。\n",
+			user.Username, int(s.PasswordResetTokenDuration.Minutes()), link.String()),
+	})
+	return nil
+}
+
+// ResetPassword 使用找回密码令牌设置新密码。令牌只能使用一次，成功后该用户所有已签发的令牌失效
+func (s *AuthService) ResetPassword(token, newPassword, ip string) error {
+	var resetToken models.PasswordResetToken
+	if err := s.db.Where("token_hash = ?", hashToken(token)).First(&resetToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	now := time.Now()
+	if resetToken.UsedAt != nil || now.After(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	user, err := s.GetUserByID(resetToken.UserID)
+	if err != nil {
+		return err
+	}
+	// 先检查密码规则，不符合时令牌仍可使用
+	if err := s.PasswordPolicy.Validate(user.Username, newPassword); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 按条件更新，同一令牌并发提交时只有一次有效
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		if err := s.setPassword(tx, &user, newPassword); err != nil {
+			return err
+		}
+		// 能收到邮件说明是账号本人，解除因登录失败造成的临时锁定
+		if user.Status == models.StatusSuspended && user.LockedUntil != nil {
+			if err := tx.Model(&user).Updates(map[string]interface{}{
+				"status": models.StatusActive, "locked_until": nil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("scope = ? AND target = ?", loginScopeAccount, loginAccountKey(user.Username)).
+			Delete(&models.LoginFailure{}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditPasswordReset, &user, ip, "reset with an emailed token")
+	})
+}
+
+// PurgePasswordResetTokens 删除已使用或已过期的找回密码令牌
+func (s *AuthService) PurgePasswordResetTokens() (int64, error) {
+	result := s.db.Where("used_at IS NOT NULL OR expires_at < ?", time.Now()).Delete(&models.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
+
+// setPassword 检查密码规则并保存新密码，同时递增 TokenVersion 并吊销所有会话
+func (s *AuthService) setPassword(tx *gorm.DB, user *models.User, password string) error {
+	if err := s.PasswordPolicy.Validate(user.Username, password); err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+		return err
+	}
+	return invalidateUserTokens(tx, user.ID, time.Now())
+}
+
+// notify 在后台发送通知，失败时只记录日志
+func (s *AuthService) notify(msg Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := s.Notifier.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send notification to %s: %v", msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,103 @@
+// services/practice_feedback.go
+package services
+
+import (
+	"learn/internal/dto"
+	"learn/internal/models"
+	"time"
+)
+
+// PracticeFeedback 按题库的反馈设置生成作答后的即时反馈，题库设置为不反馈时返回 nil
+func (s *QuizService) PracticeFeedback(result *AttemptResult) (*dto.PracticeFeedback, error) {
+	bank, err := s.GetQuestionBank(result.Question.QuestionBankID)
+	if err != nil {
+		return nil, err
+	}
+	if bank.FeedbackMode == models.FeedbackNone {
+		return nil, nil
+	}
+
+	feedback := newPracticeFeedback(result.Grade)
+	if bank.FeedbackMode != models.FeedbackFull {
+		return &feedback, nil
+	}
+
+	// 完整反馈按回顾场景下发正确答案和解析，相关题目仍按练习场景隐藏答案
+	question := NewQuestionResponse(result.Question, AnswerPolicyFor(ViewReview))
+	feedback.Question = &question
+	related, err := s.GetRelatedQuestions(result.Question.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range related {
+		feedback.RelatedQuestions = append(feedback.RelatedQuestions, NewQuestionResponse(&related[i], AnswerPolicyFor(ViewPractice)))
+	}
+	return &feedback, nil
+}
+
+// GradingVisible 判断能否向学员下发作答的对错、得分和经验值。题库设置为不反馈（考试）时不下发，
+// 否则学员可以从答错次数、得分等字段推断答案，与 PracticeFeedback 的判断一致
+func (s *QuizService) GradingVisible(questionBankID uint) (bool, error) {
+	bank, err := s.GetQuestionBank(questionBankID)
+	if err != nil {
+		return false, err
+	}
+	return bank.FeedbackMode != models.FeedbackNone, nil
+}
+
+// newPracticeFeedback 将判分结果转换为反馈，组合题附带各小题的结果
+func newPracticeFeedback(grade *GradeResult) dto.PracticeFeedback {
+	feedback := dto.PracticeFeedback{
+		QuestionID:   grade.QuestionID,
+		Correct:      grade.Correct,
+		Score:        grade.Score,
+		BlankResults: grade.Blanks,
+	}
+	for _, child := range grade.Children {
+		feedback.Children = append(feedback.Children, newPracticeFeedback(child))
+	}
+	return feedback
+}
+
+// CanReviewQuestion 判断学员能否回顾题目的答案和解析。完整反馈的题库作答后即可回顾；
+// 其他题库要等包含该题的作业提交后不能再提交，即已截止且不接受迟交，或提交次数已用完
+func (s *QuizService) CanReviewQuestion(userID, questionID uint) (bool, error) {
+	var question models.Question
+	if err := s.db.Select("id", "question_bank_id").First(&question, questionID).Error; err != nil {
+		return false, err
+	}
+	bank, err := s.GetQuestionBank(question.QuestionBankID)
+	if err != nil {
+		return false, err
+	}
+	if bank.FeedbackMode == models.FeedbackFull {
+		attempt, err := s.GetQuestionAttempt(userID, questionID)
+		return attempt != nil, err
+	}
+
+	var assignments []models.Assignment
+	err = s.db.Where("id IN (?)", s.db.Model(&models.AssignmentSubmission{}).Select("assignment_id").Where("user_id = ?", userID)).
+		Where("question_bank_id = ? OR id IN (?)", question.QuestionBankID,
+			s.db.Model(&models.AssignmentQuestion{}).Select("assignment_id").Where("question_id = ?", questionID)).
+		Find(&assignments).Error
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, assignment := range assignments {
+		if now.After(assignment.DueAt) && assignment.LatePolicy == models.LateNotAllowed {
+			return true, nil
+		}
+		if assignment.MaxAttempts > 0 {
+			var submitted int64
+			if err := s.db.Model(&models.AssignmentSubmission{}).
+				Where("assignment_id = ? AND user_id = ?", assignment.ID, userID).Count(&submitted).Error; err != nil {
+				return false, err
+			}
+			if submitted >= int64(assignment.MaxAttempts) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,248 @@
+// services/question_group.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// groupQuestionType 处理组合题：一段共享材料加若干道有序的小题。
+// 小题本身也是 Question，通过 ParentID 关联，可以是除组合题外的任意题型。
+type groupQuestionType struct{}
+
+func (t groupQuestionType) Build(question *models.Question, answers dto.QuestionAnswers) {
+	question.Stimulus = nil
+	if answers.Stimulus != nil {
+		question.Stimulus = &models.QuestionStimulus{
+			Text:      answers.Stimulus.Text,
+			MediaURL:  answers.Stimulus.MediaURL,
+			MediaType: answers.Stimulus.MediaType,
+		}
+	}
+
+	question.Children = nil
+	for i, req := range answers.Children {
+		child := models.Question{
+			ID:             req.ID,
+			QuestionBankID: question.QuestionBankID,
+			QuestionType:   req.QuestionType,
+			Content:        req.Content,
+			Explanation:    req.Explanation,
+			AuthorID:       question.AuthorID,
+			Position:       i + 1,
+		}
+		// 未知题型留给 Validate 报错
+		if childType, err := GetQuestionType(req.QuestionType); err == nil && req.QuestionType != models.QuestionTypeGroup {
+			childType.Build(&child, req.Answers())
+		}
+		question.Children = append(question.Children, child)
+	}
+}
+
+func (t groupQuestionType) Validate(question *models.Question) error {
+	if question.Stimulus == nil ||
+		(strings.TrimSpace(question.Stimulus.Text) == "" && strings.TrimSpace(question.Stimulus.MediaURL) == "") {
+		return errors.New("group question requires a stimulus text or media")
+	}
+	if len(question.Children) == 0 {
+		return errors.New("group question requires at least one child question")
+	}
+	for i := range question.Children {
+		child := &question.Children[i]
+		if child.QuestionType == models.QuestionTypeGroup {
+			return errors.New("group question cannot be nested")
+		}
+		childType, err := GetQuestionType(child.QuestionType)
+		if err != nil {
+			return fmt.Errorf("child question %d: %v", i+1, err)
+		}
+		if err := childType.Validate(child); err != nil {
+			return fmt.Errorf("child question %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (t groupQuestionType) SaveAnswers(tx *gorm.DB, question *models.Question) error {
+	if err := tx.Where("question_id = ?", question.ID).Delete(&models.QuestionStimulus{}).Error; err != nil {
+		return err
+	}
+	if question.Stimulus != nil {
+		question.Stimulus.QuestionID = question.ID
+		if err := tx.Create(question.Stimulus).Error; err != nil {
+			return err
+		}
+	}
+
+	// 带 ID 的小题原地更新，保留作答记录、错题本和试卷中的引用；只删除不再出现的小题
+	var existing []models.Question
+	if err := tx.Where("parent_id = ?", question.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	previous := make(map[uint]models.Question, len(existing))
+	for _, child := range existing {
+		previous[child.ID] = child
+	}
+	for i := range question.Children {
+		child := &question.Children[i]
+		child.ParentID = &question.ID
+		child.QuestionBankID = question.QuestionBankID
+		child.AuthorID = question.AuthorID
+		child.Position = i + 1
+		old, ok := previous[child.ID]
+		if !ok {
+			child.ID = 0
+			if err := tx.Create(child).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		delete(previous, child.ID)
+		if err := saveChildQuestion(tx, child, old); err != nil {
+			return err
+		}
+	}
+	for _, child := range previous {
+		if err := deleteChildQuestion(tx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveChildQuestion 更新已有的小题及其答案，题型变化时先清理旧题型的答案
+func saveChildQuestion(tx *gorm.DB, child *models.Question, old models.Question) error {
+	if old.QuestionType != child.QuestionType {
+		if oldType, err := GetQuestionType(old.QuestionType); err == nil {
+			if err := oldType.DeleteAnswers(tx, child.ID); err != nil {
+				return err
+			}
+		}
+	}
+	child.CreatedAt = old.CreatedAt
+	if err := tx.Omit("CreatedAt", clause.Associations).Save(child).Error; err != nil {
+		return err
+	}
+	childType, err := GetQuestionType(child.QuestionType)
+	if err != nil {
+		return err
+	}
+	return childType.SaveAnswers(tx, child)
+}
+
+// deleteChildQuestion 删除小题及其答案和标签
+func deleteChildQuestion(tx *gorm.DB, child models.Question) error {
+	if childType, err := GetQuestionType(child.QuestionType); err == nil {
+		if err := childType.DeleteAnswers(tx, child.ID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&child).Association("Tags").Clear(); err != nil {
+		return err
+	}
+	return tx.Delete(&models.Question{}, child.ID).Error
+}
+
+func (t groupQuestionType) DeleteAnswers(tx *gorm.DB, questionID uint) error {
+	if err := tx.Where("question_id = ?", questionID).Delete(&models.QuestionStimulus{}).Error; err != nil {
+		return err
+	}
+	var children []models.Question
+	if err := tx.Where("parent_id = ?", questionID).Find(&children).Error; err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := deleteChildQuestion(tx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t groupQuestionType) Preloads() []string {
+	preloads := []string{"Stimulus", "Children"}
+	for _, questionType := range QuestionTypes() {
+		if questionType == models.QuestionTypeGroup {
+			continue
+		}
+		for _, association := range questionTypes[questionType].Preloads() {
+			preloads = append(preloads, "Children."+association)
+		}
+	}
+	return preloads
+}
+
+func (t groupQuestionType) FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy) {
+	if question.Stimulus != nil {
+		resp.Stimulus = &dto.Stimulus{
+			Text:      question.Stimulus.Text,
+			MediaURL:  question.Stimulus.MediaURL,
+			MediaType: question.Stimulus.MediaType,
+		}
+	}
+	for _, child := range sortedChildren(question) {
+		resp.Children = append(resp.Children, NewQuestionResponse(child, policy))
+	}
+}
+
+func (t groupQuestionType) Grade(question *models.Question, answer interface{}) (*GradeResult, error) {
+	// 作答是以小题 ID 为键的对象，未作答的小题按错误计
+	answers := map[string]interface{}{}
+	if answer != nil {
+		var ok bool
+		if answers, ok = answer.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w for group question", ErrInvalidAnswer)
+		}
+	}
+
+	result := &GradeResult{QuestionID: question.ID, Correct: true}
+	recorded := map[string]json.RawMessage{}
+	for _, child := range sortedChildren(question) {
+		childType, err := GetQuestionType(child.QuestionType)
+		if err != nil {
+			return nil, err
+		}
+		key := strconv.FormatUint(uint64(child.ID), 10)
+		childResult, err := childType.Grade(child, answers[key])
+		if err != nil {
+			return nil, fmt.Errorf("child question %d: %w", child.ID, err)
+		}
+		result.Children = append(result.Children, childResult)
+		result.Correct = result.Correct && childResult.Correct
+		result.Score += childResult.Score
+		if childResult.Answer != nil {
+			recorded[key] = childResult.Answer
+		}
+	}
+	if len(result.Children) > 0 {
+		result.Score /= float64(len(result.Children))
+	} else {
+		result.Correct = false
+	}
+
+	answerJSON, err := json.Marshal(recorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answers: %v", err)
+	}
+	result.Answer = answerJSON
+	return result, nil
+}
+
+// sortedChildren 按 Position 返回组合题的小题
+func sortedChildren(question *models.Question) []*models.Question {
+	children := make([]*models.Question, len(question.Children))
+	for i := range question.Children {
+		children[i] = &question.Children[i]
+	}
+	sort.SliceStable(children, func(i, j int) bool { return children[i].Position < children[j].Position })
+	return children
+}
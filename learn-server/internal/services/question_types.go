@@ -0,0 +1,433 @@
+// services/question_types.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidQuestion 表示题目数据不符合其题型的要求
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// ErrInvalidAnswer 表示作答格式与题型不匹配
+var ErrInvalidAnswer = errors.New("invalid answer format")
+
+// QuestionTypeHandler 描述一种题型在录入、存储、下发和判分各环节的行为。
+// 新增题型只需实现该接口并通过 RegisterQuestionType 注册。
+type QuestionTypeHandler interface {
+	// Build 将请求中的答案字段填充到题目上
+	Build(question *models.Question, answers dto.QuestionAnswers)
+	// Validate 校验题目的答案数据是否完整
+	Validate(question *models.Question) error
+	// SaveAnswers 在事务中用题目上的答案替换已存储的答案
+	SaveAnswers(tx *gorm.DB, question *models.Question) error
+	// DeleteAnswers 在事务中删除题目的全部答案
+	DeleteAnswers(tx *gorm.DB, questionID uint) error
+	// Preloads 返回加载答案时需要预加载的关联
+	Preloads() []string
+	// FillResponse 按可见性策略将答案写入响应
+	FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy)
+	// Grade 判定作答是否正确，并返回需要记录的答案
+	Grade(question *models.Question, answer interface{}) (*GradeResult, error)
+}
+
+// AnswerKeyProvider 是题型可选实现的接口，返回离线练习包中供设备本地判分的标准答案，
+// 每个元素单独散列后下发。没有实现的题型（例如问答题）只能联网判分
+type AnswerKeyProvider interface {
+	AnswerKey(question *models.Question) []string
+}
+
+// GradeResult 是一道题的判分结果
+type GradeResult struct {
+	QuestionID uint
+	Correct    bool
+	Score      float64         // 得分比例，取值 0~1
+	Answer     json.RawMessage // 需要记录的作答
+	Blanks     []bool          // 填空题每一空是否正确
+	Children   []*GradeResult  // 组合题各小题的结果
+}
+
+// newGradeResult 按对错生成判分结果
+func newGradeResult(question *models.Question, correct bool, answer json.RawMessage) *GradeResult {
+	result := &GradeResult{QuestionID: question.ID, Correct: correct, Answer: answer}
+	if correct {
+		result.Score = 1
+	}
+	return result
+}
+
+var questionTypes = map[models.QuestionType]QuestionTypeHandler{}
+
+// RegisterQuestionType 注册题型的处理器，重复注册会覆盖已有的处理器
+func RegisterQuestionType(questionType models.QuestionType, handler QuestionTypeHandler) {
+	questionTypes[questionType] = handler
+}
+
+// GetQuestionType 返回题型对应的处理器
+func GetQuestionType(questionType models.QuestionType) (QuestionTypeHandler, error) {
+	handler, ok := questionTypes[questionType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown question type %d", ErrInvalidQuestion, questionType)
+	}
+	return handler, nil
+}
+
+// QuestionTypes 返回所有已注册的题型
+func QuestionTypes() []models.QuestionType {
+	types := make([]models.QuestionType, 0, len(questionTypes))
+	for t := range questionTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+func init() {
+	RegisterQuestionType(models.QuestionTypeSingleChoice, choiceQuestionType{multiple: false})
+	RegisterQuestionType(models.QuestionTypeMultipleChoice, choiceQuestionType{multiple: true})
+	RegisterQuestionType(models.QuestionTypeTrueFalse, trueFalseQuestionType{})
+	RegisterQuestionType(models.QuestionTypeWrittenAnswer, writtenQuestionType{})
+	RegisterQuestionType(models.QuestionTypeFillInTheBlank, fillInTheBlankQuestionType{})
+	RegisterQuestionType(models.QuestionTypeGroup, groupQuestionType{})
+}
+
+// choiceQuestionType 处理单选题和多选题
+type choiceQuestionType struct {
+	multiple bool
+}
+
+func (t choiceQuestionType) Build(question *models.Question, answers dto.QuestionAnswers) {
+	question.AnswerOptions = nil
+	for _, option := range answers.AnswerOptions {
+		question.AnswerOptions = append(question.AnswerOptions, models.AnswerOption{
+			OptionText: option.OptionText,
+			IsCorrect:  option.IsCorrect,
+		})
+	}
+}
+
+func (t choiceQuestionType) Validate(question *models.Question) error {
+	if len(question.AnswerOptions) < 2 {
+		return errors.New("choice question requires at least two options")
+	}
+	correct := 0
+	for _, option := range question.AnswerOptions {
+		if strings.TrimSpace(option.OptionText) == "" {
+			return errors.New("option text must not be empty")
+		}
+		if option.IsCorrect {
+			correct++
+		}
+	}
+	if t.multiple && correct == 0 {
+		return errors.New("multiple choice question requires at least one correct option")
+	}
+	if !t.multiple && correct != 1 {
+		return errors.New("single choice question requires exactly one correct option")
+	}
+	return nil
+}
+
+func (t choiceQuestionType) SaveAnswers(tx *gorm.DB, question *models.Question) error {
+	// 删除旧的选项并插入新的选项
+	if err := t.DeleteAnswers(tx, question.ID); err != nil {
+		return err
+	}
+	for i := range question.AnswerOptions {
+		question.AnswerOptions[i].ID = 0
+		question.AnswerOptions[i].QuestionID = question.ID
+		if err := tx.Create(&question.AnswerOptions[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t choiceQuestionType) DeleteAnswers(tx *gorm.DB, questionID uint) error {
+	return tx.Where("question_id = ?", questionID).Delete(&models.AnswerOption{}).Error
+}
+
+func (t choiceQuestionType) Preloads() []string {
+	return []string{"AnswerOptions"}
+}
+
+func (t choiceQuestionType) FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy) {
+	for _, option := range question.AnswerOptions {
+		resp.AnswerOptions = append(resp.AnswerOptions, dto.AnswerOption{
+			ID:         option.ID,
+			OptionText: option.OptionText,
+			IsCorrect:  option.IsCorrect && policy.ShowAnswers,
+		})
+	}
+}
+
+func (t choiceQuestionType) Grade(question *models.Question, answer interface{}) (*GradeResult, error) {
+	// 作答为所选选项的 ID 列表
+	if answer == nil {
+		return newGradeResult(question, false, nil), nil
+	}
+	values, ok := answer.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w for choice question", ErrInvalidAnswer)
+	}
+	provided := make([]uint, len(values))
+	for i, val := range values {
+		floatVal, ok := val.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w for choice question", ErrInvalidAnswer)
+		}
+		provided[i] = uint(floatVal)
+	}
+
+	correct := []uint{}
+	for _, option := range question.AnswerOptions {
+		if option.IsCorrect {
+			correct = append(correct, option.ID)
+		}
+	}
+
+	answerJSON, err := json.Marshal(provided)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answer: %v", err)
+	}
+	return newGradeResult(question, compareAnswers(provided, correct), answerJSON), nil
+}
+
+// AnswerKey 返回按 ID 升序排列的正确选项 JSON 数组，例如 [3,5]
+func (t choiceQuestionType) AnswerKey(question *models.Question) []string {
+	correct := []uint{}
+	for _, option := range question.AnswerOptions {
+		if option.IsCorrect {
+			correct = append(correct, option.ID)
+		}
+	}
+	sort.Slice(correct, func(i, j int) bool { return correct[i] < correct[j] })
+	key, _ := json.Marshal(correct)
+	return []string{string(key)}
+}
+
+// trueFalseQuestionType 处理判断题
+type trueFalseQuestionType struct{}
+
+func (t trueFalseQuestionType) Build(question *models.Question, answers dto.QuestionAnswers) {
+	question.TrueFalseAnswer = nil
+	if answers.TrueFalse != nil {
+		question.TrueFalseAnswer = &models.TrueFalseAnswer{IsTrue: *answers.TrueFalse}
+	}
+}
+
+func (t trueFalseQuestionType) Validate(question *models.Question) error {
+	if question.TrueFalseAnswer == nil {
+		return errors.New("true/false question requires an answer")
+	}
+	return nil
+}
+
+func (t trueFalseQuestionType) SaveAnswers(tx *gorm.DB, question *models.Question) error {
+	if question.TrueFalseAnswer == nil {
+		return nil
+	}
+	question.TrueFalseAnswer.QuestionID = question.ID
+	return tx.Save(question.TrueFalseAnswer).Error
+}
+
+func (t trueFalseQuestionType) DeleteAnswers(tx *gorm.DB, questionID uint) error {
+	return tx.Where("question_id = ?", questionID).Delete(&models.TrueFalseAnswer{}).Error
+}
+
+func (t trueFalseQuestionType) Preloads() []string {
+	return []string{"TrueFalseAnswer"}
+}
+
+func (t trueFalseQuestionType) FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy) {
+	if question.TrueFalseAnswer != nil && policy.ShowAnswers {
+		resp.TrueFalseAnswer = &dto.TrueFalseAnswer{IsTrue: question.TrueFalseAnswer.IsTrue}
+	}
+}
+
+// AnswerKey 返回 true 或 false
+func (t trueFalseQuestionType) AnswerKey(question *models.Question) []string {
+	if question.TrueFalseAnswer == nil {
+		return nil
+	}
+	return []string{strconv.FormatBool(question.TrueFalseAnswer.IsTrue)}
+}
+
+func (t trueFalseQuestionType) Grade(question *models.Question, answer interface{}) (*GradeResult, error) {
+	if answer == nil {
+		return newGradeResult(question, false, nil), nil
+	}
+	provided, ok := answer.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%w for true/false question", ErrInvalidAnswer)
+	}
+	answerJSON, err := json.Marshal(provided)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answer: %v", err)
+	}
+	isCorrect := question.TrueFalseAnswer != nil && provided == question.TrueFalseAnswer.IsTrue
+	return newGradeResult(question, isCorrect, answerJSON), nil
+}
+
+// writtenQuestionType 处理问答题，参考答案可以为空
+type writtenQuestionType struct{}
+
+func (t writtenQuestionType) Build(question *models.Question, answers dto.QuestionAnswers) {
+	question.WrittenAnswer = &models.WrittenAnswer{AnswerText: answers.AnswerText}
+}
+
+func (t writtenQuestionType) Validate(question *models.Question) error {
+	return nil
+}
+
+func (t writtenQuestionType) SaveAnswers(tx *gorm.DB, question *models.Question) error {
+	if question.WrittenAnswer == nil {
+		return nil
+	}
+	question.WrittenAnswer.QuestionID = question.ID
+	return tx.Save(question.WrittenAnswer).Error
+}
+
+func (t writtenQuestionType) DeleteAnswers(tx *gorm.DB, questionID uint) error {
+	return tx.Where("question_id = ?", questionID).Delete(&models.WrittenAnswer{}).Error
+}
+
+func (t writtenQuestionType) Preloads() []string {
+	return []string{"WrittenAnswer"}
+}
+
+func (t writtenQuestionType) FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy) {
+	if question.WrittenAnswer != nil && policy.ShowAnswers {
+		resp.WrittenAnswer = &dto.WrittenAnswer{AnswerText: question.WrittenAnswer.AnswerText}
+	}
+}
+
+func (t writtenQuestionType) Grade(question *models.Question, answer interface{}) (*GradeResult, error) {
+	// 问答题无法自动判分，作答非空即视为完成，留待人工批改
+	if answer == nil {
+		return newGradeResult(question, false, nil), nil
+	}
+	provided, ok := answer.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w for written answer question", ErrInvalidAnswer)
+	}
+	answerJSON, err := json.Marshal(provided)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answer: %v", err)
+	}
+	return newGradeResult(question, strings.TrimSpace(provided) != "", answerJSON), nil
+}
+
+// fillInTheBlankQuestionType 处理填空题，按顺序逐空比对
+type fillInTheBlankQuestionType struct{}
+
+func (t fillInTheBlankQuestionType) Build(question *models.Question, answers dto.QuestionAnswers) {
+	question.FillInTheBlanks = nil
+	for _, blank := range answers.Blanks {
+		question.FillInTheBlanks = append(question.FillInTheBlanks, models.FillInTheBlankAnswer{
+			BlankText: blank.BlankText,
+		})
+	}
+}
+
+func (t fillInTheBlankQuestionType) Validate(question *models.Question) error {
+	if len(question.FillInTheBlanks) == 0 {
+		return errors.New("fill-in-the-blank question requires at least one blank")
+	}
+	for _, blank := range question.FillInTheBlanks {
+		if strings.TrimSpace(blank.BlankText) == "" {
+			return errors.New("blank answer must not be empty")
+		}
+	}
+	return nil
+}
+
+func (t fillInTheBlankQuestionType) SaveAnswers(tx *gorm.DB, question *models.Question) error {
+	// 删除旧的填空答案并插入新的填空答案
+	if err := t.DeleteAnswers(tx, question.ID); err != nil {
+		return err
+	}
+	for i := range question.FillInTheBlanks {
+		question.FillInTheBlanks[i].ID = 0
+		question.FillInTheBlanks[i].QuestionID = question.ID
+		if err := tx.Create(&question.FillInTheBlanks[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t fillInTheBlankQuestionType) DeleteAnswers(tx *gorm.DB, questionID uint) error {
+	return tx.Where("question_id = ?", questionID).Delete(&models.FillInTheBlankAnswer{}).Error
+}
+
+func (t fillInTheBlankQuestionType) Preloads() []string {
+	return []string{"FillInTheBlanks"}
+}
+
+func (t fillInTheBlankQuestionType) FillResponse(resp *dto.QuestionResponse, question *models.Question, policy AnswerPolicy) {
+	for _, blank := range question.FillInTheBlanks {
+		text := blank.BlankText
+		if !policy.ShowAnswers {
+			text = ""
+		}
+		resp.FillInTheBlanks = append(resp.FillInTheBlanks, dto.FillInTheBlankAnswer{BlankText: text})
+	}
+}
+
+// AnswerKey 按顺序返回每一空的答案
+func (t fillInTheBlankQuestionType) AnswerKey(question *models.Question) []string {
+	key := make([]string, len(question.FillInTheBlanks))
+	for i, blank := range question.FillInTheBlanks {
+		key[i] = blank.BlankText
+	}
+	return key
+}
+
+func (t fillInTheBlankQuestionType) Grade(question *models.Question, answer interface{}) (*GradeResult, error) {
+	if answer == nil {
+		return newGradeResult(question, false, nil), nil
+	}
+	values, ok := answer.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w for fill-in-the-blank question", ErrInvalidAnswer)
+	}
+	provided := make([]string, len(values))
+	for i, val := range values {
+		strVal, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w for fill-in-the-blank question", ErrInvalidAnswer)
+		}
+		provided[i] = strVal
+	}
+
+	// 逐空比较，得分按答对的空数计算
+	blanks := make([]bool, len(question.FillInTheBlanks))
+	correctBlanks := 0
+	for i, blank := range question.FillInTheBlanks {
+		if i < len(provided) && provided[i] == blank.BlankText {
+			blanks[i] = true
+			correctBlanks++
+		}
+	}
+	isCorrect := len(provided) == len(question.FillInTheBlanks) && correctBlanks == len(blanks)
+
+	answerJSON, err := json.Marshal(provided)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answers: %v", err)
+	}
+	result := newGradeResult(question, isCorrect, answerJSON)
+	result.Blanks = blanks
+	if len(blanks) > 0 {
+		result.Score = float64(correctBlanks) / float64(len(blanks))
+	}
+	return result, nil
+}
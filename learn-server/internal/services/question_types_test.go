@@ -0,0 +1,248 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"learn/internal/dto"
+	"learn/internal/models"
+	"learn/internal/services"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// 模拟 JSON 解码后的作答
+func decodeAnswer(t *testing.T, raw string) interface{} {
+	var answer interface{}
+	if err := json.Unmarshal([]byte(raw), &answer); err != nil {
+		t.Fatalf("Failed to decode answer %s: %v", raw, err)
+	}
+	return answer
+}
+
+func TestQuestionTypesRegistered(t *testing.T) {
+	for _, questionType := range []models.QuestionType{
+		models.QuestionTypeSingleChoice,
+		models.QuestionTypeMultipleChoice,
+		models.QuestionTypeTrueFalse,
+		models.QuestionTypeWrittenAnswer,
+		models.QuestionTypeFillInTheBlank,
+	} {
+		if _, err := services.GetQuestionType(questionType); err != nil {
+			t.Errorf("Expected question type %v to be registered, got %v", questionType, err)
+		}
+	}
+
+	if _, err := services.GetQuestionType(models.QuestionType(99)); !errors.Is(err, services.ErrInvalidQuestion) {
+		t.Errorf("Expected ErrInvalidQuestion for unknown type, got %v", err)
+	}
+}
+
+func TestQuestionTypeValidate(t *testing.T) {
+	trueValue := true
+	tests := []struct {
+		name         string
+		questionType models.QuestionType
+		answers      dto.QuestionAnswers
+		valid        bool
+	}{
+		{"single choice", models.QuestionTypeSingleChoice, dto.QuestionAnswers{AnswerOptions: []dto.AnswerOption{{OptionText: "3"}, {OptionText: "4", IsCorrect: true}}}, true},
+		{"single choice with two correct", models.QuestionTypeSingleChoice, dto.QuestionAnswers{AnswerOptions: []dto.AnswerOption{{OptionText: "3", IsCorrect: true}, {OptionText: "4", IsCorrect: true}}}, false},
+		{"single choice with one option", models.QuestionTypeSingleChoice, dto.QuestionAnswers{AnswerOptions: []dto.AnswerOption{{OptionText: "4", IsCorrect: true}}}, false},
+		{"multiple choice", models.QuestionTypeMultipleChoice, dto.QuestionAnswers{AnswerOptions: []dto.AnswerOption{{OptionText: "3", IsCorrect: true}, {OptionText: "4", IsCorrect: true}}}, true},
+		{"multiple choice without correct", models.QuestionTypeMultipleChoice, dto.QuestionAnswers{AnswerOptions: []dto.AnswerOption{{OptionText: "3"}, {OptionText: "4"}}}, false},
+		{"true/false", models.QuestionTypeTrueFalse, dto.QuestionAnswers{TrueFalse: &trueValue}, true},
+		{"true/false without answer", models.QuestionTypeTrueFalse, dto.QuestionAnswers{}, false},
+		{"written without reference", models.QuestionTypeWrittenAnswer, dto.QuestionAnswers{}, true},
+		{"fill in the blank", models.QuestionTypeFillInTheBlank, dto.QuestionAnswers{Blanks: []dto.FillInTheBlankAnswer{{BlankText: "Paris"}}}, true},
+		{"fill in the blank without blanks", models.QuestionTypeFillInTheBlank, dto.QuestionAnswers{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questionType, err := services.GetQuestionType(tt.questionType)
+			if err != nil {
+				t.Fatalf("Failed to get question type: %v", err)
+			}
+			question := models.Question{QuestionType: tt.questionType}
+			questionType.Build(&question, tt.answers)
+			err = questionType.Validate(&question)
+			if tt.valid && err != nil {
+				t.Errorf("Expected question to be valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected question to be invalid")
+			}
+		})
+	}
+}
+
+func TestQuestionTypeGrade(t *testing.T) {
+	choice := models.Question{AnswerOptions: []models.AnswerOption{{ID: 1}, {ID: 2, IsCorrect: true}, {ID: 3, IsCorrect: true}}}
+	trueFalse := models.Question{TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true}}
+	blanks := models.Question{FillInTheBlanks: []models.FillInTheBlankAnswer{{BlankText: "Paris"}, {BlankText: "France"}}}
+
+	tests := []struct {
+		name         string
+		questionType models.QuestionType
+		question     models.Question
+		answer       string
+		correct      bool
+		invalid      bool
+	}{
+		{"multiple choice correct", models.QuestionTypeMultipleChoice, choice, `[3, 2]`, true, false},
+		{"multiple choice partial", models.QuestionTypeMultipleChoice, choice, `[2]`, false, false},
+		{"multiple choice wrong format", models.QuestionTypeMultipleChoice, choice, `"2"`, false, true},
+		{"true/false correct", models.QuestionTypeTrueFalse, trueFalse, `true`, true, false},
+		{"true/false wrong", models.QuestionTypeTrueFalse, trueFalse, `false`, false, false},
+		{"true/false wrong format", models.QuestionTypeTrueFalse, trueFalse, `"true"`, false, true},
+		{"written answered", models.QuestionTypeWrittenAnswer, models.Question{}, `"REST is..."`, true, false},
+		{"written empty", models.QuestionTypeWrittenAnswer, models.Question{}, `""`, false, false},
+		{"fill in the blank correct", models.QuestionTypeFillInTheBlank, blanks, `["Paris", "France"]`, true, false},
+		{"fill in the blank out of order", models.QuestionTypeFillInTheBlank, blanks, `["France", "Paris"]`, false, false},
+		{"fill in the blank missing", models.QuestionTypeFillInTheBlank, blanks, `["Paris"]`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questionType, err := services.GetQuestionType(tt.questionType)
+			if err != nil {
+				t.Fatalf("Failed to get question type: %v", err)
+			}
+			result, err := questionType.Grade(&tt.question, decodeAnswer(t, tt.answer))
+			if tt.invalid {
+				if !errors.Is(err, services.ErrInvalidAnswer) {
+					t.Errorf("Expected ErrInvalidAnswer, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to grade answer: %v", err)
+			}
+			if result.Correct != tt.correct {
+				t.Errorf("Expected correct=%v, got %v", tt.correct, result.Correct)
+			}
+			if result.Answer == nil {
+				t.Errorf("Expected answer to be recorded")
+			}
+		})
+	}
+}
+
+func TestGroupQuestionGrade(t *testing.T) {
+	group := models.Question{
+		ID:           10,
+		QuestionType: models.QuestionTypeGroup,
+		Children: []models.Question{
+			{ID: 12, Position: 2, QuestionType: models.QuestionTypeTrueFalse, TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true}},
+			{ID: 11, Position: 1, QuestionType: models.QuestionTypeFillInTheBlank, FillInTheBlanks: []models.FillInTheBlankAnswer{{BlankText: "cat"}}},
+		},
+	}
+	questionType, err := services.GetQuestionType(models.QuestionTypeGroup)
+	if err != nil {
+		t.Fatalf("Failed to get question type: %v", err)
+	}
+
+	result, err := questionType.Grade(&group, decodeAnswer(t, `{"11": ["cat"], "12": true}`))
+	if err != nil {
+		t.Fatalf("Failed to grade answer: %v", err)
+	}
+	if !result.Correct || result.Score != 1 {
+		t.Errorf("Expected full score, got %+v", result)
+	}
+
+	// 未作答的小题按错误计
+	result, err = questionType.Grade(&group, decodeAnswer(t, `{"11": ["cat"]}`))
+	if err != nil {
+		t.Fatalf("Failed to grade answer: %v", err)
+	}
+	if result.Correct || result.Score != 0.5 {
+		t.Errorf("Expected half score, got %+v", result)
+	}
+	if len(result.Children) != 2 || result.Children[0].QuestionID != 11 {
+		t.Errorf("Expected children graded in position order, got %+v", result.Children)
+	}
+
+	if _, err := questionType.Grade(&group, decodeAnswer(t, `[true]`)); !errors.Is(err, services.ErrInvalidAnswer) {
+		t.Errorf("Expected ErrInvalidAnswer, got %v", err)
+	}
+}
+
+func TestQuestionTypeRedactsAnswers(t *testing.T) {
+	question := models.Question{
+		AnswerOptions:   []models.AnswerOption{{ID: 1, OptionText: "4", IsCorrect: true}},
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+		WrittenAnswer:   &models.WrittenAnswer{AnswerText: "REST is..."},
+		FillInTheBlanks: []models.FillInTheBlankAnswer{{BlankText: "Paris"}},
+	}
+
+	for _, typ := range services.QuestionTypes() {
+		questionType, _ := services.GetQuestionType(typ)
+		var resp dto.QuestionResponse
+		questionType.FillResponse(&resp, &question, services.AnswerPolicyFor(services.ViewPractice))
+
+		for _, option := range resp.AnswerOptions {
+			if option.IsCorrect {
+				t.Errorf("Type %v leaked a correct option", typ)
+			}
+		}
+		for _, blank := range resp.FillInTheBlanks {
+			if blank.BlankText != "" {
+				t.Errorf("Type %v leaked a blank answer", typ)
+			}
+		}
+		if resp.TrueFalseAnswer != nil || resp.WrittenAnswer != nil {
+			t.Errorf("Type %v leaked an answer", typ)
+		}
+	}
+}
+
+func TestUpdateQuestionChangesType(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	err = db.AutoMigrate(&models.QuestionBank{}, &models.Question{}, &models.AnswerOption{},
+		&models.TrueFalseAnswer{}, &models.WrittenAnswer{}, &models.FillInTheBlankAnswer{},
+		&models.QuestionStimulus{}, &models.Tag{}, &models.User{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	quizService := services.NewQuizService(db)
+
+	created, err := quizService.CreateQuestion(models.Question{
+		QuestionBankID: 1,
+		Content:        "What is 2+2?",
+		QuestionType:   models.QuestionTypeSingleChoice,
+		AnswerOptions:  []models.AnswerOption{{OptionText: "3"}, {OptionText: "4", IsCorrect: true}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create question: %v", err)
+	}
+
+	_, err = quizService.UpdateQuestion(models.Question{
+		ID:              created.ID,
+		QuestionBankID:  1,
+		Content:         "Is 2+2 equal to 4?",
+		QuestionType:    models.QuestionTypeTrueFalse,
+		TrueFalseAnswer: &models.TrueFalseAnswer{IsTrue: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update question: %v", err)
+	}
+
+	var options int64
+	db.Model(&models.AnswerOption{}).Where("question_id = ?", created.ID).Count(&options)
+	if options != 0 {
+		t.Errorf("Expected old options to be removed, got %v", options)
+	}
+
+	detail, err := quizService.GetQuestionDetail(created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get question detail: %v", err)
+	}
+	if detail.TrueFalseAnswer == nil || !detail.TrueFalseAnswer.IsTrue {
+		t.Errorf("Expected true/false answer to be saved, got %v", detail.TrueFalseAnswer)
+	}
+}
@@ -10,14 +10,35 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	// ErrQuestionNotFound 表示作答的题目不存在
+	ErrQuestionNotFound = errors.New("question not found")
+	// ErrDuplicateAnswer 表示批量作答中同一道题出现了多次
+	ErrDuplicateAnswer = errors.New("question answered more than once")
+	// ErrInvalidBatch 表示批量作答为空或题目数超过上限
+	ErrInvalidBatch = errors.New("invalid attempt batch")
+	// ErrQuestionInUse 表示题目被冻结的试卷或仍可提交的作业引用，不能删除
+	ErrQuestionInUse = errors.New("question is in use")
+)
+
+// MaxAttemptBatchSize 是一次批量作答最多包含的题目数
+const MaxAttemptBatchSize = 200
+
 type QuizService struct {
 	db *gorm.DB
+	// NotebookClearStreak 是错题自动移出错题本所需的连续答对次数
+	NotebookClearStreak uint
+	// XPPerQuestion 是答对一道题获得的经验值
+	XPPerQuestion int
+	// Events 用于推送获得徽章的通知，为空时不推送
+	Events *EventBus
 }
 
 func NewQuizService(db *gorm.DB) *QuizService {
-	return &QuizService{db: db}
+	return &QuizService{db: db, NotebookClearStreak: DefaultNotebookClearStreak, XPPerQuestion: DefaultXPPerQuestion}
 }
 
 // GetQuestionBanks returns all question banks
@@ -29,18 +50,46 @@ func (s *QuizService) GetQuestionBanks() ([]models.QuestionBank, error) {
 	return questionBanks, nil
 }
 
+// GetQuestionBank returns a question bank by ID
+func (s *QuizService) GetQuestionBank(questionBankID uint) (*models.QuestionBank, error) {
+	var questionBank models.QuestionBank
+	if err := s.db.First(&questionBank, questionBankID).Error; err != nil {
+		return nil, err
+	}
+	return &questionBank, nil
+}
+
 // CreateQuestionBank creates a new question bank
-func (s *QuizService) CreateQuestionBank(name string) (*models.QuestionBank, error) {
-	questionBank := models.QuestionBank{Name: name}
+func (s *QuizService) CreateQuestionBank(name string, feedbackMode models.FeedbackMode) (*models.QuestionBank, error) {
+	questionBank := models.QuestionBank{Name: name, FeedbackMode: feedbackMode}
 	if err := s.db.Create(&questionBank).Error; err != nil {
 		return nil, err
 	}
 	return &questionBank, nil
 }
 
+// UpdateQuestionBank updates the name and settings of a question bank
+func (s *QuizService) UpdateQuestionBank(questionBankID uint, name string, feedbackMode models.FeedbackMode) (*models.QuestionBank, error) {
+	questionBank, err := s.GetQuestionBank(questionBankID)
+	if err != nil {
+		return nil, err
+	}
+	questionBank.Name = name
+	questionBank.FeedbackMode = feedbackMode
+	// Select 保证零值的反馈方式也会被写入
+	if err := s.db.Model(questionBank).Select("Name", "FeedbackMode").Updates(questionBank).Error; err != nil {
+		return nil, err
+	}
+	return questionBank, nil
+}
+
 // CreateQuestion creates a new question with associated tags and answers based on question type
 // services/quiz_service.go
 func (s *QuizService) CreateQuestion(question models.Question) (*models.Question, error) {
+	if err := validateQuestion(&question); err != nil {
+		return nil, err
+	}
+
 	tx := s.db.Begin()
 
 	// 处理标签的创建或关联
@@ -74,6 +123,11 @@ func (s *QuizService) CreateQuestion(question models.Question) (*models.Question
 
 // UpdateQuestion updates an existing question and its related answers and tags based on question type
 func (s *QuizService) UpdateQuestion(question models.Question) (*models.Question, error) {
+	if err := validateQuestion(&question); err != nil {
+		return nil, err
+	}
+	questionType, _ := GetQuestionType(question.QuestionType)
+
 	tx := s.db.Begin()
 
 	// 处理标签的创建或关联
@@ -91,59 +145,34 @@ func (s *QuizService) UpdateQuestion(question models.Question) (*models.Question
 		}
 	}
 
-	// 更新问题内容和解释
-	if err := tx.Omit("CreatedAt").Save(&question).Error; err != nil {
+	// 题型变化时先清理旧题型的答案
+	var existing models.Question
+	if err := tx.First(&existing, question.ID).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
-
-	// 根据问题类型处理答案更新
-	switch question.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		// 删除旧的选项并插入新的选项
-		if err := tx.Where("question_id = ?", question.ID).Delete(&models.AnswerOption{}).Error; err != nil {
-			tx.Rollback()
-			return nil, err
-		}
-		for i := range question.AnswerOptions {
-			question.AnswerOptions[i].QuestionID = question.ID
-			if err := tx.Create(&question.AnswerOptions[i]).Error; err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-		}
-
-	case models.QuestionTypeTrueFalse:
-		// 更新或创建判断题答案
-		if question.TrueFalseAnswer != nil {
-			if err := tx.Save(question.TrueFalseAnswer).Error; err != nil {
+	// 小题只能随组合题一起调整归属和顺序
+	question.ParentID = existing.ParentID
+	question.Position = existing.Position
+	if existing.QuestionType != question.QuestionType {
+		if oldType, err := GetQuestionType(existing.QuestionType); err == nil {
+			if err := oldType.DeleteAnswers(tx, question.ID); err != nil {
 				tx.Rollback()
 				return nil, err
 			}
 		}
+	}
 
-	case models.QuestionTypeWrittenAnswer:
-		// 更新或创建问答题答案
-		if question.WrittenAnswer != nil {
-			if err := tx.Save(question.WrittenAnswer).Error; err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-		}
+	// 更新问题内容和解释，答案和标签单独处理
+	if err := tx.Omit("CreatedAt", clause.Associations).Save(&question).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	case models.QuestionTypeFillInTheBlank:
-		// 删除旧的填空答案并插入新的填空答案
-		if err := tx.Where("question_id = ?", question.ID).Delete(&models.FillInTheBlankAnswer{}).Error; err != nil {
-			tx.Rollback()
-			return nil, err
-		}
-		for i := range question.FillInTheBlanks {
-			question.FillInTheBlanks[i].QuestionID = question.ID
-			if err := tx.Create(&question.FillInTheBlanks[i]).Error; err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-		}
+	// 根据问题类型处理答案更新
+	if err := questionType.SaveAnswers(tx, &question); err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	// 更新标签
@@ -160,51 +189,112 @@ func (s *QuizService) UpdateQuestion(question models.Question) (*models.Question
 	return &question, nil
 }
 
-// DeleteQuestion deletes an existing question and its related answers based on the question type
+// DeleteQuestion deletes an existing question and its related answers based on the question type.
+// 被冻结的试卷或仍可提交的作业引用的题目不能删除，其余引用随题目一并清理。
 func (s *QuizService) DeleteQuestion(questionID uint) error {
-	tx := s.db.Begin()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var question models.Question
+		if err := tx.Where("parent_id IS NULL").First(&question, questionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrQuestionNotFound
+			}
+			return err
+		}
 
-	var question models.Question
-	if err := tx.First(&question, questionID).Error; err != nil {
-		tx.Rollback()
-		return errors.New("question not found")
-	}
+		// 组合题的小题随题目一起删除，引用检查和清理都要包括小题
+		ids := []uint{questionID}
+		var childIDs []uint
+		if err := tx.Model(&models.Question{}).Where("parent_id = ?", questionID).Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+		ids = append(ids, childIDs...)
+		if err := ensureQuestionUnused(tx, question, ids); err != nil {
+			return err
+		}
 
-	// 根据类型删除相关答案
-	switch question.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		if err := tx.Where("question_id = ?", questionID).Delete(&models.AnswerOption{}).Error; err != nil {
-			tx.Rollback()
+		// 根据类型删除相关答案
+		questionType, err := GetQuestionType(question.QuestionType)
+		if err != nil {
 			return err
 		}
-	case models.QuestionTypeTrueFalse:
-		if err := tx.Where("question_id = ?", questionID).Delete(&models.TrueFalseAnswer{}).Error; err != nil {
-			tx.Rollback()
+		if err := questionType.DeleteAnswers(tx, questionID); err != nil {
 			return err
 		}
-	case models.QuestionTypeWrittenAnswer:
-		if err := tx.Where("question_id = ?", questionID).Delete(&models.WrittenAnswer{}).Error; err != nil {
-			tx.Rollback()
+
+		// 删除与其他题目的关联
+		if err := tx.Where("question_id = ? OR related_question_id = ?", questionID, questionID).
+			Delete(&models.RelatedQuestion{}).Error; err != nil {
 			return err
 		}
-	case models.QuestionTypeFillInTheBlank:
-		if err := tx.Where("question_id = ?", questionID).Delete(&models.FillInTheBlankAnswer{}).Error; err != nil {
-			tx.Rollback()
+		if err := tx.Model(&question).Association("Tags").Clear(); err != nil {
 			return err
 		}
-	}
 
-	// 删除问题本身
-	if err := tx.Delete(&models.Question{}, questionID).Error; err != nil {
-		tx.Rollback()
+		// 从未冻结的试卷和已截止的作业中移除，清理学员的作答记录和错题本。
+		// 经验值流水和离线同步记录是历史记录，予以保留
+		if err := tx.Where("section_id IN (?)", tx.Model(&models.PaperSection{}).Select("paper_sections.id").
+			Joins("JOIN papers ON papers.id = paper_sections.paper_id").Where("papers.frozen = ?", false)).
+			Where("question_id IN ?", ids).Delete(&models.PaperQuestion{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.AssignmentQuestion{}, &models.QuestionAttempt{}, &models.NotebookEntry{}} {
+			if err := tx.Where("question_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// 删除问题本身
+		return tx.Delete(&models.Question{}, questionID).Error
+	})
+}
+
+// ensureQuestionUnused 检查题目是否被冻结的试卷（包括组卷规则冻结的候选题）或仍可提交的作业引用
+func ensureQuestionUnused(tx *gorm.DB, question models.Question, ids []uint) error {
+	var count int64
+	if err := tx.Model(&models.PaperQuestion{}).
+		Joins("JOIN paper_sections ON paper_sections.id = paper_questions.section_id").
+		Joins("JOIN papers ON papers.id = paper_sections.paper_id").
+		Where("papers.frozen = ? AND paper_questions.question_id IN ?", true, ids).
+		Count(&count).Error; err != nil {
 		return err
 	}
+	if count > 0 {
+		return fmt.Errorf("%w: referenced by a frozen paper", ErrQuestionInUse)
+	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
+	var rules []models.BlueprintRule
+	if err := tx.Joins("JOIN paper_sections ON paper_sections.id = blueprint_rules.section_id").
+		Joins("JOIN papers ON papers.id = paper_sections.paper_id").
+		Where("papers.frozen = ? AND blueprint_rules.question_bank_id IN ?", true, []uint{0, question.QuestionBankID}).
+		Find(&rules).Error; err != nil {
 		return err
 	}
+	for _, rule := range rules {
+		var candidates []uint
+		if len(rule.Candidates) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(rule.Candidates, &candidates); err != nil {
+			return fmt.Errorf("failed to unmarshal candidates: %v", err)
+		}
+		for _, candidate := range candidates {
+			if candidate == question.ID {
+				return fmt.Errorf("%w: drawn by a frozen paper", ErrQuestionInUse)
+			}
+		}
+	}
 
+	// 截止后仍允许迟交的作业一直可以提交
+	if err := tx.Model(&models.AssignmentQuestion{}).
+		Joins("JOIN assignments ON assignments.id = assignment_questions.assignment_id").
+		Where("assignment_questions.question_id IN ?", ids).
+		Where("assignments.due_at > ? OR assignments.late_policy <> ?", time.Now(), models.LateNotAllowed).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: referenced by an open assignment", ErrQuestionInUse)
+	}
 	return nil
 }
 
@@ -218,23 +308,8 @@ func (s *QuizService) GetQuestionDetail(questionID uint) (*models.Question, erro
 	}
 
 	// 根据问题类型预加载相关的答案表
-	switch question.QuestionType {
-	case models.QuestionTypeMultipleChoice, models.QuestionTypeSingleChoice:
-		if err := s.db.Preload("AnswerOptions").First(&question, questionID).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeTrueFalse:
-		if err := s.db.Preload("TrueFalseAnswer").First(&question, questionID).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeWrittenAnswer:
-		if err := s.db.Preload("WrittenAnswer").First(&question, questionID).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeFillInTheBlank:
-		if err := s.db.Preload("FillInTheBlanks").First(&question, questionID).Error; err != nil {
-			return nil, err
-		}
+	if err := s.loadAnswers(&question); err != nil {
+		return nil, err
 	}
 
 	// 预加载标签
@@ -253,13 +328,13 @@ func (s *QuizService) GetQuestions(questionBankID uint, tag string) ([]models.Qu
 	if tag != "" {
 		err := s.db.Joins("JOIN question_tags qt ON qt.question_id = questions.id").
 			Joins("JOIN tags t ON qt.tag_id = t.id").
-			Where("questions.question_bank_id = ? AND t.name = ?", questionBankID, tag).
+			Where("questions.question_bank_id = ? AND questions.parent_id IS NULL AND t.name = ?", questionBankID, tag).
 			Find(&questions).Error
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		err := s.db.Where("question_bank_id = ?", questionBankID).Find(&questions).Error
+		err := s.db.Where("question_bank_id = ? AND parent_id IS NULL", questionBankID).Find(&questions).Error
 		if err != nil {
 			return nil, err
 		}
@@ -274,7 +349,8 @@ func (s *QuizService) GetQuestionsWithPagination(questionBankID uint, tag string
 	var total int64
 
 	// 计算总记录数
-	query := s.db.Model(&models.Question{}).Where("question_bank_id = ?", questionBankID)
+	// 组合题的小题随组合题一起展示，不单独列出
+	query := s.db.Model(&models.Question{}).Where("question_bank_id = ? AND parent_id IS NULL", questionBankID)
 	if tag != "" {
 		query = query.Joins("JOIN question_tags qt ON qt.question_id = questions.id").
 			Joins("JOIN tags t ON t.id = qt.tag_id").Where("t.name = ?", tag)
@@ -297,35 +373,16 @@ func (s *QuizService) GetQuestionsWithPagination(questionBankID uint, tag string
 func (s *QuizService) GetRandomQuestions(questionBankID uint, limit int) ([]models.Question, error) {
 	var questions []models.Question
 
-	// 使用随机函数获取指定数量的随机问题
-	if err := s.db.Where("question_bank_id = ?", questionBankID).
+	// 使用随机函数获取指定数量的随机问题，组合题的小题随组合题一起下发
+	if err := s.db.Where("question_bank_id = ? AND parent_id IS NULL", questionBankID).
 		Order("RANDOM()").Limit(limit).Find(&questions).Error; err != nil {
 		return nil, err
 	}
 
 	// 根据问题类型有选择地预加载对应的答案关联数据
 	for i := range questions {
-		switch questions[i].QuestionType {
-		case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
-			// 预加载选择题的选项
-			if err := s.db.Preload("AnswerOptions").Find(&questions[i]).Error; err != nil {
-				return nil, err
-			}
-		case models.QuestionTypeTrueFalse:
-			// 预加载判断题的答案
-			if err := s.db.Preload("TrueFalseAnswer").Find(&questions[i]).Error; err != nil {
-				return nil, err
-			}
-		case models.QuestionTypeWrittenAnswer:
-			// 预加载问答题的答案
-			if err := s.db.Preload("WrittenAnswer").Find(&questions[i]).Error; err != nil {
-				return nil, err
-			}
-		case models.QuestionTypeFillInTheBlank:
-			// 预加载填空题的答案
-			if err := s.db.Preload("FillInTheBlanks").Find(&questions[i]).Error; err != nil {
-				return nil, err
-			}
+		if err := s.loadAnswers(&questions[i]); err != nil {
+			return nil, err
 		}
 	}
 
@@ -344,178 +401,297 @@ func (s *QuizService) GetQuestionAttempt(userID uint, questionID uint) (*models.
 	return &attempt, nil
 }
 
-func (s *QuizService) RecordQuestionAttempt(userID uint, questionID uint, answer interface{}) (*models.QuestionAttempt, error) {
-	var question models.Question
-	if err := s.db.First(&question, "id = ?", questionID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("question not found")
-		}
+// AttemptResult 是一次作答的记录和判分结果
+type AttemptResult struct {
+	Question *models.Question // 含答案的题目，只在顶层结果上设置
+	Attempt  *models.QuestionAttempt
+	Grade    *GradeResult
+	Children []AttemptResult // 组合题各小题的作答结果
+	XP       int             // 本次获得的经验值，只在顶层结果上设置
+	Badges   []models.Badge  // 本次新获得的徽章，只在顶层结果上设置
+	// Superseded 表示作答时间早于已记录的最近一次作答，只计入作答次数，不改变最近作答和错题本
+	Superseded bool
+}
+
+// QuestionAnswer 是对一道题的作答
+type QuestionAnswer struct {
+	QuestionID uint
+	Answer     interface{}
+}
+
+// RecordQuestionAttempt grades the answer and records the attempt; for group questions
+// every child question gets its own attempt record as well
+func (s *QuizService) RecordQuestionAttempt(userID uint, questionID uint, answer interface{}) (*AttemptResult, error) {
+	results, err := s.RecordQuestionAttempts(userID, []QuestionAnswer{{QuestionID: questionID, Answer: answer}})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// RecordQuestionAttempts 先为所有作答判分，再在同一事务中记录；
+// 任一题目不存在或作答格式错误时不记录任何结果
+func (s *QuizService) RecordQuestionAttempts(userID uint, answers []QuestionAnswer) ([]*AttemptResult, error) {
+	graded, err := s.gradeAnswers(answers, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := firstGradeError(graded); err != nil {
 		return nil, err
 	}
+	return s.recordGraded(userID, graded)
+}
 
-	isCorrect := false
+// AttemptItem 是批量作答中一道题的结果，Err 非空时该题没有记录
+type AttemptItem struct {
+	Result *AttemptResult
+	Err    error // ErrQuestionNotFound、ErrInvalidAnswer 或 ErrDuplicateAnswer
+}
 
-	// Preload relevant associations based on question type
-	switch question.QuestionType {
-	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
-		if err := s.db.Preload("AnswerOptions").First(&question).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeTrueFalse:
-		if err := s.db.Preload("TrueFalseAnswer").First(&question).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeWrittenAnswer:
-		if err := s.db.Preload("WrittenAnswer").First(&question).Error; err != nil {
-			return nil, err
-		}
-	case models.QuestionTypeFillInTheBlank:
-		if err := s.db.Preload("FillInTheBlanks").First(&question).Error; err != nil {
-			return nil, err
-		}
-	default:
-		return nil, fmt.Errorf("unknown question type")
+// RecordQuestionAttemptBatch 为整套题判分，并在同一事务中记录所有能判分的作答。
+// 与 RecordQuestionAttempts 不同，题目不存在、作答格式错误或重复作答只影响该题
+func (s *QuizService) RecordQuestionAttemptBatch(userID uint, answers []QuestionAnswer) ([]AttemptItem, error) {
+	if len(answers) == 0 || len(answers) > MaxAttemptBatchSize {
+		return nil, fmt.Errorf("%w: between 1 and %d answers are required", ErrInvalidBatch, MaxAttemptBatchSize)
 	}
+	graded, err := s.gradeAnswers(answers, true)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.recordGraded(userID, graded)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]AttemptItem, len(graded))
+	for i := range graded {
+		items[i] = AttemptItem{Result: results[i], Err: graded[i].err}
+	}
+	return items, nil
+}
 
-	// Verify the answer based on question type
-	var lastAnswerJSON []byte
-	switch question.QuestionType {
-	case models.QuestionTypeSingleChoice, models.QuestionTypeMultipleChoice:
-		// For multiple choice questions, compare the answer options
-		providedAnswersFloat, ok := answer.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid answer format for multiple choice question")
-		}
+// gradedAnswer 是一条作答的判分结果，err 非空时该条无法记录
+type gradedAnswer struct {
+	question *models.Question
+	grade    *GradeResult
+	err      error
+}
 
-		// Convert []interface{} (float64) to []uint
-		providedAnswers := make([]uint, len(providedAnswersFloat))
-		for i, val := range providedAnswersFloat {
-			floatVal, ok := val.(float64)
-			if !ok {
-				return nil, fmt.Errorf("invalid answer format for multiple choice question")
+// gradeAnswers 为每条作答判分。题目不存在、作答格式错误，以及 rejectDuplicates 时同一道题的重复作答
+// 只记在该条的 err 中，其他错误直接返回
+func (s *QuizService) gradeAnswers(answers []QuestionAnswer, rejectDuplicates bool) ([]gradedAnswer, error) {
+	graded := make([]gradedAnswer, len(answers))
+	seen := map[uint]bool{}
+	for i, answer := range answers {
+		if rejectDuplicates && seen[answer.QuestionID] {
+			graded[i].err = ErrDuplicateAnswer
+			continue
+		}
+		seen[answer.QuestionID] = true
+		question, grade, err := s.gradeQuestion(answer.QuestionID, answer.Answer)
+		if err != nil {
+			if !errors.Is(err, ErrQuestionNotFound) && !errors.Is(err, ErrInvalidAnswer) {
+				return nil, err
 			}
-			providedAnswers[i] = uint(floatVal)
+			graded[i].err = err
+			continue
 		}
+		graded[i].question, graded[i].grade = question, grade
+	}
+	return graded, nil
+}
 
-		correctAnswers := []uint{}
-		for _, option := range question.AnswerOptions {
-			if option.IsCorrect {
-				correctAnswers = append(correctAnswers, option.ID)
-			}
+// firstGradeError 返回第一条无法记录的作答的错误
+func firstGradeError(graded []gradedAnswer) error {
+	for _, answer := range graded {
+		if answer.err != nil {
+			return answer.err
 		}
+	}
+	return nil
+}
 
-		isCorrect = compareAnswers(providedAnswers, correctAnswers)
+// recordGraded 在新事务中记录能判分的作答，提交后发送徽章通知
+func (s *QuizService) recordGraded(userID uint, graded []gradedAnswer) ([]*AttemptResult, error) {
+	var results []*AttemptResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = s.recordGradedAttempts(tx, userID, graded, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishBadges(userID, results)
+	return results, nil
+}
 
-		// Record the provided answer
-		answerJSON, err := json.Marshal(providedAnswers)
+// recordGradedAttempts 在调用方的事务中记录能判分的作答，返回与 graded 一一对应的结果，无法记录的为 nil。
+// 徽章通知由调用方在事务提交后发送
+func (s *QuizService) recordGradedAttempts(tx *gorm.DB, userID uint, graded []gradedAnswer, answeredAt time.Time) ([]*AttemptResult, error) {
+	results := make([]*AttemptResult, len(graded))
+	for i, answer := range graded {
+		if answer.err != nil {
+			continue
+		}
+		result, err := s.recordAttempt(tx, userID, answer.question, answer.grade, answeredAt)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal answer: %v", err)
+			return nil, err
 		}
-		lastAnswerJSON = answerJSON
+		results[i] = result
+	}
+	return results, nil
+}
 
-	case models.QuestionTypeTrueFalse:
-		// For true/false questions, compare the boolean value
-		isCorrect = false
-		if answer != nil {
-			providedAnswer, ok := answer.(bool)
-			if !ok {
-				return nil, fmt.Errorf("invalid answer format for true/false question")
-			}
-			isCorrect = (providedAnswer == question.TrueFalseAnswer.IsTrue)
-			// Record the provided answer
-			answerJSON, err := json.Marshal(providedAnswer)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal answer: %v", err)
-			}
-			lastAnswerJSON = answerJSON
+// recordAttempt 在事务中记录一道已判分的作答，并更新错题本和经验值
+func (s *QuizService) recordAttempt(tx *gorm.DB, userID uint, question *models.Question, grade *GradeResult, answeredAt time.Time) (*AttemptResult, error) {
+	result, err := saveAttempt(tx, userID, grade, answeredAt)
+	if err != nil {
+		return nil, err
+	}
+	// 组合题整体进出错题本，小题不单独加入
+	if !result.Superseded {
+		if err := updateNotebook(tx, userID, result.Attempt, grade.Correct, s.NotebookClearStreak); err != nil {
+			return nil, err
 		}
+	}
+	if result.XP, result.Badges, err = s.updateGamification(tx, userID, question, grade); err != nil {
+		return nil, err
+	}
+	result.Question = question
+	return result, nil
+}
 
-	case models.QuestionTypeWrittenAnswer:
-		// For written questions, simply record the answer for manual grading
-		isCorrect = true
-		if answer != nil {
-			providedAnswer, ok := answer.(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid answer format for written answer question")
-			}
-			// Record the provided answer
-			answerJSON, err := json.Marshal(providedAnswer)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal answer: %v", err)
-			}
-			lastAnswerJSON = answerJSON
+// gradeQuestion 加载题目和答案并判分
+func (s *QuizService) gradeQuestion(questionID uint, answer interface{}) (*models.Question, *GradeResult, error) {
+	var question models.Question
+	if err := s.db.First(&question, "id = ?", questionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrQuestionNotFound
 		}
+		return nil, nil, err
+	}
 
-	case models.QuestionTypeFillInTheBlank:
-		// For fill-in-the-blank questions, compare each blank answer
-		isCorrect = false
-		if answer != nil {
-			providedAnswersInterface, ok := answer.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("invalid answer format for fill-in-the-blank question")
-			}
-
-			// Convert []interface{} to []string
-			providedAnswers := make([]string, len(providedAnswersInterface))
-			for i, val := range providedAnswersInterface {
-				strVal, ok := val.(string)
-				if !ok {
-					return nil, fmt.Errorf("invalid answer format for fill-in-the-blank question")
-				}
-				providedAnswers[i] = strVal
-			}
+	// Preload relevant associations and verify the answer based on question type
+	questionType, err := GetQuestionType(question.QuestionType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.loadAnswers(&question); err != nil {
+		return nil, nil, err
+	}
+	grade, err := questionType.Grade(&question, answer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &question, grade, nil
+}
 
-			if len(providedAnswers) != len(question.FillInTheBlanks) {
-				isCorrect = false
-			} else {
-				isCorrect = true
-				for i, blank := range question.FillInTheBlanks {
-					if providedAnswers[i] != blank.BlankText {
-						isCorrect = false
-						break
-					}
-				}
-			}
+// saveAttempt 按判分结果创建或更新用户的答题记录；作答时间早于最近一次作答时只累计次数
+func saveAttempt(tx *gorm.DB, userID uint, grade *GradeResult, answeredAt time.Time) (*AttemptResult, error) {
+	var attempt models.QuestionAttempt
+	superseded := false
+	err := tx.Where("user_id = ? AND question_id = ?", userID, grade.QuestionID).First(&attempt).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// Create a new attempt record if not found
+		attempt = models.QuestionAttempt{
+			UserID:       userID,
+			QuestionID:   grade.QuestionID,
+			Attempts:     1,
+			LastAnswerAt: answeredAt,
+			LastAnswer:   grade.Answer,
+			LastScore:    grade.Score,
+		}
+		if grade.Correct {
+			attempt.ConsecutiveCorrect = 1
+		} else {
+			attempt.Wrong = 1
+		}
+	} else if answeredAt.Before(attempt.LastAnswerAt) {
+		attempt.CountEarlierAnswer(grade.Correct)
+		superseded = true
+	} else {
+		attempt.UpdateAnswer(grade.Answer, grade.Correct)
+		attempt.LastAnswerAt = answeredAt
+		attempt.LastScore = grade.Score
+	}
+	if err := tx.Save(&attempt).Error; err != nil {
+		return nil, err
+	}
 
-			// Record the provided answers
-			answerJSON, err := json.Marshal(providedAnswers)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal answers: %v", err)
-			}
-			lastAnswerJSON = answerJSON
+	result := &AttemptResult{Attempt: &attempt, Grade: grade, Superseded: superseded}
+	for _, childGrade := range grade.Children {
+		child, err := saveAttempt(tx, userID, childGrade, answeredAt)
+		if err != nil {
+			return nil, err
 		}
+		result.Children = append(result.Children, *child)
+	}
+	return result, nil
+}
 
-	default:
-		return nil, fmt.Errorf("unknown question type")
+// SetRelatedQuestions 替换题目的相关题目
+func (s *QuizService) SetRelatedQuestions(questionID uint, relatedIDs []uint) error {
+	var count int64
+	if err := s.db.Model(&models.Question{}).Where("id IN ? AND id <> ?", relatedIDs, questionID).Count(&count).Error; err != nil {
+		return err
+	}
+	if len(relatedIDs) > 0 && int(count) != len(relatedIDs) {
+		return fmt.Errorf("%w: related question not found", ErrInvalidQuestion)
 	}
 
-	// Record the attempt
-	var attempt models.QuestionAttempt
-	err := s.db.Where("user_id = ? AND question_id = ?", userID, questionID).First(&attempt).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Create a new attempt record if not found
-			attempt = models.QuestionAttempt{
-				UserID:             userID,
-				QuestionID:         questionID,
-				Attempts:           1,
-				ConsecutiveCorrect: 0,
-				LastAnswerAt:       time.Now(),
-				LastAnswer:         lastAnswerJSON,
-			}
-			if isCorrect {
-				attempt.ConsecutiveCorrect = 1
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", questionID).Delete(&models.RelatedQuestion{}).Error; err != nil {
+			return err
+		}
+		for _, relatedID := range relatedIDs {
+			related := models.RelatedQuestion{QuestionID: questionID, RelatedQuestionID: relatedID}
+			if err := tx.Create(&related).Error; err != nil {
+				return err
 			}
-			return &attempt, s.db.Create(&attempt).Error
 		}
+		return nil
+	})
+}
+
+// GetRelatedQuestions 返回题目的相关题目
+func (s *QuizService) GetRelatedQuestions(questionID uint) ([]models.Question, error) {
+	var questions []models.Question
+	if err := s.db.Joins("JOIN related_questions rq ON rq.related_question_id = questions.id").
+		Where("rq.question_id = ?", questionID).Find(&questions).Error; err != nil {
 		return nil, err
 	}
+	return questions, nil
+}
 
-	attempt.UpdateAnswer(lastAnswerJSON, isCorrect)
-	if err := s.db.Save(&attempt).Error; err != nil {
-		return nil, err
+// loadAnswers 按题型预加载题目的答案
+func (s *QuizService) loadAnswers(question *models.Question) error {
+	questionType, err := GetQuestionType(question.QuestionType)
+	if err != nil {
+		return err
 	}
-	return &attempt, nil
+	query := s.db
+	for _, association := range questionType.Preloads() {
+		query = query.Preload(association)
+	}
+	return query.First(question, question.ID).Error
+}
+
+// validateQuestion 按题型校验题目，错误统一包装为 ErrInvalidQuestion
+func validateQuestion(question *models.Question) error {
+	if question.Difficulty < 0 || question.Difficulty > 5 {
+		return fmt.Errorf("%w: difficulty must be between 1 and 5, or 0 when unset", ErrInvalidQuestion)
+	}
+	questionType, err := GetQuestionType(question.QuestionType)
+	if err != nil {
+		return err
+	}
+	if err := questionType.Validate(question); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+	return nil
 }
 
 func compareAnswers(providedAnswers, correctAnswers []uint) bool {
@@ -538,8 +714,8 @@ func compareAnswers(providedAnswers, correctAnswers []uint) bool {
 func (s *QuizService) GetQuestionAttempts(userID uint, questionBankID uint, consecutiveCorrectThreshold uint) ([]dto.QuestionAttemptResponse, error) {
 	var attempts []models.QuestionAttempt
 	if err := s.db.Joins("JOIN questions ON questions.id = question_attempts.question_id").
-		Where("questions.question_bank_id = ? AND question_attempts.consecutive_correct < ?",
-			questionBankID, consecutiveCorrectThreshold).
+		Where("question_attempts.user_id = ? AND questions.question_bank_id = ? AND question_attempts.consecutive_correct < ?",
+			userID, questionBankID, consecutiveCorrectThreshold).
 		Find(&attempts).Error; err != nil {
 		return nil, err
 	}
@@ -551,6 +727,7 @@ func (s *QuizService) GetQuestionAttempts(userID uint, questionBankID uint, cons
 			Attempts:           attempt.Attempts,
 			Wrong:              attempt.Wrong,
 			ConsecutiveCorrect: attempt.ConsecutiveCorrect,
+			LastScore:          attempt.LastScore,
 			LastAnswerAt:       attempt.LastAnswerAt,
 		})
 	}
@@ -0,0 +1,416 @@
+// services/registration.go
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"learn/pkg/utils"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultEmailVerificationTokenDuration 是验证邮箱令牌的默认有效期
+	DefaultEmailVerificationTokenDuration = 24 * time.Hour
+	// verificationResendInterval 内同一用户只发送一次验证邮件
+	verificationResendInterval = time.Minute
+)
+
+// 注册申请的审核方式
+const (
+	ApprovedByAdmin       = "admin"
+	ApprovedByInviteCode  = "invite_code"
+	ApprovedByEmailDomain = "email_domain"
+)
+
+// 审计日志的事件类型
+const (
+	AuditRegistrationApproved = "registration_approved"
+	AuditRegistrationRejected = "registration_rejected"
+)
+
+var (
+	ErrEmailRequired            = errors.New("email address is required")
+	ErrVerificationUnavailable  = errors.New("email verification is not configured")
+	ErrInvalidRegistrationCode  = errors.New("invalid registration invite code")
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrRegistrationNotPending   = errors.New("registration has already been decided")
+	ErrUnknownRole              = errors.New("role does not exist")
+)
+
+// AutoApproveRule 是注册申请自动通过的规则，InviteCode 和 EmailDomain 设置其一
+type AutoApproveRule struct {
+	InviteCode  string   // 注册时填写该邀请码的申请立即通过
+	EmailDomain string   // 邮箱属于该域名的申请在邮箱验证后通过，不含子域名
+	Roles       []string // 自动通过时授予的角色
+}
+
+// RegistrationSettings 控制自助注册的审核和邮箱验证
+type RegistrationSettings struct {
+	RequireEmailVerification  bool          // 注册必须填写邮箱，邮箱验证后账号才会启用
+	VerifyEmailURL            string        // 验证邮件中的链接，令牌作为 token 参数附加；为空时不发送验证邮件
+	VerificationTokenDuration time.Duration // 验证邮箱令牌的有效期
+	AutoApprove               []AutoApproveRule
+}
+
+// RegistrationFilter 是查询注册申请的条件，零值表示不过滤
+type RegistrationFilter struct {
+	Status string
+}
+
+// Register 自助注册：创建待审核的用户和注册申请。邀请码匹配时申请立即通过，
+// 填写了邮箱时发送验证邮件。账号在申请通过且（需要时）邮箱已验证后启用
+func (s *AuthService) Register(username, password, email, inviteCode, ip string) (models.Registration, error) {
+	settings := s.Registration
+	var rule *AutoApproveRule
+	if inviteCode != "" {
+		if rule = settings.inviteRule(inviteCode); rule == nil {
+			return models.Registration{}, ErrInvalidRegistrationCode
+		}
+	}
+	if settings.RequireEmailVerification && strings.TrimSpace(email) == "" {
+		return models.Registration{}, ErrEmailRequired
+	}
+
+	registration := models.Registration{Status: models.RegistrationPending, InviteCode: inviteCode}
+	user, err := s.createUser(username, password, email, nil, models.StatusPending, func(tx *gorm.DB, user *models.User) error {
+		registration.UserID = user.ID
+		if err := tx.Create(&registration).Error; err != nil {
+			return err
+		}
+		if rule == nil {
+			return nil
+		}
+		return s.approveRegistration(tx, &registration, user, rule.Roles, ApprovedByInviteCode, nil, ip)
+	})
+	if err != nil {
+		return models.Registration{}, err
+	}
+	registration.User = user
+	if user.Email != nil {
+		if err := s.sendEmailVerification(&user); err != nil {
+			return models.Registration{}, err
+		}
+	}
+	return registration, nil
+}
+
+// VerifyEmail 使用验证邮件中的令牌验证邮箱。邮箱域名匹配自动通过规则时同时通过注册申请
+func (s *AuthService) VerifyEmail(token, ip string) error {
+	var record models.EmailVerificationToken
+	if err := s.db.Where("token_hash = ?", hashToken(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	now := time.Now()
+	if record.UsedAt != nil || now.After(record.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidVerificationToken
+		}
+		var user models.User
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			return err
+		}
+		// 发送后邮箱已经修改，令牌作废
+		if user.Email == nil || *user.Email != record.Email {
+			return ErrInvalidVerificationToken
+		}
+		user.EmailVerifiedAt = &now
+		if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+			return err
+		}
+
+		var registration models.Registration
+		err := tx.Where("user_id = ?", user.ID).First(&registration).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if registration.Status == models.RegistrationPending {
+			if rule := s.Registration.domainRule(*user.Email); rule != nil {
+				return s.approveRegistration(tx, &registration, &user, rule.Roles, ApprovedByEmailDomain, nil, ip)
+			}
+		}
+		return s.activateIfReady(tx, &registration, &user)
+	})
+}
+
+// ResendEmailVerification 重新发送验证邮件。为避免泄露账号是否存在，用户不存在或邮箱已验证时同样返回 nil
+func (s *AuthService) ResendEmailVerification(login string) error {
+	if s.Notifier == nil || s.Registration.VerifyEmailURL == "" {
+		return ErrVerificationUnavailable
+	}
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return nil
+	}
+	var user models.User
+	err := s.db.Where("username = ? OR email = ?", login, strings.ToLower(login)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+	var recent int64
+	if err := s.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-verificationResendInterval)).Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent > 0 {
+		return nil
+	}
+	return s.sendEmailVerification(&user)
+}
+
+// GetRegistrations 分页查询注册申请，先提交的排在前面
+func (s *AuthService) GetRegistrations(filter RegistrationFilter, page, pageSize int) ([]models.Registration, int64, error) {
+	query := s.db.Model(&models.Registration{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var registrations []models.Registration
+	err := query.Preload("User").Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&registrations).Error
+	return registrations, total, err
+}
+
+// ApproveRegistration 通过注册申请并授予角色，邮箱需要验证但尚未验证时，账号在验证后启用
+func (s *AuthService) ApproveRegistration(id uint, roles []string, reviewer models.User, ip string) (models.Registration, error) {
+	registration, err := s.decideRegistration(id, func(tx *gorm.DB, registration *models.Registration, user *models.User) error {
+		return s.approveRegistration(tx, registration, user, roles, ApprovedByAdmin, &reviewer, ip)
+	})
+	if err != nil {
+		return models.Registration{}, err
+	}
+	body := "你的注册申请已经通过，现在可以登录了。\n"
+	if registration.User.Status != models.StatusActive {
+		body = "你的注册申请已经通过，请先点击验证邮件中的链接验证邮箱，之后即可登录。\n"
+	}
+	s.notifyUser(&registration.User, "注册申请已通过", body)
+	return registration, nil
+}
+
+// RejectRegistration 拒绝注册申请，账号保持停用
+func (s *AuthService) RejectRegistration(id uint, reason string, reviewer models.User, ip string) (models.Registration, error) {
+	registration, err := s.decideRegistration(id, func(tx *gorm.DB, registration *models.Registration, user *models.User) error {
+		now := time.Now()
+		registration.Status = models.RegistrationRejected
+		registration.ApprovedBy = ApprovedByAdmin
+		registration.Reason = reason
+		registration.ReviewerID = &reviewer.ID
+		registration.DecidedAt = &now
+		if err := tx.Omit("User").Save(registration).Error; err != nil {
+			return err
+		}
+		user.Status = models.StatusInactive
+		if err := tx.Model(user).Update("status", models.StatusInactive).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, AuditRegistrationRejected, user, ip, fmt.Sprintf("rejected by %s: %s", reviewer.Username, reason))
+	})
+	if err != nil {
+		return models.Registration{}, err
+	}
+	body := "很抱歉，你的注册申请没有通过。\n"
+	if reason != "" {
+		body = fmt.Sprintf("很抱歉，你的注册申请没有通过，原因：%s\n", reason)
+	}
+	s.notifyUser(&registration.User, "注册申请未通过", body)
+	return registration, nil
+}
+
+// EnsureRegistrations 为没有注册申请的待审核用户补建申请，使其出现在审核列表中
+func (s *AuthService) EnsureRegistrations() (int64, error) {
+	var users []models.User
+	if err := s.db.Where("status = ? AND id NOT IN (?)", models.StatusPending,
+		s.db.Model(&models.Registration{}).Select("user_id")).Find(&users).Error; err != nil {
+		return 0, err
+	}
+	for _, user := range users {
+		registration := models.Registration{UserID: user.ID, Status: models.RegistrationPending, CreatedAt: user.CreatedAt}
+		if err := s.db.Create(&registration).Error; err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(users)), nil
+}
+
+// decideRegistration 在事务中加载待审核的申请及其用户并执行 decide
+func (s *AuthService) decideRegistration(id uint,
+	decide func(tx *gorm.DB, registration *models.Registration, user *models.User) error) (models.Registration, error) {
+	var registration models.Registration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").First(&registration, id).Error; err != nil {
+			return err
+		}
+		if registration.Status != models.RegistrationPending {
+			return ErrRegistrationNotPending
+		}
+		// 条件更新，避免两个管理员同时审核
+		result := tx.Model(&models.Registration{}).Where("id = ? AND status = ?", id, models.RegistrationPending).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRegistrationNotPending
+		}
+		return decide(tx, &registration, &registration.User)
+	})
+	return registration, err
+}
+
+// approveRegistration 把申请标记为通过并授予角色，条件满足时启用账号
+func (s *AuthService) approveRegistration(tx *gorm.DB, registration *models.Registration, user *models.User,
+	roles []string, approvedBy string, reviewer *models.User, ip string) error {
+	now := time.Now()
+	registration.Status = models.RegistrationApproved
+	registration.ApprovedBy = approvedBy
+	registration.DecidedAt = &now
+	detail := "approved by " + approvedBy
+	if reviewer != nil {
+		registration.ReviewerID = &reviewer.ID
+		detail = "approved by " + reviewer.Username
+	}
+	if err := tx.Omit("User").Save(registration).Error; err != nil {
+		return err
+	}
+	for _, roleName := range roles {
+		var role models.Role
+		if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", ErrUnknownRole, roleName)
+			}
+			return err
+		}
+		if err := tx.Model(user).Association("Roles").Append(&role); err != nil {
+			return err
+		}
+	}
+	if len(roles) > 0 {
+		detail += ", roles: " + strings.Join(roles, ", ")
+	}
+	if err := recordAudit(tx, AuditRegistrationApproved, user, ip, detail); err != nil {
+		return err
+	}
+	return s.activateIfReady(tx, registration, user)
+}
+
+// activateIfReady 申请已通过且（需要时）邮箱已验证时启用待审核的账号
+func (s *AuthService) activateIfReady(tx *gorm.DB, registration *models.Registration, user *models.User) error {
+	if registration.Status != models.RegistrationApproved || user.Status != models.StatusPending {
+		return nil
+	}
+	if s.Registration.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil
+	}
+	user.Status = models.StatusActive
+	return tx.Model(user).Update("status", models.StatusActive).Error
+}
+
+// sendEmailVerification 生成验证令牌并发送验证邮件，未配置通知方式或链接时不发送
+func (s *AuthService) sendEmailVerification(user *models.User) error {
+	if s.Notifier == nil || s.Registration.VerifyEmailURL == "" || user.Email == nil {
+		return nil
+	}
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	duration := s.Registration.VerificationTokenDuration
+	if duration <= 0 {
+		duration = DefaultEmailVerificationTokenDuration
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 只有最新的令牌有效
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.EmailVerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     *user.Email,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(duration),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	link, err := url.Parse(s.Registration.VerifyEmailURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	s.notify(Message{
+		To:      *user.Email,
+		Subject: "验证邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %d 小时内打开下面的链接验证你的邮箱：\n\n%s\n\n如果你没有注册过账号，请忽略这封邮件。\n",
+			user.Username, int(duration.Hours()), link.String()),
+	})
+	return nil
+}
+
+// notifyUser 向有邮箱的用户发送通知，未配置通知方式时不发送
+func (s *AuthService) notifyUser(user *models.User, subject, body string) {
+	if s.Notifier == nil || user.Email == nil {
+		return
+	}
+	s.notify(Message{To: *user.Email, Subject: subject, Body: fmt.Sprintf("%s，你好：\n\n%s", user.Username, body)})
+}
+
+// PurgeEmailVerificationTokens 删除已使用或已过期的验证邮箱令牌
+func (s *AuthService) PurgeEmailVerificationTokens() (int64, error) {
+	result := s.db.Where("used_at IS NOT NULL OR expires_at < ?", time.Now()).Delete(&models.EmailVerificationToken{})
+	return result.RowsAffected, result.Error
+}
+
+// inviteRule 返回邀请码匹配的规则
+func (settings RegistrationSettings) inviteRule(code string) *AutoApproveRule {
+	for i, rule := range settings.AutoApprove {
+		if rule.InviteCode != "" && subtle.ConstantTimeCompare([]byte(rule.InviteCode), []byte(code)) == 1 {
+			return &settings.AutoApprove[i]
+		}
+	}
+	return nil
+}
+
+// domainRule 返回邮箱域名匹配的规则
+func (settings RegistrationSettings) domainRule(email string) *AutoApproveRule {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return nil
+	}
+	domain := email[at+1:]
+	for i, rule := range settings.AutoApprove {
+		if rule.EmailDomain != "" && strings.EqualFold(strings.TrimPrefix(rule.EmailDomain, "@"), domain) {
+			return &settings.AutoApprove[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// services/session.go
+package services
+
+import (
+	"errors"
+	"learn/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionDevice 是登录或刷新令牌时记录的设备信息
+type SessionDevice struct {
+	Name      string // 客户端提供的设备名称
+	UserAgent string
+	IP        string
+}
+
+var ErrSessionRevoked = errors.New("session is no longer valid")
+
+// GetUserSessions 返回用户仍然有效的会话，最近活动的排在前面
+func (s *AuthService) GetUserSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession 吊销用户的某个会话，会话的 Refresh Token 和 Access Token 随即失效。
+// 会话不属于该用户时返回 gorm.ErrRecordNotFound
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	var session models.Session
+	if err := s.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return revokeFamily(tx, session.FamilyID, time.Now())
+	})
+}
+
+// ValidateSession 检查 Access Token 绑定的会话是否仍然有效
+func (s *AuthService) ValidateSession(userID, sessionID uint) error {
+	var session models.Session
+	if err := s.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if !session.Active(time.Now()) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// truncate 按字符截断字符串，避免超出列长度
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
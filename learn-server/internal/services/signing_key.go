@@ -0,0 +1,408 @@
+// services/signing_key.go
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"learn/internal/models"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-jose/go-jose/v4"
+)
+
+// Access Token 支持的签名算法
+const (
+	SigningHS256 = "HS256" // 使用 jwt.secret，其他服务没有密钥无法验证
+	SigningRS256 = "RS256"
+	SigningEdDSA = "EdDSA"
+)
+
+const (
+	// DefaultKeyRotationInterval 是每个签名密钥用于签名的默认时长
+	DefaultKeyRotationInterval = 30 * 24 * time.Hour
+	// DefaultKeyRotationGrace 是新密钥提前发布、旧密钥被取代后继续验证的默认时长
+	DefaultKeyRotationGrace = 24 * time.Hour
+	// DefaultKeyRefreshInterval 是遇到未知 kid 时从数据库重新加载密钥的默认最小间隔
+	DefaultKeyRefreshInterval = 10 * time.Second
+)
+
+var (
+	ErrUnsupportedSigningAlgorithm = errors.New("unsupported JWT signing algorithm")
+	ErrUnknownSigningKey           = errors.New("unknown JWT signing key")
+	ErrInvalidTokenClaims          = errors.New("invalid token claims")
+	ErrMissingJWTSecret            = errors.New("jwt secret is not configured")
+)
+
+// SigningKeySettings 控制 Access Token 的签名算法和密钥轮换
+type SigningKeySettings struct {
+	Algorithm        string        // HS256、RS256 或 EdDSA
+	RotationInterval time.Duration // 每个密钥用于签名的时长，为 0 时不自动轮换
+	GracePeriod      time.Duration // 新密钥提前发布、旧密钥被取代后继续验证的时长，不短于 Access Token 有效期
+	RefreshInterval  time.Duration // 遇到未知 kid 时从数据库重新加载密钥的最小间隔，为 0 时使用默认值
+}
+
+// signingKey 是解析后的签名密钥
+type signingKey struct {
+	kid         string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	activatesAt time.Time
+}
+
+// signingKeyring 缓存数据库中的签名密钥，按 activatesAt 升序排列
+type signingKeyring struct {
+	settings    SigningKeySettings
+	mu          sync.RWMutex
+	keys        []signingKey
+	legacyUntil time.Time // 此前仍接受切换到非对称密钥之前以 HS256 签发的令牌
+	refreshedAt time.Time // 最近一次因未知 kid 重新加载密钥的时间
+}
+
+// current 返回 now 时用于签名的密钥，即已启用的密钥中最新的一个
+func (r *signingKeyring) current(now time.Time) (signingKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if !r.keys[i].activatesAt.After(now) {
+			return r.keys[i], true
+		}
+	}
+	return signingKey{}, false
+}
+
+func (r *signingKeyring) find(kid string) (signingKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.kid == kid {
+			return key, true
+		}
+	}
+	return signingKey{}, false
+}
+
+// acceptsLegacy 判断 now 时是否仍接受 HS256 令牌
+func (r *signingKeyring) acceptsLegacy(now time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return now.Before(r.legacyUntil)
+}
+
+// allowRefresh 判断 now 时能否因未知 kid 重新加载密钥，可以时记录本次加载时间
+func (r *signingKeyring) allowRefresh(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.refreshedAt.IsZero() && now.Sub(r.refreshedAt) < r.settings.RefreshInterval {
+		return false
+	}
+	r.refreshedAt = now
+	return true
+}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningEdDSA, func() jwt.SigningMethod { return signingMethodEdDSA{} })
+}
+
+// signingMethodEdDSA 为 jwt-go 实现 Ed25519 签名（RFC 8037）
+type signingMethodEdDSA struct{}
+
+func (signingMethodEdDSA) Alg() string {
+	return SigningEdDSA
+}
+
+func (signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// ConfigureSigningKeys 设置 Access Token 的签名方式。HS256 使用 jwt.secret；
+// RS256 和 EdDSA 使用数据库中的密钥，没有该算法的密钥时立即生成
+func (s *AuthService) ConfigureSigningKeys(settings SigningKeySettings) error {
+	// 使用非对称密钥时 MFAToken 仍以 jwt.secret 签名，任何算法都必须配置
+	if s.jwtSecret == "" {
+		return ErrMissingJWTSecret
+	}
+	switch settings.Algorithm {
+	case "", SigningHS256:
+		s.signingKeys = nil
+		return nil
+	case SigningRS256, SigningEdDSA:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedSigningAlgorithm, settings.Algorithm)
+	}
+	// 旧密钥签发的令牌全部过期之前不能删除它
+	if settings.GracePeriod < s.accessTokenDuration {
+		settings.GracePeriod = s.accessTokenDuration
+	}
+	if settings.RefreshInterval <= 0 {
+		settings.RefreshInterval = DefaultKeyRefreshInterval
+	}
+	s.signingKeys = &signingKeyring{settings: settings}
+	return s.RotateSigningKeys()
+}
+
+// RotateSigningKeys 按计划轮换签名密钥并重新加载。当前密钥到期前 GracePeriod 生成新密钥，
+// 先通过 JWKS 发布，到期后才用于签名；被取代超过 GracePeriod 的密钥删除。
+// 多个实例共用数据库时各自定期调用即可
+func (s *AuthService) RotateSigningKeys() error {
+	ring := s.signingKeys
+	if ring == nil {
+		return nil
+	}
+	settings := ring.settings
+	now := time.Now()
+	var keys []models.SigningKey
+	if err := s.db.Order("activates_at, id").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	var current, latest *models.SigningKey
+	for i := range keys {
+		if !keys[i].ActivatesAt.After(now) {
+			current = &keys[i]
+		}
+	}
+	if len(keys) > 0 {
+		latest = &keys[len(keys)-1]
+	}
+	switch {
+	case latest == nil || latest.Algorithm != settings.Algorithm:
+		// 首次启用或更换算法时新密钥立即生效，尚未启用的旧算法密钥不再需要
+		if err := s.db.Where("activates_at > ?", now).Delete(&models.SigningKey{}).Error; err != nil {
+			return err
+		}
+		if err := s.createSigningKey(settings.Algorithm, now); err != nil {
+			return err
+		}
+	case latest == current && settings.RotationInterval > 0:
+		activatesAt := current.ActivatesAt.Add(settings.RotationInterval)
+		if !now.Before(activatesAt.Add(-settings.GracePeriod)) {
+			if activatesAt.Before(now) {
+				activatesAt = now
+			}
+			if err := s.createSigningKey(settings.Algorithm, activatesAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 被取代超过宽限期的密钥签发的令牌都已过期
+	var expired []uint
+	for i := 0; i+1 < len(keys); i++ {
+		if keys[i+1].ActivatesAt.Add(settings.GracePeriod).Before(now) {
+			expired = append(expired, keys[i].ID)
+		}
+	}
+	if len(expired) > 0 {
+		if err := s.db.Delete(&models.SigningKey{}, expired).Error; err != nil {
+			return err
+		}
+	}
+	return s.loadSigningKeys()
+}
+
+func (s *AuthService) loadSigningKeys() error {
+	var records []models.SigningKey
+	if err := s.db.Order("activates_at, id").Find(&records).Error; err != nil {
+		return err
+	}
+	keys := make([]signingKey, 0, len(records))
+	for _, record := range records {
+		key, err := parseSigningKey(record)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	ring := s.signingKeys
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.keys = keys
+	// 最早的密钥启用时切换到非对称密钥，之前以 HS256 签发的令牌在一个有效期内仍然有效。
+	// 更早的密钥被删除时已超过宽限期，这个时间不会因此推后到现在之后
+	if len(keys) > 0 {
+		ring.legacyUntil = keys[0].activatesAt.Add(s.accessTokenDuration)
+	}
+	return nil
+}
+
+func (s *AuthService) createSigningKey(algorithm string, activatesAt time.Time) error {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case SigningRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case SigningEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedSigningAlgorithm, algorithm)
+	}
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	kid, err := signingKeyID(private.Public())
+	if err != nil {
+		return err
+	}
+	return s.db.Create(&models.SigningKey{
+		KID:         kid,
+		Algorithm:   algorithm,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ActivatesAt: activatesAt,
+	}).Error
+}
+
+// signingKeyID 使用公钥的 JWK 指纹（RFC 7638）作为 kid
+func signingKeyID(public crypto.PublicKey) (string, error) {
+	thumbprint, err := (&jose.JSONWebKey{Key: public}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+func parseSigningKey(record models.SigningKey) (signingKey, error) {
+	block, _ := pem.Decode([]byte(record.PrivateKey))
+	if block == nil {
+		return signingKey{}, fmt.Errorf("signing key %s: invalid PEM", record.KID)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("signing key %s: %w", record.KID, err)
+	}
+	var method jwt.SigningMethod
+	switch parsed.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = signingMethodEdDSA{}
+	}
+	if method == nil || method.Alg() != record.Algorithm {
+		return signingKey{}, fmt.Errorf("%w: signing key %s is not %s", ErrUnsupportedSigningAlgorithm, record.KID, record.Algorithm)
+	}
+	return signingKey{
+		kid:         record.KID,
+		method:      method,
+		private:     parsed.(crypto.Signer),
+		activatesAt: record.ActivatesAt,
+	}, nil
+}
+
+// lookupSigningKey 按 kid 查找验证密钥。缓存中没有时从数据库重新加载，以便验证其他实例刚生成的密钥签发的令牌；
+// 为避免伪造的 kid 让每个请求都查询数据库，最多每隔 RefreshInterval 重新加载一次
+func (s *AuthService) lookupSigningKey(kid string) (signingKey, error) {
+	ring := s.signingKeys
+	if key, ok := ring.find(kid); ok {
+		return key, nil
+	}
+	if kid == "" || !ring.allowRefresh(time.Now()) {
+		return signingKey{}, ErrUnknownSigningKey
+	}
+	if err := s.loadSigningKeys(); err != nil {
+		return signingKey{}, err
+	}
+	if key, ok := ring.find(kid); ok {
+		return key, nil
+	}
+	return signingKey{}, ErrUnknownSigningKey
+}
+
+// signAccessToken 使用当前的签名密钥签发令牌，非对称密钥在头部写入 kid
+func (s *AuthService) signAccessToken(claims jwt.MapClaims) (string, error) {
+	ring := s.signingKeys
+	if ring == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	}
+	key, ok := ring.current(time.Now())
+	if !ok {
+		return "", ErrUnknownSigningKey
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// ParseAccessToken 验证 Access Token 的签名和有效期并返回声明。
+// 使用非对称密钥时按头部的 kid 选择公钥，切换后超过一个 Access Token 有效期不再接受 HS256 令牌
+func (s *AuthService) ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	ring := s.signingKeys
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if ring == nil || ring.acceptsLegacy(time.Now()) {
+				return []byte(s.jwtSecret), nil
+			}
+			return nil, ErrUnsupportedSigningAlgorithm
+		}
+		if ring == nil {
+			return nil, ErrUnsupportedSigningAlgorithm
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.lookupSigningKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, ErrUnsupportedSigningAlgorithm
+		}
+		return key.private.Public(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidTokenClaims
+	}
+	return claims, nil
+}
+
+// JWKS 返回验证 Access Token 的公钥，包括尚未启用和宽限期内的密钥。使用 HS256 时为空
+func (s *AuthService) JWKS() jose.JSONWebKeySet {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	ring := s.signingKeys
+	if ring == nil {
+		return set
+	}
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	for _, key := range ring.keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       key.private.Public(),
+			KeyID:     key.kid,
+			Algorithm: key.method.Alg(),
+			Use:       "sig",
+		})
+	}
+	return set
+}